@@ -0,0 +1,137 @@
+import { test, expect } from '@playwright/test';
+import { waitForAppReady } from '../helpers/wait.js';
+import { A1_GPS_IMAGE } from '../helpers/fixtures.js';
+
+// Caption and alt text are edited as the "description" and "alt" meta keys in
+// the library info panel; the server mirrors them to the photo's XMP sidecar.
+
+const LIB_NAME = 'E2E Captions';
+
+test.describe('Photo captions and alt text', () => {
+    let libID;
+    let photoID;
+
+    async function metaValue(request, key) {
+        const entries = await (await request.get(`/api/library/${libID}/photo/${photoID}/meta`)).json();
+        return (entries || []).find(e => e.key === key)?.value;
+    }
+
+    test.beforeAll(async ({ request }) => {
+        test.setTimeout(120_000);
+        const existing = await (await request.get('/api/library/')).json();
+        await Promise.all(existing.filter(l => l.name === LIB_NAME).map(l => request.delete(`/api/library/${l.id}`)));
+
+        const res = await request.post('/api/library/', {
+            data: { name: LIB_NAME, description: '', sourcePath: 'folder-a/a1' },
+        });
+        expect(res.status()).toBe(201);
+        libID = (await res.json()).id;
+
+        const reindexRes = await request.post(`/api/library/${libID}/reindex`, { timeout: 90_000 });
+        expect(reindexRes.ok()).toBe(true);
+
+        const idRes = await request.get(`/api/library/${libID}/photo-id-by-path?path=${encodeURIComponent(A1_GPS_IMAGE)}`);
+        expect(idRes.status()).toBe(200);
+        photoID = (await idRes.json()).photoID;
+    });
+
+    test.afterAll(async ({ request }) => {
+        if (!libID) return;
+        // Clearing the keys also clears them from the sidecar.
+        for (const key of ['description', 'alt']) {
+            await request.delete(`/api/library/${libID}/photo/${photoID}/meta?key=${key}`).catch(() => {});
+        }
+        await request.delete(`/api/library/${libID}`);
+    });
+
+    // ── API ───────────────────────────────────────────────────────────────────
+
+    test('caption and alt text round-trip through the meta API', async ({ request }) => {
+        for (const [key, value] of [['description', 'Harbour at dusk'], ['alt', 'Fishing boats moored at a stone pier']]) {
+            const res = await request.put(`/api/library/${libID}/photo/${photoID}/meta`, { data: { key, value } });
+            expect(res.status()).toBe(204);
+            expect(await metaValue(request, key)).toBe(value);
+        }
+
+        expect((await request.delete(`/api/library/${libID}/photo/${photoID}/meta?key=alt`)).status()).toBe(204);
+        expect(await metaValue(request, 'alt')).toBeUndefined();
+        expect(await metaValue(request, 'description')).toBe('Harbour at dusk');
+    });
+
+    // ── UI ────────────────────────────────────────────────────────────────────
+
+    test.describe('info panel', () => {
+        // Opens the library and focuses the photo with the info panel expanded.
+        async function openPhoto(page) {
+            await page.goto('/');
+            await waitForAppReady(page);
+            await page.locator('#mode-library').click();
+            await page.waitForSelector('.library-list-view', { timeout: 8_000 });
+
+            const card = page.locator('.library-card', { hasText: LIB_NAME });
+            await card.waitFor({ state: 'visible', timeout: 8_000 });
+            await card.locator('.lib-open').click();
+            await page.waitForSelector('.library-detail', { timeout: 8_000 });
+
+            await page.keyboard.press('i');
+            await page.waitForSelector('#lib-info-panel .info-panel.expanded', { timeout: 5_000 });
+
+            const photo = page.locator(`#lib-pane .grid-item[data-name="${A1_GPS_IMAGE}"]`);
+            await photo.waitFor({ state: 'visible', timeout: 8_000 });
+            await photo.click();
+        }
+
+        test.beforeEach(async ({ request }) => {
+            for (const key of ['description', 'alt']) {
+                await request.delete(`/api/library/${libID}/photo/${photoID}/meta?key=${key}`);
+            }
+        });
+
+        test('shows title, caption and alt text fields', async ({ page }) => {
+            await openPhoto(page);
+            const panel = page.locator('#lib-info-panel');
+            await expect(panel.locator('.info-title-val[data-key="title"]')).toBeVisible({ timeout: 8_000 });
+            const caption = panel.locator('.info-caption-val[data-key="description"]');
+            const alt = panel.locator('.info-caption-val[data-key="alt"]');
+            await expect(caption).toHaveAttribute('data-placeholder', 'Add caption...');
+            await expect(alt).toHaveAttribute('data-placeholder', 'Describe the image...');
+        });
+
+        test('editing the caption and alt text saves them', async ({ page, request }) => {
+            await openPhoto(page);
+            const panel = page.locator('#lib-info-panel');
+
+            const caption = panel.locator('.info-caption-val[data-key="description"]');
+            await caption.waitFor({ state: 'visible', timeout: 8_000 });
+            await caption.click();
+            await page.keyboard.type('Evening light over the bay');
+            await page.keyboard.press('Enter');
+            await expect.poll(() => metaValue(request, 'description')).toBe('Evening light over the bay');
+
+            const alt = panel.locator('.info-caption-val[data-key="alt"]');
+            await alt.click();
+            await page.keyboard.type('Sun setting behind hills across calm water');
+            await page.keyboard.press('Enter');
+            await expect.poll(() => metaValue(request, 'alt')).toBe('Sun setting behind hills across calm water');
+
+            // Caption fields are not repeated among the generic meta rows.
+            await expect(panel.locator('.info-meta-val[data-key="description"]')).toHaveCount(1);
+            await expect(panel.locator('.info-meta-val[data-key="alt"]')).toHaveCount(1);
+        });
+
+        test('removing the alt text clears it', async ({ page, request }) => {
+            await request.put(`/api/library/${libID}/photo/${photoID}/meta`, {
+                data: { key: 'alt', value: 'A lighthouse on a rocky coast' },
+            });
+            await openPhoto(page);
+
+            const panel = page.locator('#lib-info-panel');
+            const alt = panel.locator('.info-caption-val[data-key="alt"]');
+            await expect(alt).toHaveText('A lighthouse on a rocky coast', { timeout: 8_000 });
+
+            await panel.locator('.info-meta-del[data-key="alt"]').click();
+            await expect(alt).toHaveAttribute('data-placeholder', 'Describe the image...');
+            await expect.poll(() => metaValue(request, 'alt')).toBeUndefined();
+        });
+    });
+});
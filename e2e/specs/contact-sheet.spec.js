@@ -0,0 +1,76 @@
+import { test, expect } from '@playwright/test';
+import { GPS_PATH, NO_GPS_PATH, GPS_IMAGE, navigateToFolder } from '../helpers/fixtures.js';
+
+test.describe('Contact sheet', () => {
+  // ── API ───────────────────────────────────────────────────────────────────
+
+  test('POST /api/export/contact-sheet returns a PDF', async ({ request }) => {
+    const res = await request.post('/api/export/contact-sheet', {
+      data: { files: [GPS_PATH, NO_GPS_PATH], title: 'E2E sheet', columns: 2, rows: 2 },
+    });
+    expect(res.status()).toBe(200);
+    expect(res.headers()['content-type']).toBe('application/pdf');
+    expect(res.headers()['content-disposition']).toContain('contact-sheet.pdf');
+    const buf = await res.body();
+    expect(buf.subarray(0, 5).toString()).toBe('%PDF-');
+  });
+
+  test('letter landscape with custom caption fields', async ({ request }) => {
+    const res = await request.post('/api/export/contact-sheet', {
+      data: { files: [GPS_PATH], pageSize: 'letter', landscape: true, fields: ['filename', 'exif'] },
+    });
+    expect(res.status()).toBe(200);
+    expect((await res.body()).subarray(0, 5).toString()).toBe('%PDF-');
+  });
+
+  test('invalid requests are rejected', async ({ request }) => {
+    const cases = [
+      { files: [] },
+      { files: [GPS_PATH], columns: 11 },
+      { files: [GPS_PATH], pageSize: 'a3' },
+      { files: [GPS_PATH], fields: ['shoesize'] },
+      { files: ['../outside.jpg'] },
+    ];
+    for (const data of cases) {
+      const res = await request.post('/api/export/contact-sheet', { data });
+      expect(res.status(), JSON.stringify(data)).toBe(400);
+    }
+  });
+
+  // ── UI ────────────────────────────────────────────────────────────────────
+
+  test('export dialog downloads a contact sheet for the selection', async ({ page }) => {
+    await page.goto('/');
+    await page.waitForSelector('.breadcrumb', { timeout: 10_000 });
+    await navigateToFolder(page, 'folder-b');
+    await page.waitForSelector(`[data-name="${GPS_IMAGE}"]`, { timeout: 10_000 });
+    await page.locator(`[data-name="${GPS_IMAGE}"]`).click();
+    await page.locator('.tools-menu-btn').click();
+    await page.locator('button.tool-item[data-tool="export"]').click();
+    const modal = page.locator('.export-modal');
+    await expect(modal).toBeVisible({ timeout: 5_000 });
+
+    page.once('dialog', dialog => dialog.accept('2x3'));
+    const downloadPromise = page.waitForEvent('download', { timeout: 30_000 });
+    await modal.locator('#export-sheet-btn').click();
+    const download = await downloadPromise;
+    expect(download.suggestedFilename()).toBe('contact-sheet.pdf');
+    await expect(modal.locator('#export-sheet-btn')).toBeEnabled();
+  });
+
+  test('an invalid grid is reported without a request', async ({ page }) => {
+    await page.goto('/');
+    await page.waitForSelector('.breadcrumb', { timeout: 10_000 });
+    await navigateToFolder(page, 'folder-b');
+    await page.waitForSelector(`[data-name="${GPS_IMAGE}"]`, { timeout: 10_000 });
+    await page.locator(`[data-name="${GPS_IMAGE}"]`).click();
+    await page.locator('.tools-menu-btn').click();
+    await page.locator('button.tool-item[data-tool="export"]').click();
+    const modal = page.locator('.export-modal');
+    await expect(modal).toBeVisible({ timeout: 5_000 });
+
+    page.once('dialog', dialog => dialog.accept('lots'));
+    await modal.locator('#export-sheet-btn').click();
+    await expect(modal.locator('.export-status')).toContainText('columns x rows');
+  });
+});
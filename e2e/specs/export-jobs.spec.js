@@ -0,0 +1,56 @@
+import { test, expect } from '@playwright/test';
+import { GPS_PATH, NO_GPS_PATH } from '../helpers/fixtures.js';
+
+// Polls GET /api/jobs/{id} until the job has finished.
+async function waitForJob(request, id) {
+  let job;
+  await expect(async () => {
+    const res = await request.get(`/api/jobs/${id}`);
+    expect(res.status()).toBe(200);
+    job = await res.json();
+    expect(['done', 'failed', 'cancelled']).toContain(job.status);
+  }).toPass({ timeout: 30_000 });
+  return job;
+}
+
+test.describe('Export jobs', () => {
+  test('background export runs to a downloadable ZIP', async ({ request }) => {
+    const res = await request.post('/api/jobs/export', {
+      data: { files: [GPS_PATH, NO_GPS_PATH], format: 'jpeg', quality: 80, scale: {}, exifMode: 'strip' },
+    });
+    expect(res.status()).toBe(202);
+    const { id, kind } = await res.json();
+    expect(id).toBeTruthy();
+    expect(kind).toBe('export');
+
+    const job = await waitForJob(request, id);
+    expect(job.status).toBe('done');
+    expect(job.done).toBe(2);
+    expect(job.total).toBe(2);
+    expect(job.expiresAt).toBeTruthy();
+
+    const list = await (await request.get('/api/jobs')).json();
+    expect(list.some(j => j.id === id)).toBe(true);
+
+    // The result stays downloadable: fetch it twice.
+    for (let i = 0; i < 2; i++) {
+      const dl = await request.get(`/api/jobs/${id}/download`);
+      expect(dl.status()).toBe(200);
+      expect(dl.headers()['content-type']).toContain('application/zip');
+      const buf = await dl.body();
+      expect(buf.subarray(0, 2).toString()).toBe('PK');
+    }
+
+    // A finished job cannot be cancelled.
+    expect((await request.post(`/api/jobs/${id}/cancel`)).status()).toBe(409);
+
+    expect((await request.delete(`/api/jobs/${id}`)).status()).toBe(204);
+    expect((await request.get(`/api/jobs/${id}`)).status()).toBe(404);
+    expect((await request.get(`/api/jobs/${id}/download`)).status()).toBe(404);
+  });
+
+  test('unknown job IDs return 404', async ({ request }) => {
+    expect((await request.get('/api/jobs/does-not-exist')).status()).toBe(404);
+    expect((await request.post('/api/jobs/does-not-exist/resume')).status()).toBe(404);
+  });
+});
@@ -0,0 +1,137 @@
+import { test, expect } from '@playwright/test';
+import { GPS_PATH, GPS_IMAGE, navigateToFolder } from '../helpers/fixtures.js';
+
+// Preset names are unique per run so repeated runs don't collide on 409.
+const uniqueName = prefix => `${prefix} ${Date.now()}`;
+
+async function openExportModal(page) {
+  await page.goto('/');
+  await page.waitForSelector('.breadcrumb', { timeout: 10_000 });
+  await navigateToFolder(page, 'folder-b');
+  await page.waitForSelector(`[data-name="${GPS_IMAGE}"]`, { timeout: 10_000 });
+  await page.locator(`[data-name="${GPS_IMAGE}"]`).click();
+  await page.locator('.tools-menu-btn').click();
+  await page.locator('button.tool-item[data-tool="export"]').click();
+  const modal = page.locator('.export-modal');
+  await expect(modal).toBeVisible({ timeout: 5_000 });
+  return modal;
+}
+
+test.describe('Export presets', () => {
+  // ── API ───────────────────────────────────────────────────────────────────
+
+  test('create, read, update and delete a preset', async ({ request }) => {
+    const name = uniqueName('Web small');
+    const preset = {
+      name,
+      options: { format: 'jpeg', quality: 70, scale: { mode: 'percent', percent: 50 }, exifMode: 'strip' },
+      namePattern: '{YYYY}/{name}',
+    };
+
+    const created = await request.post('/api/export/presets', { data: preset });
+    expect(created.status()).toBe(201);
+
+    // Creating the same name again is a conflict.
+    expect((await request.post('/api/export/presets', { data: preset })).status()).toBe(409);
+
+    const list = await (await request.get('/api/export/presets')).json();
+    expect(list.some(p => p.name === name)).toBe(true);
+
+    const got = await (await request.get(`/api/export/presets/${encodeURIComponent(name)}`)).json();
+    expect(got.options.quality).toBe(70);
+    expect(got.namePattern).toBe('{YYYY}/{name}');
+
+    const updated = await request.put(`/api/export/presets/${encodeURIComponent(name)}`, {
+      data: { ...preset, options: { ...preset.options, quality: 90 } },
+    });
+    expect(updated.status()).toBe(200);
+    const reread = await (await request.get(`/api/export/presets/${encodeURIComponent(name)}`)).json();
+    expect(reread.options.quality).toBe(90);
+
+    expect((await request.delete(`/api/export/presets/${encodeURIComponent(name)}`)).status()).toBe(204);
+    expect((await request.get(`/api/export/presets/${encodeURIComponent(name)}`)).status()).toBe(404);
+  });
+
+  test('invalid presets are rejected', async ({ request }) => {
+    const res = await request.post('/api/export/presets', {
+      data: { name: 'a/b', options: { format: 'jpeg' } },
+    });
+    expect(res.status()).toBe(400);
+
+    const bad = await request.post('/api/export/presets', {
+      data: { name: uniqueName('Bad format'), options: { format: 'bmp' } },
+    });
+    expect(bad.status()).toBe(400);
+  });
+
+  test('zip-stream export accepts a preset by name', async ({ request }) => {
+    const name = uniqueName('Stream');
+    await request.post('/api/export/presets', {
+      data: { name, options: { format: 'jpeg', quality: 60, scale: {}, exifMode: 'strip' } },
+    });
+    try {
+      const res = await request.post('/api/export/zip-stream', {
+        data: { files: [GPS_PATH], preset: name },
+      });
+      expect(res.status()).toBe(200);
+      const text = await res.text();
+      expect(text).toContain('"token"');
+    } finally {
+      await request.delete(`/api/export/presets/${encodeURIComponent(name)}`);
+    }
+  });
+
+  test('unknown preset in an export request is rejected', async ({ request }) => {
+    const res = await request.post('/api/export/zip-stream', {
+      data: { files: [GPS_PATH], preset: 'no such preset' },
+    });
+    expect(res.status()).toBe(400);
+  });
+
+  // ── UI ────────────────────────────────────────────────────────────────────
+
+  test('selecting a preset applies its settings in the export dialog', async ({ page, request }) => {
+    const name = uniqueName('Apply');
+    await request.post('/api/export/presets', {
+      data: {
+        name,
+        options: { format: 'png', quality: 42, scale: {}, exifMode: 'keep' },
+        namePattern: '{YYYY}/{name}',
+      },
+    });
+    try {
+      const modal = await openExportModal(page);
+      const select = modal.locator('.export-preset-select');
+      await expect(select.locator(`option[value="${name}"]`)).toHaveCount(1);
+      await expect(modal.locator('.export-preset-delete')).toBeDisabled();
+
+      await select.selectOption(name);
+      await expect(modal.locator('[data-format="png"]')).toHaveClass(/active/);
+      await expect(modal.locator('[name="exif-mode"][value="keep"]')).toBeChecked();
+      await expect(modal.locator('.export-name-pattern')).toHaveValue('{YYYY}/{name}');
+      await expect(modal.locator('.export-preset-delete')).toBeEnabled();
+    } finally {
+      await request.delete(`/api/export/presets/${encodeURIComponent(name)}`);
+    }
+  });
+
+  test('saving and deleting a preset from the export dialog', async ({ page, request }) => {
+    const name = uniqueName('Saved');
+    const modal = await openExportModal(page);
+
+    await modal.locator('.export-quality-slider').fill('55');
+    page.once('dialog', dialog => dialog.accept(name));
+    await modal.locator('.export-preset-save').click();
+    await expect(modal.locator('.export-status')).toContainText(`Preset "${name}" saved.`);
+    await expect(modal.locator('.export-preset-select')).toHaveValue(name);
+
+    const saved = await request.get(`/api/export/presets/${encodeURIComponent(name)}`);
+    expect(saved.status()).toBe(200);
+    expect((await saved.json()).options.quality).toBe(55);
+
+    page.once('dialog', dialog => dialog.accept());
+    await modal.locator('.export-preset-delete').click();
+    await expect(modal.locator(`.export-preset-select option[value="${name}"]`)).toHaveCount(0);
+    expect((await request.get(`/api/export/presets/${encodeURIComponent(name)}`)).status()).toBe(404);
+  });
+});
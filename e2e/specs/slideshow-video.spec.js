@@ -0,0 +1,91 @@
+import { test, expect } from '@playwright/test';
+import { GPS_PATH, NO_GPS_PATH, GPS_IMAGE, navigateToFolder } from '../helpers/fixtures.js';
+
+// Rendering needs ffmpeg on the server; validation does not.
+async function ffmpegAvailable(request) {
+  const tools = await (await request.get('/api/tools/check')).json();
+  return tools.ffmpeg?.available === true;
+}
+
+async function waitForJob(request, id) {
+  let job;
+  await expect(async () => {
+    const res = await request.get(`/api/jobs/${id}`);
+    expect(res.status()).toBe(200);
+    job = await res.json();
+    expect(['done', 'failed', 'cancelled']).toContain(job.status);
+  }).toPass({ timeout: 120_000 });
+  return job;
+}
+
+test.describe('Slideshow video', () => {
+  // ── API ───────────────────────────────────────────────────────────────────
+
+  test('invalid slideshow requests are rejected', async ({ request }) => {
+    const cases = [
+      { files: [] },
+      { files: [GPS_PATH], width: 1281, height: 720 },
+      { files: [GPS_PATH], slideDuration: 61 },
+      { files: [GPS_PATH], transition: 'wipe' },
+      { files: [GPS_PATH], music: '../secret.mp3' },
+      { files: ['../outside.jpg'] },
+    ];
+    for (const data of cases) {
+      const res = await request.post('/api/jobs/slideshow', { data });
+      expect(res.status(), JSON.stringify(data)).toBe(400);
+    }
+  });
+
+  test('renders an MP4 as a background job', async ({ request }) => {
+    test.skip(!(await ffmpegAvailable(request)), 'ffmpeg not available');
+    test.setTimeout(180_000);
+
+    const res = await request.post('/api/jobs/slideshow', {
+      data: { files: [GPS_PATH, NO_GPS_PATH], width: 640, height: 360, slideDuration: 2, transition: 'crossfade' },
+    });
+    expect(res.status()).toBe(202);
+    const { id, kind } = await res.json();
+    expect(kind).toBe('slideshow');
+
+    const job = await waitForJob(request, id);
+    expect(job.status).toBe('done');
+
+    const dl = await request.get(`/api/jobs/${id}/download`);
+    expect(dl.status()).toBe(200);
+    expect(dl.headers()['content-disposition']).toContain('.mp4');
+    // MP4 files carry an "ftyp" box right after the 4-byte size.
+    expect((await dl.body()).subarray(4, 8).toString()).toBe('ftyp');
+
+    await request.delete(`/api/jobs/${id}`);
+  });
+
+  // ── UI ────────────────────────────────────────────────────────────────────
+
+  test('slideshow dialog renders the selection as a video', async ({ page, request }) => {
+    test.skip(!(await ffmpegAvailable(request)), 'ffmpeg not available');
+    test.setTimeout(180_000);
+
+    await page.goto('/');
+    await page.waitForSelector('.breadcrumb', { timeout: 10_000 });
+    await navigateToFolder(page, 'folder-b');
+    await page.waitForSelector(`[data-name="${GPS_IMAGE}"]`, { timeout: 10_000 });
+    await page.locator(`[data-name="${GPS_IMAGE}"]`).click();
+
+    await page.locator('.slideshow-btn').click();
+    const modal = page.locator('.slideshow-modal');
+    await expect(modal).toBeVisible({ timeout: 5_000 });
+
+    const renderBtn = modal.locator('.ss-video-btn');
+    await expect(renderBtn).toBeEnabled();
+    await modal.locator('.ss-delay-range').fill('1');
+    await modal.locator('.ss-video-resolution').selectOption('1280x720');
+
+    const downloadPromise = page.waitForEvent('download', { timeout: 150_000 });
+    await renderBtn.click();
+    await expect(renderBtn).toBeDisabled();
+    const download = await downloadPromise;
+    expect(download.suggestedFilename()).toMatch(/\.mp4$/);
+    await expect(modal.locator('.ss-video-status')).toHaveText('Video ready.');
+    await expect(renderBtn).toBeEnabled();
+  });
+});
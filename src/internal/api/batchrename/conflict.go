@@ -5,26 +5,35 @@ import (
 	"path/filepath"
 	"strings"
 
+	"huepattl.de/unterlumen/internal/geocode"
+	"huepattl.de/unterlumen/internal/library"
 	"huepattl.de/unterlumen/internal/media"
 	"huepattl.de/unterlumen/internal/pathguard"
 )
 
-func resolveBatchMappings(root string, files []string, pattern string) []batchRenameMapping {
+func resolveBatchMappings(root string, files []string, pattern string, geo *geocode.Gazetteer) []batchRenameMapping {
 	mappings := make([]batchRenameMapping, len(files))
 	for i, file := range files {
-		mappings[i] = resolveOneMapping(root, file, pattern, i+1)
+		mappings[i] = resolveOneMapping(root, file, pattern, i+1, geo)
 	}
 	return mappings
 }
 
-func resolveOneMapping(root, file, pattern string, seq int) batchRenameMapping {
+func resolveOneMapping(root, file, pattern string, seq int, geo *geocode.Gazetteer) batchRenameMapping {
 	abs, ok := pathguard.SafePath(root, file)
 	if !ok {
 		return batchRenameMapping{File: file, Error: "invalid path"}
 	}
 
-	originalName := strings.TrimSuffix(filepath.Base(abs), filepath.Ext(abs))
 	ext := strings.ToLower(filepath.Ext(abs))
+	newName := sanitizeFilename(resolveForFile(abs, pattern, seq, geo)) + ext
+	return batchRenameMapping{File: file, NewName: newName}
+}
+
+// resolveForFile reads the metadata of the file at abs and resolves pattern
+// against it. Missing metadata resolves to "unknown".
+func resolveForFile(abs, pattern string, seq int, geo *geocode.Gazetteer) string {
+	originalName := strings.TrimSuffix(filepath.Base(abs), filepath.Ext(abs))
 
 	exifData, err := media.ExtractAllEXIF(abs)
 	var tags map[string]string
@@ -34,6 +43,7 @@ func resolveOneMapping(root, file, pattern string, seq int) batchRenameMapping {
 		if exifData.DateTaken != nil {
 			dateTaken = *exifData.DateTaken
 		}
+		addPlaceTags(tags, exifData, geo)
 	} else {
 		tags = make(map[string]string)
 	}
@@ -43,9 +53,20 @@ func resolveOneMapping(root, file, pattern string, seq int) batchRenameMapping {
 		photoTitle = t
 	}
 
-	resolved := resolvePattern(pattern, tags, dateTaken, originalName, photoTitle, seq)
-	newName := sanitizeFilename(resolved) + ext
-	return batchRenameMapping{File: file, NewName: newName}
+	return resolvePattern(pattern, tags, dateTaken, originalName, photoTitle, seq)
+}
+
+// addPlaceTags adds reverse-geocoded place names to tags for the {country},
+// {region} and {city} tokens. Leaves tags unchanged without GPS or a gazetteer.
+func addPlaceTags(tags map[string]string, d *media.ExifData, geo *geocode.Gazetteer) {
+	if d.Latitude == nil || d.Longitude == nil {
+		return
+	}
+	if p, ok := geo.Lookup(*d.Latitude, *d.Longitude); ok {
+		tags[library.FieldCountry] = p.Country
+		tags[library.FieldRegion] = p.Region
+		tags[library.FieldCity] = p.City
+	}
 }
 
 func applyConflictSuffixes(mappings []batchRenameMapping) int {
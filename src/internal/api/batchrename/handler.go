@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"huepattl.de/unterlumen/internal/geocode"
 	"huepattl.de/unterlumen/internal/library"
 	"huepattl.de/unterlumen/internal/media"
 	"huepattl.de/unterlumen/internal/pathguard"
@@ -42,11 +43,11 @@ type batchRenameExecuteResponse struct {
 // Handle registers the batch-rename routes on mux.
 // libMgr may be nil if library support could not be initialised.
 func Handle(mux *http.ServeMux, root string, cache *media.ScanCache, libMgr *library.Manager) {
-	mux.HandleFunc("/api/batch-rename/preview", handleBatchRenamePreview(root, cache))
+	mux.HandleFunc("/api/batch-rename/preview", handleBatchRenamePreview(root, cache, libMgr))
 	mux.HandleFunc("/api/batch-rename/execute", handleBatchRenameExecute(root, cache, libMgr))
 }
 
-func handleBatchRenamePreview(root string, cache *media.ScanCache) http.HandlerFunc {
+func handleBatchRenamePreview(root string, cache *media.ScanCache, libMgr *library.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -58,7 +59,7 @@ func handleBatchRenamePreview(root string, cache *media.ScanCache) http.HandlerF
 			return
 		}
 
-		mappings := resolveBatchMappings(root, req.Files, req.Pattern)
+		mappings := resolveBatchMappings(root, req.Files, req.Pattern, gazetteerOf(libMgr))
 		conflicts := applyConflictSuffixes(mappings)
 
 		w.Header().Set("Content-Type", "application/json")
@@ -78,7 +79,7 @@ func handleBatchRenameExecute(root string, cache *media.ScanCache, libMgr *libra
 			return
 		}
 
-		mappings := resolveBatchMappings(root, req.Files, req.Pattern)
+		mappings := resolveBatchMappings(root, req.Files, req.Pattern, gazetteerOf(libMgr))
 		applyConflictSuffixes(mappings)
 
 		if results, hasErrors := validateNoErrors(mappings); hasErrors {
@@ -130,6 +131,15 @@ func handleBatchRenameExecute(root string, cache *media.ScanCache, libMgr *libra
 	}
 }
 
+// gazetteerOf returns the library manager's reverse-geocoding index, or nil
+// (place tokens resolve to "unknown") when library support is unavailable.
+func gazetteerOf(libMgr *library.Manager) *geocode.Gazetteer {
+	if libMgr == nil {
+		return nil
+	}
+	return libMgr.Gazetteer()
+}
+
 func decodeRenameRequest(w http.ResponseWriter, r *http.Request) (batchRenameRequest, bool) {
 	var req batchRenameRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -0,0 +1,53 @@
+package batchrename
+
+import (
+	"strings"
+
+	"huepattl.de/unterlumen/internal/library"
+)
+
+// OutputNames resolves an export filename pattern for each source file (absolute
+// paths), using the same tokens as batch rename; {seq} counts from 1 in the
+// order given. A "/" in the pattern creates subfolders: every segment is
+// sanitised on its own, so names can never escape the output directory. ext
+// (e.g. ".jpg") is appended and duplicates are suffixed like rename conflicts.
+// libMgr provides the gazetteer for place tokens and may be nil.
+func OutputNames(absPaths []string, pattern, ext string, libMgr *library.Manager) []string {
+	geo := gazetteerOf(libMgr)
+	names := make([]string, len(absPaths))
+	for i, abs := range absPaths {
+		names[i] = sanitizeRelPath(resolveForFile(abs, pattern, i+1, geo)) + ext
+	}
+	return DedupeNames(names)
+}
+
+// DedupeNames returns names with _001, _002, … appended to every name that
+// occurs more than once, as batch rename does for conflicting targets.
+func DedupeNames(names []string) []string {
+	mappings := make([]batchRenameMapping, len(names))
+	for i, n := range names {
+		mappings[i] = batchRenameMapping{NewName: n}
+	}
+	applyConflictSuffixes(mappings)
+	out := make([]string, len(names))
+	for i, m := range mappings {
+		out[i] = m.NewName
+	}
+	return out
+}
+
+// sanitizeRelPath sanitises each "/"-separated segment of a resolved pattern
+// and drops empty ones.
+func sanitizeRelPath(p string) string {
+	var segs []string
+	for _, seg := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if strings.TrimSpace(seg) == "" {
+			continue
+		}
+		segs = append(segs, sanitizeFilename(seg))
+	}
+	if len(segs) == 0 {
+		return "unnamed"
+	}
+	return strings.Join(segs, "/")
+}
@@ -0,0 +1,53 @@
+package batchrename
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputNamesSubfoldersAndConflicts(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a", "DSCF0001.JPG")
+	b := filepath.Join(dir, "b", "DSCF0001.JPG")
+	for _, p := range []string{a, b} {
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		os.WriteFile(p, []byte("not a jpeg"), 0o644)
+	}
+
+	got := OutputNames([]string{a, b}, "{YYYY}/{original}", ".jpg", nil)
+	want := []string{"unknown/DSCF0001_001.jpg", "unknown/DSCF0001_002.jpg"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("name[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	got = OutputNames([]string{a, b}, "export/{seq:4}", ".png", nil)
+	if got[0] != "export/0001.png" || got[1] != "export/0002.png" {
+		t.Errorf("seq names = %v", got)
+	}
+}
+
+func TestSanitizeRelPath(t *testing.T) {
+	cases := map[string]string{
+		"2024/10/photo":      "2024/10/photo",
+		"../../etc/passwd":   "unnamed/unnamed/etc/passwd",
+		"/abs//double/ x ":   "abs/double/x",
+		`win\style\path`:     "win/style/path",
+		"":                   "unnamed",
+		"my photo/new name!": "my-photo/new-name",
+	}
+	for in, want := range cases {
+		if got := sanitizeRelPath(in); got != want {
+			t.Errorf("sanitizeRelPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDedupeNames(t *testing.T) {
+	got := DedupeNames([]string{"x.jpg", "y.jpg", "x.jpg"})
+	if got[0] != "x_001.jpg" || got[1] != "y.jpg" || got[2] != "x_002.jpg" {
+		t.Errorf("DedupeNames = %v", got)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"huepattl.de/unterlumen/internal/library"
 )
 
 var seqPattern = regexp.MustCompile(`\{seq(?::(\d+))?\}`)
@@ -39,6 +41,9 @@ func resolvePattern(pattern string, tags map[string]string, dateTaken string, or
 	result = strings.ReplaceAll(result, "{aperture}", formatAperture(tags))
 	result = strings.ReplaceAll(result, "{focal}", formatFocal(tags))
 	result = strings.ReplaceAll(result, "{shutter}", formatShutter(tags))
+	result = strings.ReplaceAll(result, "{country}", exifTagValue(tags, library.FieldCountry))
+	result = strings.ReplaceAll(result, "{region}", exifTagValue(tags, library.FieldRegion))
+	result = strings.ReplaceAll(result, "{city}", exifTagValue(tags, library.FieldCity))
 	result = strings.ReplaceAll(result, "{original}", originalName)
 	result = strings.ReplaceAll(result, "{title}", slugify(title))
 
@@ -145,3 +145,18 @@ func TestResolvePatternTitle(t *testing.T) {
 		t.Errorf("got %q", got)
 	}
 }
+
+func TestResolvePattern_PlaceTokens(t *testing.T) {
+	tags := map[string]string{"Country": "Germany", "Region": "Bavaria", "City": "Munich"}
+	result := resolvePattern("{country}_{region}_{city}", tags, "", "x", "", 1)
+	if result != "Germany_Bavaria_Munich" {
+		t.Errorf("got %q, want 'Germany_Bavaria_Munich'", result)
+	}
+}
+
+func TestResolvePattern_PlaceTokensUnknown(t *testing.T) {
+	result := resolvePattern("{city}", map[string]string{}, "", "x", "", 1)
+	if result != "unknown" {
+		t.Errorf("photo without place should produce 'unknown', got %q", result)
+	}
+}
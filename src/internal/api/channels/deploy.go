@@ -0,0 +1,88 @@
+package apichannels
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"huepattl.de/unterlumen/internal/channels"
+	"huepattl.de/unterlumen/internal/deploy"
+)
+
+// deploying holds the slugs of channels with a deploy in progress.
+var deploying sync.Map
+
+// validateDeploy checks the deploy settings in a site channel's handler config.
+func validateDeploy(ch *channels.Channel) error {
+	if !ch.SiteExport {
+		return nil
+	}
+	if _, err := deploy.FromConfig(ch.HandlerConfig); err != nil && !errors.Is(err, deploy.ErrNotConfigured) {
+		return fmt.Errorf("deploy: %w", err)
+	}
+	return nil
+}
+
+// deployChannel pushes a site channel's generated site to the target in its
+// handler config, streaming progress as server-sent events:
+// {"step":"upload"|"delete","done","total","file"} per file, then
+// {"complete":true,...deploy.Result} or {"complete":true,"error":...}.
+func deployChannel(store *channels.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		slug := r.PathValue("slug")
+		ch, err := store.Get(slug)
+		if err != nil {
+			http.Error(w, "channel not found", http.StatusNotFound)
+			return
+		}
+		if !ch.SiteExport {
+			http.Error(w, "channel is not configured for site export", http.StatusBadRequest)
+			return
+		}
+		target, err := deploy.FromConfig(ch.HandlerConfig)
+		if err != nil {
+			http.Error(w, "deploy: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		siteDir := filepath.Join(store.OutputDir(slug), "site")
+		if _, err := os.Stat(filepath.Join(siteDir, "index.html")); err != nil {
+			http.Error(w, "site has not been generated yet", http.StatusBadRequest)
+			return
+		}
+		if _, busy := deploying.LoadOrStore(slug, true); busy {
+			http.Error(w, "a deploy of this channel is already running", http.StatusConflict)
+			return
+		}
+		defer deploying.Delete(slug)
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		emit := func(v any) {
+			data, _ := json.Marshal(v)
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+
+		res, err := deploy.Run(r.Context(), siteDir, target, deploy.Options{
+			Exclude:  []string{"site.json"}, // publish state, not part of the site
+			Progress: func(p deploy.Progress) { emit(p) },
+		})
+		if err != nil {
+			emit(map[string]any{"complete": true, "error": err.Error()})
+			return
+		}
+		emit(map[string]any{"complete": true, "target": res.Target, "uploaded": res.Uploaded, "deleted": res.Deleted, "files": res.Files})
+	}
+}
@@ -3,6 +3,8 @@ package apichannels
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -14,6 +16,7 @@ import (
 
 	"huepattl.de/unterlumen/internal/channels"
 	"huepattl.de/unterlumen/internal/media"
+	"huepattl.de/unterlumen/internal/objstore"
 )
 
 var slugRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,61}[a-z0-9]$|^[a-z0-9]$`)
@@ -32,6 +35,7 @@ func Handle(mux *http.ServeMux, store *channels.Store) {
 	mux.HandleFunc("GET /api/channels/{slug}/logo", logoStatus(store))
 	mux.HandleFunc("POST /api/channels/{slug}/logo", uploadLogo(store))
 	mux.HandleFunc("DELETE /api/channels/{slug}/logo", deleteLogo(store))
+	mux.HandleFunc("POST /api/channels/{slug}/deploy", deployChannel(store))
 }
 
 func writeJSON(w http.ResponseWriter, v any) {
@@ -62,6 +66,14 @@ func createChannel(store *channels.Store) http.HandlerFunc {
 			http.Error(w, "valid slug and name are required", http.StatusBadRequest)
 			return
 		}
+		if err := ch.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := validateHandlerConfig(&ch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if _, err := store.Get(ch.Slug); err == nil {
 			http.Error(w, "channel slug already exists", http.StatusConflict)
 			return
@@ -88,6 +100,14 @@ func updateChannel(store *channels.Store) http.HandlerFunc {
 			http.Error(w, "name is required", http.StatusBadRequest)
 			return
 		}
+		if err := ch.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := validateHandlerConfig(&ch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err := store.Save(&ch); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -96,6 +116,18 @@ func updateChannel(store *channels.Store) http.HandlerFunc {
 	}
 }
 
+// validateHandlerConfig checks the deploy and object storage settings that
+// live in the handler config next to (or instead of) a handler's own keys.
+func validateHandlerConfig(ch *channels.Channel) error {
+	if err := validateDeploy(ch); err != nil {
+		return err
+	}
+	if _, err := objstore.FromConfig(ch.HandlerConfig); err != nil && !errors.Is(err, objstore.ErrNotConfigured) {
+		return fmt.Errorf("storage: %w", err)
+	}
+	return nil
+}
+
 func deleteChannel(store *channels.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		slug := r.PathValue("slug")
@@ -0,0 +1,429 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apilibrary "huepattl.de/unterlumen/internal/api/library"
+	"huepattl.de/unterlumen/internal/library"
+	"huepattl.de/unterlumen/internal/media"
+	"huepattl.de/unterlumen/internal/pdf"
+)
+
+// maxContactSheetPhotos caps how many photos one sheet may contain, so a broad
+// library search cannot produce a PDF with thousands of pages.
+const maxContactSheetPhotos = 1000
+
+// Caption fields that can be printed under each thumbnail.
+const (
+	sheetFieldFilename = "filename"
+	sheetFieldDate     = "date"
+	sheetFieldExif     = "exif"
+)
+
+type contactSheetRequest struct {
+	Files      []string `json:"files,omitempty"`
+	SourcePath string   `json:"sourcePath,omitempty"`
+	Search     string   `json:"search,omitempty"` // query string in GET /api/library/search syntax
+	Title      string   `json:"title,omitempty"`
+	Columns    int      `json:"columns,omitempty"`  // default 4
+	Rows       int      `json:"rows,omitempty"`     // default 5
+	PageSize   string   `json:"pageSize,omitempty"` // "a4" (default) or "letter"
+	Landscape  bool     `json:"landscape,omitempty"`
+	Fields     []string `json:"fields,omitempty"` // caption fields; default filename, date, exif
+}
+
+// sheetPhoto is one photo placed on a contact sheet.
+type sheetPhoto struct {
+	path  string
+	name  string
+	date  string     // "2006-01-02 15:04", empty when unknown
+	exif  string     // aperture · shutter · ISO · film simulation
+	thumb *pdf.Image // nil when no thumbnail could be made
+}
+
+// sheetLayout holds the page geometry derived from the request, in points.
+type sheetLayout struct {
+	page         pdf.Size
+	cols, rows   int
+	margin       float64
+	cellW, cellH float64
+	gap          float64
+	gridTop      float64 // y of the top edge of the first row
+	imageH       float64 // height of the thumbnail box within a cell
+	fields       []string
+}
+
+const (
+	sheetMargin     = 36
+	sheetGap        = 10
+	sheetHeaderH    = 44
+	sheetFooterH    = 24
+	sheetCaptionPt  = 7
+	sheetCaptionLn  = 9
+	sheetMinImageH  = 24
+	sheetThumbDPI   = 300
+	sheetThumbMin   = 200
+	sheetThumbMax   = 1024
+	sheetJPEGQual   = 85
+	sheetDateLayout = "2006-01-02 15:04"
+)
+
+func handleContactSheet(root string, serverRole bool, libMgr *library.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req contactSheetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		layout, err := newSheetLayout(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var paths []string
+		title := req.Title
+		switch {
+		case req.Search != "":
+			if libMgr == nil {
+				http.Error(w, "library search is not available", http.StatusBadRequest)
+				return
+			}
+			var searchTitle string
+			paths, searchTitle, err = searchSheetPaths(libMgr, req.Search)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if title == "" {
+				title = searchTitle
+			}
+		default:
+			eRoot := effectiveRoot(root, req.SourcePath)
+			for _, f := range req.Files {
+				abs, ok := resolveFilePath(eRoot, serverRole, f)
+				if !ok {
+					http.Error(w, "invalid path: "+f, http.StatusBadRequest)
+					return
+				}
+				paths = append(paths, abs)
+			}
+			if title == "" {
+				title = commonFolderName(paths)
+			}
+		}
+		if len(paths) == 0 {
+			http.Error(w, "no photos selected", http.StatusBadRequest)
+			return
+		}
+		if len(paths) > maxContactSheetPhotos {
+			http.Error(w, fmt.Sprintf("too many photos (max %d)", maxContactSheetPhotos), http.StatusBadRequest)
+			return
+		}
+		if title == "" {
+			title = "Contact sheet"
+		}
+
+		doc := pdf.New()
+		doc.Title = title
+		photos := loadSheetPhotos(r.Context(), doc, paths, layout.thumbSize())
+		if r.Context().Err() != nil {
+			return
+		}
+		layout.render(doc, title, photos, time.Now())
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `attachment; filename="contact-sheet.pdf"`)
+		doc.Write(w)
+	}
+}
+
+// newSheetLayout validates the grid options and computes cell geometry.
+func newSheetLayout(req contactSheetRequest) (*sheetLayout, error) {
+	l := &sheetLayout{cols: req.Columns, rows: req.Rows, margin: sheetMargin, gap: sheetGap}
+	if l.cols == 0 {
+		l.cols = 4
+	}
+	if l.rows == 0 {
+		l.rows = 5
+	}
+	if l.cols < 1 || l.cols > 10 || l.rows < 1 || l.rows > 15 {
+		return nil, fmt.Errorf("grid must be 1–10 columns and 1–15 rows")
+	}
+
+	switch strings.ToLower(req.PageSize) {
+	case "", "a4":
+		l.page = pdf.A4
+	case "letter":
+		l.page = pdf.Letter
+	default:
+		return nil, fmt.Errorf("unsupported page size %q", req.PageSize)
+	}
+	if req.Landscape {
+		l.page = l.page.Landscape()
+	}
+
+	l.fields = req.Fields
+	if l.fields == nil {
+		l.fields = []string{sheetFieldFilename, sheetFieldDate, sheetFieldExif}
+	}
+	for _, f := range l.fields {
+		if f != sheetFieldFilename && f != sheetFieldDate && f != sheetFieldExif {
+			return nil, fmt.Errorf("unknown caption field %q", f)
+		}
+	}
+
+	gridH := l.page.H - 2*l.margin - sheetHeaderH - sheetFooterH
+	l.cellW = (l.page.W - 2*l.margin - float64(l.cols-1)*l.gap) / float64(l.cols)
+	l.cellH = (gridH - float64(l.rows-1)*l.gap) / float64(l.rows)
+	l.gridTop = l.page.H - l.margin - sheetHeaderH
+	l.imageH = l.cellH - l.captionH()
+	if l.imageH < sheetMinImageH {
+		return nil, fmt.Errorf("grid %dx%d leaves no room for thumbnails on this page", l.cols, l.rows)
+	}
+	return l, nil
+}
+
+func (l *sheetLayout) captionH() float64 {
+	if len(l.fields) == 0 {
+		return 0
+	}
+	return float64(len(l.fields))*sheetCaptionLn + 3
+}
+
+// thumbSize returns the thumbnail edge length in pixels that prints the cell
+// at roughly 300 dpi.
+func (l *sheetLayout) thumbSize() int {
+	px := int(math.Max(l.cellW, l.imageH) / 72 * sheetThumbDPI)
+	return max(sheetThumbMin, min(px, sheetThumbMax))
+}
+
+func (l *sheetLayout) perPage() int { return l.cols * l.rows }
+
+// render lays out photos across as many pages as needed.
+func (l *sheetLayout) render(doc *pdf.Document, title string, photos []*sheetPhoto, now time.Time) {
+	pages := (len(photos) + l.perPage() - 1) / l.perPage()
+	subtitle := sheetSubtitle(photos)
+	for p := 0; p < pages; p++ {
+		page := doc.AddPage(l.page)
+		l.drawHeader(page, title, subtitle)
+		l.drawFooter(page, now, p+1, pages)
+
+		start := p * l.perPage()
+		end := min(start+l.perPage(), len(photos))
+		for i, ph := range photos[start:end] {
+			col, row := i%l.cols, i/l.cols
+			x := l.margin + float64(col)*(l.cellW+l.gap)
+			top := l.gridTop - float64(row)*(l.cellH+l.gap)
+			l.drawCell(page, ph, x, top)
+		}
+	}
+}
+
+func (l *sheetLayout) drawHeader(page *pdf.Page, title, subtitle string) {
+	width := l.page.W - 2*l.margin
+	y := l.page.H - l.margin - 14
+	page.Text(l.margin, y, 14, pdf.HelveticaBold, 0, pdf.Truncate(title, 14, width, pdf.HelveticaBold))
+	page.Text(l.margin, y-14, 8, pdf.Helvetica, 0.4, pdf.Truncate(subtitle, 8, width, pdf.Helvetica))
+	lineY := l.page.H - l.margin - sheetHeaderH + 10
+	page.Line(l.margin, lineY, l.page.W-l.margin, lineY, 0.5, 0.7)
+}
+
+func (l *sheetLayout) drawFooter(page *pdf.Page, now time.Time, n, total int) {
+	y := l.margin
+	page.Text(l.margin, y, 7, pdf.Helvetica, 0.4, "Generated "+now.Format(sheetDateLayout))
+	label := fmt.Sprintf("Page %d of %d", n, total)
+	page.Text(l.page.W-l.margin-pdf.TextWidth(label, 7, pdf.Helvetica), y, 7, pdf.Helvetica, 0.4, label)
+}
+
+// drawCell draws one thumbnail fitted and centred in its box, with the
+// caption lines below. top is the y of the cell's upper edge.
+func (l *sheetLayout) drawCell(page *pdf.Page, ph *sheetPhoto, x, top float64) {
+	boxY := top - l.imageH
+	if ph.thumb != nil {
+		scale := math.Min(l.cellW/float64(ph.thumb.W), l.imageH/float64(ph.thumb.H))
+		w, h := float64(ph.thumb.W)*scale, float64(ph.thumb.H)*scale
+		page.Image(ph.thumb, x+(l.cellW-w)/2, boxY+(l.imageH-h)/2, w, h)
+	} else {
+		page.Rect(x, boxY, l.cellW, l.imageH, 0.5, 0.75)
+		label := "No preview"
+		page.Text(x+(l.cellW-pdf.TextWidth(label, 7, pdf.Helvetica))/2, boxY+l.imageH/2, 7, pdf.Helvetica, 0.5, label)
+	}
+
+	y := boxY - sheetCaptionLn
+	for _, f := range l.fields {
+		var text string
+		font, gray := pdf.Helvetica, 0.35
+		switch f {
+		case sheetFieldFilename:
+			text, font, gray = ph.name, pdf.HelveticaBold, 0
+		case sheetFieldDate:
+			text = ph.date
+		case sheetFieldExif:
+			text = ph.exif
+		}
+		if text != "" {
+			page.Text(x, y, sheetCaptionPt, font, gray, pdf.Truncate(text, sheetCaptionPt, l.cellW, font))
+		}
+		y -= sheetCaptionLn
+	}
+}
+
+// loadSheetPhotos reads captions and thumbnails for paths in parallel and
+// adds the thumbnails to doc in the original order.
+func loadSheetPhotos(ctx context.Context, doc *pdf.Document, paths []string, size int) []*sheetPhoto {
+	photos := make([]*sheetPhoto, len(paths))
+	thumbs := make([][]byte, len(paths))
+	sem := make(chan struct{}, media.DefaultExportWorkers())
+	var wg sync.WaitGroup
+	for i, p := range paths {
+		photos[i] = &sheetPhoto{path: p, name: filepath.Base(p)}
+		wg.Add(1)
+		go func(i int, ph *sheetPhoto) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
+			ph.date, ph.exif = sheetCaption(ph.path)
+			thumbs[i], _ = sheetThumbnail(ctx, ph.path, size)
+		}(i, photos[i])
+	}
+	wg.Wait()
+
+	for i, ph := range photos {
+		if thumbs[i] == nil {
+			continue
+		}
+		ph.thumb, _ = doc.AddJPEG(thumbs[i])
+	}
+	return photos
+}
+
+// sheetThumbnail returns a JPEG thumbnail from the regular thumbnail
+// pipeline, normalised to RGB so the PDF can embed it as is.
+func sheetThumbnail(ctx context.Context, path string, size int) ([]byte, error) {
+	var data []byte
+	var err error
+	if media.IsHEIF(path) {
+		data, err = media.ExtractHEIFPreviewThumbnail(ctx, path, size)
+	} else {
+		data, _, err = media.GenerateThumbnailCached(ctx, path, size, media.ExtractOrientation(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pdf.NormalizeJPEG(data, sheetJPEGQual)
+}
+
+// sheetCaption returns the capture date and a short exposure summary.
+func sheetCaption(path string) (date, summary string) {
+	data, err := media.ExtractAllEXIF(path)
+	if err != nil || data == nil {
+		return "", ""
+	}
+	if data.DateTaken != nil && len(*data.DateTaken) >= 16 {
+		date = strings.Replace((*data.DateTaken)[:16], "T", " ", 1)
+	}
+	return date, exifSummary(data.Tags)
+}
+
+// exifSummary formats aperture, shutter speed, ISO and film simulation, e.g.
+// "f/2.8 · 1/500 s · ISO 200 · Classic Chrome".
+func exifSummary(tags map[string]string) string {
+	var parts []string
+	for _, s := range []string{
+		media.FormatAperture(tags["FNumber"]),
+		media.FormatShutter(tags["ExposureTime"]),
+		media.FormatISO(tags["ISOSpeedRatings"]),
+		strings.Trim(tags["FilmSimulation"], `"`),
+	} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// sheetSubtitle describes the sheet contents: photo count and date range.
+func sheetSubtitle(photos []*sheetPhoto) string {
+	s := fmt.Sprintf("%d photos", len(photos))
+	if len(photos) == 1 {
+		s = "1 photo"
+	}
+	var dates []string
+	for _, ph := range photos {
+		if ph.date != "" {
+			dates = append(dates, ph.date[:10])
+		}
+	}
+	if len(dates) > 0 {
+		sort.Strings(dates)
+		if first, last := dates[0], dates[len(dates)-1]; first == last {
+			s += " · " + first
+		} else {
+			s += " · " + first + " – " + last
+		}
+	}
+	return s
+}
+
+// searchSheetPaths runs a library search given in the query-string syntax of
+// GET /api/library/search and returns the matching files. The title is the
+// album title when the search filters on one.
+func searchSheetPaths(libMgr *library.Manager, query string) ([]string, string, error) {
+	q, err := url.ParseQuery(strings.TrimPrefix(query, "?"))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid search: %w", err)
+	}
+	ids, opts, err := apilibrary.ParseSearchQuery(q)
+	if err != nil {
+		return nil, "", err
+	}
+	opts.Offset = 0
+	opts.Limit = maxContactSheetPhotos + 1
+	res, err := libMgr.SearchLibraries(ids, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	paths := make([]string, 0, len(res.Results))
+	for _, p := range res.Results {
+		paths = append(paths, p.PathHint)
+	}
+	title := opts.AlbumTitle
+	if title == "" {
+		title = "Library search"
+	}
+	return paths, title, nil
+}
+
+// commonFolderName returns the name of the folder shared by all paths, or ""
+// when they span several folders.
+func commonFolderName(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	dir := filepath.Dir(paths[0])
+	for _, p := range paths[1:] {
+		if filepath.Dir(p) != dir {
+			return ""
+		}
+	}
+	return filepath.Base(dir)
+}
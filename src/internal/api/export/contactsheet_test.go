@@ -0,0 +1,82 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestContactSheetPaginates(t *testing.T) {
+	root := t.TempDir()
+	var files []string
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("shoot/img%d.jpg", i)
+		writeJPEG(t, filepath.Join(root, name))
+		files = append(files, name)
+	}
+	mux := http.NewServeMux()
+	Handle(mux, root, true, nil, nil)
+
+	body, _ := json.Marshal(contactSheetRequest{Files: files, Columns: 2, Rows: 1})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/export/contact-sheet", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	out := w.Body.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-")) {
+		t.Fatal("response is not a PDF")
+	}
+	// 5 photos at 2 per page → 3 pages; the title defaults to the folder name.
+	if !bytes.Contains(out, []byte("/Count 3")) || !bytes.Contains(out, []byte("/Title (shoot)")) {
+		t.Error("unexpected page count or title")
+	}
+	if n := bytes.Count(out, []byte("/Subtype /Image")); n != 5 {
+		t.Errorf("embedded %d images, want 5", n)
+	}
+}
+
+func TestContactSheetRejectsBadRequests(t *testing.T) {
+	root := t.TempDir()
+	writeJPEG(t, filepath.Join(root, "a.jpg"))
+	mux := http.NewServeMux()
+	Handle(mux, root, true, nil, nil)
+
+	for name, req := range map[string]contactSheetRequest{
+		"empty":          {},
+		"grid too large": {Files: []string{"a.jpg"}, Columns: 11},
+		"page size":      {Files: []string{"a.jpg"}, PageSize: "a3"},
+		"field":          {Files: []string{"a.jpg"}, Fields: []string{"lens"}},
+		"escape":         {Files: []string{"../a.jpg"}},
+		"no library":     {Search: "make=Fujifilm"},
+	} {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/export/contact-sheet", bytes.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", name, w.Code)
+		}
+	}
+}
+
+func TestExifSummary(t *testing.T) {
+	tags := map[string]string{
+		"FNumber":         `"28/10"`,
+		"ExposureTime":    `"1/500"`,
+		"ISOSpeedRatings": "200",
+		"FilmSimulation":  "Classic Chrome",
+	}
+	if got, want := exifSummary(tags), "f/2.8 · 1/500 s · ISO 200 · Classic Chrome"; got != want {
+		t.Errorf("exifSummary = %q, want %q", got, want)
+	}
+	if got := exifSummary(map[string]string{"FNumber": "8/1", "ExposureTime": "2/1"}); got != "f/8 · 2 s" {
+		t.Errorf("exifSummary = %q", got)
+	}
+}
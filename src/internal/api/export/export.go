@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"os/exec"
@@ -17,6 +18,9 @@ import (
 	"sync"
 	"time"
 
+	"huepattl.de/unterlumen/internal/api/batchrename"
+	"huepattl.de/unterlumen/internal/jobs"
+	"huepattl.de/unterlumen/internal/library"
 	"huepattl.de/unterlumen/internal/media"
 	"huepattl.de/unterlumen/internal/pathguard"
 )
@@ -34,8 +38,20 @@ type exportRequest struct {
 	Quality     int                `json:"quality"`
 	Scale       media.ScaleOptions `json:"scale"`
 	ExifMode    string             `json:"exifMode"`
+	ColorMode   string             `json:"colorMode,omitempty"` // "srgb" (default) or "preserve"
+	Sharpen     string             `json:"sharpen,omitempty"`   // output sharpening preset, e.g. "screen_standard"
+	MaxBytes    int64              `json:"maxBytes,omitempty"`  // output size limit; quality is searched to fit
 	Destination string             `json:"destination"`
 	SourcePath  string             `json:"sourcePath,omitempty"`
+	Watermark   *media.Watermark   `json:"watermark,omitempty"` // imagePath is resolved like Files
+	Artist      string             `json:"artist,omitempty"`
+	Copyright   string             `json:"copyright,omitempty"`
+	UsageTerms  string             `json:"usageTerms,omitempty"`
+	NamePattern string             `json:"namePattern,omitempty"` // batch-rename tokens; "/" creates subfolders
+	Preset      string             `json:"preset,omitempty"`      // named preset supplying every field left unset
+
+	// AllowDownscale lets a MaxBytes export also reduce the dimensions.
+	AllowDownscale bool `json:"allowDownscale,omitempty"`
 }
 
 type estimateRequest struct {
@@ -45,6 +61,9 @@ type estimateRequest struct {
 	Scale      media.ScaleOptions `json:"scale"`
 	Method     string             `json:"method"` // "heuristic" or "encode"
 	SourcePath string             `json:"sourcePath,omitempty"`
+	MaxBytes   int64              `json:"maxBytes,omitempty"`
+
+	AllowDownscale bool `json:"allowDownscale,omitempty"`
 }
 
 type estimateEntry struct {
@@ -55,6 +74,7 @@ type estimateEntry struct {
 	OrigHeight  int    `json:"origHeight"`
 	Width       int    `json:"width"`
 	Height      int    `json:"height"`
+	Quality     int    `json:"quality,omitempty"` // encode method: quality chosen for maxBytes
 	Error       string `json:"error,omitempty"`
 }
 
@@ -63,34 +83,57 @@ type estimateResponse struct {
 }
 
 type exportResult struct {
-	File    string `json:"file"`
-	Success bool   `json:"success"`
-	Error   string `json:"error,omitempty"`
+	File    string  `json:"file"`
+	Success bool    `json:"success"`
+	Error   string  `json:"error,omitempty"`
+	Quality int     `json:"quality,omitempty"` // quality used, e.g. as chosen for maxBytes
+	Scale   float64 `json:"scale,omitempty"`   // extra downscale applied for maxBytes; omitted if none
 }
 
 type exportSaveResponse struct {
 	Results []exportResult `json:"results"`
+	Summary exportSummary  `json:"summary"`
 }
 
+// zipStreamEvent is one SSE event of a streamed export. Per-file events arrive
+// in completion order; the final event has Complete set and carries Summary.
 type zipStreamEvent struct {
-	File     string `json:"file,omitempty"`
-	Done     int    `json:"done"`
-	Total    int    `json:"total"`
-	Complete bool   `json:"complete,omitempty"`
-	Token    string `json:"token,omitempty"`
-	Error    string `json:"error,omitempty"`
+	File     string         `json:"file,omitempty"`
+	Path     string         `json:"path,omitempty"` // file as given in the request
+	Done     int            `json:"done"`
+	Total    int            `json:"total"`
+	Attempts int            `json:"attempts,omitempty"`
+	Quality  int            `json:"quality,omitempty"` // quality used for File
+	Scale    float64        `json:"scale,omitempty"`   // extra downscale applied for maxBytes
+	Complete bool           `json:"complete,omitempty"`
+	Token    string         `json:"token,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Summary  *exportSummary `json:"summary,omitempty"`
 }
 
 // Handle registers all /api/export/* routes on mux.
-func Handle(mux *http.ServeMux, root string, serverRole bool) {
+// libMgr supplies the privacy zones for the "keep_outside_zones" EXIF mode; may be nil.
+// libMgr also stores the export presets; the preset routes are only
+// registered when it is non-nil.
+// jobMgr, if non-nil, gets the export job runner and POST /api/jobs/export.
+func Handle(mux *http.ServeMux, root string, serverRole bool, libMgr *library.Manager, jobMgr *jobs.Manager) {
 	mux.HandleFunc("/api/export/estimate", handleExportEstimate(root, serverRole))
-	mux.HandleFunc("/api/export/zip", handleExportZip(root, serverRole))
-	mux.HandleFunc("/api/export/zip-stream", handleExportZipStream(root, serverRole))
+	mux.HandleFunc("/api/export/zip", handleExportZip(root, serverRole, libMgr))
+	mux.HandleFunc("/api/export/zip-stream", handleExportZipStream(root, serverRole, libMgr))
 	mux.HandleFunc("/api/export/zip-download", handleExportZipDownload())
-	mux.HandleFunc("/api/export/save", handleExportSave(root, serverRole))
+	mux.HandleFunc("/api/export/save", handleExportSave(root, serverRole, libMgr))
+	mux.HandleFunc("/api/export/save-stream", handleExportSaveStream(root, serverRole, libMgr))
+	mux.HandleFunc("/api/export/contact-sheet", handleContactSheet(root, serverRole, libMgr))
 	if !serverRole {
 		mux.HandleFunc("/api/export/folder-picker", handleFolderPicker())
 	}
+	if libMgr != nil {
+		handlePresets(mux, libMgr)
+	}
+	if jobMgr != nil {
+		jobMgr.Register(JobKind, exportJobRunner(root, serverRole, libMgr))
+		mux.HandleFunc("POST /api/jobs/export", handleExportJob(jobMgr, root, serverRole, libMgr))
+	}
 }
 
 func handleExportEstimate(root string, serverRole bool) http.HandlerFunc {
@@ -106,7 +149,7 @@ func handleExportEstimate(root string, serverRole bool) http.HandlerFunc {
 			return
 		}
 
-		opts := media.ExportOptions{Format: req.Format, Quality: req.Quality, Scale: req.Scale}
+		opts := media.ExportOptions{Format: req.Format, Quality: req.Quality, Scale: req.Scale, MaxBytes: req.MaxBytes, AllowDownscale: req.AllowDownscale}
 		eRoot := effectiveRoot(root, req.SourcePath)
 		var estimates []estimateEntry
 		for _, relPath := range req.Files {
@@ -130,7 +173,7 @@ func handleExportEstimate(root string, serverRole bool) http.HandlerFunc {
 }
 
 func estimateEncode(relPath, absPath string, opts media.ExportOptions) estimateEntry {
-	data, err := media.ExportImage(absPath, opts)
+	res, err := media.ExportImageResult(absPath, opts)
 	if err != nil {
 		return estimateEntry{File: relPath, Error: err.Error()}
 	}
@@ -141,14 +184,18 @@ func estimateEncode(relPath, absPath string, opts media.ExportOptions) estimateE
 	}
 	origW, origH := media.GetSourceDims(absPath)
 	_, _, _, _, outW, outH, _ := media.EstimateSize(absPath, opts)
+	if res.Scale < 1 {
+		outW, outH = max(1, int(float64(outW)*res.Scale)), max(1, int(float64(outH)*res.Scale))
+	}
 	return estimateEntry{
 		File:        relPath,
 		InputBytes:  inputBytes,
-		OutputBytes: int64(len(data)),
+		OutputBytes: int64(len(res.Data)),
 		OrigWidth:   origW,
 		OrigHeight:  origH,
 		Width:       outW,
 		Height:      outH,
+		Quality:     res.Quality,
 	}
 }
 
@@ -168,129 +215,202 @@ func estimateHeuristic(relPath, absPath string, opts media.ExportOptions) estima
 	}
 }
 
-func handleExportZip(root string, serverRole bool) http.HandlerFunc {
+func handleExportZip(root string, serverRole bool, libMgr *library.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		var req exportRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "invalid request body", http.StatusBadRequest)
+		req, ok := decodeExportRequest(w, r, libMgr)
+		if !ok {
 			return
 		}
 
-		opts := exportOpts(req)
 		eRoot := effectiveRoot(root, req.SourcePath)
+		opts, err := exportOpts(req, libMgr, eRoot, serverRole)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		w.Header().Set("Content-Type", "application/zip")
 		w.Header().Set("Content-Disposition", `attachment; filename="export.zip"`)
 
+		names := outputNames(req, eRoot, serverRole, libMgr)
 		zw := zip.NewWriter(w)
 		defer zw.Close()
 
-		for _, relPath := range req.Files {
-			absPath, ok := resolveFilePath(eRoot, serverRole, relPath)
-			if !ok {
-				continue
-			}
-			data, err := media.ExportImage(absPath, opts)
-			if err != nil {
-				continue
-			}
-			outName := media.ExportedName(filepath.Base(relPath), req.Format)
-			if fw, err := zw.Create(outName); err == nil {
-				fw.Write(data)
-			}
+		err = newExportEngine(eRoot, serverRole, opts).run(r.Context(), req.Files,
+			func(int, exportOutcome) {},
+			func(o exportOutcome) {
+				if o.Err != nil {
+					return
+				}
+				if fw, err := zw.Create(names[o.Index]); err == nil {
+					fw.Write(o.Data)
+				}
+			})
+		if err != nil {
+			// The ZIP is already streaming; all that is left is to log it.
+			log.Printf("Export ZIP of %d files aborted: %v", len(req.Files), err)
 		}
 	}
 }
 
-func handleExportSave(root string, serverRole bool) http.HandlerFunc {
+func handleExportSave(root string, serverRole bool, libMgr *library.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		var req exportRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "invalid request body", http.StatusBadRequest)
+		req, ok := decodeExportRequest(w, r, libMgr)
+		if !ok {
 			return
 		}
 
-		if req.Destination == "" {
-			http.Error(w, "destination is required", http.StatusBadRequest)
+		destAbs, err := resolveDestination(root, serverRole, req.Destination)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		var destAbs string
-		if filepath.IsAbs(req.Destination) {
-			if serverRole {
-				http.Error(w, "absolute destination paths not allowed in server mode", http.StatusBadRequest)
-				return
-			}
-			info, err := os.Stat(req.Destination)
-			if err != nil || !info.IsDir() {
-				http.Error(w, "destination directory does not exist", http.StatusBadRequest)
-				return
-			}
-			destAbs = req.Destination
-		} else {
-			var ok bool
-			destAbs, ok = pathguard.SafePath(root, req.Destination)
-			if !ok {
-				http.Error(w, "invalid destination path", http.StatusBadRequest)
-				return
-			}
-			info, err := os.Stat(destAbs)
-			if err != nil || !info.IsDir() {
-				http.Error(w, "destination directory does not exist", http.StatusBadRequest)
-				return
-			}
+		eRoot := effectiveRoot(root, req.SourcePath)
+		opts, err := exportOpts(req, libMgr, eRoot, serverRole)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var summary exportSummary
+		names := outputNames(req, eRoot, serverRole, libMgr)
+		results, err := processExportBatch(r.Context(), newExportEngine(eRoot, serverRole, opts), req.Files, names, destAbs,
+			func(int, exportOutcome) {}, &summary)
+		if err != nil {
+			log.Printf("Export to %s aborted: %v", destAbs, err)
+			http.Error(w, "export aborted: "+err.Error(), http.StatusInternalServerError)
+			return
 		}
-
-		opts := exportOpts(req)
-		results := processExportBatch(effectiveRoot(root, req.SourcePath), req.Files, destAbs, req.Format, opts, serverRole)
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(exportSaveResponse{Results: results})
+		json.NewEncoder(w).Encode(exportSaveResponse{Results: results, Summary: summary})
 	}
 }
 
-func processExportBatch(root string, files []string, dest, format string, opts media.ExportOptions, serverRole bool) []exportResult {
-	var results []exportResult
-	for _, relPath := range files {
-		absPath, ok := resolveFilePath(root, serverRole, relPath)
+// handleExportSaveStream is the SSE variant of handleExportSave: it saves to
+// the destination folder and reports each file as it finishes.
+func handleExportSaveStream(root string, serverRole bool, libMgr *library.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		req, ok := decodeExportRequest(w, r, libMgr)
 		if !ok {
-			results = append(results, exportResult{File: relPath, Error: "invalid path"})
-			continue
+			return
+		}
+		destAbs, err := resolveDestination(root, serverRole, req.Destination)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-		data, err := media.ExportImage(absPath, opts)
+		eRoot := effectiveRoot(root, req.SourcePath)
+		opts, err := exportOpts(req, libMgr, eRoot, serverRole)
 		if err != nil {
-			results = append(results, exportResult{File: relPath, Error: err.Error()})
-			continue
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-		outPath := filepath.Join(dest, media.ExportedName(filepath.Base(relPath), format))
-		if err := os.WriteFile(outPath, data, 0644); err != nil {
-			results = append(results, exportResult{File: relPath, Error: err.Error()})
-			continue
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		send := sseWriter(w, flusher)
+		total := len(req.Files)
+		var summary exportSummary
+		names := outputNames(req, eRoot, serverRole, libMgr)
+		_, err = processExportBatch(r.Context(), newExportEngine(eRoot, serverRole, opts), req.Files, names, destAbs,
+			func(done int, o exportOutcome) { send(progressEvent(done, total, o)) }, &summary)
+		if err != nil {
+			log.Printf("Export to %s aborted: %v", destAbs, err)
+			send(zipStreamEvent{Error: "export aborted: " + err.Error()})
+			return
 		}
-		results = append(results, exportResult{File: relPath, Success: true})
+		send(zipStreamEvent{Done: total, Total: total, Complete: true, Summary: &summary})
 	}
-	return results
 }
 
-func handleExportZipStream(root string, serverRole bool) http.HandlerFunc {
+// resolveDestination validates an export destination folder. Absolute paths
+// are only accepted outside server mode; relative paths are confined to root.
+func resolveDestination(root string, serverRole bool, dest string) (string, error) {
+	if dest == "" {
+		return "", fmt.Errorf("destination is required")
+	}
+	destAbs := dest
+	if filepath.IsAbs(dest) {
+		if serverRole {
+			return "", fmt.Errorf("absolute destination paths not allowed in server mode")
+		}
+	} else {
+		var ok bool
+		destAbs, ok = pathguard.SafePath(root, dest)
+		if !ok {
+			return "", fmt.Errorf("invalid destination path")
+		}
+	}
+	info, err := os.Stat(destAbs)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("destination directory does not exist")
+	}
+	return destAbs, nil
+}
+
+// processExportBatch exports files into dest in parallel, writing files[i] to
+// dest/names[i] (subfolders are created as needed). Write errors count as
+// failures in summary just like export errors. progress is called as each file
+// finishes exporting; results are returned in input order. The error is the
+// engine's, e.g. when ctx is cancelled before all files are written.
+func processExportBatch(ctx context.Context, engine *exportEngine, files, names []string, dest string, progress func(int, exportOutcome), summary *exportSummary) ([]exportResult, error) {
+	var results []exportResult
+	err := engine.run(ctx, files, progress, func(o exportOutcome) {
+		if o.Err == nil {
+			outPath := filepath.Join(dest, filepath.FromSlash(names[o.Index]))
+			if o.Err = os.MkdirAll(filepath.Dir(outPath), 0o755); o.Err == nil {
+				o.Err = os.WriteFile(outPath, o.Data, 0644)
+			}
+		}
+		summary.add(o)
+		if o.Err != nil {
+			results = append(results, exportResult{File: o.File, Error: o.Err.Error()})
+			return
+		}
+		results = append(results, exportResult{File: o.File, Success: true, Quality: o.Quality, Scale: o.downscale()})
+	})
+	return results, err
+}
+
+func handleExportZipStream(root string, serverRole bool, libMgr *library.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		var req exportRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "invalid request body", http.StatusBadRequest)
+		req, ok := decodeExportRequest(w, r, libMgr)
+		if !ok {
+			return
+		}
+
+		eRoot := effectiveRoot(root, req.SourcePath)
+		opts, err := exportOpts(req, libMgr, eRoot, serverRole)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
@@ -306,9 +426,10 @@ func handleExportZipStream(root string, serverRole bool) http.HandlerFunc {
 		w.WriteHeader(http.StatusOK)
 
 		send := sseWriter(w, flusher)
-		opts := exportOpts(req)
 
-		tmpPath, err := buildZipFile(r.Context(), effectiveRoot(root, req.SourcePath), serverRole, req.Files, req.Format, opts, send)
+		var summary exportSummary
+		names := outputNames(req, eRoot, serverRole, libMgr)
+		tmpPath, err := buildZipFile(r.Context(), newExportEngine(eRoot, serverRole, opts), req.Files, names, send, &summary)
 		if err != nil {
 			return // buildZipFile already sent the error event or client disconnected
 		}
@@ -319,11 +440,14 @@ func handleExportZipStream(root string, serverRole bool) http.HandlerFunc {
 		zipJobsMu.Unlock()
 
 		scheduleZipExpiry(token, tmpPath)
-		send(zipStreamEvent{Done: len(req.Files), Total: len(req.Files), Complete: true, Token: token})
+		send(zipStreamEvent{Done: len(req.Files), Total: len(req.Files), Complete: true, Token: token, Summary: &summary})
 	}
 }
 
-func buildZipFile(ctx context.Context, root string, serverRole bool, files []string, format string, opts media.ExportOptions, send func(zipStreamEvent)) (string, error) {
+// buildZipFile exports files in parallel into a temp ZIP, files[i] as entry
+// names[i], and returns its path. Entries are written in input order
+// regardless of completion order.
+func buildZipFile(ctx context.Context, engine *exportEngine, files, names []string, send func(zipStreamEvent), summary *exportSummary) (string, error) {
 	tmpFile, err := os.CreateTemp("", "unterlumen-zip-*.zip")
 	if err != nil {
 		send(zipStreamEvent{Error: err.Error()})
@@ -334,35 +458,24 @@ func buildZipFile(ctx context.Context, root string, serverRole bool, files []str
 	zw := zip.NewWriter(tmpFile)
 	total := len(files)
 
-	for i, relPath := range files {
-		select {
-		case <-ctx.Done():
-			zw.Close()
-			tmpFile.Close()
-			os.Remove(tmpPath)
-			return "", ctx.Err()
-		default:
-		}
-
-		send(zipStreamEvent{File: filepath.Base(relPath), Done: i, Total: total})
-
-		absPath, ok := resolveFilePath(root, serverRole, relPath)
-		if !ok {
-			continue
-		}
-		data, err := media.ExportImage(absPath, opts)
-		if err != nil {
-			send(zipStreamEvent{File: filepath.Base(relPath), Done: i + 1, Total: total, Error: err.Error()})
-			continue
-		}
-		outName := media.ExportedName(filepath.Base(relPath), format)
-		if fw, err := zw.Create(outName); err == nil {
-			fw.Write(data)
-		}
-	}
-
+	err = engine.run(ctx, files,
+		func(done int, o exportOutcome) { send(progressEvent(done, total, o)) },
+		func(o exportOutcome) {
+			if o.Err == nil {
+				var fw io.Writer
+				if fw, o.Err = zw.Create(names[o.Index]); o.Err == nil {
+					_, o.Err = fw.Write(o.Data)
+				}
+			}
+			summary.add(o)
+		})
 	zw.Close()
 	tmpFile.Close()
+	if err != nil {
+		log.Printf("Export ZIP of %d files aborted: %v", len(files), err)
+		os.Remove(tmpPath)
+		return "", err
+	}
 	return tmpPath, nil
 }
 
@@ -496,13 +609,124 @@ func resolveFilePath(root string, serverRole bool, filePath string) (string, boo
 	return pathguard.SafePath(root, filePath)
 }
 
-func exportOpts(req exportRequest) media.ExportOptions {
-	return media.ExportOptions{
-		Format:   req.Format,
-		Quality:  req.Quality,
-		Scale:    req.Scale,
-		ExifMode: req.ExifMode,
+// decodeExportRequest decodes an export request body and applies its preset.
+// On failure it writes a 400 response and returns false.
+func decodeExportRequest(w http.ResponseWriter, r *http.Request, libMgr *library.Manager) (exportRequest, bool) {
+	var req exportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+	if err := applyPreset(&req, libMgr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// applyPreset fills the fields req leaves unset from the preset it names, so a
+// request may override individual preset settings.
+func applyPreset(req *exportRequest, libMgr *library.Manager) error {
+	if req.Preset == "" {
+		return nil
+	}
+	if libMgr == nil {
+		return fmt.Errorf("export presets are not available without a library directory")
+	}
+	p, err := libMgr.GetPreset(req.Preset)
+	if err != nil {
+		return fmt.Errorf("preset %q: %w", req.Preset, err)
+	}
+	o := p.Options
+	setDefault(&req.Format, o.Format)
+	setDefault(&req.ExifMode, o.ExifMode)
+	setDefault(&req.ColorMode, o.ColorMode)
+	setDefault(&req.Sharpen, o.Sharpen)
+	setDefault(&req.Artist, o.Artist)
+	setDefault(&req.Copyright, o.Copyright)
+	setDefault(&req.UsageTerms, o.UsageTerms)
+	setDefault(&req.NamePattern, p.NamePattern)
+	setDefault(&req.Destination, p.Destination)
+	if req.Quality == 0 {
+		req.Quality = o.Quality
+	}
+	if req.MaxBytes == 0 {
+		req.MaxBytes = o.MaxBytes
+		req.AllowDownscale = req.AllowDownscale || o.AllowDownscale
+	}
+	if req.Scale.Mode == "" {
+		req.Scale = o.Scale
+	}
+	if req.Watermark == nil {
+		req.Watermark = o.Watermark
+	}
+	return nil
+}
+
+func setDefault(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// outputNames returns the output path, relative to the ZIP root or destination
+// folder, for each of req.Files: req.NamePattern resolved with batch-rename
+// tokens, or the exported source base name. Names that occur more than once —
+// e.g. DSCF0001.JPG from two folders — get _001, _002, … suffixes.
+func outputNames(req exportRequest, root string, serverRole bool, libMgr *library.Manager) []string {
+	if req.NamePattern == "" {
+		names := make([]string, len(req.Files))
+		for i, f := range req.Files {
+			names[i] = media.ExportedName(filepath.Base(f), req.Format)
+		}
+		return batchrename.DedupeNames(names)
+	}
+	absPaths := make([]string, len(req.Files))
+	for i, f := range req.Files {
+		absPaths[i], _ = resolveFilePath(root, serverRole, f) // "" for invalid paths; they fail on export anyway
+	}
+	ext := filepath.Ext(media.ExportedName("x", req.Format))
+	return batchrename.OutputNames(absPaths, req.NamePattern, ext, libMgr)
+}
+
+// exportOpts builds the export options for req. root is the effective source
+// root used to resolve a watermark logo path.
+func exportOpts(req exportRequest, libMgr *library.Manager, root string, serverRole bool) (media.ExportOptions, error) {
+	opts := libMgr.WithPrivacyZones(media.ExportOptions{
+		Format:     req.Format,
+		Quality:    req.Quality,
+		Scale:      req.Scale,
+		ExifMode:   req.ExifMode,
+		ColorMode:  req.ColorMode,
+		Sharpen:    req.Sharpen,
+		Artist:     req.Artist,
+		Copyright:  req.Copyright,
+		UsageTerms: req.UsageTerms,
+		MaxBytes:   req.MaxBytes,
+
+		AllowDownscale: req.AllowDownscale,
+	})
+	if err := media.ValidateResampling(opts); err != nil {
+		return opts, err
+	}
+	if opts.MaxBytes < 0 {
+		return opts, fmt.Errorf("maxBytes must not be negative")
+	}
+	if wm := req.Watermark; wm.Enabled() {
+		if err := wm.Validate(); err != nil {
+			return opts, err
+		}
+		resolved := *wm
+		if wm.ImagePath != "" {
+			abs, ok := resolveFilePath(root, serverRole, wm.ImagePath)
+			if !ok {
+				return opts, fmt.Errorf("invalid watermark image path")
+			}
+			resolved.ImagePath = abs
+		}
+		opts.Watermark = &resolved
 	}
+	return opts, nil
 }
 
 func sseWriter(w http.ResponseWriter, flusher http.Flusher) func(zipStreamEvent) {
@@ -0,0 +1,61 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveWithMaxBytesReportsQuality(t *testing.T) {
+	root := t.TempDir()
+	writeJPEG(t, filepath.Join(root, "a.jpg"))
+	os.MkdirAll(filepath.Join(root, "out"), 0o755)
+	mux := http.NewServeMux()
+	Handle(mux, root, true, nil, nil)
+
+	save := func(req exportRequest) exportSaveResponse {
+		t.Helper()
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/export/save", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("save: %d %s", w.Code, w.Body.String())
+		}
+		var resp exportSaveResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp
+	}
+
+	resp := save(exportRequest{Files: []string{"a.jpg"}, Format: "jpeg", Quality: 77, MaxBytes: 1 << 20, Destination: "out"})
+	if len(resp.Results) != 1 || !resp.Results[0].Success || resp.Results[0].Quality != 77 {
+		t.Errorf("results = %+v, want success at quality 77", resp.Results)
+	}
+
+	resp = save(exportRequest{Files: []string{"a.jpg"}, Format: "jpeg", MaxBytes: 10, Destination: "out"})
+	if len(resp.Results) != 1 || resp.Results[0].Success || !strings.Contains(resp.Results[0].Error, "unreachable") {
+		t.Errorf("results = %+v, want size-limit failure", resp.Results)
+	}
+}
+
+func TestSaveReportsCancelledExport(t *testing.T) {
+	root := t.TempDir()
+	writeJPEG(t, filepath.Join(root, "a.jpg"))
+	os.MkdirAll(filepath.Join(root, "out"), 0o755)
+	mux := http.NewServeMux()
+	Handle(mux, root, true, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	body, _ := json.Marshal(exportRequest{Files: []string{"a.jpg"}, Format: "jpeg", Destination: "out"})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/export/save", bytes.NewReader(body)).WithContext(ctx))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "export aborted") {
+		t.Errorf("cancelled save: %d %s", w.Code, w.Body.String())
+	}
+}
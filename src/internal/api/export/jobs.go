@@ -0,0 +1,151 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"huepattl.de/unterlumen/internal/jobs"
+	"huepattl.de/unterlumen/internal/library"
+)
+
+// JobKind is the jobs.Manager kind for background ZIP exports.
+const JobKind = "export"
+
+// exportJobState is the checkpoint of an export job. Files before Next have
+// been exported (staged) or have failed; a resumed job continues at Next.
+type exportJobState struct {
+	Next    int           `json:"next"`
+	Summary exportSummary `json:"summary"`
+}
+
+// handleExportJob queues a background ZIP export. The body is the same as for
+// /api/export/zip-stream; the response is the created job.
+func handleExportJob(mgr *jobs.Manager, root string, serverRole bool, libMgr *library.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, ok := decodeExportRequest(w, r, libMgr)
+		if !ok {
+			return
+		}
+		if len(req.Files) == 0 {
+			http.Error(w, "files are required", http.StatusBadRequest)
+			return
+		}
+		// Validate options up front so a bad request fails now, not in the background.
+		if _, err := exportOpts(req, libMgr, effectiveRoot(root, req.SourcePath), serverRole); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		job, err := mgr.Submit(JobKind, req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+	}
+}
+
+// exportJobRunner exports a job's files into <job dir>/staged/ — one file per
+// input, named by index — checkpointing after each, and assembles export.zip
+// once all files are through. Staging keeps a resumed job from having to
+// reopen a half-written ZIP.
+func exportJobRunner(root string, serverRole bool, libMgr *library.Manager) jobs.Runner {
+	return func(ctx context.Context, run *jobs.Run) error {
+		var req exportRequest
+		if err := run.Params(&req); err != nil {
+			return err
+		}
+		eRoot := effectiveRoot(root, req.SourcePath)
+		opts, err := exportOpts(req, libMgr, eRoot, serverRole)
+		if err != nil {
+			return err
+		}
+		var st exportJobState
+		if _, err := run.State(&st); err != nil {
+			return err
+		}
+		stageDir := filepath.Join(run.Dir(), "staged")
+		if err := os.MkdirAll(stageDir, 0o700); err != nil {
+			return err
+		}
+		names := outputNames(req, eRoot, serverRole, libMgr)
+		run.SetTotal(len(req.Files))
+
+		var checkpointErr error
+		err = newExportEngine(eRoot, serverRole, opts).run(ctx, req.Files[st.Next:],
+			func(int, exportOutcome) {},
+			func(o exportOutcome) {
+				if o.Err == nil {
+					o.Err = os.WriteFile(stagedPath(stageDir, st.Next), o.Data, 0o600)
+				}
+				st.Summary.add(o)
+				st.Next++
+				if err := run.Checkpoint(st.Next, filepath.Base(o.File), st); err != nil && checkpointErr == nil {
+					checkpointErr = err
+				}
+			})
+		if err != nil {
+			return err
+		}
+		if checkpointErr != nil {
+			return checkpointErr
+		}
+		if st.Summary.Succeeded == 0 {
+			return fmt.Errorf("all %d files failed to export", len(req.Files))
+		}
+
+		if err := assembleZip(filepath.Join(run.Dir(), "export.zip"), stageDir, names); err != nil {
+			return err
+		}
+		os.RemoveAll(stageDir)
+		run.SetResult("export.zip")
+		return nil
+	}
+}
+
+func stagedPath(stageDir string, index int) string {
+	return filepath.Join(stageDir, fmt.Sprintf("%06d", index))
+}
+
+// assembleZip writes the staged files into a ZIP at dst in input order, staged
+// file i as names[i], skipping files that failed.
+func assembleZip(dst, stageDir string, names []string) error {
+	tmp := dst + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+	for i, name := range names {
+		data, err := os.ReadFile(stagedPath(stageDir, i))
+		if err != nil {
+			continue
+		}
+		fw, err := zw.Create(name)
+		if err == nil {
+			_, err = fw.Write(data)
+		}
+		if err != nil {
+			zw.Close()
+			f.Close()
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"huepattl.de/unterlumen/internal/jobs"
+)
+
+func writeJPEG(t *testing.T, path string) {
+	t.Helper()
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 6)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportJobProducesOrderedZip(t *testing.T) {
+	root := t.TempDir()
+	writeJPEG(t, filepath.Join(root, "b.jpg"))
+	writeJPEG(t, filepath.Join(root, "a.jpg"))
+
+	mgr, err := jobs.NewManager(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	Handle(mux, root, true, nil, mgr)
+	mgr.Start(context.Background())
+
+	body, _ := json.Marshal(exportRequest{Files: []string{"b.jpg", "missing.jpg", "a.jpg"}, Format: "png", ExifMode: "strip"})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/jobs/export", bytes.NewReader(body)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var job jobs.Job
+	json.NewDecoder(w.Body).Decode(&job)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for job.Status != jobs.StatusDone {
+		if time.Now().After(deadline) || job.Status == jobs.StatusFailed {
+			t.Fatalf("job did not finish: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+		job, _ = mgr.Get(job.ID)
+	}
+
+	var st exportJobState
+	json.Unmarshal(job.State, &st)
+	if st.Next != 3 || st.Summary.Succeeded != 2 || st.Summary.Failed != 1 || st.Summary.Failures[0].File != "missing.jpg" {
+		t.Errorf("state = %+v", st)
+	}
+
+	path, err := mgr.ResultPath(job.ID)
+	if err != nil {
+		t.Fatalf("ResultPath: %v", err)
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if len(names) != 2 || names[0] != "b.png" || names[1] != "a.png" {
+		t.Errorf("zip entries = %v, want [b.png a.png]", names)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "staged")); !os.IsNotExist(err) {
+		t.Error("staged files not cleaned up")
+	}
+}
+
+func TestExportJobRejectsEmptyRequest(t *testing.T) {
+	mgr, _ := jobs.NewManager(t.TempDir(), time.Hour)
+	mux := http.NewServeMux()
+	Handle(mux, t.TempDir(), true, nil, mgr)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/jobs/export", bytes.NewReader([]byte(`{"files":[]}`))))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,193 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"huepattl.de/unterlumen/internal/media"
+)
+
+// exportRetries is how often a file is retried after a transient failure
+// (see media.IsTransient); exportRetryDelay is multiplied by the attempt number.
+const (
+	exportRetries    = 2
+	exportRetryDelay = 500 * time.Millisecond
+)
+
+var errInvalidPath = errors.New("invalid path")
+
+// exportOutcome is the result of exporting one file of a batch.
+type exportOutcome struct {
+	Index    int
+	File     string // path as given in the request
+	Data     []byte
+	Quality  int     // quality used (see media.ExportResult)
+	Scale    float64 // extra downscale applied for maxBytes (1 = none)
+	Err      error
+	Attempts int
+}
+
+// downscale returns o.Scale for reporting, or 0 if the image was not shrunk.
+func (o exportOutcome) downscale() float64 {
+	if o.Scale > 0 && o.Scale < 1 {
+		return o.Scale
+	}
+	return 0
+}
+
+// exportSummary is sent with the final event of a batch.
+type exportSummary struct {
+	Total     int            `json:"total"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Failures  []exportResult `json:"failures,omitempty"`
+}
+
+func (s *exportSummary) add(o exportOutcome) {
+	s.Total++
+	if o.Err != nil {
+		s.Failed++
+		s.Failures = append(s.Failures, exportResult{File: o.File, Error: o.Err.Error()})
+		return
+	}
+	s.Succeeded++
+}
+
+// exportEngine exports a batch of files with bounded parallelism.
+type exportEngine struct {
+	workers int
+	export  func(relPath string) (media.ExportResult, error)
+	retries int
+	delay   time.Duration
+}
+
+// newExportEngine returns an engine exporting files relative to root with opts,
+// using media.DefaultExportWorkers concurrent workers.
+func newExportEngine(root string, serverRole bool, opts media.ExportOptions) *exportEngine {
+	return &exportEngine{
+		workers: media.DefaultExportWorkers(),
+		retries: exportRetries,
+		delay:   exportRetryDelay,
+		export: func(relPath string) (media.ExportResult, error) {
+			absPath, ok := resolveFilePath(root, serverRole, relPath)
+			if !ok {
+				return media.ExportResult{}, errInvalidPath
+			}
+			return media.ExportImageResult(absPath, opts)
+		},
+	}
+}
+
+// run exports files and calls progress as each file finishes (completion
+// order, with the number of finished files) and commit in input order, so
+// callers can write ZIP entries in the order requested. Both callbacks run on
+// the caller's goroutine. To bound memory, at most 2×workers finished exports
+// wait for commit. run returns ctx.Err() if ctx is cancelled before all files
+// are committed.
+func (e *exportEngine) run(ctx context.Context, files []string, progress func(done int, o exportOutcome), commit func(o exportOutcome)) error {
+	workers := max(1, e.workers)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// window limits how far dispatch may run ahead of commit.
+	window := make(chan struct{}, 2*workers)
+	tasks := make(chan int)
+	results := make(chan exportOutcome)
+
+	go func() {
+		defer close(tasks)
+		for i := range files {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case tasks <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				o := e.exportOne(ctx, i, files[i])
+				select {
+				case results <- o:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]exportOutcome)
+	next, done := 0, 0
+	for next < len(files) {
+		select {
+		case o, ok := <-results:
+			if !ok {
+				return ctx.Err()
+			}
+			done++
+			progress(done, o)
+			pending[o.Index] = o
+			for {
+				p, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				commit(p)
+				next++
+				<-window
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// exportOne exports a single file, retrying transient failures.
+func (e *exportEngine) exportOne(ctx context.Context, index int, relPath string) exportOutcome {
+	o := exportOutcome{Index: index, File: relPath}
+	for attempt := 0; ; attempt++ {
+		o.Attempts = attempt + 1
+		var res media.ExportResult
+		res, o.Err = e.export(relPath)
+		o.Data, o.Quality, o.Scale = res.Data, res.Quality, res.Scale
+		if o.Err == nil || attempt >= e.retries || !media.IsTransient(o.Err) {
+			return o
+		}
+		select {
+		case <-time.After(e.delay * time.Duration(attempt+1)):
+		case <-ctx.Done():
+			o.Err = ctx.Err()
+			return o
+		}
+	}
+}
+
+// progressEvent builds the per-file SSE event for o.
+func progressEvent(done, total int, o exportOutcome) zipStreamEvent {
+	evt := zipStreamEvent{File: filepath.Base(o.File), Path: o.File, Done: done, Total: total, Attempts: o.Attempts}
+	if o.Err != nil {
+		evt.Error = o.Err.Error()
+	} else {
+		evt.Quality, evt.Scale = o.Quality, o.downscale()
+	}
+	return evt
+}
@@ -0,0 +1,137 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"huepattl.de/unterlumen/internal/media"
+)
+
+func TestExportEngineCommitsInInputOrder(t *testing.T) {
+	files := []string{"a.jpg", "b.jpg", "c.jpg", "d.jpg", "e.jpg", "f.jpg"}
+	e := &exportEngine{
+		workers: 3,
+		export: rawExport(func(relPath string) ([]byte, error) {
+			// Earlier files take longer, so completion order is reversed.
+			time.Sleep(time.Duration('g'-relPath[0]) * 5 * time.Millisecond)
+			return []byte(relPath), nil
+		}),
+	}
+	var committed []string
+	var progressed int
+	err := e.run(context.Background(), files,
+		func(done int, o exportOutcome) { progressed = done },
+		func(o exportOutcome) { committed = append(committed, string(o.Data)) })
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if progressed != len(files) {
+		t.Errorf("progress done = %d, want %d", progressed, len(files))
+	}
+	if fmt.Sprint(committed) != fmt.Sprint(files) {
+		t.Errorf("commit order = %v, want %v", committed, files)
+	}
+}
+
+func TestExportEngineBoundsConcurrency(t *testing.T) {
+	var active, peak atomic.Int32
+	e := &exportEngine{
+		workers: 2,
+		export: rawExport(func(string) ([]byte, error) {
+			n := active.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			active.Add(-1)
+			return nil, nil
+		}),
+	}
+	files := make([]string, 10)
+	if err := e.run(context.Background(), files, func(int, exportOutcome) {}, func(exportOutcome) {}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", peak.Load())
+	}
+}
+
+func TestExportEngineRetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	e := &exportEngine{
+		workers: 1,
+		retries: 2,
+		export: rawExport(func(relPath string) ([]byte, error) {
+			switch relPath {
+			case "flaky.heic":
+				if calls.Add(1) < 3 {
+					return nil, fmt.Errorf("ffmpeg failed: %w", syscall.EAGAIN)
+				}
+				return []byte("ok"), nil
+			case "broken.jpg":
+				return nil, errors.New("decode image: unexpected EOF")
+			}
+			return []byte("ok"), nil
+		}),
+	}
+	var summary exportSummary
+	var attempts = map[string]int{}
+	err := e.run(context.Background(), []string{"flaky.heic", "broken.jpg", "fine.png"},
+		func(_ int, o exportOutcome) { attempts[o.File] = o.Attempts },
+		summary.add)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if attempts["flaky.heic"] != 3 {
+		t.Errorf("flaky attempts = %d, want 3", attempts["flaky.heic"])
+	}
+	if attempts["broken.jpg"] != 1 {
+		t.Errorf("permanent failure retried: %d attempts", attempts["broken.jpg"])
+	}
+	if summary.Total != 3 || summary.Succeeded != 2 || summary.Failed != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+	if len(summary.Failures) != 1 || summary.Failures[0].File != "broken.jpg" {
+		t.Errorf("failures = %+v", summary.Failures)
+	}
+}
+
+func TestExportEngineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &exportEngine{
+		workers: 2,
+		export: rawExport(func(string) ([]byte, error) {
+			time.Sleep(2 * time.Millisecond)
+			return nil, nil
+		}),
+	}
+	files := make([]string, 50)
+	committed := 0
+	err := e.run(ctx, files, func(int, exportOutcome) {}, func(exportOutcome) {
+		if committed++; committed == 5 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("run err = %v, want context.Canceled", err)
+	}
+	if committed >= len(files) {
+		t.Errorf("all files committed despite cancel")
+	}
+}
+
+// rawExport adapts a byte-returning export func for exportEngine.
+func rawExport(f func(string) ([]byte, error)) func(string) (media.ExportResult, error) {
+	return func(relPath string) (media.ExportResult, error) {
+		data, err := f(relPath)
+		return media.ExportResult{Data: data}, err
+	}
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"huepattl.de/unterlumen/internal/library"
+)
+
+// handlePresets registers the export preset CRUD routes. Presets are stored in
+// the lib dir (export-presets.json) and can be referenced by name through the
+// "preset" field of any export request.
+func handlePresets(mux *http.ServeMux, libMgr *library.Manager) {
+	mux.HandleFunc("GET /api/export/presets", listPresets(libMgr))
+	mux.HandleFunc("POST /api/export/presets", createPreset(libMgr))
+	mux.HandleFunc("GET /api/export/presets/{name}", getPreset(libMgr))
+	mux.HandleFunc("PUT /api/export/presets/{name}", updatePreset(libMgr))
+	mux.HandleFunc("DELETE /api/export/presets/{name}", deletePreset(libMgr))
+}
+
+func writePresetError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, library.ErrPresetNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, library.ErrPresetExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func listPresets(libMgr *library.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		presets, err := libMgr.ListPresets()
+		if err != nil {
+			writePresetError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(presets)
+	}
+}
+
+func getPreset(libMgr *library.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := libMgr.GetPreset(r.PathValue("name"))
+		if err != nil {
+			writePresetError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+	}
+}
+
+func createPreset(libMgr *library.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var p library.ExportPreset
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := p.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := libMgr.CreatePreset(p); err != nil {
+			writePresetError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(p)
+	}
+}
+
+// updatePreset replaces the preset {name}. A different name in the body
+// renames it.
+func updatePreset(libMgr *library.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		var p library.ExportPreset
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if p.Name == "" {
+			p.Name = name
+		}
+		if err := p.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := libMgr.UpdatePreset(name, p); err != nil {
+			writePresetError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+	}
+}
+
+func deletePreset(libMgr *library.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := libMgr.DeletePreset(r.PathValue("name")); err != nil {
+			writePresetError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
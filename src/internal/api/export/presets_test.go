@@ -0,0 +1,88 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"huepattl.de/unterlumen/internal/library"
+	"huepattl.de/unterlumen/internal/media"
+)
+
+func TestPresetCRUDAndSaveByName(t *testing.T) {
+	root := t.TempDir()
+	writeJPEG(t, filepath.Join(root, "in", "a.jpg"))
+	os.MkdirAll(filepath.Join(root, "out"), 0o755)
+
+	libMgr, err := library.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	Handle(mux, root, true, libMgr, nil)
+
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		json.NewEncoder(&buf).Encode(body)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, path, &buf))
+		return w
+	}
+
+	preset := library.ExportPreset{
+		Name:        "web",
+		Options:     media.ExportOptions{Format: "png", ExifMode: "strip"},
+		NamePattern: "web/{original}",
+		Destination: "out",
+	}
+	if w := do(http.MethodPost, "/api/export/presets", preset); w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/api/export/presets", preset); w.Code != http.StatusConflict {
+		t.Errorf("duplicate create: %d, want 409", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/export/presets", library.ExportPreset{Name: "bad", Options: media.ExportOptions{Format: "bmp"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid create: %d, want 400", w.Code)
+	}
+
+	w := do(http.MethodPost, "/api/export/save", exportRequest{Files: []string{"in/a.jpg"}, Preset: "web"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("save by preset: %d %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, "out", "web", "a.png")); err != nil {
+		t.Errorf("preset export not written: %v", err)
+	}
+
+	// Request fields override the preset.
+	w = do(http.MethodPost, "/api/export/save", exportRequest{Files: []string{"in/a.jpg"}, Preset: "web", Format: "jpeg", NamePattern: "{original}"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("save with override: %d %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, "out", "a.jpg")); err != nil {
+		t.Errorf("override export not written: %v", err)
+	}
+
+	if w := do(http.MethodPost, "/api/export/save", exportRequest{Files: []string{"in/a.jpg"}, Preset: "nope"}); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown preset: %d, want 400", w.Code)
+	}
+
+	preset.Name = "web2"
+	if w := do(http.MethodPut, "/api/export/presets/web", preset); w.Code != http.StatusOK {
+		t.Fatalf("rename: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/api/export/presets/web", nil); w.Code != http.StatusNotFound {
+		t.Errorf("old name after rename: %d, want 404", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/export/presets/web2", nil); w.Code != http.StatusNoContent {
+		t.Errorf("delete: %d", w.Code)
+	}
+	var list []library.ExportPreset
+	json.NewDecoder(do(http.MethodGet, "/api/export/presets", nil).Body).Decode(&list)
+	if len(list) != 0 {
+		t.Errorf("presets after delete = %+v", list)
+	}
+}
@@ -0,0 +1,228 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"huepattl.de/unterlumen/internal/jobs"
+	"huepattl.de/unterlumen/internal/media"
+)
+
+// SlideshowJobKind is the jobs.Manager kind for slideshow videos.
+const SlideshowJobKind = "slideshow"
+
+type slideshowRequest struct {
+	Files      []string `json:"files"`
+	SourcePath string   `json:"sourcePath,omitempty"`
+	media.SlideshowOptions
+	Music     string `json:"music,omitempty"`     // built-in track from web/music, e.g. "Sunlight_Through_Leaves.mp3"
+	AudioFile string `json:"audioFile,omitempty"` // audio file resolved like Files; overrides Music
+}
+
+// slideshowJobState is the checkpoint of a slideshow job. Photos before Next
+// have been prepared as slides or have failed. Encoding is a single ffmpeg
+// run and restarts from the beginning when the job is resumed.
+type slideshowJobState struct {
+	Next   int   `json:"next"`
+	Failed []int `json:"failed,omitempty"`
+}
+
+// HandleSlideshow registers POST /api/jobs/slideshow. music holds the
+// built-in tracks (the web/music directory); it may be nil.
+func HandleSlideshow(mux *http.ServeMux, root string, serverRole bool, jobMgr *jobs.Manager, music fs.FS) {
+	jobMgr.Register(SlideshowJobKind, slideshowJobRunner(root, serverRole, music))
+	mux.HandleFunc("POST /api/jobs/slideshow", handleSlideshowJob(jobMgr, root, serverRole, music))
+}
+
+// handleSlideshowJob validates a slideshow request and queues the job. The
+// response is the created job; progress is available from /api/jobs/{id} and
+// /api/jobs/{id}/events, the video from /api/jobs/{id}/download.
+func handleSlideshowJob(mgr *jobs.Manager, root string, serverRole bool, music fs.FS) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req slideshowRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if _, _, err := resolveSlideshow(&req, root, serverRole, music); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !media.CheckFFmpeg().Available {
+			http.Error(w, "ffmpeg is required for slideshow videos", http.StatusServiceUnavailable)
+			return
+		}
+		job, err := mgr.Submit(SlideshowJobKind, req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+	}
+}
+
+// resolveSlideshow applies defaults, validates the request and resolves the
+// photo paths and the audio file path. A built-in track is only checked here;
+// it has no path until the runner copies it out (see copyMusicTrack).
+func resolveSlideshow(req *slideshowRequest, root string, serverRole bool, music fs.FS) ([]string, string, error) {
+	if len(req.Files) == 0 {
+		return nil, "", fmt.Errorf("files are required")
+	}
+	if len(req.Files) > media.MaxSlides {
+		return nil, "", fmt.Errorf("too many photos (max %d)", media.MaxSlides)
+	}
+	if req.Width == 0 && req.Height == 0 {
+		req.Width, req.Height = 1920, 1080
+	}
+	if req.SlideDuration == 0 {
+		req.SlideDuration = 5
+	}
+	if err := req.SlideshowOptions.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	eRoot := effectiveRoot(root, req.SourcePath)
+	paths := make([]string, len(req.Files))
+	for i, f := range req.Files {
+		abs, ok := resolveFilePath(eRoot, serverRole, f)
+		if !ok {
+			return nil, "", fmt.Errorf("invalid path: %s", f)
+		}
+		paths[i] = abs
+	}
+
+	switch {
+	case req.AudioFile != "":
+		abs, ok := resolveFilePath(eRoot, serverRole, req.AudioFile)
+		if info, err := os.Stat(abs); !ok || err != nil || info.IsDir() {
+			return nil, "", fmt.Errorf("invalid audio file: %s", req.AudioFile)
+		}
+		return paths, abs, nil
+	case req.Music != "":
+		// Track names are plain file names inside web/music.
+		if music == nil || req.Music != path.Base(req.Music) || !fs.ValidPath(req.Music) {
+			return nil, "", fmt.Errorf("unknown music track %q", req.Music)
+		}
+		if _, err := fs.Stat(music, req.Music); err != nil {
+			return nil, "", fmt.Errorf("unknown music track %q", req.Music)
+		}
+	}
+	return paths, "", nil
+}
+
+// slideshowJobRunner prepares every photo as a JPEG slide in <job dir>/slides/,
+// checkpointing after each, then encodes slideshow.mp4 with ffmpeg. Progress
+// counts one unit per photo plus one per second of encoded video.
+func slideshowJobRunner(root string, serverRole bool, music fs.FS) jobs.Runner {
+	return func(ctx context.Context, run *jobs.Run) error {
+		var req slideshowRequest
+		if err := run.Params(&req); err != nil {
+			return err
+		}
+		paths, audio, err := resolveSlideshow(&req, root, serverRole, music)
+		if err != nil {
+			return err
+		}
+		opts := req.SlideshowOptions
+		var st slideshowJobState
+		if _, err := run.State(&st); err != nil {
+			return err
+		}
+
+		slideDir := filepath.Join(run.Dir(), "slides")
+		if err := os.MkdirAll(slideDir, 0o700); err != nil {
+			return err
+		}
+		encodeUnits := func(n int) int { return int(math.Ceil(opts.Duration(n))) }
+		run.SetTotal(len(paths) + encodeUnits(len(paths)-len(st.Failed)))
+
+		// Ken Burns zooms into the slide, so it needs more pixels than the frame.
+		boxW, boxH := opts.Width, opts.Height
+		if opts.Transition == media.TransitionKenBurns {
+			boxW, boxH = 2*boxW, 2*boxH
+		}
+		for st.Next < len(paths) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := media.PrepareSlide(paths[st.Next], slidePath(slideDir, st.Next), boxW, boxH); err != nil {
+				st.Failed = append(st.Failed, st.Next)
+			}
+			st.Next++
+			if err := run.Checkpoint(st.Next, filepath.Base(paths[st.Next-1]), st); err != nil {
+				return err
+			}
+		}
+
+		failed := make(map[int]bool, len(st.Failed))
+		for _, i := range st.Failed {
+			failed[i] = true
+		}
+		var slides []string
+		for i := range paths {
+			if !failed[i] {
+				slides = append(slides, slidePath(slideDir, i))
+			}
+		}
+		if len(slides) == 0 {
+			return fmt.Errorf("none of the %d photos could be prepared", len(paths))
+		}
+		run.SetTotal(len(paths) + encodeUnits(len(slides)))
+
+		if audio == "" && req.Music != "" {
+			if audio, err = copyMusicTrack(music, req.Music, run.Dir()); err != nil {
+				return err
+			}
+		}
+		opts.Audio = audio
+
+		part := filepath.Join(run.Dir(), "slideshow.part.mp4")
+		err = media.RenderSlideshow(ctx, slides, opts, part, func(seconds float64) {
+			run.SetProgress(len(paths)+min(int(seconds), encodeUnits(len(slides))), "encoding")
+		})
+		if err != nil {
+			os.Remove(part)
+			return err
+		}
+		if err := os.Rename(part, filepath.Join(run.Dir(), "slideshow.mp4")); err != nil {
+			return err
+		}
+		os.RemoveAll(slideDir)
+		run.SetResult("slideshow.mp4")
+		return nil
+	}
+}
+
+func slidePath(dir string, index int) string {
+	return filepath.Join(dir, fmt.Sprintf("%06d.jpg", index))
+}
+
+// copyMusicTrack copies a built-in track out of the embedded web files into
+// dir, since ffmpeg needs a real file. It returns the copy's path.
+func copyMusicTrack(music fs.FS, name, dir string) (string, error) {
+	src, err := music.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	dst := filepath.Join(dir, "music"+filepath.Ext(name))
+	f, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return "", err
+	}
+	return dst, f.Close()
+}
@@ -0,0 +1,74 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"huepattl.de/unterlumen/internal/jobs"
+	"huepattl.de/unterlumen/internal/media"
+)
+
+func TestResolveSlideshowDefaultsAndMusic(t *testing.T) {
+	root := t.TempDir()
+	writeJPEG(t, filepath.Join(root, "a.jpg"))
+	music := fstest.MapFS{"track.mp3": {Data: []byte("ID3")}}
+
+	req := slideshowRequest{Files: []string{"a.jpg"}, Music: "track.mp3"}
+	paths, audio, err := resolveSlideshow(&req, root, true, music)
+	if err != nil {
+		t.Fatalf("resolveSlideshow: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != filepath.Join(root, "a.jpg") || audio != "" {
+		t.Errorf("paths %v, audio %q", paths, audio)
+	}
+	if req.Width != 1920 || req.Height != 1080 || req.SlideDuration != 5 || req.Transition != media.TransitionCrossfade {
+		t.Errorf("defaults not applied: %+v", req.SlideshowOptions)
+	}
+
+	dst, err := copyMusicTrack(music, req.Music, t.TempDir())
+	if err != nil || filepath.Base(dst) != "music.mp3" {
+		t.Errorf("copyMusicTrack = %q, %v", dst, err)
+	}
+}
+
+func TestSlideshowJobRejectsBadRequests(t *testing.T) {
+	root := t.TempDir()
+	writeJPEG(t, filepath.Join(root, "a.jpg"))
+	mgr, err := jobs.NewManager(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	HandleSlideshow(mux, root, true, mgr, fstest.MapFS{"track.mp3": {Data: []byte("ID3")}})
+
+	many := make([]string, media.MaxSlides+1)
+	for i := range many {
+		many[i] = "a.jpg"
+	}
+	for name, req := range map[string]slideshowRequest{
+		"no files":   {},
+		"too many":   {Files: many},
+		"escape":     {Files: []string{"../a.jpg"}},
+		"transition": {Files: []string{"a.jpg"}, SlideshowOptions: media.SlideshowOptions{Transition: "wipe"}},
+		"music":      {Files: []string{"a.jpg"}, Music: "missing.mp3"},
+		"music path": {Files: []string{"a.jpg"}, Music: "../track.mp3"},
+		"odd width":  {Files: []string{"a.jpg"}, SlideshowOptions: media.SlideshowOptions{Width: 1281, Height: 720}},
+		"audio file": {Files: []string{"a.jpg"}, AudioFile: "nope.mp3"},
+	} {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/jobs/slideshow", bytes.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", name, w.Code)
+		}
+	}
+	if n := len(mgr.List()); n != 0 {
+		t.Errorf("%d jobs submitted for invalid requests", n)
+	}
+}
@@ -0,0 +1,171 @@
+// Package apijobs provides HTTP handlers for listing and controlling
+// background jobs. Job kinds register their own submit endpoints
+// (e.g. POST /api/jobs/export).
+package apijobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"huepattl.de/unterlumen/internal/jobs"
+)
+
+// Handle registers the /api/jobs routes on mux.
+func Handle(mux *http.ServeMux, mgr *jobs.Manager) {
+	mux.HandleFunc("GET /api/jobs", listJobs(mgr))
+	mux.HandleFunc("GET /api/jobs/{id}", getJob(mgr))
+	mux.HandleFunc("DELETE /api/jobs/{id}", deleteJob(mgr))
+	mux.HandleFunc("POST /api/jobs/{id}/cancel", cancelJob(mgr))
+	mux.HandleFunc("POST /api/jobs/{id}/resume", resumeJob(mgr))
+	mux.HandleFunc("GET /api/jobs/{id}/download", downloadJob(mgr))
+	mux.HandleFunc("GET /api/jobs/{id}/events", jobEvents(mgr, eventInterval))
+}
+
+// eventInterval is how often jobEvents polls the job for changes.
+const eventInterval = 500 * time.Millisecond
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJobError maps jobs errors to HTTP status codes.
+func writeJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, jobs.ErrState):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func listJobs(mgr *jobs.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, mgr.List())
+	}
+}
+
+func getJob(mgr *jobs.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := mgr.Get(r.PathValue("id"))
+		if err != nil {
+			writeJobError(w, err)
+			return
+		}
+		writeJSON(w, job)
+	}
+}
+
+func deleteJob(mgr *jobs.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := mgr.Delete(r.PathValue("id")); err != nil {
+			writeJobError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func cancelJob(mgr *jobs.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if err := mgr.Cancel(id); err != nil {
+			writeJobError(w, err)
+			return
+		}
+		job, _ := mgr.Get(id)
+		writeJSON(w, job)
+	}
+}
+
+func resumeJob(mgr *jobs.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if err := mgr.Resume(id); err != nil {
+			writeJobError(w, err)
+			return
+		}
+		job, _ := mgr.Get(id)
+		writeJSON(w, job)
+	}
+}
+
+// jobEvents streams the job as server-sent events: once on connect and again
+// whenever its progress or status changes. The stream ends after the job has
+// finished.
+func jobEvents(mgr *jobs.Manager, interval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		job, err := mgr.Get(id)
+		if err != nil {
+			writeJobError(w, err)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var last jobs.Job
+		for {
+			if job.Status != last.Status || job.Done != last.Done || job.Total != last.Total || !job.UpdatedAt.Equal(last.UpdatedAt) {
+				data, _ := json.Marshal(job)
+				fmt.Fprintf(w, "data: %s\n\n", data)
+				flusher.Flush()
+				last = job
+			}
+			switch job.Status {
+			case jobs.StatusDone, jobs.StatusFailed, jobs.StatusCancelled:
+				return
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+			}
+			if job, err = mgr.Get(id); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// downloadJob serves a finished job's result. Unlike the one-shot ZIP tokens of
+// /api/export/zip-download, the file stays available until the job is deleted
+// or its retention period ends.
+func downloadJob(mgr *jobs.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		path, err := mgr.ResultPath(id)
+		if err != nil {
+			writeJobError(w, err)
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			http.Error(w, "could not open result", http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			http.Error(w, "could not open result", http.StatusInternalServerError)
+			return
+		}
+		name := "unterlumen-" + id + filepath.Ext(path)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+		http.ServeContent(w, r, name, info.ModTime(), f)
+	}
+}
@@ -0,0 +1,332 @@
+package apilibrary
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Feed file names at the site root.
+const (
+	atomFeedFile = "feed.xml"
+	rssFeedFile  = "rss.xml"
+	jsonFeedFile = "feed.json"
+)
+
+// feedEntry is one album as it appears in every feed format.
+type feedEntry struct {
+	URL        string // album page, also the entry ID
+	Title      string
+	Summary    string
+	Published  time.Time
+	Updated    time.Time
+	PhotoCount int
+	CoverURL   string
+	CoverType  string
+	CoverSize  int64 // 0 when the cover file is missing
+}
+
+// buildFeedEntries returns the feed entries for albums, newest first.
+func buildFeedEntries(siteDir, base string, albums []SiteAlbum) []feedEntry {
+	sorted := make([]SiteAlbum, len(albums))
+	copy(sorted, albums)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].PublishedAt.After(sorted[j].PublishedAt)
+	})
+	entries := make([]feedEntry, 0, len(sorted))
+	for _, a := range sorted {
+		folder := albumFolderName(a)
+		e := feedEntry{
+			URL:        base + "/albums/" + folder + "/",
+			Title:      a.Title,
+			Published:  a.PublishedAt.UTC(),
+			Updated:    a.PublishedAt.UTC(),
+			PhotoCount: a.PhotoCount,
+		}
+		if !a.UpdatedAt.IsZero() {
+			e.Updated = a.UpdatedAt.UTC()
+		}
+		e.Summary = fmt.Sprintf("%d photo", a.PhotoCount)
+		if a.PhotoCount != 1 {
+			e.Summary += "s"
+		}
+		e.Summary += ", " + dateRangeStr(a.PublishedAt, a.UpdatedAt) + "."
+		if a.CoverFile != "" {
+			e.CoverURL = e.URL + a.CoverFile
+			e.CoverType = mime.TypeByExtension(strings.ToLower(path.Ext(a.CoverFile)))
+			if e.CoverType == "" {
+				e.CoverType = "image/jpeg"
+			}
+			if info, err := os.Stat(filepath.Join(siteDir, "albums", folder, filepath.FromSlash(a.CoverFile))); err == nil {
+				e.CoverSize = info.Size()
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// feedUpdated returns the most recent change across entries, or the zero
+// time for an empty site.
+func feedUpdated(entries []feedEntry) time.Time {
+	var t time.Time
+	for _, e := range entries {
+		if e.Updated.After(t) {
+			t = e.Updated
+		}
+	}
+	return t
+}
+
+// generateFeeds writes feed.xml (Atom), rss.xml (RSS 2.0) and feed.json
+// (JSON Feed 1.1) to the site root, one entry per album.
+// Only called when siteURL is non-empty; feed readers need absolute URLs.
+func generateFeeds(siteDir, siteTitle string, albums []SiteAlbum, siteURL string) error {
+	if siteTitle == "" {
+		siteTitle = "Photo Albums"
+	}
+	base := strings.TrimRight(siteURL, "/")
+	entries := buildFeedEntries(siteDir, base, albums)
+	updated := feedUpdated(entries)
+
+	atom, err := renderAtomFeed(siteTitle, base, updated, entries)
+	if err != nil {
+		return err
+	}
+	rss, err := renderRSSFeed(siteTitle, base, updated, entries)
+	if err != nil {
+		return err
+	}
+	jsonFeed, err := renderJSONFeed(siteTitle, base, entries)
+	if err != nil {
+		return err
+	}
+	for name, data := range map[string][]byte{atomFeedFile: atom, rssFeedFile: rss, jsonFeedFile: jsonFeed} {
+		if err := os.WriteFile(filepath.Join(siteDir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* --- Atom --- */
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	ID        string     `xml:"id"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   string     `xml:"summary"`
+	Content   *atomText  `xml:"content,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name   `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string     `xml:"title"`
+	ID      string     `xml:"id"`
+	Links   []atomLink `xml:"link"`
+	Updated string     `xml:"updated"`
+	Author  struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+func renderAtomFeed(title, base string, updated time.Time, entries []feedEntry) ([]byte, error) {
+	f := atomFeed{
+		Title: title,
+		ID:    base + "/",
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: base + "/" + atomFeedFile},
+			{Rel: "alternate", Type: "text/html", Href: base + "/"},
+		},
+		Updated:   updated.Format(time.RFC3339),
+		Generator: "Unterlumen",
+	}
+	f.Author.Name = title // Atom requires an author; the site is the best we know
+	for _, e := range entries {
+		ae := atomEntry{
+			Title:     e.Title,
+			ID:        e.URL,
+			Links:     []atomLink{{Rel: "alternate", Type: "text/html", Href: e.URL}},
+			Published: e.Published.Format(time.RFC3339),
+			Updated:   e.Updated.Format(time.RFC3339),
+			Summary:   e.Summary,
+		}
+		if e.CoverURL != "" {
+			ae.Links = append(ae.Links, atomLink{Rel: "enclosure", Type: e.CoverType, Href: e.CoverURL, Length: e.CoverSize})
+			ae.Content = &atomText{
+				Type: "html",
+				Body: fmt.Sprintf(`<p><a href="%s"><img src="%s" alt="%s"></a></p><p>%s</p>`,
+					xmlAttr(e.URL), xmlAttr(e.CoverURL), xmlAttr(e.Title), xmlAttr(e.Summary)),
+			}
+		}
+		f.Entries = append(f.Entries, ae)
+	}
+	return marshalFeedXML(f)
+}
+
+/* --- RSS 2.0 --- */
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Description string        `xml:"description"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	AtomNS  string   `xml:"xmlns:atom,attr"`
+	Channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		SelfLink      atomLink  `xml:"atom:link"`
+		LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+		Generator     string    `xml:"generator"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+func renderRSSFeed(title, base string, updated time.Time, entries []feedEntry) ([]byte, error) {
+	f := rssFeed{Version: "2.0", AtomNS: "http://www.w3.org/2005/Atom"}
+	f.Channel.Title = title
+	f.Channel.Link = base + "/"
+	f.Channel.Description = fmt.Sprintf("New albums on %s", title)
+	f.Channel.SelfLink = atomLink{Rel: "self", Type: "application/rss+xml", Href: base + "/" + rssFeedFile}
+	if !updated.IsZero() {
+		f.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+	f.Channel.Generator = "Unterlumen"
+	for _, e := range entries {
+		item := rssItem{
+			Title:       e.Title,
+			Link:        e.URL,
+			GUID:        rssGUID{IsPermaLink: true, Value: e.URL},
+			PubDate:     e.Published.Format(time.RFC1123Z),
+			Description: e.Summary,
+		}
+		if e.CoverURL != "" {
+			item.Enclosure = &rssEnclosure{URL: e.CoverURL, Length: e.CoverSize, Type: e.CoverType}
+		}
+		f.Channel.Items = append(f.Channel.Items, item)
+	}
+	return marshalFeedXML(f)
+}
+
+/* --- JSON Feed 1.1 --- */
+
+type jsonFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url"`
+	Title         string               `json:"title"`
+	ContentText   string               `json:"content_text"`
+	Image         string               `json:"image,omitempty"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+	Unterlumen    struct {
+		PhotoCount int `json:"photo_count"`
+	} `json:"_unterlumen"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+func renderJSONFeed(title, base string, entries []feedEntry) ([]byte, error) {
+	f := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       title,
+		HomePageURL: base + "/",
+		FeedURL:     base + "/" + jsonFeedFile,
+		Items:       []jsonFeedItem{},
+	}
+	for _, e := range entries {
+		item := jsonFeedItem{
+			ID:            e.URL,
+			URL:           e.URL,
+			Title:         e.Title,
+			ContentText:   e.Summary,
+			Image:         e.CoverURL,
+			DatePublished: e.Published.Format(time.RFC3339),
+			DateModified:  e.Updated.Format(time.RFC3339),
+		}
+		item.Unterlumen.PhotoCount = e.PhotoCount
+		if e.CoverURL != "" {
+			item.Attachments = []jsonFeedAttachment{{URL: e.CoverURL, MimeType: e.CoverType, SizeInBytes: e.CoverSize}}
+		}
+		f.Items = append(f.Items, item)
+	}
+	return json.MarshalIndent(f, "", "  ")
+}
+
+func marshalFeedXML(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// xmlAttr escapes s for use inside HTML markup embedded in a feed.
+func xmlAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s)) //nolint:errcheck
+	return b.String()
+}
+
+// siteFeedLinks is included in the <head> of every site page so browsers
+// and feed readers can discover the feeds.
+const siteFeedLinks = `
+{{- if .Nav.FeedBaseURL}}
+<link rel="alternate" type="application/atom+xml" title="Atom feed" href="{{.Nav.FeedBaseURL}}/feed.xml">
+<link rel="alternate" type="application/rss+xml" title="RSS feed" href="{{.Nav.FeedBaseURL}}/rss.xml">
+<link rel="alternate" type="application/feed+json" title="JSON Feed" href="{{.Nav.FeedBaseURL}}/feed.json">
+{{- end}}`
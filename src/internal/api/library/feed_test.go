@@ -0,0 +1,130 @@
+package apilibrary
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFeedSite(t *testing.T) string {
+	t.Helper()
+	siteDir := t.TempDir()
+	albums := []SiteAlbum{
+		{PostID: "p1", Slug: "winter", Title: "Winter & Snow", PublishedAt: time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC), PhotoCount: 1, CoverFile: "cover.jpg"},
+		{PostID: "p2", Slug: "summer", Title: "Summer", PublishedAt: time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2026, 8, 2, 9, 0, 0, 0, time.UTC), PhotoCount: 12, CoverFile: "cover.jpg"},
+	}
+	cover := filepath.Join(siteDir, "albums", "summer", "cover.jpg")
+	os.MkdirAll(filepath.Dir(cover), 0o755)
+	if err := os.WriteFile(cover, make([]byte, 1234), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateFeeds(siteDir, "My Photos", albums, "https://example.com/"); err != nil {
+		t.Fatalf("generateFeeds: %v", err)
+	}
+	return siteDir
+}
+
+func TestGenerateFeedsAtom(t *testing.T) {
+	siteDir := writeFeedSite(t)
+	data, err := os.ReadFile(filepath.Join(siteDir, "feed.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("invalid Atom: %v\n%s", err, data)
+	}
+	if feed.Updated != "2026-08-02T09:00:00Z" || len(feed.Entries) != 2 {
+		t.Fatalf("feed = %+v", feed)
+	}
+	e := feed.Entries[0] // newest first
+	if e.ID != "https://example.com/albums/summer/" || e.Published != "2026-07-01T09:00:00Z" || e.Updated != "2026-08-02T09:00:00Z" {
+		t.Errorf("entry = %+v", e)
+	}
+	if !strings.Contains(e.Summary, "12 photos") {
+		t.Errorf("summary = %q", e.Summary)
+	}
+	enc := e.Links[1]
+	if enc.Rel != "enclosure" || enc.Href != "https://example.com/albums/summer/cover.jpg" || enc.Type != "image/jpeg" || enc.Length != 1234 {
+		t.Errorf("enclosure = %+v", enc)
+	}
+	if feed.Entries[1].Title != "Winter & Snow" {
+		t.Errorf("title = %q", feed.Entries[1].Title)
+	}
+}
+
+func TestGenerateFeedsRSS(t *testing.T) {
+	siteDir := writeFeedSite(t)
+	data, err := os.ReadFile(filepath.Join(siteDir, "rss.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed struct {
+		Channel struct {
+			Items []struct {
+				Title     string `xml:"title"`
+				GUID      string `xml:"guid"`
+				PubDate   string `xml:"pubDate"`
+				Enclosure struct {
+					URL    string `xml:"url,attr"`
+					Length int64  `xml:"length,attr"`
+				} `xml:"enclosure"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("invalid RSS: %v\n%s", err, data)
+	}
+	items := feed.Channel.Items
+	if len(items) != 2 || items[0].GUID != "https://example.com/albums/summer/" || items[0].PubDate != "Wed, 01 Jul 2026 09:00:00 +0000" {
+		t.Fatalf("items = %+v", items)
+	}
+	if items[0].Enclosure.Length != 1234 || items[1].Enclosure.URL != "https://example.com/albums/winter/cover.jpg" {
+		t.Errorf("enclosures = %+v", items)
+	}
+}
+
+func TestGenerateFeedsJSON(t *testing.T) {
+	siteDir := writeFeedSite(t)
+	data, err := os.ReadFile(filepath.Join(siteDir, "feed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed jsonFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		t.Fatal(err)
+	}
+	if feed.Version != "https://jsonfeed.org/version/1.1" || feed.FeedURL != "https://example.com/feed.json" || len(feed.Items) != 2 {
+		t.Fatalf("feed = %+v", feed)
+	}
+	if it := feed.Items[1]; it.Unterlumen.PhotoCount != 1 || it.DateModified != it.DatePublished || it.Image == "" {
+		t.Errorf("item = %+v", it)
+	}
+}
+
+func TestSitePagesLinkFeeds(t *testing.T) {
+	nav := SiteNavContext{SiteName: "My Photos", FeedBaseURL: "https://example.com"}
+	pages := map[string]string{
+		"index": mustHTML(GenerateSiteIndex("My Photos", "", "https://example.com", nil, nav)),
+		"album": mustHTML(GenerateSiteGallery("Summer", "", nil, GalleryOptions{SiteURL: "https://example.com", AlbumSlug: "summer", Nav: nav})),
+	}
+	for name, html := range pages {
+		for _, want := range []string{
+			`<link rel="alternate" type="application/atom+xml" title="Atom feed" href="https://example.com/feed.xml">`,
+			`href="https://example.com/rss.xml"`,
+			`href="https://example.com/feed.json"`,
+		} {
+			if !strings.Contains(html, want) {
+				t.Errorf("%s: missing %s", name, want)
+			}
+		}
+	}
+	if html := mustHTML(GenerateSiteIndex("My Photos", "", "", nil, SiteNavContext{})); strings.Contains(html, `rel="alternate"`) {
+		t.Error("feed links without a site URL")
+	}
+}
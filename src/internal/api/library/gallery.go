@@ -1,7 +1,6 @@
 package apilibrary
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -42,10 +41,25 @@ func saveGalleryState(statePath string, gs *GalleryState) error {
 
 // GalleryItem describes one photo in the exported HTML gallery.
 type GalleryItem struct {
-	PhotoID       string // library photo ID (SHA-256); empty for pre-photoID entries
-	Filename      string // full-res filename (relative to index.html)
-	ThumbFilename string // thumbnail filename (relative to index.html)
-	Width, Height int    // full-res dimensions
+	PhotoID       string          // library photo ID (SHA-256); empty for pre-photoID entries
+	Filename      string          // full-res filename (relative to index.html)
+	ThumbFilename string          // thumbnail filename (relative to index.html)
+	Width, Height int             // full-res dimensions
+	Renditions    []SiteRendition // resized copies for srcset; nil when none were configured
+	Title         string          // photo title; may be empty
+	AltText       string          // image description for alt attributes; may be empty
+}
+
+// alt returns the alt text of the photo at index i of total in the gallery
+// title: its alt text, else its title, else its position.
+func (item GalleryItem) alt(title string, i, total int) string {
+	if item.AltText != "" {
+		return item.AltText
+	}
+	if item.Title != "" {
+		return item.Title
+	}
+	return fmt.Sprintf("%s – Photo %d of %d", title, i+1, total)
 }
 
 // thumbDimensions returns approximate thumbnail dimensions for a GalleryItem.
@@ -60,21 +74,37 @@ func (item GalleryItem) thumbDimensions() (w, h int) {
 	return 700, item.Height * 700 / item.Width
 }
 
+// responsiveImages returns the srcsets of the grid thumbnail (thumbWidth wide)
+// and of the lightbox image. Both are empty without renditions.
+func (item GalleryItem) responsiveImages(thumbWidth int) (grid, full responsiveImage) {
+	fullImg := fullCandidate(item.Filename, item.Width)
+	grid = buildResponsiveImage(item.Renditions, "", srcsetCandidate{File: item.ThumbFilename, Width: thumbWidth}, fullImg)
+	full = buildResponsiveImage(item.Renditions, "", fullImg)
+	return grid, full
+}
+
 // galleryFigureData holds per-photo data for static figure tag generation.
 // Used by both galleryTmpl (gallery.go) and siteGalleryTmpl (site.go).
 type galleryFigureData struct {
 	Index       int
 	Thumb       string
 	Full        string
+	Page        string // photo detail page; site albums only
 	Loading     string // "eager" or "lazy"
 	Alt         string
 	ThumbWidth  int // 0 = omit width/height attrs
 	ThumbHeight int
+	Srcset      string // "" = plain <img>
+	Sizes       string
+	Sources     []pictureSource // modern formats; wrap the <img> in <picture> when set
 }
 
 type galleryPhoto struct {
-	Full  string `json:"full"`
-	Thumb string `json:"thumb"`
+	Full    string          `json:"full"`
+	Thumb   string          `json:"thumb"`
+	Alt     string          `json:"alt,omitempty"`
+	Srcset  string          `json:"srcset,omitempty"`
+	Sources []pictureSource `json:"sources,omitempty"`
 }
 
 // GalleryOptions carries optional extras for the generated gallery page.
@@ -86,10 +116,10 @@ type GalleryOptions struct {
 	SiteURL     string    // base URL e.g. "https://example.com"; enables canonical, OG tags, sitemap
 	AlbumSlug   string    // album folder name; used with SiteURL to build absolute album URL
 	PublishedAt time.Time // used for datePublished in JSON-LD
-	Nav         SiteNavContext
+	Nav         SiteNavContext // site pages; single galleries only use its template overrides
 }
 
-var galleryTmpl = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+const galleryPageSrc = `<!DOCTYPE html>
 <html lang="en" data-default-theme="dark">
 <head>
 <meta charset="UTF-8">
@@ -179,6 +209,7 @@ h1 {
 }
 .gallery figure:hover img { opacity: 0.88; }
 .gallery img { display: block; width: 100%; height: auto; transition: opacity 0.15s; }
+picture { display: contents; }
 
 /* --- Lightbox --- */
 #lb {
@@ -268,14 +299,16 @@ footer a:hover { color: var(--text-dim); }
 
 <main class="gallery" id="gallery">
 {{range .Figures}}<figure data-index="{{.Index}}">
-  <img src="{{.Thumb}}" loading="{{.Loading}}" alt="{{.Alt}}"{{if .ThumbWidth}} width="{{.ThumbWidth}}" height="{{.ThumbHeight}}"{{end}}>
+  {{if .Sources}}{{$sizes := .Sizes}}<picture>{{range .Sources}}<source type="{{.Type}}" srcset="{{.Srcset}}" sizes="{{$sizes}}">{{end}}{{end -}}
+  <img src="{{.Thumb}}"{{if .Srcset}} srcset="{{.Srcset}}" sizes="{{.Sizes}}"{{end}} loading="{{.Loading}}" alt="{{.Alt}}"{{if .ThumbWidth}} width="{{.ThumbWidth}}" height="{{.ThumbHeight}}"{{end}}>
+  {{- if .Sources}}</picture>{{end}}
 </figure>
 {{end}}</main>
 
 <div id="lb">
   <button id="lb-close" title="Close (Esc)">&times;</button>
   <button class="lb-nav" id="lb-prev" title="Previous (←)"><svg width="28" height="28" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round" aria-hidden="true"><polyline points="15 18 9 12 15 6"/></svg></button>
-  <img id="lb-img" src="" alt="">
+  <picture id="lb-pic"><img id="lb-img" src="" alt=""></picture>
   <button class="lb-nav" id="lb-next" title="Next (→)"><svg width="28" height="28" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round" aria-hidden="true"><polyline points="9 18 15 12 9 6"/></svg></button>
   <div id="lb-counter"></div>
 </div>
@@ -308,14 +341,34 @@ let cur = 0;
 function open(idx) {
   cur = idx;
   const lb = document.getElementById('lb');
-  document.getElementById('lb-img').src = photos[idx].full;
+  showPhoto(photos[idx]);
   lb.classList.add('open');
   document.body.style.overflow = 'hidden';
   updateCounter();
 }
 
+// showPhoto points the lightbox image at p, offering its renditions so the
+// browser can pick one sized to the screen.
+function showPhoto(p) {
+  const pic = document.getElementById('lb-pic');
+  const img = document.getElementById('lb-img');
+  pic.querySelectorAll('source').forEach(s => s.remove());
+  (p.sources || []).forEach(src => {
+    const s = document.createElement('source');
+    s.type = src.type;
+    s.srcset = src.srcset;
+    s.sizes = '100vw';
+    pic.insertBefore(s, img);
+  });
+  img.sizes = p.srcset ? '100vw' : '';
+  img.srcset = p.srcset || '';
+  img.src = p.full;
+  img.alt = p.alt || '';
+}
+
 function close() {
   document.getElementById('lb').classList.remove('open');
+  document.getElementById('lb-img').srcset = '';
   document.getElementById('lb-img').src = '';
   document.body.style.overflow = '';
 }
@@ -345,21 +398,34 @@ document.querySelectorAll('#gallery figure').forEach(fig => {
 </script>
 </body>
 </html>
-`))
+`
+
+var galleryTmpl = template.Must(template.New("gallery").Parse(galleryPageSrc))
+
+// galleryPageData is the data of gallery.html, a single-gallery page.
+type galleryPageData struct {
+	Title       string
+	Description string
+	LDJSON      template.JS
+	PhotosJSON  template.JS
+	ZipFilename string
+	Figures     []galleryFigureData
+}
 
 // GenerateGallery returns a self-contained index.html for the given title, photos, and options.
-func GenerateGallery(title string, items []GalleryItem, opts GalleryOptions) []byte {
+func GenerateGallery(title string, items []GalleryItem, opts GalleryOptions) ([]byte, error) {
 	total := len(items)
 	photos := make([]galleryPhoto, 0, total)
 	figures := make([]galleryFigureData, 0, total)
 	for i, item := range items {
-		photos = append(photos, galleryPhoto{Full: item.Filename, Thumb: item.ThumbFilename})
+		tw, th := item.thumbDimensions()
+		grid, full := item.responsiveImages(tw)
+		alt := item.alt(title, i, total)
+		photos = append(photos, galleryPhoto{Full: item.Filename, Thumb: item.ThumbFilename, Alt: alt, Srcset: full.Srcset, Sources: full.Sources})
 		loading := "lazy"
 		if i < 2 {
 			loading = "eager"
 		}
-		alt := fmt.Sprintf("%s – Photo %d of %d", title, i+1, total)
-		tw, th := item.thumbDimensions()
 		figures = append(figures, galleryFigureData{
 			Index:       i,
 			Thumb:       item.ThumbFilename,
@@ -368,6 +434,9 @@ func GenerateGallery(title string, items []GalleryItem, opts GalleryOptions) []b
 			Alt:         alt,
 			ThumbWidth:  tw,
 			ThumbHeight: th,
+			Srcset:      grid.Srcset,
+			Sizes:       gridSizes,
+			Sources:     grid.Sources,
 		})
 	}
 	photosJSON, _ := json.Marshal(photos)
@@ -393,15 +462,7 @@ func GenerateGallery(title string, items []GalleryItem, opts GalleryOptions) []b
 	}
 	ldJSON, _ := json.Marshal(ldMap)
 
-	var buf bytes.Buffer
-	galleryTmpl.Execute(&buf, struct { //nolint:errcheck
-		Title       string
-		Description string
-		LDJSON      template.JS
-		PhotosJSON  template.JS
-		ZipFilename string
-		Figures     []galleryFigureData
-	}{
+	return opts.Nav.templates.execute("gallery.html", galleryTmpl, galleryPageData{
 		Title:       title,
 		Description: description,
 		LDJSON:      template.JS(ldJSON),
@@ -409,7 +470,6 @@ func GenerateGallery(title string, items []GalleryItem, opts GalleryOptions) []b
 		ZipFilename: opts.ZipFilename,
 		Figures:     figures,
 	})
-	return buf.Bytes()
 }
 
 // buildGalleryItems reconstructs GalleryItem entries from a SiteAlbum's stored photos.
@@ -417,8 +477,31 @@ func GenerateGallery(title string, items []GalleryItem, opts GalleryOptions) []b
 func buildGalleryItems(photos []SitePhoto) []GalleryItem {
 	items := make([]GalleryItem, len(photos))
 	for i, p := range photos {
-		items[i] = GalleryItem{PhotoID: p.PhotoID, Filename: p.Filename, ThumbFilename: p.ThumbFilename}
+		items[i] = GalleryItem{
+			PhotoID:       p.PhotoID,
+			Filename:      p.Filename,
+			ThumbFilename: p.ThumbFilename,
+			Width:         p.Width,
+			Height:        p.Height,
+			Renditions:    p.Renditions,
+			Title:         p.Title,
+			AltText:       p.AltText,
+		}
 	}
 	return items
 }
 
+// sitePhotoFromItem is the inverse of buildGalleryItems for one photo.
+func sitePhotoFromItem(item GalleryItem) SitePhoto {
+	return SitePhoto{
+		PhotoID:       item.PhotoID,
+		Filename:      item.Filename,
+		ThumbFilename: item.ThumbFilename,
+		Width:         item.Width,
+		Height:        item.Height,
+		Renditions:    item.Renditions,
+		Title:         item.Title,
+		AltText:       item.AltText,
+	}
+}
+
@@ -0,0 +1,111 @@
+package apilibrary
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	lib "huepattl.de/unterlumen/internal/library"
+)
+
+// geoQueryKeys are the query parameters consumed by parseGeoFilters; they must
+// not be treated as EXIF text filters.
+var geoQueryKeys = map[string]bool{"bbox": true, "near": true, "radius_km": true, "zoom": true}
+
+// parseGeoFilters reads the optional geographic filters into opts:
+//
+//	bbox=minLon,minLat,maxLon,maxLat   (GeoJSON order; minLon > maxLon crosses the antimeridian)
+//	near=lat,lon&radius_km=N
+func parseGeoFilters(q url.Values, opts *lib.ListPhotosOpts) error {
+	if v := q.Get("bbox"); v != "" {
+		f, err := parseFloats(v, 4)
+		if err != nil {
+			return fmt.Errorf("invalid bbox: %w", err)
+		}
+		if f[1] > f[3] || f[1] < -90 || f[3] > 90 {
+			return fmt.Errorf("invalid bbox: latitude out of range")
+		}
+		opts.BBox = &lib.GeoBBox{MinLon: f[0], MinLat: f[1], MaxLon: f[2], MaxLat: f[3]}
+	}
+	if v := q.Get("near"); v != "" {
+		f, err := parseFloats(v, 2)
+		if err != nil {
+			return fmt.Errorf("invalid near: %w", err)
+		}
+		km, err := strconv.ParseFloat(q.Get("radius_km"), 64)
+		if err != nil || km <= 0 {
+			return fmt.Errorf("radius_km must be a positive number")
+		}
+		opts.Radius = &lib.GeoRadius{Lat: f[0], Lon: f[1], Km: km}
+	}
+	return nil
+}
+
+func parseFloats(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d comma-separated numbers", n)
+	}
+	out := make([]float64, n)
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+type geoClustersResponse struct {
+	Zoom     int              `json:"zoom"`
+	CellDeg  float64          `json:"cellDeg"`
+	Total    int              `json:"total"` // photos across all clusters
+	Clusters []lib.GeoCluster `json:"clusters"`
+}
+
+// geoClusters serves server-side clustered map markers for the given zoom and
+// viewport (bbox). All search filters of /api/library/search apply.
+func geoClusters(mgr *lib.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		zoom, err := strconv.Atoi(q.Get("zoom"))
+		if err != nil || zoom < 0 || zoom > 22 {
+			http.Error(w, "zoom must be an integer between 0 and 22", http.StatusBadRequest)
+			return
+		}
+		opts := lib.ListPhotosOpts{
+			Filters:        parseTextFilters(q),
+			NumericFilters: parseNumericFilters(q),
+			DateMin:        q.Get("date_taken_min"),
+			DateMax:        q.Get("date_taken_max"),
+			MetaFilters:    parseMetaFilters(q),
+			AlbumTitle:     q.Get("album_title"),
+			ExtFilter:      q.Get("ext"),
+		}
+		if ch := q.Get("channel"); ch != "" {
+			opts.MetaExists = []string{"published:" + ch}
+		}
+		if err := parseGeoFilters(q, &opts); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		cellDeg := lib.ClusterCellDeg(zoom)
+		clusters, err := mgr.GeoClusters(parseIDList(q.Get("ids")), opts, cellDeg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp := geoClustersResponse{Zoom: zoom, CellDeg: cellDeg, Clusters: clusters}
+		if resp.Clusters == nil {
+			resp.Clusters = []lib.GeoCluster{}
+		}
+		for _, c := range clusters {
+			resp.Total += c.Count
+		}
+		writeJSON(w, resp)
+	}
+}
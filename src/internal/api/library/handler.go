@@ -8,6 +8,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	_ "image/jpeg"
@@ -15,6 +16,7 @@ import (
 	"io"
 	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -24,6 +26,7 @@ import (
 
 	_ "golang.org/x/image/webp"
 
+	"huepattl.de/unterlumen/internal/api/batchrename"
 	"huepattl.de/unterlumen/internal/channels"
 	lib "huepattl.de/unterlumen/internal/library"
 	"huepattl.de/unterlumen/internal/media"
@@ -33,6 +36,9 @@ import (
 // Handle registers all library API routes on mux.
 // root is the browse boundary directory; serverRole is true when running in server/container mode.
 func Handle(mux *http.ServeMux, mgr *lib.Manager, imgCache *media.ImageCache, root string, serverRole bool, chStore *channels.Store) {
+	publish := publishPhotos(mgr, chStore, root, serverRole)
+	dispatcher := newPublishDispatcher(mgr, publish)
+
 	mux.HandleFunc("GET /api/library/", listLibraries(mgr, root))
 	mux.HandleFunc("POST /api/library/", createLibrary(mgr, root))
 	mux.HandleFunc("PUT /api/library-order", setLibraryOrder(mgr))
@@ -40,6 +46,7 @@ func Handle(mux *http.ServeMux, mgr *lib.Manager, imgCache *media.ImageCache, ro
 	mux.HandleFunc("PATCH /api/settings", patchSettings(mgr))
 	mux.HandleFunc("GET /api/library/detect", detectLibrary(mgr, root))
 	mux.HandleFunc("GET /api/library/search", searchLibraries(mgr))
+	mux.HandleFunc("GET /api/library/geo-clusters", geoClusters(mgr))
 	mux.HandleFunc("GET /api/library/exif-ranges", globalExifRanges(mgr))
 	mux.HandleFunc("GET /api/library/exif-values", globalExifValues(mgr))
 	mux.HandleFunc("GET /api/library/meta-keys", globalMetaKeys(mgr))
@@ -70,10 +77,19 @@ func Handle(mux *http.ServeMux, mgr *lib.Manager, imgCache *media.ImageCache, ro
 	mux.HandleFunc("GET /api/library/{id}/photo/{photoID}/meta", getMeta(mgr))
 	mux.HandleFunc("PUT /api/library/{id}/photo/{photoID}/meta", upsertMeta(mgr))
 	mux.HandleFunc("DELETE /api/library/{id}/photo/{photoID}/meta", deleteMeta(mgr, chStore))
-	mux.HandleFunc("POST /api/library/{id}/publish", publishPhotos(mgr, chStore, root, serverRole))
+	mux.HandleFunc("POST /api/library/{id}/publish", publish)
+	mux.HandleFunc("POST /api/library/{id}/publish-schedule", schedulePublish(mgr, chStore, serverRole, dispatcher))
+	mux.HandleFunc("GET /api/publish-queue", listScheduled(mgr))
+	mux.HandleFunc("PATCH /api/publish-queue/{queueID}", reschedulePublish(mgr, dispatcher))
+	mux.HandleFunc("DELETE /api/publish-queue/{queueID}", cancelScheduled(mgr))
 	mux.HandleFunc("POST /api/library/{id}/publish-download", publishDownload(mgr, chStore))
 	mux.HandleFunc("POST /api/channels/{slug}/rebuild-site", rebuildSite(chStore, mgr))
 	mux.HandleFunc("GET /api/channels/{slug}/galleries", listGalleries(chStore))
+
+	// Scheduled posts need channels; without a channel store they stay queued.
+	if chStore != nil {
+		go dispatcher.run(context.Background())
+	}
 }
 
 func writeJSON(w http.ResponseWriter, v any) {
@@ -258,7 +274,8 @@ func patchSettings(mgr *lib.Manager) http.HandlerFunc {
 			return
 		}
 		var patch struct {
-			LibrarySortMode *string `json:"librarySortMode"`
+			LibrarySortMode *string              `json:"librarySortMode"`
+			PrivacyZones    *[]media.PrivacyZone `json:"privacyZones"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
 			http.Error(w, "invalid JSON", http.StatusBadRequest)
@@ -267,6 +284,15 @@ func patchSettings(mgr *lib.Manager) http.HandlerFunc {
 		if patch.LibrarySortMode != nil {
 			current.LibrarySortMode = *patch.LibrarySortMode
 		}
+		if patch.PrivacyZones != nil {
+			for _, z := range *patch.PrivacyZones {
+				if err := z.Validate(); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			current.PrivacyZones = *patch.PrivacyZones
+		}
 		if err := mgr.SaveSettings(current); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -364,7 +390,7 @@ func libraryScan(mgr *lib.Manager, scan func(*lib.Indexer, chan<- lib.Progress))
 			go func() {
 				defer store.Close()
 				defer mgr.EndScan(id)
-				indexer := lib.NewIndexer(store, mgr.LibDir(id), libInfo.SourcePath)
+				indexer := mgr.NewIndexer(store, id, libInfo.SourcePath)
 				scan(indexer, rawCh)
 			}()
 		} else {
@@ -514,6 +540,10 @@ func listPhotos(mgr *lib.Manager) http.HandlerFunc {
 			DateMin:        q.Get("date_taken_min"),
 			DateMax:        q.Get("date_taken_max"),
 		}
+		if err := parseGeoFilters(q, &opts); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		opts.Offset, _ = strconv.Atoi(q.Get("offset"))
 		opts.Limit, _ = strconv.Atoi(q.Get("limit"))
 		if opts.Limit <= 0 || opts.Limit > 500 {
@@ -529,21 +559,36 @@ func listPhotos(mgr *lib.Manager) http.HandlerFunc {
 	}
 }
 
+// ParseSearchQuery reads the cross-library search parameters of
+// GET /api/library/search — ids, EXIF text and numeric filters, date range,
+// meta_*, channel, album_title, ext and the geo filters — from q. Offset and
+// limit are left to the caller.
+func ParseSearchQuery(q url.Values) (ids []string, opts lib.ListPhotosOpts, err error) {
+	opts = lib.ListPhotosOpts{
+		Filters:        parseTextFilters(q),
+		NumericFilters: parseNumericFilters(q),
+		DateMin:        q.Get("date_taken_min"),
+		DateMax:        q.Get("date_taken_max"),
+		MetaFilters:    parseMetaFilters(q),
+		AlbumTitle:     q.Get("album_title"),
+		ExtFilter:      q.Get("ext"),
+	}
+	if ch := q.Get("channel"); ch != "" {
+		opts.MetaExists = []string{"published:" + ch}
+	}
+	if err := parseGeoFilters(q, &opts); err != nil {
+		return nil, opts, err
+	}
+	return parseIDList(q.Get("ids")), opts, nil
+}
+
 func searchLibraries(mgr *lib.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		q := r.URL.Query()
-		ids := parseIDList(q.Get("ids"))
-		opts := lib.ListPhotosOpts{
-			Filters:        parseTextFilters(q),
-			NumericFilters: parseNumericFilters(q),
-			DateMin:        q.Get("date_taken_min"),
-			DateMax:        q.Get("date_taken_max"),
-			MetaFilters:    parseMetaFilters(q),
-			AlbumTitle:     q.Get("album_title"),
-			ExtFilter:      q.Get("ext"),
-		}
-		if ch := q.Get("channel"); ch != "" {
-			opts.MetaExists = []string{"published:" + ch}
+		ids, opts, err := ParseSearchQuery(q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 		opts.Offset, _ = strconv.Atoi(q.Get("offset"))
 		opts.Limit, _ = strconv.Atoi(q.Get("limit"))
@@ -708,6 +753,9 @@ func parseTextFilters(vals map[string][]string) map[string]string {
 		if k == "q" || k == "offset" || k == "limit" || k == "ids" || len(vs) == 0 {
 			continue
 		}
+		if geoQueryKeys[k] {
+			continue
+		}
 		if strings.HasSuffix(k, "_min") || strings.HasSuffix(k, "_max") {
 			continue
 		}
@@ -1183,6 +1231,14 @@ func getMeta(mgr *lib.Manager) http.HandlerFunc {
 	}
 }
 
+// sidecarMetaKeys are the meta keys mirrored into the photo's XMP sidecar:
+// dc:title, the dc:description caption and Iptc4xmpCore:AltTextAccessibility.
+var sidecarMetaKeys = map[string]func(photoPath, value string) error{
+	"title":       media.WriteTitle,
+	"description": media.WriteDescription,
+	"alt":         media.WriteAltText,
+}
+
 func upsertMeta(mgr *lib.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := r.PathValue("id")
@@ -1208,9 +1264,9 @@ func upsertMeta(mgr *lib.Manager) http.HandlerFunc {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if body.Key == "title" {
+		if write := sidecarMetaKeys[body.Key]; write != nil {
 			if pathHint, phErr := store.GetPhotoPathHint(photoID); phErr == nil && pathHint != "" {
-				media.WriteTitle(pathHint, body.Value) //nolint:errcheck
+				write(pathHint, body.Value) //nolint:errcheck
 			}
 		}
 		w.WriteHeader(http.StatusNoContent)
@@ -1271,9 +1327,9 @@ func deleteMeta(mgr *lib.Manager, chStore *channels.Store) http.HandlerFunc {
 			}
 		}
 
-		if key == "title" {
+		if write := sidecarMetaKeys[key]; write != nil {
 			if pathHint, phErr := store.GetPhotoPathHint(photoID); phErr == nil && pathHint != "" {
-				media.WriteTitle(pathHint, "") //nolint:errcheck
+				write(pathHint, "") //nolint:errcheck
 			}
 		}
 		if err := store.DeleteMeta(photoID, key); err != nil {
@@ -1329,6 +1385,9 @@ func unpublishPhotoFromSite(store *lib.Store, ch *channels.Channel, chStore *cha
 				if sp.ThumbFilename != "" {
 					os.Remove(filepath.Join(albumDir, sp.ThumbFilename))             //nolint:errcheck
 				}
+				for _, r := range sp.Renditions {
+					os.Remove(filepath.Join(albumDir, filepath.FromSlash(r.Filename))) //nolint:errcheck
+				}
 				modified = true
 			} else {
 				kept = append(kept, sp)
@@ -1345,9 +1404,12 @@ func unpublishPhotoFromSite(store *lib.Store, ch *channels.Channel, chStore *cha
 	}
 
 	// Remove albums that are now empty and regenerate HTML for those that remain.
+	// The photo's files are already gone, so a page that fails to render is
+	// skipped and reported once the site state is saved.
 	rootNav := buildSiteNavContext(ch, siteDir, true)
 	albumNav := buildSiteNavContext(ch, siteDir, false)
 	var remaining []SiteAlbum
+	var renderErrs []error
 	for _, album := range albums {
 		if album.PhotoCount == 0 {
 			os.RemoveAll(filepath.Join(siteDir, "albums", albumFolderName(album))) //nolint:errcheck
@@ -1369,7 +1431,7 @@ func unpublishPhotoFromSite(store *lib.Store, ch *channels.Channel, chStore *cha
 			}
 		}
 		dateStr := dateRangeStr(album.PublishedAt, album.UpdatedAt)
-		albumHTML := GenerateSiteGallery(album.Title, ch.SiteTheme, items, GalleryOptions{
+		albumHTML, err := GenerateSiteGallery(album.Title, ch.SiteTheme, items, GalleryOptions{
 			ZipFilename: zipName,
 			SiteTitle:   ch.SiteTitle,
 			DateStr:     dateStr,
@@ -1378,22 +1440,40 @@ func unpublishPhotoFromSite(store *lib.Store, ch *channels.Channel, chStore *cha
 			PublishedAt: album.PublishedAt,
 			Nav:         albumNav,
 		})
-		os.WriteFile(filepath.Join(albumDir, "index.html"), albumHTML, 0o644) //nolint:errcheck
+		if err != nil {
+			renderErrs = append(renderErrs, fmt.Errorf("album page: %w", err))
+		} else {
+			os.WriteFile(filepath.Join(albumDir, "index.html"), albumHTML, 0o644) //nolint:errcheck
+		}
+		if err := writeSitePhotoPages(albumDir, album, ch, albumNav); err != nil {
+			renderErrs = append(renderErrs, fmt.Errorf("photo pages: %w", err))
+		}
 	}
 
 	if err := saveSiteState(statePath, remaining); err != nil {
 		return fmt.Errorf("save site state: %w", err)
 	}
 
-	siteHTML := GenerateSiteIndex(ch.SiteTitle, ch.SiteTheme, ch.SiteURL, remaining, rootNav)
-	os.WriteFile(filepath.Join(siteDir, "index.html"), siteHTML, 0o644) //nolint:errcheck
-	generateAboutPage(siteDir, ch, avatarExistsAt(siteDir), rootNav)   //nolint:errcheck
-	generateImprintPage(siteDir, ch, rootNav)                           //nolint:errcheck
-	generateRobotsTxt(siteDir, ch.SiteURL)                              //nolint:errcheck
+	if err := writeSiteBrowsePages(siteDir, remaining, ch, &rootNav); err != nil {
+		renderErrs = append(renderErrs, fmt.Errorf("browse pages: %w", err))
+	}
+	if siteHTML, err := GenerateSiteIndex(ch.SiteTitle, ch.SiteTheme, ch.SiteURL, remaining, rootNav); err != nil {
+		renderErrs = append(renderErrs, fmt.Errorf("site index: %w", err))
+	} else {
+		os.WriteFile(filepath.Join(siteDir, "index.html"), siteHTML, 0o644) //nolint:errcheck
+	}
+	if err := generateAboutPage(siteDir, ch, avatarExistsAt(siteDir), rootNav); err != nil {
+		renderErrs = append(renderErrs, fmt.Errorf("about page: %w", err))
+	}
+	if err := generateImprintPage(siteDir, ch, rootNav); err != nil {
+		renderErrs = append(renderErrs, fmt.Errorf("legal page: %w", err))
+	}
+	generateRobotsTxt(siteDir, ch.SiteURL) //nolint:errcheck
 	if ch.SiteURL != "" {
 		generateSitemap(siteDir, remaining, ch.SiteURL) //nolint:errcheck
+		generateFeeds(siteDir, ch.SiteTitle, remaining, ch.SiteURL) //nolint:errcheck
 	}
-	return nil
+	return errors.Join(renderErrs...)
 }
 
 // --- Publish ---
@@ -1405,7 +1485,11 @@ type publishResult struct {
 	ThumbFilename string `json:"thumbFilename,omitempty"`
 	Width         int    `json:"width,omitempty"`
 	Height        int    `json:"height,omitempty"`
+	Quality       int    `json:"quality,omitempty"` // quality used, e.g. as chosen for the channel's maxBytes
+	URL           string `json:"url,omitempty"`     // remote post URL when the channel has a handler
 	Error         string `json:"error,omitempty"`
+
+	Renditions []SiteRendition `json:"renditions,omitempty"` // gallery/site: resized copies for srcset
 }
 
 func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serverRole bool) http.HandlerFunc {
@@ -1425,6 +1509,8 @@ func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serve
 			TargetPostID string   `json:"targetPostID,omitempty"` // non-empty = add to existing gallery/album
 			RecordXMP    *bool    `json:"recordXMP,omitempty"`    // nil → true (default)
 			OutputPath   string   `json:"outputPath,omitempty"`   // per-publish override
+
+			Caption string `json:"caption,omitempty"` // post text for handler channels; defaults to galleryTitle
 		}
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			http.Error(w, "invalid JSON", http.StatusBadRequest)
@@ -1472,6 +1558,13 @@ func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serve
 		addToExisting := body.TargetPostID != ""
 		galleryMode := ch.GalleryExport && (body.GalleryTitle != "" || addToExisting)
 		siteMode := ch.SiteExport && (body.GalleryTitle != "" || addToExisting)
+		if galleryMode || siteMode {
+			// Report template errors before anything is exported.
+			if _, err := loadSiteTemplates(ch.SiteTemplateDir); err != nil {
+				http.Error(w, "site templates: "+err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+		}
 		channelDir := chStore.OutputDir(body.Channel)
 		if body.OutputPath != "" {
 			if serverRole && filepath.IsAbs(body.OutputPath) {
@@ -1557,14 +1650,44 @@ func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serve
 		}
 
 		recordXMP := body.RecordXMP == nil || *body.RecordXMP
+		opts := mgr.WithPrivacyZones(ch.ExportOptions())
 
 		if !galleryMode && !siteMode {
 			// Fast synchronous path for regular (non-gallery) publishes.
+			// With a handler, photos are recorded only once the upload succeeded.
+			var handler channels.Handler
+			if ch.Handler != "" {
+				var ok bool
+				if handler, ok = channels.LookupHandler(ch.Handler); !ok {
+					http.Error(w, "unknown handler: "+ch.Handler, http.StatusBadRequest)
+					return
+				}
+				if err := handler.ValidateConfig(ch.HandlerConfigFor(body.Account)); err != nil {
+					http.Error(w, "handler config: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
 			var results []publishResult
-			for _, photoID := range body.PhotoIDs {
-				res := publishOne(store, ch, pub, ts, outDir, "", photoID, recordXMP)
+			names := publishNames(mgr, store, ch, ts, outDir, body.PhotoIDs)
+			for i, photoID := range body.PhotoIDs {
+				res := publishOne(store, ch, opts, pub, names[i], outDir, "", photoID, recordXMP && handler == nil)
 				results = append(results, res)
 			}
+			if b := channelBucket(ch); b != nil && handler == nil {
+				uploadResults(r.Context(), b, channelDir, results)
+			}
+			if handler != nil {
+				text := body.Caption
+				if text == "" {
+					text = body.GalleryTitle
+				}
+				if err := publishRemote(r.Context(), store, ch, handler, pub, text, results, recordXMP); err != nil {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusBadGateway)
+					json.NewEncoder(w).Encode(map[string]any{"postID": postID, "results": results, "error": ch.Handler + ": " + err.Error()})
+					return
+				}
+			}
 			writeJSON(w, map[string]any{"postID": postID, "results": results})
 			return
 		}
@@ -1594,25 +1717,27 @@ func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serve
 
 		total := len(body.PhotoIDs)
 		var results []publishResult
+		names := publishNames(mgr, store, ch, ts, outDir, body.PhotoIDs)
 		for i, photoID := range body.PhotoIDs {
-			res := publishOne(store, ch, pub, ts, outDir, thumbDir, photoID, recordXMP)
+			res := publishOne(store, ch, opts, pub, names[i], outDir, thumbDir, photoID, recordXMP)
 			results = append(results, res)
 			emit(map[string]any{"step": "photo", "done": i + 1, "total": total, "file": res.Filename})
 		}
 
 		// Build merged items list: existing photos first, then newly exported.
-		var items []GalleryItem
-		for _, ep := range existingPhotos {
-			items = append(items, GalleryItem{PhotoID: ep.PhotoID, Filename: ep.Filename, ThumbFilename: ep.ThumbFilename})
-		}
+		items := buildGalleryItems(existingPhotos)
 		for _, res := range results {
 			if res.Error == "" && res.Filename != "" {
+				title, _, alt := photoCaptions(store, res.PhotoID)
 				items = append(items, GalleryItem{
 					PhotoID:       res.PhotoID,
 					Filename:      res.Filename,
 					ThumbFilename: res.ThumbFilename,
 					Width:         res.Width,
 					Height:        res.Height,
+					Renditions:    res.Renditions,
+					Title:         title,
+					AltText:       alt,
 				})
 			}
 		}
@@ -1652,8 +1777,9 @@ func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serve
 		// Generate HTML gallery.
 		emit(map[string]any{"step": "html", "done": 0, "total": 1, "file": "Generating gallery…"})
 		var html []byte
+		var renderErr error
 		if siteMode {
-			html = GenerateSiteGallery(galleryTitle, ch.SiteTheme, items, GalleryOptions{
+			html, renderErr = GenerateSiteGallery(galleryTitle, ch.SiteTheme, items, GalleryOptions{
 				ZipFilename: zipName,
 				SiteTitle:   ch.SiteTitle,
 				DateStr:     dateStr,
@@ -1663,7 +1789,19 @@ func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serve
 				Nav:         buildSiteNavContext(ch, filepath.Join(channelDir, "site"), false),
 			})
 		} else {
-			html = GenerateGallery(galleryTitle, items, GalleryOptions{ZipFilename: zipName, DateStr: dateStr})
+			html, renderErr = GenerateGallery(galleryTitle, items, GalleryOptions{
+				ZipFilename: zipName,
+				DateStr:     dateStr,
+				Nav:         SiteNavContext{templates: channelSiteTemplates(ch)},
+			})
+			if err := copySiteTemplateAssets(ch.SiteTemplateDir, filepath.Join(outDir, "assets")); err != nil {
+				emit(map[string]any{"error": "copy template assets: " + err.Error()})
+				return
+			}
+		}
+		if renderErr != nil {
+			emit(map[string]any{"error": "render gallery: " + renderErr.Error()})
+			return
 		}
 		indexPath := filepath.Join(outDir, "index.html")
 		if err := os.WriteFile(indexPath, html, 0o644); err != nil {
@@ -1681,7 +1819,7 @@ func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serve
 			}
 			sitePhotos := make([]SitePhoto, len(items))
 			for i, item := range items {
-				sitePhotos[i] = SitePhoto{PhotoID: item.PhotoID, Filename: item.Filename, ThumbFilename: item.ThumbFilename}
+				sitePhotos[i] = sitePhotoFromItem(item)
 			}
 			gs := &GalleryState{
 				PostID:      albumPostID,
@@ -1704,7 +1842,7 @@ func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serve
 					os.WriteFile(filepath.Join(outDir, "cover.jpg"), cover, 0o644) //nolint:errcheck
 				}
 			}
-			if assetsErr := writeSiteAssets(filepath.Join(siteDir, "assets")); assetsErr != nil {
+			if assetsErr := writeSiteAssets(filepath.Join(siteDir, "assets"), ch.SiteTemplateDir); assetsErr != nil {
 				emit(map[string]any{"error": "write site assets: " + assetsErr.Error()})
 				return
 			}
@@ -1712,8 +1850,11 @@ func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serve
 			siteAlbums, _ := loadSiteState(statePath)
 			sitePhotos := make([]SitePhoto, len(items))
 			for i, item := range items {
-				sitePhotos[i] = SitePhoto{PhotoID: item.PhotoID, Filename: item.Filename, ThumbFilename: item.ThumbFilename}
+				sitePhotos[i] = sitePhotoFromItem(item)
 			}
+			// Existing photos keep their recorded details; new ones read them from the library.
+			copy(sitePhotos, existingPhotos)
+			fillSitePhotoDetails(store, mgr.PrivacyZones(), sitePhotos[len(existingPhotos):])
 			if addToExisting {
 				// Update existing album entry; preserve PublishedAt for sort order.
 				for i := range siteAlbums {
@@ -1741,22 +1882,49 @@ func publishPhotos(mgr *lib.Manager, chStore *channels.Store, root string, serve
 				emit(map[string]any{"error": "save site state: " + saveErr.Error()})
 				return
 			}
+			for _, album := range siteAlbums {
+				if album.PostID == albumPostID {
+					if pagesErr := writeSitePhotoPages(outDir, album, ch, buildSiteNavContext(ch, siteDir, false)); pagesErr != nil {
+						emit(map[string]any{"error": "write photo pages: " + pagesErr.Error()})
+						return
+					}
+				}
+			}
 			rootNav := buildSiteNavContext(ch, siteDir, true)
-			siteHTML := GenerateSiteIndex(ch.SiteTitle, ch.SiteTheme, ch.SiteURL, siteAlbums, rootNav)
+			if browseErr := writeSiteBrowsePages(siteDir, siteAlbums, ch, &rootNav); browseErr != nil {
+				emit(map[string]any{"error": "write browse pages: " + browseErr.Error()})
+				return
+			}
+			siteHTML, renderErr := GenerateSiteIndex(ch.SiteTitle, ch.SiteTheme, ch.SiteURL, siteAlbums, rootNav)
+			if renderErr != nil {
+				emit(map[string]any{"error": "render site index: " + renderErr.Error()})
+				return
+			}
 			if writeErr := os.WriteFile(filepath.Join(siteDir, "index.html"), siteHTML, 0o644); writeErr != nil {
 				emit(map[string]any{"error": "write site index: " + writeErr.Error()})
 				return
 			}
-			generateAboutPage(siteDir, ch, avatarExistsAt(siteDir), rootNav)   //nolint:errcheck
-			generateImprintPage(siteDir, ch, rootNav)                           //nolint:errcheck
+			if pageErr := generateAboutPage(siteDir, ch, avatarExistsAt(siteDir), rootNav); pageErr != nil {
+				emit(map[string]any{"error": "write about page: " + pageErr.Error()})
+				return
+			}
+			if pageErr := generateImprintPage(siteDir, ch, rootNav); pageErr != nil {
+				emit(map[string]any{"error": "write legal page: " + pageErr.Error()})
+				return
+			}
 			generateRobotsTxt(siteDir, ch.SiteURL)                     //nolint:errcheck
 			if ch.SiteURL != "" {
 				generateSitemap(siteDir, siteAlbums, ch.SiteURL) //nolint:errcheck
+				generateFeeds(siteDir, ch.SiteTitle, siteAlbums, ch.SiteURL) //nolint:errcheck
 			}
 			emit(map[string]any{"step": "site", "done": 1, "total": 1, "file": "Site index updated"})
-			emit(map[string]any{"complete": true, "postID": postID, "galleryPath": outDir, "sitePath": siteDir, "results": results})
+			done := map[string]any{"complete": true, "postID": postID, "galleryPath": outDir, "sitePath": siteDir, "results": results}
+			syncGalleryStorage(r.Context(), ch, channelDir, siteDir, emit, done)
+			emit(done)
 		} else {
-			emit(map[string]any{"complete": true, "postID": postID, "galleryPath": outDir, "results": results})
+			done := map[string]any{"complete": true, "postID": postID, "galleryPath": outDir, "results": results}
+			syncGalleryStorage(r.Context(), ch, channelDir, outDir, emit, done)
+			emit(done)
 		}
 	}
 }
@@ -1814,11 +1982,8 @@ func publishDownload(mgr *lib.Manager, chStore *channels.Store) http.HandlerFunc
 		recordXMP := body.RecordXMP != nil && *body.RecordXMP
 		publishedAt := time.Now().UTC()
 		ts := publishedAt.Format("20060102T150405Z")
-		opts := ch.ExportOptions()
-		ext := "." + ch.Format
-		if ch.Format == "jpeg" {
-			ext = ".jpg"
-		}
+		opts := mgr.WithPrivacyZones(ch.ExportOptions())
+		names := publishNames(mgr, store, ch, ts, "", body.PhotoIDs)
 
 		var pub media.Publication
 		if recordXMP {
@@ -1830,7 +1995,7 @@ func publishDownload(mgr *lib.Manager, chStore *channels.Store) http.HandlerFunc
 		}
 
 		zw := zip.NewWriter(tmpFile)
-		for _, photoID := range body.PhotoIDs {
+		for i, photoID := range body.PhotoIDs {
 			pathHint, pathErr := store.GetPhotoPathHint(photoID)
 			if pathErr != nil || pathHint == "" {
 				continue
@@ -1854,9 +2019,7 @@ func publishDownload(mgr *lib.Manager, chStore *channels.Store) http.HandlerFunc
 			if expErr != nil {
 				continue
 			}
-			base := strings.TrimSuffix(filepath.Base(pathHint), filepath.Ext(pathHint))
-			outName := ch.Slug + "_" + ts + "_" + base + ext
-			if fw, fwErr := zw.Create(outName); fwErr == nil {
+			if fw, fwErr := zw.Create(names[i]); fwErr == nil {
 				fw.Write(data) //nolint:errcheck
 			}
 		}
@@ -1918,52 +2081,76 @@ var galleryThumbOpts = media.ExportOptions{
 	},
 }
 
-func publishOne(store *lib.Store, ch *channels.Channel, pub media.Publication, ts, outDir, thumbDir, photoID string, recordXMP bool) publishResult {
+// publishNames returns the output file name, relative to outDir, for each photo:
+// the channel's NamePattern resolved with batch-rename tokens (duplicates
+// suffixed, and existing files in outDir left untouched when outDir is set),
+// or the default <slug>_<timestamp>_<base><ext>.
+func publishNames(mgr *lib.Manager, store *lib.Store, ch *channels.Channel, ts, outDir string, photoIDs []string) []string {
+	ext := filepath.Ext(media.ExportedName("x", ch.Format))
+	paths := make([]string, len(photoIDs))
+	for i, photoID := range photoIDs {
+		paths[i], _ = store.GetPhotoPathHint(photoID)
+	}
+	if ch.NamePattern == "" {
+		names := make([]string, len(paths))
+		for i, p := range paths {
+			base := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+			names[i] = ch.Slug + "_" + ts + "_" + base + ext
+		}
+		return names
+	}
+	names := batchrename.OutputNames(paths, ch.NamePattern, ext, mgr)
+	if outDir != "" {
+		taken := make(map[string]bool)
+		for i, n := range names {
+			names[i] = freeName(outDir, n, taken)
+		}
+	}
+	return names
+}
+
+// freeName returns name, or name with the first free _NNN suffix, such that no
+// file of that name exists in dir and it is not in taken (which it is added to).
+func freeName(dir, name string, taken map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for n := 1; ; n++ {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(candidate))); os.IsNotExist(err) && !taken[candidate] {
+			taken[candidate] = true
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%03d%s", base, n, ext)
+	}
+}
+
+func publishOne(store *lib.Store, ch *channels.Channel, opts media.ExportOptions, pub media.Publication, outName, outDir, thumbDir, photoID string, recordXMP bool) publishResult {
 	pathHint, err := store.GetPhotoPathHint(photoID)
 	if err != nil || pathHint == "" {
 		return publishResult{PhotoID: photoID, Error: "photo not found"}
 	}
 
 	if recordXMP {
-		if err := media.AppendPublication(pathHint, pub); err != nil {
+		if err := recordPublication(store, photoID, pathHint, pub); err != nil {
 			return publishResult{PhotoID: photoID, Error: "xmp: " + err.Error()}
 		}
-		metaVal := pub.PublishedAt.UTC().Format(time.RFC3339)
-		chKey := "published:" + pub.Channel
-		qualKey := chKey + ":" + pub.PostID
-		store.UpsertMeta(photoID, chKey, metaVal)   //nolint:errcheck
-		store.UpsertMeta(photoID, qualKey, metaVal) //nolint:errcheck
-		if pub.Account != "" {
-			store.UpsertMeta(photoID, chKey+":account", pub.Account)   //nolint:errcheck
-			store.UpsertMeta(photoID, qualKey+":account", pub.Account) //nolint:errcheck
-		}
-		if pub.PostID != "" {
-			store.UpsertMeta(photoID, chKey+":postid", pub.PostID) //nolint:errcheck
-		}
-		if pub.GalleryTitle != "" {
-			store.UpsertMeta(photoID, chKey+":title", pub.GalleryTitle)   //nolint:errcheck
-			store.UpsertMeta(photoID, qualKey+":title", pub.GalleryTitle) //nolint:errcheck
-		}
 	}
 
-	exported, err := media.ExportImage(pathHint, ch.ExportOptions())
+	result, err := media.ExportImageResult(pathHint, opts)
 	if err != nil {
 		return publishResult{PhotoID: photoID, Error: "export: " + err.Error()}
 	}
+	exported := result.Data
 
-	ext := "." + ch.Format
-	if ch.Format == "jpeg" {
-		ext = ".jpg"
+	outPath := filepath.Join(outDir, filepath.FromSlash(outName))
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return publishResult{PhotoID: photoID, Error: "write export: " + err.Error()}
 	}
-	base := strings.TrimSuffix(filepath.Base(pathHint), filepath.Ext(pathHint))
-	outName := ch.Slug + "_" + ts + "_" + base + ext
-	outPath := filepath.Join(outDir, outName)
-
 	if err := os.WriteFile(outPath, exported, 0o644); err != nil {
 		return publishResult{PhotoID: photoID, Error: "write export: " + err.Error()}
 	}
 
-	res := publishResult{PhotoID: photoID, OutputPath: outPath, Filename: outName}
+	res := publishResult{PhotoID: photoID, OutputPath: outPath, Filename: outName, Quality: result.Quality}
 	if cfg, _, err := image.DecodeConfig(bytes.NewReader(exported)); err == nil {
 		res.Width = cfg.Width
 		res.Height = cfg.Height
@@ -1972,15 +2159,120 @@ func publishOne(store *lib.Store, ch *channels.Channel, pub media.Publication, t
 	if thumbDir != "" {
 		if thumb, err := media.ExportImage(pathHint, galleryThumbOpts); err == nil {
 			thumbName := "thumbs/" + outName
-			if err := os.WriteFile(filepath.Join(thumbDir, outName), thumb, 0o644); err == nil {
+			thumbPath := filepath.Join(thumbDir, filepath.FromSlash(outName))
+			os.MkdirAll(filepath.Dir(thumbPath), 0o755) //nolint:errcheck
+			if err := os.WriteFile(thumbPath, thumb, 0o644); err == nil {
 				res.ThumbFilename = thumbName
 			}
 		}
+		if len(ch.RenditionWidths) > 0 {
+			res.Renditions = exportRenditions(pathHint, opts, ch.RenditionWidths, res.Width, res.Height, outName, outDir)
+		}
 	}
 
 	return res
 }
 
+// recordPublication appends pub to the photo's XMP sidecar and mirrors it
+// into photo_meta as published:<channel>[:<postID>][:account|:postid|:title|:url].
+func recordPublication(store *lib.Store, photoID, pathHint string, pub media.Publication) error {
+	if err := media.AppendPublication(pathHint, pub); err != nil {
+		return err
+	}
+	metaVal := pub.PublishedAt.UTC().Format(time.RFC3339)
+	chKey := "published:" + pub.Channel
+	qualKey := chKey + ":" + pub.PostID
+	store.UpsertMeta(photoID, chKey, metaVal)   //nolint:errcheck
+	store.UpsertMeta(photoID, qualKey, metaVal) //nolint:errcheck
+	if pub.Account != "" {
+		store.UpsertMeta(photoID, chKey+":account", pub.Account)   //nolint:errcheck
+		store.UpsertMeta(photoID, qualKey+":account", pub.Account) //nolint:errcheck
+	}
+	if pub.PostID != "" {
+		store.UpsertMeta(photoID, chKey+":postid", pub.PostID) //nolint:errcheck
+	}
+	if pub.GalleryTitle != "" {
+		store.UpsertMeta(photoID, chKey+":title", pub.GalleryTitle)   //nolint:errcheck
+		store.UpsertMeta(photoID, qualKey+":title", pub.GalleryTitle) //nolint:errcheck
+	}
+	if pub.URL != "" {
+		store.UpsertMeta(photoID, chKey+":url", pub.URL)   //nolint:errcheck
+		store.UpsertMeta(photoID, qualKey+":url", pub.URL) //nolint:errcheck
+	}
+	return nil
+}
+
+// publishRemote hands the exported files of a publish to the channel's
+// handler and records each uploaded photo with its remote post URL. Photos
+// whose export failed are left out of the batch. The returned error is the
+// handler's; if it posted part of the batch before failing, that part is
+// still recorded, so the photos already online can be tracked.
+func publishRemote(ctx context.Context, store *lib.Store, ch *channels.Channel, h channels.Handler, pub media.Publication, text string, results []publishResult, recordXMP bool) error {
+	var batch channels.Batch
+	batch.Text = text
+	var idx []int
+	for i, res := range results {
+		if res.Error != "" {
+			continue
+		}
+		title, caption, alt := photoCaptions(store, res.PhotoID)
+		if alt == "" {
+			alt = title
+		}
+		batch.Items = append(batch.Items, channels.BatchItem{Path: res.OutputPath, AltText: alt, Title: title, Caption: caption})
+		idx = append(idx, i)
+	}
+	if len(batch.Items) == 0 {
+		return nil
+	}
+	post, pubErr := h.Publish(ctx, ch.HandlerConfigFor(pub.Account), batch)
+	if post == nil {
+		return pubErr
+	}
+	for j, i := range idx {
+		p := pub
+		p.RemoteID, p.URL = post.ID, post.URL
+		if j < len(post.Items) {
+			p.RemoteID, p.URL = post.Items[j].PostID, post.Items[j].URL
+		}
+		if pubErr != nil && (j >= len(post.Items) || post.Items[j].PostID == "") {
+			continue // not posted
+		}
+		results[i].URL = p.URL
+		if !recordXMP {
+			continue
+		}
+		pathHint, err := store.GetPhotoPathHint(results[i].PhotoID)
+		if err != nil || pathHint == "" {
+			continue
+		}
+		if err := recordPublication(store, results[i].PhotoID, pathHint, p); err != nil {
+			results[i].Error = "xmp: " + err.Error()
+		}
+	}
+	return pubErr
+}
+
+// photoCaptions returns the photo's "title", "description" and "alt" meta
+// values; missing ones are "".
+func photoCaptions(store *lib.Store, photoID string) (title, caption, alt string) {
+	entries, err := store.GetMeta(photoID)
+	if err != nil {
+		return "", "", ""
+	}
+	for _, e := range entries {
+		switch e.Key {
+		case "title":
+			title = e.Value
+		case "description":
+			caption = e.Value
+		case "alt":
+			alt = e.Value
+		}
+	}
+	return title, caption, alt
+}
+
 // scanAlbumPhotos reconstructs a GalleryItem list from the files on disk.
 // Used when rebuilding albums that were published before photo metadata was stored in site.json.
 func scanAlbumPhotos(albumDir string) []GalleryItem {
@@ -2122,7 +2414,11 @@ func rebuildSite(chStore *channels.Store, mgr *lib.Manager) http.HandlerFunc {
 			return
 		}
 
-		if err := writeSiteAssets(filepath.Join(siteDir, "assets")); err != nil {
+		if _, err := loadSiteTemplates(ch.SiteTemplateDir); err != nil {
+			http.Error(w, "site templates: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if err := writeSiteAssets(filepath.Join(siteDir, "assets"), ch.SiteTemplateDir); err != nil {
 			http.Error(w, "write site assets: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -2189,6 +2485,9 @@ func rebuildSite(chStore *channels.Store, mgr *lib.Manager) http.HandlerFunc {
 			}
 			remaining = append(remaining, album)
 		}
+		if refreshSitePhotoDetails(mgr, remaining) {
+			stateDirty = true
+		}
 		if stateDirty {
 			saveSiteState(statePath, remaining) //nolint:errcheck
 		}
@@ -2213,16 +2512,21 @@ func rebuildSite(chStore *channels.Store, mgr *lib.Manager) http.HandlerFunc {
 						continue // legacy entry — no source link, cannot re-export
 					}
 					photoPath := filepath.Join(albumDir, sp.Filename)
-					if _, statErr := os.Stat(photoPath); statErr == nil {
-						continue // file already on disk
+					_, statErr := os.Stat(photoPath)
+					if statErr == nil && renditionsComplete(albumDir, sp.Renditions) {
+						continue // files already on disk
 					}
 					srcPath, srcErr := findPhotoSourcePath(mgr, sp.PhotoID)
 					if srcErr != nil || srcPath == "" {
 						continue
 					}
-					if exported, exportErr := media.ExportImage(srcPath, ch.ExportOptions()); exportErr == nil {
-						os.WriteFile(photoPath, exported, 0o644) //nolint:errcheck
+					exportOpts := mgr.WithPrivacyZones(ch.ExportOptions())
+					if statErr != nil {
+						if exported, exportErr := media.ExportImage(srcPath, exportOpts); exportErr == nil {
+							os.WriteFile(photoPath, exported, 0o644) //nolint:errcheck
+						}
 					}
+					restoreRenditions(srcPath, exportOpts, albumDir, sp.Renditions)
 					if sp.ThumbFilename != "" {
 						thumbPath := filepath.Join(albumDir, sp.ThumbFilename)
 						if _, statErr := os.Stat(thumbPath); os.IsNotExist(statErr) {
@@ -2254,7 +2558,7 @@ func rebuildSite(chStore *channels.Store, mgr *lib.Manager) http.HandlerFunc {
 				}
 			}
 			dateStr := dateRangeStr(album.PublishedAt, album.UpdatedAt)
-			albumHTML := GenerateSiteGallery(album.Title, ch.SiteTheme, items, GalleryOptions{
+			albumHTML, err := GenerateSiteGallery(album.Title, ch.SiteTheme, items, GalleryOptions{
 				ZipFilename: zipName,
 				SiteTitle:   ch.SiteTitle,
 				DateStr:     dateStr,
@@ -2263,22 +2567,60 @@ func rebuildSite(chStore *channels.Store, mgr *lib.Manager) http.HandlerFunc {
 				PublishedAt: album.PublishedAt,
 				Nav:         albumNav,
 			})
+			if err != nil {
+				http.Error(w, "render album page: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
 			os.WriteFile(filepath.Join(albumDir, "index.html"), albumHTML, 0o644) //nolint:errcheck
+			pageAlbum := *album
+			if len(pageAlbum.Photos) == 0 {
+				// Legacy album rebuilt from the files on disk.
+				for _, item := range items {
+					pageAlbum.Photos = append(pageAlbum.Photos, SitePhoto{Filename: item.Filename, ThumbFilename: item.ThumbFilename})
+				}
+			}
+			if err := writeSitePhotoPages(albumDir, pageAlbum, ch, albumNav); err != nil {
+				http.Error(w, "write photo pages: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
 		}
 
-		siteHTML := GenerateSiteIndex(ch.SiteTitle, ch.SiteTheme, ch.SiteURL, remaining, rootNav)
+		if err := writeSiteBrowsePages(siteDir, remaining, ch, &rootNav); err != nil {
+			http.Error(w, "write browse pages: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		siteHTML, err := GenerateSiteIndex(ch.SiteTitle, ch.SiteTheme, ch.SiteURL, remaining, rootNav)
+		if err != nil {
+			http.Error(w, "render site index: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
 		if err := os.WriteFile(filepath.Join(siteDir, "index.html"), siteHTML, 0o644); err != nil {
 			http.Error(w, "write site index: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
-		generateAboutPage(siteDir, ch, avatarExistsAt(siteDir), rootNav) //nolint:errcheck
-		generateImprintPage(siteDir, ch, rootNav)                         //nolint:errcheck
+		if err := generateAboutPage(siteDir, ch, avatarExistsAt(siteDir), rootNav); err != nil {
+			http.Error(w, "write about page: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := generateImprintPage(siteDir, ch, rootNav); err != nil {
+			http.Error(w, "write legal page: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
 		generateRobotsTxt(siteDir, ch.SiteURL)                            //nolint:errcheck
 		if ch.SiteURL != "" {
 			generateSitemap(siteDir, remaining, ch.SiteURL) //nolint:errcheck
+			generateFeeds(siteDir, ch.SiteTitle, remaining, ch.SiteURL) //nolint:errcheck
 		}
 
-		writeJSON(w, map[string]any{"sitePath": siteDir, "albumCount": len(remaining)})
+		resp := map[string]any{"sitePath": siteDir, "albumCount": len(remaining)}
+		if b := channelBucket(ch); b != nil {
+			if res, err := syncChannelDir(r.Context(), b, chStore.OutputDir(channelSlug), siteDir, nil); err != nil {
+				resp["storageError"] = err.Error()
+			} else {
+				resp["storage"] = res
+			}
+		}
+		writeJSON(w, resp)
 	}
 }
 
@@ -5,10 +5,12 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	lib "huepattl.de/unterlumen/internal/library"
+	"huepattl.de/unterlumen/internal/media"
 )
 
 func newTestManager(t *testing.T) *lib.Manager {
@@ -119,3 +121,87 @@ func TestDeleteLibraryPhotoSuccess(t *testing.T) {
 		t.Errorf("photo record still present after successful delete (hint=%q, err=%v)", hint, err)
 	}
 }
+
+// TestGeoClustersHandler verifies the geo-clusters endpoint validates zoom and
+// returns clustered markers honouring the viewport.
+func TestGeoClustersHandler(t *testing.T) {
+	mgr := newTestManager(t)
+	l, err := mgr.CreateLibrary("Geo", "", t.TempDir())
+	if err != nil {
+		t.Fatalf("CreateLibrary: %v", err)
+	}
+	store, err := mgr.OpenStore(l.ID)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	for id, js := range map[string]string{
+		"a": `{"latitude":48.137,"longitude":11.575}`,
+		"b": `{"latitude":52.520,"longitude":13.405}`,
+	} {
+		if err := store.UpsertPhoto(id, "/x/"+id+".jpg", id+".jpg", 1, time.Now(), js, "", "", "jpeg"); err != nil {
+			t.Fatalf("UpsertPhoto: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	geoClusters(mgr)(rec, httptest.NewRequest("GET", "/api/library/geo-clusters", nil))
+	if rec.Code != 400 {
+		t.Errorf("missing zoom: expected 400, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	geoClusters(mgr)(rec, httptest.NewRequest("GET", "/api/library/geo-clusters?zoom=8&bbox=10,47,12,49", nil))
+	var resp geoClustersResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Total != 1 || len(resp.Clusters) != 1 || resp.Clusters[0].PhotoID != "a" || resp.Clusters[0].LibraryID != l.ID {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+// TestPatchSettingsPrivacyZones verifies privacy zones are validated and persisted.
+func TestPatchSettingsPrivacyZones(t *testing.T) {
+	mgr := newTestManager(t)
+
+	rec := httptest.NewRecorder()
+	patchSettings(mgr)(rec, httptest.NewRequest("PATCH", "/api/settings",
+		strings.NewReader(`{"privacyZones":[{"name":"Home","latitude":48.1,"longitude":11.5,"radiusM":0}]}`)))
+	if rec.Code != 400 {
+		t.Errorf("zero radius: expected 400, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	patchSettings(mgr)(rec, httptest.NewRequest("PATCH", "/api/settings",
+		strings.NewReader(`{"privacyZones":[{"name":"Home","latitude":48.1,"longitude":11.5,"radiusM":400,"action":"fuzz"}]}`)))
+	if rec.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	zones := mgr.PrivacyZones()
+	if len(zones) != 1 || zones[0].Name != "Home" || zones[0].Action != "fuzz" {
+		t.Errorf("unexpected stored zones %+v", zones)
+	}
+	opts := mgr.WithPrivacyZones(media.ExportOptions{ExifMode: media.ExifModeKeepOutsideZones})
+	if len(opts.PrivacyZones) != 1 {
+		t.Errorf("expected zones attached to export options")
+	}
+	if opts := mgr.WithPrivacyZones(media.ExportOptions{ExifMode: "keep"}); opts.PrivacyZones != nil {
+		t.Errorf("zones should only be attached for keep_outside_zones")
+	}
+}
+
+func TestFreeNameSkipsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "2024"), 0o755)
+	os.WriteFile(filepath.Join(dir, "2024", "x.jpg"), nil, 0o644)
+	taken := map[string]bool{}
+	if got := freeName(dir, "2024/x.jpg", taken); got != "2024/x_001.jpg" {
+		t.Errorf("freeName = %q, want 2024/x_001.jpg", got)
+	}
+	if got := freeName(dir, "2024/x.jpg", taken); got != "2024/x_002.jpg" {
+		t.Errorf("second freeName = %q, want 2024/x_002.jpg", got)
+	}
+	if got := freeName(dir, "y.jpg", taken); got != "y.jpg" {
+		t.Errorf("freeName = %q, want y.jpg", got)
+	}
+}
@@ -0,0 +1,256 @@
+package apilibrary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"huepattl.de/unterlumen/internal/channels"
+	lib "huepattl.de/unterlumen/internal/library"
+	"huepattl.de/unterlumen/internal/media"
+)
+
+// fakeHandler records the batch it was given and answers with one post.
+type fakeHandler struct {
+	batch   channels.Batch
+	cfg     map[string]string
+	err     error
+	partial int // with err: number of items posted before failing
+}
+
+func (h *fakeHandler) ValidateConfig(cfg map[string]string) error {
+	if cfg["token"] == "" {
+		return errors.New("token is required")
+	}
+	return nil
+}
+
+func (h *fakeHandler) Publish(_ context.Context, cfg map[string]string, batch channels.Batch) (*channels.RemotePost, error) {
+	h.cfg, h.batch = cfg, batch
+	if h.err != nil && h.partial == 0 {
+		return nil, h.err
+	}
+	post := &channels.RemotePost{ID: "77", URL: "https://remote.example/77", Items: make([]channels.RemoteItem, len(batch.Items))}
+	for i := range batch.Items {
+		if h.err != nil && i >= h.partial {
+			break
+		}
+		post.Items[i] = channels.RemoteItem{PostID: "77", URL: post.URL, MediaID: fmt.Sprint(i)}
+	}
+	return post, h.err
+}
+
+// setupHandlerPublish creates a library with two photos and a channel using
+// a fake handler registered under a name unique to the test.
+func setupHandlerPublish(t *testing.T, h *fakeHandler) (*lib.Manager, *lib.Store, *channels.Store, string, string) {
+	t.Helper()
+	mgr := newTestManager(t)
+	source := t.TempDir()
+	l, err := mgr.CreateLibrary("Test", "", source)
+	if err != nil {
+		t.Fatalf("CreateLibrary: %v", err)
+	}
+	store, err := mgr.OpenStore(l.ID)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	for i := 1; i <= 2; i++ {
+		p := filepath.Join(source, fmt.Sprintf("p%d.jpg", i))
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 32, 24)), nil)
+		if err := os.WriteFile(p, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		id := fmt.Sprintf("photo%d", i)
+		if err := store.UpsertPhoto(id, p, filepath.Base(p), int64(buf.Len()), time.Now(), "{}", "", "", "jpeg"); err != nil {
+			t.Fatalf("UpsertPhoto: %v", err)
+		}
+	}
+	store.UpsertMeta("photo1", "title", "Harbour at dawn") //nolint:errcheck
+
+	name := "fake-" + t.Name()
+	channels.RegisterHandler(name, h)
+	chStore := channels.NewStore(t.TempDir(), t.TempDir())
+	ch := &channels.Channel{
+		Slug: "social", Name: "Social", Format: "jpeg", Quality: 85,
+		Handler:       name,
+		HandlerConfig: map[string]string{"instance": "https://remote.example"},
+		Accounts:      []channels.Account{{ID: "me", Config: map[string]string{"token": "secret"}}},
+	}
+	if err := ch.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if err := chStore.Save(ch); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return mgr, store, chStore, l.ID, source
+}
+
+func postPublish(t *testing.T, mgr *lib.Manager, chStore *channels.Store, libID string, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/library/"+libID+"/publish", bytes.NewReader(data))
+	req.SetPathValue("id", libID)
+	rec := httptest.NewRecorder()
+	publishPhotos(mgr, chStore, t.TempDir(), false)(rec, req)
+	return rec
+}
+
+func TestPublishThroughHandler(t *testing.T) {
+	h := &fakeHandler{}
+	mgr, store, chStore, libID, source := setupHandlerPublish(t, h)
+
+	rec := postPublish(t, mgr, chStore, libID, map[string]any{
+		"photoIDs": []string{"photo1", "photo2"}, "channel": "social", "account": "me", "caption": "Morning walk",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		PostID  string          `json:"postID"`
+		Results []publishResult `json:"results"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if h.batch.Text != "Morning walk" || len(h.batch.Items) != 2 {
+		t.Fatalf("batch = %+v", h.batch)
+	}
+	if h.batch.Items[0].AltText != "Harbour at dawn" || h.batch.Items[1].AltText != "" {
+		t.Errorf("alt texts = %q, %q", h.batch.Items[0].AltText, h.batch.Items[1].AltText)
+	}
+	if h.cfg["instance"] != "https://remote.example" || h.cfg["token"] != "secret" {
+		t.Errorf("handler config = %v", h.cfg)
+	}
+	for _, res := range resp.Results {
+		if res.Error != "" || res.URL != "https://remote.example/77" {
+			t.Errorf("result = %+v", res)
+		}
+	}
+
+	pubs, err := media.ReadSidecar(filepath.Join(source, "p1.jpg"))
+	if err != nil || len(pubs) != 1 {
+		t.Fatalf("sidecar publications = %v, %v", pubs, err)
+	}
+	if pubs[0].URL != "https://remote.example/77" || pubs[0].RemoteID != "77" || pubs[0].Account != "me" {
+		t.Errorf("publication = %+v", pubs[0])
+	}
+	meta := map[string]string{}
+	entries, _ := store.GetMeta("photo2")
+	for _, e := range entries {
+		meta[e.Key] = e.Value
+	}
+	if meta["published:social:url"] != "https://remote.example/77" || meta["published:social:"+resp.PostID+":url"] == "" {
+		t.Errorf("meta = %v", meta)
+	}
+}
+
+func TestPublishThroughHandlerFailureRecordsNothing(t *testing.T) {
+	h := &fakeHandler{err: errors.New("instance unreachable")}
+	mgr, store, chStore, libID, source := setupHandlerPublish(t, h)
+
+	rec := postPublish(t, mgr, chStore, libID, map[string]any{
+		"photoIDs": []string{"photo1"}, "channel": "social", "account": "me",
+	})
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status %d, want 502", rec.Code)
+	}
+	if _, err := os.Stat(media.SidecarPath(filepath.Join(source, "p1.jpg"))); !os.IsNotExist(err) {
+		t.Error("sidecar written for a failed upload")
+	}
+	if entries, _ := store.GetMeta("photo1"); len(entries) != 1 { // only the title
+		t.Errorf("meta = %v", entries)
+	}
+}
+
+func TestPublishThroughHandlerRecordsPartialThread(t *testing.T) {
+	h := &fakeHandler{err: errors.New("reply rejected"), partial: 1}
+	mgr, _, chStore, libID, source := setupHandlerPublish(t, h)
+
+	rec := postPublish(t, mgr, chStore, libID, map[string]any{
+		"photoIDs": []string{"photo1", "photo2"}, "channel": "social", "account": "me",
+	})
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status %d, want 502", rec.Code)
+	}
+	pubs, err := media.ReadSidecar(filepath.Join(source, "p1.jpg"))
+	if err != nil || len(pubs) != 1 || pubs[0].URL != "https://remote.example/77" {
+		t.Errorf("posted photo not recorded: %v, %v", pubs, err)
+	}
+	if _, err := os.Stat(media.SidecarPath(filepath.Join(source, "p2.jpg"))); !os.IsNotExist(err) {
+		t.Error("sidecar written for a photo that was not posted")
+	}
+}
+
+// memBucket is a minimal S3 stand-in (path-style, no listing pagination or
+// signature checks) recording uploaded objects.
+type memBucket struct {
+	mu      sync.Mutex
+	objects map[string]string // key → Content-Type
+}
+
+func (m *memBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	switch r.Method {
+	case http.MethodPut:
+		io.Copy(io.Discard, r.Body)
+		m.objects[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		io.WriteString(w, `<ListBucketResult></ListBucketResult>`)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestPublishUploadsToObjectStorage(t *testing.T) {
+	bucket := &memBucket{objects: map[string]string{}}
+	srv := httptest.NewServer(bucket)
+	defer srv.Close()
+
+	mgr, _, chStore, libID, _ := setupHandlerPublish(t, &fakeHandler{})
+	ch, _ := chStore.Get("social")
+	ch.Handler = ""
+	ch.HandlerConfig = map[string]string{
+		"storage": "s3", "s3_endpoint": srv.URL, "s3_bucket": "bucket", "s3_prefix": "pub",
+		"s3_access_key": "k", "s3_secret_key": "s",
+	}
+	if err := chStore.Save(ch); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := postPublish(t, mgr, chStore, libID, map[string]any{"photoIDs": []string{"photo1"}, "channel": "social"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Results []publishResult `json:"results"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if len(resp.Results) != 1 || resp.Results[0].Error != "" {
+		t.Fatalf("results = %+v", resp.Results)
+	}
+	key := "pub/" + resp.Results[0].Filename
+	if bucket.objects[key] != "image/jpeg" {
+		t.Errorf("objects = %v, want %s", bucket.objects, key)
+	}
+	if want := srv.URL + "/bucket/" + key; resp.Results[0].URL != want {
+		t.Errorf("url = %s, want %s", resp.Results[0].URL, want)
+	}
+}
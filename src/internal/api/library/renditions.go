@@ -0,0 +1,219 @@
+package apilibrary
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"huepattl.de/unterlumen/internal/media"
+)
+
+// renditionsDir is the folder below an album that holds resized copies, one
+// subfolder per width: sizes/960/DSCF1234.jpg, sizes/960/DSCF1234.webp, …
+const renditionsDir = "sizes"
+
+// SiteRendition is one resized copy of a published photo, referenced from
+// srcset attributes.
+type SiteRendition struct {
+	Filename string `json:"filename"` // relative to the album dir
+	Format   string `json:"format"`   // "jpeg", "webp" or "avif"
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// renditionFormats returns the formats renditions are written in: JPEG as
+// the fallback every browser reads, plus AVIF and WebP when an encoder is
+// installed.
+func renditionFormats() []string {
+	formats := []string{"jpeg"}
+	if media.AVIFAvailable() {
+		formats = append(formats, "avif")
+	}
+	if media.WebPAvailable() {
+		formats = append(formats, "webp")
+	}
+	return formats
+}
+
+// renditionWidths returns the sorted, de-duplicated widths below fullWidth;
+// the full-size export already serves the largest candidate.
+func renditionWidths(widths []int, fullWidth int) []int {
+	var out []int
+	seen := map[int]bool{}
+	for _, w := range widths {
+		if w <= 0 || seen[w] || (fullWidth > 0 && w >= fullWidth) {
+			continue
+		}
+		seen[w] = true
+		out = append(out, w)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// renditionOptions derives the export options of one rendition from the
+// channel's: same metadata, colour and watermark handling, but a fixed width,
+// the rendition's format and no size cap.
+func renditionOptions(opts media.ExportOptions, format string, width int) media.ExportOptions {
+	opts.Format = format
+	opts.MaxBytes = 0
+	opts.AllowDownscale = false
+	if opts.Quality <= 0 || opts.Quality > 90 {
+		opts.Quality = 82
+	}
+	opts.Scale = media.ScaleOptions{
+		Mode:         media.ScaleModeMaxDim,
+		MaxDimension: "width",
+		MaxValue:     width,
+		Filter:       opts.Scale.Filter,
+	}
+	return opts
+}
+
+// renditionName returns the rendition path of outName, relative to the album dir.
+func renditionName(outName, format string, width int) string {
+	return path.Join(renditionsDir, strconv.Itoa(width), media.ExportedName(outName, format))
+}
+
+// exportRenditions writes the configured widths of srcPath in every
+// available format below outDir and returns what was written. Heights are
+// derived from the full-size dimensions. Failed encodes are skipped; the
+// page then simply offers fewer candidates.
+func exportRenditions(srcPath string, opts media.ExportOptions, widths []int, fullWidth, fullHeight int, outName, outDir string) []SiteRendition {
+	var out []SiteRendition
+	for _, w := range renditionWidths(widths, fullWidth) {
+		h := 0
+		if fullWidth > 0 {
+			h = fullHeight * w / fullWidth
+		}
+		for _, format := range renditionFormats() {
+			data, err := media.ExportImage(srcPath, renditionOptions(opts, format, w))
+			if err != nil {
+				continue
+			}
+			name := renditionName(outName, format, w)
+			p := filepath.Join(outDir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+				continue
+			}
+			if err := os.WriteFile(p, data, 0o644); err != nil {
+				continue
+			}
+			out = append(out, SiteRendition{Filename: name, Format: format, Width: w, Height: h})
+		}
+	}
+	return out
+}
+
+// renditionsComplete reports whether every rendition file exists below albumDir.
+func renditionsComplete(albumDir string, renditions []SiteRendition) bool {
+	for _, r := range renditions {
+		if _, err := os.Stat(filepath.Join(albumDir, filepath.FromSlash(r.Filename))); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// restoreRenditions re-exports renditions whose files are missing from
+// albumDir, e.g. after the output folder was wiped.
+func restoreRenditions(srcPath string, opts media.ExportOptions, albumDir string, renditions []SiteRendition) {
+	for _, r := range renditions {
+		p := filepath.Join(albumDir, filepath.FromSlash(r.Filename))
+		if _, err := os.Stat(p); err == nil {
+			continue
+		}
+		if data, err := media.ExportImage(srcPath, renditionOptions(opts, r.Format, r.Width)); err == nil {
+			os.MkdirAll(filepath.Dir(p), 0o755) //nolint:errcheck
+			os.WriteFile(p, data, 0o644)        //nolint:errcheck
+		}
+	}
+}
+
+// sizes attributes matching the layouts of the generated pages.
+const (
+	gridSizes  = "(max-width: 540px) 100vw, (max-width: 1100px) 50vw, 550px" // two-column masonry grid
+	photoSizes = "(max-width: 1100px) 100vw, 1100px"                         // photo detail page
+)
+
+// pictureSource is a <source> of a <picture> element.
+type pictureSource struct {
+	Type   string `json:"type"` // MIME type, e.g. "image/avif"
+	Srcset string `json:"srcset"`
+}
+
+// srcsetCandidate is one image in a srcset.
+type srcsetCandidate struct {
+	File   string // relative to the album dir
+	Width  int
+	Format string // "" = the <img> fallback
+}
+
+// fullCandidate returns the full-size export as a srcset candidate in the
+// format its extension implies. Formats browsers cannot be expected to
+// read (JPEG XL) yield an empty candidate, which is ignored.
+func fullCandidate(filename string, width int) srcsetCandidate {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".webp":
+		return srcsetCandidate{filename, width, "webp"}
+	case ".avif":
+		return srcsetCandidate{filename, width, "avif"}
+	case ".jxl":
+		return srcsetCandidate{}
+	}
+	return srcsetCandidate{filename, width, ""}
+}
+
+// responsiveImage holds the srcset data of one photo as seen from a page.
+type responsiveImage struct {
+	Srcset  string          // JPEG renditions plus fallback extras; "" without renditions
+	Sources []pictureSource // modern formats, best first
+}
+
+// buildResponsiveImage assembles srcsets from renditions. prefix is the path
+// from the page to the album dir ("" or "../"); extras (thumbnail, full
+// image) join the srcset of their format.
+func buildResponsiveImage(renditions []SiteRendition, prefix string, extras ...srcsetCandidate) responsiveImage {
+	if len(renditions) == 0 {
+		return responsiveImage{}
+	}
+	byFormat := map[string][]srcsetCandidate{}
+	for _, r := range renditions {
+		format := r.Format
+		if format == "jpeg" {
+			format = ""
+		}
+		byFormat[format] = append(byFormat[format], srcsetCandidate{r.Filename, r.Width, format})
+	}
+	for _, e := range extras {
+		if e.File != "" && e.Width > 0 {
+			byFormat[e.Format] = append(byFormat[e.Format], e)
+		}
+	}
+	img := responsiveImage{Srcset: formatSrcset(byFormat[""], prefix)}
+	for _, format := range []string{"avif", "webp"} {
+		if c := byFormat[format]; len(c) > 0 {
+			img.Sources = append(img.Sources, pictureSource{Type: media.ExportMIMEType(format), Srcset: formatSrcset(c, prefix)})
+		}
+	}
+	return img
+}
+
+func formatSrcset(candidates []srcsetCandidate, prefix string) string {
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Width < candidates[j].Width })
+	parts := make([]string, 0, len(candidates))
+	seen := map[int]bool{}
+	for _, c := range candidates {
+		if seen[c.Width] {
+			continue
+		}
+		seen[c.Width] = true
+		// Commas separate candidates, so they must not appear in URLs.
+		u := strings.ReplaceAll(urlPath(prefix+c.File), ",", "%2C")
+		parts = append(parts, u+" "+strconv.Itoa(c.Width)+"w")
+	}
+	return strings.Join(parts, ", ")
+}
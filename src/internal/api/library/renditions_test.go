@@ -0,0 +1,111 @@
+package apilibrary
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"huepattl.de/unterlumen/internal/media"
+)
+
+func TestRenditionWidths(t *testing.T) {
+	got := renditionWidths([]int{1600, 480, 960, 480, 2560}, 2000)
+	if len(got) != 3 || got[0] != 480 || got[1] != 960 || got[2] != 1600 {
+		t.Errorf("widths = %v", got)
+	}
+}
+
+func TestExportRenditions(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.jpg")
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1200, 800)), nil)
+	os.WriteFile(src, buf.Bytes(), 0o644)
+
+	outDir := filepath.Join(dir, "album")
+	opts := media.ExportOptions{Format: "jpeg", Quality: 80, ExifMode: "strip"}
+	renditions := exportRenditions(src, opts, []int{960, 480, 1200}, 1200, 800, "2026/one.jpg", outDir)
+
+	var jpegs []SiteRendition
+	for _, r := range renditions {
+		if r.Format == "jpeg" {
+			jpegs = append(jpegs, r)
+		}
+	}
+	if len(jpegs) != 2 {
+		t.Fatalf("renditions = %+v", renditions)
+	}
+	if r := jpegs[0]; r.Filename != "sizes/480/2026/one.jpg" || r.Width != 480 || r.Height != 320 {
+		t.Errorf("rendition = %+v", r)
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "sizes", "960", "2026", "one.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil || cfg.Width != 960 {
+		t.Errorf("960 rendition decodes as %+v, %v", cfg, err)
+	}
+
+	os.RemoveAll(filepath.Join(outDir, "sizes", "480"))
+	if renditionsComplete(outDir, renditions) {
+		t.Fatal("missing rendition not detected")
+	}
+	restoreRenditions(src, opts, outDir, renditions)
+	if !renditionsComplete(outDir, renditions) {
+		t.Error("rendition not restored")
+	}
+}
+
+func testRenditionItem() GalleryItem {
+	return GalleryItem{
+		Filename: "one, two.jpg", ThumbFilename: "thumbs/one, two.jpg", Width: 2000, Height: 1000,
+		Renditions: []SiteRendition{
+			{Filename: "sizes/960/one, two.jpg", Format: "jpeg", Width: 960, Height: 480},
+			{Filename: "sizes/960/one, two.webp", Format: "webp", Width: 960, Height: 480},
+			{Filename: "sizes/480/one, two.jpg", Format: "jpeg", Width: 480, Height: 240},
+		},
+	}
+}
+
+func TestGalleryFiguresUseSrcset(t *testing.T) {
+	for name, html := range map[string]string{
+		"gallery": mustHTML(GenerateGallery("Summer", []GalleryItem{testRenditionItem()}, GalleryOptions{})),
+		"site":    mustHTML(GenerateSiteGallery("Summer", "", []GalleryItem{testRenditionItem()}, GalleryOptions{})),
+	} {
+		for _, want := range []string{
+			`<picture><source type="image/webp" srcset="sizes/960/one%2C%20two.webp 960w" sizes="` + gridSizes + `">`,
+			`srcset="sizes/480/one%2C%20two.jpg 480w, thumbs/one%2C%20two.jpg 700w, sizes/960/one%2C%20two.jpg 960w, one%2C%20two.jpg 2000w"`,
+			`width="700" height="350"`,
+			`"srcset":"sizes/480/one%2C%20two.jpg 480w, sizes/960/one%2C%20two.jpg 960w, one%2C%20two.jpg 2000w"`,
+		} {
+			if !strings.Contains(html, want) {
+				t.Errorf("%s: missing %s", name, want)
+			}
+		}
+	}
+
+	plain := mustHTML(GenerateGallery("Summer", []GalleryItem{{Filename: "a.jpg", ThumbFilename: "thumbs/a.jpg"}}, GalleryOptions{}))
+	if strings.Contains(plain, "srcset=") || strings.Contains(plain, "<picture>") {
+		t.Error("srcset without renditions")
+	}
+}
+
+func TestSitePhotoPageUsesSrcset(t *testing.T) {
+	item := testRenditionItem()
+	album := SiteAlbum{Slug: "summer", Title: "Summer", Photos: []SitePhoto{sitePhotoFromItem(item)}}
+	pages := sitePhotoPageNames(sitePhotoFilenames(album.Photos))
+	html := mustHTML(GenerateSitePhotoPage(album, 0, pages, false, "", "", "", SiteNavContext{}))
+	for _, want := range []string{
+		`<source type="image/webp" srcset="../sizes/960/one%2C%20two.webp 960w" sizes="` + photoSizes + `">`,
+		`srcset="../sizes/480/one%2C%20two.jpg 480w, ../sizes/960/one%2C%20two.jpg 960w, ../one%2C%20two.jpg 2000w"`,
+		`width="2000" height="1000"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("photo page missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package apilibrary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"huepattl.de/unterlumen/internal/channels"
+	lib "huepattl.de/unterlumen/internal/library"
+)
+
+// scheduleCheckInterval bounds how long the dispatcher sleeps, so due posts
+// are picked up despite clock changes or edits of the queue file.
+const scheduleCheckInterval = time.Minute
+
+// publishDispatcher publishes queued posts (publish-queue.json in the lib dir)
+// when they are due. It replays each post through the regular publish
+// handler, so scheduled and immediate publishes behave the same.
+type publishDispatcher struct {
+	mgr     *lib.Manager
+	publish http.HandlerFunc
+	wake    chan struct{}
+}
+
+func newPublishDispatcher(mgr *lib.Manager, publish http.HandlerFunc) *publishDispatcher {
+	return &publishDispatcher{mgr: mgr, publish: publish, wake: make(chan struct{}, 1)}
+}
+
+// notify makes the dispatcher re-read the queue, e.g. after a post was added
+// or rescheduled.
+func (d *publishDispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run publishes due posts until ctx is done. Posts interrupted by a previous
+// shutdown are marked failed first. While the queue cannot be read or
+// claimed, it is retried every scheduleCheckInterval.
+func (d *publishDispatcher) run(ctx context.Context) {
+	if err := d.mgr.RecoverScheduled(); err != nil {
+		log.Printf("Warning: publish queue: %v", err)
+	}
+	for {
+		wait := d.nextWait(d.dispatchDue(ctx, time.Now()))
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-d.wake:
+			t.Stop()
+		case <-t.C:
+		}
+	}
+}
+
+// nextWait returns how long run sleeps: until the next queued post is due,
+// at most scheduleCheckInterval, and the full interval after a queue error so
+// an overdue post does not make it spin.
+func (d *publishDispatcher) nextWait(dispatchErr error) time.Duration {
+	if dispatchErr != nil {
+		return scheduleCheckInterval
+	}
+	posts, err := d.mgr.ListScheduled()
+	if err != nil {
+		log.Printf("Warning: publish queue: %v", err)
+		return scheduleCheckInterval
+	}
+	for _, p := range posts {
+		if p.Status == lib.ScheduleQueued {
+			return min(scheduleCheckInterval, max(time.Until(p.ScheduledAt), 0))
+		}
+	}
+	return scheduleCheckInterval
+}
+
+// dispatchDue publishes every queued post due at or before now, one at a
+// time. It returns the error of claiming the due posts, if any.
+func (d *publishDispatcher) dispatchDue(ctx context.Context, now time.Time) error {
+	due, err := d.mgr.ClaimDueScheduled(now)
+	if err != nil {
+		log.Printf("Warning: publish queue: %v", err)
+		return err
+	}
+	for _, p := range due {
+		pubErr := d.publishScheduled(ctx, p)
+		if pubErr != nil {
+			log.Printf("Scheduled publish %s to %s failed: %v", p.ID, p.Channel, pubErr)
+		}
+		if err := d.mgr.FinishScheduled(p.ID, pubErr); err != nil {
+			log.Printf("Warning: publish queue: %v", err)
+		}
+	}
+	return nil
+}
+
+// publishScheduled runs the publish request for p in-process. The publication
+// is dated when it actually happens, not when it was due.
+func (d *publishDispatcher) publishScheduled(ctx context.Context, p lib.ScheduledPost) error {
+	body, err := json.Marshal(map[string]any{
+		"photoIDs":     p.PhotoIDs,
+		"channel":      p.Channel,
+		"account":      p.Account,
+		"galleryTitle": p.GalleryTitle,
+		"targetPostID": p.TargetPostID,
+		"recordXMP":    p.RecordXMP,
+		"outputPath":   p.OutputPath,
+		"caption":      p.Caption,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/api/library/"+p.LibraryID+"/publish", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetPathValue("id", p.LibraryID)
+	rec := &publishRecorder{header: http.Header{}}
+	d.publish(rec, req)
+	return rec.outcome()
+}
+
+// publishRecorder captures the response of a replayed publish request. It
+// implements http.Flusher so gallery and site publishes can stream progress.
+type publishRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *publishRecorder) Header() http.Header { return r.header }
+
+func (r *publishRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+func (r *publishRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+}
+
+func (r *publishRecorder) Flush() {}
+
+// outcome turns the recorded response into an error: the request error, the
+// first error event of a stream, or the photos that failed to publish.
+func (r *publishRecorder) outcome() error {
+	if r.status >= http.StatusBadRequest {
+		var resp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(r.body.Bytes(), &resp) == nil && resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		return errors.New(strings.TrimSpace(r.body.String()))
+	}
+
+	var results []publishResult
+	if strings.HasPrefix(r.header.Get("Content-Type"), "text/event-stream") {
+		complete := false
+		for _, line := range strings.Split(r.body.String(), "\n") {
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok {
+				continue
+			}
+			var evt struct {
+				Error    string          `json:"error"`
+				Complete bool            `json:"complete"`
+				Results  []publishResult `json:"results"`
+			}
+			if json.Unmarshal([]byte(data), &evt) != nil {
+				continue
+			}
+			if evt.Error != "" {
+				return errors.New(evt.Error)
+			}
+			if evt.Complete {
+				complete, results = true, evt.Results
+			}
+		}
+		if !complete {
+			return errors.New("publish ended without completion")
+		}
+	} else {
+		var resp struct {
+			Results []publishResult `json:"results"`
+		}
+		if err := json.Unmarshal(r.body.Bytes(), &resp); err != nil {
+			return fmt.Errorf("read publish response: %w", err)
+		}
+		results = resp.Results
+	}
+
+	var failed []string
+	for _, res := range results {
+		if res.Error != "" {
+			failed = append(failed, res.PhotoID+": "+res.Error)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d photos failed: %s", len(failed), len(results), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// --- Queue endpoints ---
+
+func writeScheduleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, lib.ErrScheduledNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, lib.ErrScheduledBusy):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// schedulePublish queues a publish of library {id} for a future time. The body
+// is that of POST /api/library/{id}/publish plus "scheduledAt" (RFC 3339);
+// "publishedAt" is not accepted, as the post is dated when it goes out.
+func schedulePublish(mgr *lib.Manager, chStore *channels.Store, serverRole bool, d *publishDispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if chStore == nil {
+			http.Error(w, "channel store not available", http.StatusServiceUnavailable)
+			return
+		}
+		id := r.PathValue("id")
+
+		var body struct {
+			PhotoIDs     []string `json:"photoIDs"`
+			Channel      string   `json:"channel"`
+			Account      string   `json:"account"`
+			GalleryTitle string   `json:"galleryTitle"`
+			TargetPostID string   `json:"targetPostID,omitempty"`
+			RecordXMP    *bool    `json:"recordXMP,omitempty"`
+			OutputPath   string   `json:"outputPath,omitempty"`
+			Caption      string   `json:"caption,omitempty"`
+			ScheduledAt  string   `json:"scheduledAt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if len(body.PhotoIDs) == 0 || body.Channel == "" {
+			http.Error(w, "photoIDs and channel required", http.StatusBadRequest)
+			return
+		}
+		at, err := time.Parse(time.RFC3339, body.ScheduledAt)
+		if err != nil {
+			http.Error(w, "scheduledAt must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		if !at.After(time.Now()) {
+			http.Error(w, "scheduledAt must be in the future", http.StatusBadRequest)
+			return
+		}
+		ch, err := chStore.Get(body.Channel)
+		if err != nil {
+			http.Error(w, "channel not found: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if body.Account != "" && ch.AccountByID(body.Account) == nil {
+			http.Error(w, "account not found: "+body.Account, http.StatusBadRequest)
+			return
+		}
+		if serverRole && filepath.IsAbs(body.OutputPath) {
+			http.Error(w, "absolute paths not allowed in server mode", http.StatusBadRequest)
+			return
+		}
+		store, err := mgr.OpenStore(id)
+		if err != nil {
+			http.Error(w, "library not found", http.StatusNotFound)
+			return
+		}
+		defer store.Close()
+		// A mistyped ID would otherwise only fail at the scheduled time.
+		for _, photoID := range body.PhotoIDs {
+			ok, err := store.PhotoExists(photoID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				http.Error(w, "photo not found: "+photoID, http.StatusBadRequest)
+				return
+			}
+		}
+
+		p, err := mgr.AddScheduled(lib.ScheduledPost{
+			LibraryID:    id,
+			PhotoIDs:     body.PhotoIDs,
+			Channel:      body.Channel,
+			Account:      body.Account,
+			GalleryTitle: body.GalleryTitle,
+			TargetPostID: body.TargetPostID,
+			Caption:      body.Caption,
+			RecordXMP:    body.RecordXMP,
+			OutputPath:   body.OutputPath,
+			ScheduledAt:  at,
+		})
+		if err != nil {
+			writeScheduleError(w, err)
+			return
+		}
+		d.notify()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(p)
+	}
+}
+
+func listScheduled(mgr *lib.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		posts, err := mgr.ListScheduled()
+		if err != nil {
+			writeScheduleError(w, err)
+			return
+		}
+		writeJSON(w, posts)
+	}
+}
+
+// reschedulePublish moves a queued or failed post to {"scheduledAt": ...}. A
+// time in the past publishes it at once, e.g. to retry a failed post.
+func reschedulePublish(mgr *lib.Manager, d *publishDispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ScheduledAt string `json:"scheduledAt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		at, err := time.Parse(time.RFC3339, body.ScheduledAt)
+		if err != nil {
+			http.Error(w, "scheduledAt must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		p, err := mgr.RescheduleScheduled(r.PathValue("queueID"), at)
+		if err != nil {
+			writeScheduleError(w, err)
+			return
+		}
+		d.notify()
+		writeJSON(w, p)
+	}
+}
+
+func cancelScheduled(mgr *lib.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := mgr.CancelScheduled(r.PathValue("queueID")); err != nil {
+			writeScheduleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
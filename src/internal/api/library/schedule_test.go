@@ -0,0 +1,157 @@
+package apilibrary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"huepattl.de/unterlumen/internal/channels"
+	lib "huepattl.de/unterlumen/internal/library"
+)
+
+func postSchedule(t *testing.T, mgr *lib.Manager, chStore *channels.Store, d *publishDispatcher, libID string, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/library/"+libID+"/publish-schedule", bytes.NewReader(data))
+	req.SetPathValue("id", libID)
+	rec := httptest.NewRecorder()
+	schedulePublish(mgr, chStore, false, d)(rec, req)
+	return rec
+}
+
+func TestScheduledPublishThroughHandler(t *testing.T) {
+	h := &fakeHandler{}
+	mgr, _, chStore, libID, _ := setupHandlerPublish(t, h)
+	d := newPublishDispatcher(mgr, publishPhotos(mgr, chStore, t.TempDir(), false))
+
+	at := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	rec := postSchedule(t, mgr, chStore, d, libID, map[string]any{
+		"photoIDs": []string{"photo1", "photo2"}, "channel": "social", "account": "me",
+		"caption": "Morning walk", "scheduledAt": at.Format(time.RFC3339),
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var queued lib.ScheduledPost
+	json.NewDecoder(rec.Body).Decode(&queued) //nolint:errcheck
+	if queued.ID == "" || !queued.ScheduledAt.Equal(at) || queued.Status != lib.ScheduleQueued {
+		t.Fatalf("queued = %+v", queued)
+	}
+
+	// Nothing goes out before the due time.
+	d.dispatchDue(context.Background(), time.Now())
+	if len(h.batch.Items) != 0 {
+		t.Fatal("published before the scheduled time")
+	}
+
+	d.dispatchDue(context.Background(), at)
+	if h.batch.Text != "Morning walk" || len(h.batch.Items) != 2 {
+		t.Fatalf("batch = %+v", h.batch)
+	}
+	if posts, _ := mgr.ListScheduled(); len(posts) != 0 {
+		t.Errorf("published post still queued: %+v", posts)
+	}
+}
+
+func TestScheduledPublishFailureStaysQueued(t *testing.T) {
+	h := &fakeHandler{err: errors.New("instance unreachable")}
+	mgr, _, chStore, libID, _ := setupHandlerPublish(t, h)
+	d := newPublishDispatcher(mgr, publishPhotos(mgr, chStore, t.TempDir(), false))
+
+	p, err := mgr.AddScheduled(lib.ScheduledPost{LibraryID: libID, PhotoIDs: []string{"photo1"}, Channel: "social", Account: "me", ScheduledAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.dispatchDue(context.Background(), time.Now())
+	posts, _ := mgr.ListScheduled()
+	if len(posts) != 1 || posts[0].Status != lib.ScheduleFailed || !strings.Contains(posts[0].Error, "instance unreachable") {
+		t.Fatalf("after failure = %+v", posts)
+	}
+
+	// Reschedule, then cancel through the API.
+	req := httptest.NewRequest(http.MethodPatch, "/api/publish-queue/"+p.ID, strings.NewReader(`{"scheduledAt":"2030-01-02T08:00:00Z"}`))
+	req.SetPathValue("queueID", p.ID)
+	rec := httptest.NewRecorder()
+	reschedulePublish(mgr, d)(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"scheduled"`) {
+		t.Fatalf("reschedule: %d %s", rec.Code, rec.Body)
+	}
+	req = httptest.NewRequest(http.MethodDelete, "/api/publish-queue/"+p.ID, nil)
+	req.SetPathValue("queueID", p.ID)
+	rec = httptest.NewRecorder()
+	cancelScheduled(mgr)(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("cancel: %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	cancelScheduled(mgr)(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("cancel twice: %d", rec.Code)
+	}
+}
+
+func TestSchedulePublishValidates(t *testing.T) {
+	mgr, _, chStore, libID, _ := setupHandlerPublish(t, &fakeHandler{})
+	d := newPublishDispatcher(mgr, publishPhotos(mgr, chStore, t.TempDir(), false))
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	for name, body := range map[string]map[string]any{
+		"past":            {"photoIDs": []string{"photo1"}, "channel": "social", "scheduledAt": "2020-01-01T00:00:00Z"},
+		"no time":         {"photoIDs": []string{"photo1"}, "channel": "social"},
+		"unknown channel": {"photoIDs": []string{"photo1"}, "channel": "nope", "scheduledAt": future},
+		"unknown account": {"photoIDs": []string{"photo1"}, "channel": "social", "account": "you", "scheduledAt": future},
+		"unknown photo":   {"photoIDs": []string{"photo1", "photo9"}, "channel": "social", "scheduledAt": future},
+	} {
+		if rec := postSchedule(t, mgr, chStore, d, libID, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", name, rec.Code)
+		}
+	}
+	if posts, _ := mgr.ListScheduled(); len(posts) != 0 {
+		t.Errorf("invalid posts queued: %+v", posts)
+	}
+}
+
+func TestPublishDispatcherBacksOffOnQueueErrors(t *testing.T) {
+	mgr, _, chStore, libID, _ := setupHandlerPublish(t, &fakeHandler{})
+	d := newPublishDispatcher(mgr, publishPhotos(mgr, chStore, t.TempDir(), false))
+	if _, err := mgr.AddScheduled(lib.ScheduledPost{LibraryID: libID, PhotoIDs: []string{"photo1"}, Channel: "social", ScheduledAt: time.Now().Add(time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	if wait := d.nextWait(nil); wait > time.Second {
+		t.Errorf("wait with a post due soon = %v", wait)
+	}
+	if wait := d.nextWait(errors.New("read-only file system")); wait != scheduleCheckInterval {
+		t.Errorf("wait after a claim error = %v, want %v", wait, scheduleCheckInterval)
+	}
+}
+
+func TestPublishRecorderOutcome(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status      int
+		contentType string
+		body        string
+		wantErr     string
+	}{
+		"plain error":   {400, "text/plain", "photoIDs and channel required\n", "photoIDs and channel required"},
+		"handler error": {502, "application/json", `{"error":"mastodon: 401"}`, "mastodon: 401"},
+		"ok":            {200, "application/json", `{"results":[{"photoID":"a"}]}`, ""},
+		"photo failed":  {200, "application/json", `{"results":[{"photoID":"a"},{"photoID":"b","error":"decode"}]}`, "1 of 2 photos failed: b: decode"},
+		"stream ok":     {200, "text/event-stream", "data: {\"step\":\"photo\"}\n\ndata: {\"complete\":true,\"results\":[]}\n\n", ""},
+		"stream error":  {200, "text/event-stream", "data: {\"error\":\"write gallery: disk full\"}\n\n", "write gallery: disk full"},
+		"stream cut":    {200, "text/event-stream", "data: {\"step\":\"photo\"}\n\n", "publish ended without completion"},
+	} {
+		rec := &publishRecorder{header: http.Header{}}
+		rec.Header().Set("Content-Type", tc.contentType)
+		rec.WriteHeader(tc.status)
+		rec.Write([]byte(tc.body)) //nolint:errcheck
+		err := rec.outcome()
+		if (err == nil) != (tc.wantErr == "") || (err != nil && err.Error() != tc.wantErr) {
+			t.Errorf("%s: outcome = %v, want %q", name, err, tc.wantErr)
+		}
+	}
+}
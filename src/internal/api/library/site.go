@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -23,6 +24,26 @@ type SitePhoto struct {
 	PhotoID       string `json:"photoID,omitempty"`
 	Filename      string `json:"filename"`
 	ThumbFilename string `json:"thumbFilename"`
+
+	// Shown on the photo's detail page; captured from the library on publish
+	// and refreshed on rebuild.
+	Title   string         `json:"title,omitempty"`
+	TakenAt string         `json:"takenAt,omitempty"` // EXIF capture date, e.g. "2026-07-01T09:15:00"
+	Caption string         `json:"caption,omitempty"` // XMP dc:description
+	AltText string         `json:"altText,omitempty"` // XMP Iptc4xmpCore:AltTextAccessibility
+	Exif    *SitePhotoExif `json:"exif,omitempty"`
+
+	// Browse pages: keywords from the library's "keywords" meta entry, and
+	// the capture position rounded for the map page; nil when the photo has
+	// no GPS or was taken inside a privacy zone.
+	Keywords []string      `json:"keywords,omitempty"`
+	Location *SiteLocation `json:"location,omitempty"`
+
+	// Full-size dimensions and resized copies for srcset; empty for photos
+	// published before renditions were recorded.
+	Width      int             `json:"width,omitempty"`
+	Height     int             `json:"height,omitempty"`
+	Renditions []SiteRendition `json:"renditions,omitempty"`
 }
 
 // SiteAlbum records metadata for one published album in the site statefile.
@@ -133,6 +154,12 @@ type SiteNavContext struct {
 	LogoExists   bool
 	LogoPath     string // "assets/logo.jpg" for root-level pages; "../../assets/logo.jpg" for album pages
 	SiteName     string
+	FeedBaseURL  string // SiteURL without trailing slash; set when feeds are generated
+	HasYears     bool   // years/, tags/ and map/ browse pages exist; set by writeSiteBrowsePages
+	HasTags      bool
+	HasMap       bool
+
+	templates *siteTemplates // the channel's template overrides; nil = built-ins
 }
 
 // markdownToHTML converts markdown text to safe HTML using goldmark.
@@ -177,19 +204,24 @@ func buildSiteNavContext(ch *channels.Channel, siteDir string, rootLevel bool) S
 		LogoExists:   logoExistsAt(siteDir),
 		LogoPath:     logoPath,
 		SiteName:     ch.SiteTitle,
+		FeedBaseURL:  strings.TrimRight(ch.SiteURL, "/"),
+		templates:    channelSiteTemplates(ch),
 	}
 }
 
-// writeSiteAssets writes style.css and toggle.js into assetsDir, overwriting if present.
+// writeSiteAssets writes style.css and toggle.js into assetsDir, overwriting if present,
+// then copies the assets of templateDir (if any) over them.
 // toggle.js is fully static — it reads the default theme from data-default-theme on <html>.
-func writeSiteAssets(assetsDir string) error {
+func writeSiteAssets(assetsDir, templateDir string) error {
 	if err := os.MkdirAll(assetsDir, 0o700); err != nil {
 		return err
 	}
-	if err := os.WriteFile(assetsDir+"/style.css", []byte(siteCSS), 0o644); err != nil {
-		return err
+	for name, src := range siteAssetFiles {
+		if err := os.WriteFile(filepath.Join(assetsDir, name), []byte(src), 0o644); err != nil {
+			return err
+		}
 	}
-	return os.WriteFile(assetsDir+"/toggle.js", []byte(siteToggleJS), 0o644)
+	return copySiteTemplateAssets(templateDir, assetsDir)
 }
 
 // generateRobotsTxt writes a robots.txt to the site root.
@@ -203,16 +235,22 @@ func generateRobotsTxt(siteDir, siteURL string) error {
 	return os.WriteFile(filepath.Join(siteDir, "robots.txt"), []byte(b.String()), 0o644)
 }
 
-// generateSitemap writes a sitemap.xml to the site root.
+// generateSitemap writes a sitemap.xml to the site root, listing each album
+// page and each photo page with its image.
 // Only called when siteURL is non-empty; sitemap requires absolute URLs.
 func generateSitemap(siteDir string, albums []SiteAlbum, siteURL string) error {
 	base := strings.TrimRight(siteURL, "/")
 	var b strings.Builder
 	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
-	b.WriteString("<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">\n")
+	b.WriteString("<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\" xmlns:image=\"http://www.google.com/schemas/sitemap-image/1.1\">\n")
 	fmt.Fprintf(&b, "  <url><loc>%s/</loc></url>\n", base)
 	for _, a := range albums {
-		fmt.Fprintf(&b, "  <url><loc>%s/albums/%s/</loc></url>\n", base, albumFolderName(a))
+		albumURL := base + "/albums/" + url.PathEscape(albumFolderName(a)) + "/"
+		fmt.Fprintf(&b, "  <url><loc>%s</loc></url>\n", xmlAttr(albumURL))
+		for i, page := range sitePhotoPageNames(sitePhotoFilenames(a.Photos)) {
+			fmt.Fprintf(&b, "  <url><loc>%s</loc><image:image><image:loc>%s</image:loc></image:image></url>\n",
+				xmlAttr(albumURL+urlPath(page)), xmlAttr(albumURL+urlPath(a.Photos[i].Filename)))
+		}
 	}
 	b.WriteString("</urlset>\n")
 	return os.WriteFile(filepath.Join(siteDir, "sitemap.xml"), []byte(b.String()), 0o644)
@@ -332,6 +370,7 @@ footer a:hover { color: var(--text-dim); }
 .site-nav { display: flex; gap: 1rem; font-size: 0.82rem; align-items: baseline; }
 .site-nav a { color: var(--text-dim); text-decoration: none; }
 .site-nav a:hover { color: var(--accent); }
+.header-actions > .site-nav { margin-right: 1rem; }
 
 /* --- Theme toggle button --- */
 .theme-btn {
@@ -490,6 +529,7 @@ footer a:hover { color: var(--text-dim); }
 }
 .gallery figure:hover img { opacity: 0.88; }
 .gallery img { display: block; width: 100%; height: auto; transition: opacity 0.15s; }
+picture { display: contents; }
 
 /* --- Lightbox --- */
 #lb {
@@ -529,9 +569,73 @@ footer a:hover { color: var(--text-dim); }
   font-size: 0.8rem; color: rgba(255,255,255,0.45); letter-spacing: 0.06em;
   pointer-events: none;
 }
+#lb-page {
+  position: fixed; bottom: 1rem; right: 1.5rem;
+  font-size: 0.8rem; color: rgba(255,255,255,0.6); text-decoration: none;
+  padding: 0.4rem 0.6rem;
+}
+#lb-page:hover { color: #fff; }
 @media (pointer: coarse) {
   .lb-nav { display: none; }
 }
+
+/* --- Photo detail pages --- */
+.photo-detail { display: flex; flex-direction: column; gap: 1.5rem; }
+.photo-frame { background: var(--card-bg); border-radius: 2px; overflow: hidden; }
+.photo-frame img { display: block; width: 100%; height: auto; max-height: 85vh; object-fit: contain; }
+.photo-info { display: flex; flex-direction: column; gap: 0.4rem; }
+.photo-title { font-size: 1.2rem; }
+.photo-date { font-size: 0.85rem; color: var(--text-dim); }
+.photo-caption { font-size: 0.95rem; line-height: 1.5; white-space: pre-line; }
+.photo-exif {
+  display: grid;
+  grid-template-columns: max-content 1fr;
+  gap: 0.2rem 1rem;
+  margin-top: 0.6rem;
+  font-size: 0.8rem;
+}
+.photo-exif dt { color: var(--text-muted); }
+.photo-exif dd { color: var(--text-dim); }
+.photo-nav {
+  display: flex;
+  justify-content: space-between;
+  align-items: center;
+  font-size: 0.85rem;
+}
+.photo-nav a { color: var(--text-dim); text-decoration: none; }
+.photo-nav a:hover { color: var(--accent); }
+.photo-counter { color: var(--text-muted); font-size: 0.78rem; }
+
+/* --- Browse pages (years, tags, map) --- */
+.browse-summary { font-size: 0.85rem; color: var(--text-dim); margin-bottom: 1.5rem; }
+.browse-album { margin-bottom: 2.5rem; }
+.browse-album h2 { font-size: 1rem; font-weight: 500; margin-bottom: 0.75rem; }
+.browse-album h2 a { color: var(--heading); text-decoration: none; }
+.browse-album h2 a:hover { color: var(--accent); }
+.browse-grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(160px, 1fr)); gap: 8px; }
+.browse-grid img {
+  display: block;
+  width: 100%;
+  aspect-ratio: 1;
+  object-fit: cover;
+  border-radius: 2px;
+  background: var(--card-bg);
+  transition: opacity 0.15s;
+}
+.browse-grid a:hover img { opacity: 0.88; }
+.tag-cloud { list-style: none; display: flex; flex-wrap: wrap; gap: 0.6rem 1.4rem; align-items: baseline; line-height: 1.3; }
+.tag-cloud a { color: var(--text); text-decoration: none; }
+.tag-cloud a:hover { color: var(--accent); }
+.tag-cloud span { font-size: 0.72rem; color: var(--text-muted); }
+.tag-w1 { font-size: 0.85rem; }
+.tag-w2 { font-size: 1rem; }
+.tag-w3 { font-size: 1.2rem; }
+.tag-w4 { font-size: 1.45rem; }
+.tag-w5 { font-size: 1.75rem; }
+.site-map { width: 100%; height: 70vh; min-height: 320px; border-radius: 2px; background: var(--card-bg); }
+.map-popup { display: grid; grid-template-columns: repeat(3, 64px); gap: 4px; }
+.map-popup img { display: block; width: 64px; height: 64px; object-fit: cover; border-radius: 2px; }
+.map-popup-title { grid-column: 1 / -1; font: 500 0.8rem Helvetica, Arial, sans-serif; color: #2a2520; margin-bottom: 2px; }
 `
 
 // siteToggleJS is a fully static theme-toggle script.
@@ -573,7 +677,7 @@ type siteAlbumData struct {
 	Loading    string // "eager" for above-the-fold covers, "lazy" for the rest
 }
 
-var siteTmpl = template.Must(template.New("site").Parse(`<!DOCTYPE html>
+const siteIndexSrc = `<!DOCTYPE html>
 <html lang="en" data-default-theme="{{.DefaultTheme}}">
 <head>
 <meta charset="UTF-8">
@@ -587,7 +691,7 @@ var siteTmpl = template.Must(template.New("site").Parse(`<!DOCTYPE html>
 <meta property="og:type" content="website">
 {{- end}}
 <script type="application/ld+json">{{.LDJSON}}</script>
-<link rel="stylesheet" href="assets/style.css">
+<link rel="stylesheet" href="assets/style.css">`+siteFeedLinks+`
 <script src="assets/toggle.js"></script>
 </head>
 <body>
@@ -599,8 +703,11 @@ var siteTmpl = template.Must(template.New("site").Parse(`<!DOCTYPE html>
     <h1>{{.Title}}</h1>
   </div>
   <div class="header-actions">
-    {{- if or .Nav.HasAbout .Nav.HasImprint}}
+    {{- if or .Nav.HasYears .Nav.HasTags .Nav.HasMap .Nav.HasAbout .Nav.HasImprint}}
     <nav class="site-nav">
+      {{- if .Nav.HasYears}}<a href="years/index.html">Years</a>{{end}}
+      {{- if .Nav.HasTags}}<a href="tags/index.html">Tags</a>{{end}}
+      {{- if .Nav.HasMap}}<a href="map/index.html">Map</a>{{end}}
       {{- if .Nav.HasAbout}}<a href="about.html">About</a>{{end}}
       {{- if .Nav.HasImprint}}<a href="legal.html">Legal</a>{{end}}
     </nav>
@@ -629,11 +736,24 @@ var siteTmpl = template.Must(template.New("site").Parse(`<!DOCTYPE html>
 </footer>
 </body>
 </html>
-`))
+`
+
+var siteTmpl = template.Must(template.New("site").Parse(siteIndexSrc))
+
+// siteIndexPageData is the data of the site's index.html.
+type siteIndexPageData struct {
+	Title        string
+	DefaultTheme string
+	Description  string
+	SiteURL      string
+	LDJSON       template.JS
+	Albums       []siteAlbumData
+	Nav          SiteNavContext
+}
 
 // GenerateSiteIndex produces a static root index.html referencing shared assets.
 // Albums are ordered newest first by PublishedAt.
-func GenerateSiteIndex(siteTitle, defaultTheme, siteURL string, albums []SiteAlbum, nav SiteNavContext) []byte {
+func GenerateSiteIndex(siteTitle, defaultTheme, siteURL string, albums []SiteAlbum, nav SiteNavContext) ([]byte, error) {
 	if siteTitle == "" {
 		siteTitle = "Photo Albums"
 	}
@@ -683,16 +803,7 @@ func GenerateSiteIndex(siteTitle, defaultTheme, siteURL string, albums []SiteAlb
 		cleanSiteURL = strings.TrimRight(siteURL, "/")
 	}
 
-	var buf bytes.Buffer
-	siteTmpl.Execute(&buf, struct { //nolint:errcheck
-		Title        string
-		DefaultTheme string
-		Description  string
-		SiteURL      string
-		LDJSON       template.JS
-		Albums       []siteAlbumData
-		Nav          SiteNavContext
-	}{
+	return nav.templates.execute("index.html", siteTmpl, siteIndexPageData{
 		Title:        siteTitle,
 		DefaultTheme: defaultTheme,
 		Description:  description,
@@ -701,12 +812,11 @@ func GenerateSiteIndex(siteTitle, defaultTheme, siteURL string, albums []SiteAlb
 		Albums:       items,
 		Nav:          nav,
 	})
-	return buf.Bytes()
 }
 
 /* --- Site album gallery --- */
 
-var siteGalleryTmpl = template.Must(template.New("sitegallery").Parse(`<!DOCTYPE html>
+const siteAlbumSrc = `<!DOCTYPE html>
 <html lang="en" data-default-theme="{{.DefaultTheme}}">
 <head>
 <meta charset="UTF-8">
@@ -722,7 +832,7 @@ var siteGalleryTmpl = template.Must(template.New("sitegallery").Parse(`<!DOCTYPE
 <meta property="og:type" content="website">
 {{- end}}
 <script type="application/ld+json">{{.LDJSON}}</script>
-<link rel="stylesheet" href="../../assets/style.css">
+<link rel="stylesheet" href="../../assets/style.css">`+siteFeedLinks+`
 <script src="../../assets/toggle.js"></script>
 </head>
 <body>
@@ -749,16 +859,20 @@ var siteGalleryTmpl = template.Must(template.New("sitegallery").Parse(`<!DOCTYPE
 
 <main class="gallery" id="gallery">
 {{range .Figures}}<figure data-index="{{.Index}}">
-  <img src="{{.Thumb}}" loading="{{.Loading}}" alt="{{.Alt}}">
+  <a href="{{.Page}}">
+  {{- if .Sources}}{{$sizes := .Sizes}}<picture>{{range .Sources}}<source type="{{.Type}}" srcset="{{.Srcset}}" sizes="{{$sizes}}">{{end}}{{end -}}
+  <img src="{{.Thumb}}"{{if .Srcset}} srcset="{{.Srcset}}" sizes="{{.Sizes}}"{{end}} loading="{{.Loading}}" alt="{{.Alt}}"{{if .ThumbWidth}} width="{{.ThumbWidth}}" height="{{.ThumbHeight}}"{{end}}>
+  {{- if .Sources}}</picture>{{end}}</a>
 </figure>
 {{end}}</main>
 
 <div id="lb">
   <button id="lb-close" title="Close (Esc)">&times;</button>
   <button class="lb-nav" id="lb-prev" title="Previous (←)"><svg width="28" height="28" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round" aria-hidden="true"><polyline points="15 18 9 12 15 6"/></svg></button>
-  <img id="lb-img" src="" alt="">
+  <picture id="lb-pic"><img id="lb-img" src="" alt=""></picture>
   <button class="lb-nav" id="lb-next" title="Next (→)"><svg width="28" height="28" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round" aria-hidden="true"><polyline points="9 18 15 12 9 6"/></svg></button>
   <div id="lb-counter"></div>
+  <a id="lb-page" href="">Details</a>
 </div>
 
 <footer>
@@ -781,14 +895,35 @@ let cur = 0;
 function open(idx) {
   cur = idx;
   const lb = document.getElementById('lb');
-  document.getElementById('lb-img').src = photos[idx].full;
+  showPhoto(photos[idx]);
+  document.getElementById('lb-page').href = photos[idx].page;
   lb.classList.add('open');
   document.body.style.overflow = 'hidden';
   updateCounter();
 }
 
+// showPhoto points the lightbox image at p, offering its renditions so the
+// browser can pick one sized to the screen.
+function showPhoto(p) {
+  const pic = document.getElementById('lb-pic');
+  const img = document.getElementById('lb-img');
+  pic.querySelectorAll('source').forEach(s => s.remove());
+  (p.sources || []).forEach(src => {
+    const s = document.createElement('source');
+    s.type = src.type;
+    s.srcset = src.srcset;
+    s.sizes = '100vw';
+    pic.insertBefore(s, img);
+  });
+  img.sizes = p.srcset ? '100vw' : '';
+  img.srcset = p.srcset || '';
+  img.src = p.full;
+  img.alt = p.alt || '';
+}
+
 function close() {
   document.getElementById('lb').classList.remove('open');
+  document.getElementById('lb-img').srcset = '';
   document.getElementById('lb-img').src = '';
   document.body.style.overflow = '';
 }
@@ -813,7 +948,10 @@ document.addEventListener('keydown', e => {
 });
 
 document.querySelectorAll('#gallery figure').forEach(fig => {
-  fig.addEventListener('click', () => open(parseInt(fig.dataset.index, 10)));
+  fig.addEventListener('click', e => {
+    e.preventDefault();
+    open(parseInt(fig.dataset.index, 10));
+  });
 });
 
 let swipeStartX = 0, swipeStartY = 0;
@@ -848,35 +986,70 @@ if (menuBtn) {
 </script>
 </body>
 </html>
-`))
+`
+
+var siteGalleryTmpl = template.Must(template.New("sitegallery").Parse(siteAlbumSrc))
 
 type siteGalleryPhoto struct {
-	Full  string `json:"full"`
-	Thumb string `json:"thumb"`
+	Full    string          `json:"full"`
+	Thumb   string          `json:"thumb"`
+	Page    string          `json:"page"`
+	Alt     string          `json:"alt,omitempty"`
+	Srcset  string          `json:"srcset,omitempty"`
+	Sources []pictureSource `json:"sources,omitempty"`
+}
+
+// siteAlbumPageData is the data of an album's index.html.
+type siteAlbumPageData struct {
+	Title        string
+	PageTitle    string
+	DefaultTheme string
+	Description  string
+	PhotosJSON   template.JS
+	LDJSON       template.JS
+	ZipFilename  string
+	Figures      []galleryFigureData
+	SiteURL      string
+	AlbumURL     string
+	CoverURL     string
+	Nav          SiteNavContext
 }
 
 // GenerateSiteGallery produces an album index.html for site mode.
 // It references shared assets via ../../assets/ instead of embedding CSS.
-func GenerateSiteGallery(title, defaultTheme string, items []GalleryItem, opts GalleryOptions) []byte {
+func GenerateSiteGallery(title, defaultTheme string, items []GalleryItem, opts GalleryOptions) ([]byte, error) {
 	if defaultTheme == "" {
 		defaultTheme = "light"
 	}
 	total := len(items)
 	photos := make([]siteGalleryPhoto, 0, total)
 	figures := make([]galleryFigureData, 0, total)
+	filenames := make([]string, total)
+	for i, item := range items {
+		filenames[i] = item.Filename
+	}
+	pages := sitePhotoPageNames(filenames)
 	for i, item := range items {
-		photos = append(photos, siteGalleryPhoto{Full: item.Filename, Thumb: item.ThumbFilename})
+		tw, th := item.thumbDimensions()
+		grid, full := item.responsiveImages(tw)
+		alt := item.alt(title, i, total)
+		photos = append(photos, siteGalleryPhoto{Full: item.Filename, Thumb: item.ThumbFilename, Page: pages[i], Alt: alt, Srcset: full.Srcset, Sources: full.Sources})
 		loading := "lazy"
 		if i < 2 {
 			loading = "eager"
 		}
-		alt := fmt.Sprintf("%s – Photo %d of %d", title, i+1, total)
 		figures = append(figures, galleryFigureData{
-			Index:   i,
-			Thumb:   item.ThumbFilename,
-			Full:    item.Filename,
-			Loading: loading,
-			Alt:     alt,
+			Index:       i,
+			Thumb:       item.ThumbFilename,
+			Full:        item.Filename,
+			Page:        pages[i],
+			Loading:     loading,
+			Alt:         alt,
+			ThumbWidth:  tw,
+			ThumbHeight: th,
+			Srcset:      grid.Srcset,
+			Sizes:       gridSizes,
+			Sources:     grid.Sources,
 		})
 	}
 	photosJSON, _ := json.Marshal(photos)
@@ -917,21 +1090,7 @@ func GenerateSiteGallery(title, defaultTheme string, items []GalleryItem, opts G
 	}
 	ldJSON, _ := json.Marshal(ldMap)
 
-	var buf bytes.Buffer
-	siteGalleryTmpl.Execute(&buf, struct { //nolint:errcheck
-		Title        string
-		PageTitle    string
-		DefaultTheme string
-		Description  string
-		PhotosJSON   template.JS
-		LDJSON       template.JS
-		ZipFilename  string
-		Figures      []galleryFigureData
-		SiteURL      string
-		AlbumURL     string
-		CoverURL     string
-		Nav          SiteNavContext
-	}{
+	return opts.Nav.templates.execute("album.html", siteGalleryTmpl, siteAlbumPageData{
 		Title:        title,
 		PageTitle:    pageTitle,
 		DefaultTheme: defaultTheme,
@@ -945,19 +1104,18 @@ func GenerateSiteGallery(title, defaultTheme string, items []GalleryItem, opts G
 		CoverURL:     coverURL,
 		Nav:          opts.Nav,
 	})
-	return buf.Bytes()
 }
 
 /* --- About page --- */
 
-var siteAboutTmpl = template.Must(template.New("siteabout").Parse(`<!DOCTYPE html>
+const siteAboutSrc = `<!DOCTYPE html>
 <html lang="en" data-default-theme="{{.DefaultTheme}}">
 <head>
 <meta charset="UTF-8">
 <meta name="viewport" content="width=device-width, initial-scale=1.0">
 <title>About | {{.SiteTitle}}</title>
 <meta name="description" content="About {{.SiteTitle}}">
-<link rel="stylesheet" href="assets/style.css">
+<link rel="stylesheet" href="assets/style.css">`+siteFeedLinks+`
 <script src="assets/toggle.js"></script>
 </head>
 <body>
@@ -999,7 +1157,18 @@ var siteAboutTmpl = template.Must(template.New("siteabout").Parse(`<!DOCTYPE htm
 </footer>
 </body>
 </html>
-`))
+`
+
+var siteAboutTmpl = template.Must(template.New("siteabout").Parse(siteAboutSrc))
+
+// siteAboutPageData is the data of about.html.
+type siteAboutPageData struct {
+	SiteTitle    string
+	DefaultTheme string
+	Content      template.HTML
+	AvatarExists bool
+	Nav          SiteNavContext
+}
 
 // generateAboutPage produces about.html at the site root.
 // Does nothing if SiteAbout is empty.
@@ -1011,35 +1180,29 @@ func generateAboutPage(siteDir string, ch *channels.Channel, avatarExists bool,
 	if defaultTheme == "" {
 		defaultTheme = "light"
 	}
-	var buf bytes.Buffer
-	if err := siteAboutTmpl.Execute(&buf, struct {
-		SiteTitle    string
-		DefaultTheme string
-		Content      template.HTML
-		AvatarExists bool
-		Nav          SiteNavContext
-	}{
+	html, err := nav.templates.execute("about.html", siteAboutTmpl, siteAboutPageData{
 		SiteTitle:    ch.SiteTitle,
 		DefaultTheme: defaultTheme,
 		Content:      markdownToHTML(ch.SiteAbout),
 		AvatarExists: avatarExists,
 		Nav:          nav,
-	}); err != nil {
+	})
+	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath.Join(siteDir, "about.html"), buf.Bytes(), 0o644)
+	return os.WriteFile(filepath.Join(siteDir, "about.html"), html, 0o644)
 }
 
 /* --- Imprint / legal page --- */
 
-var siteImprintTmpl = template.Must(template.New("siteimprint").Parse(`<!DOCTYPE html>
+const siteImprintSrc = `<!DOCTYPE html>
 <html lang="en" data-default-theme="{{.DefaultTheme}}">
 <head>
 <meta charset="UTF-8">
 <meta name="viewport" content="width=device-width, initial-scale=1.0">
 <title>Legal | {{.SiteTitle}}</title>
 <meta name="description" content="Legal notice for {{.SiteTitle}}">
-<link rel="stylesheet" href="assets/style.css">
+<link rel="stylesheet" href="assets/style.css">`+siteFeedLinks+`
 <script src="assets/toggle.js"></script>
 </head>
 <body>
@@ -1076,7 +1239,17 @@ var siteImprintTmpl = template.Must(template.New("siteimprint").Parse(`<!DOCTYPE
 </footer>
 </body>
 </html>
-`))
+`
+
+var siteImprintTmpl = template.Must(template.New("siteimprint").Parse(siteImprintSrc))
+
+// siteImprintPageData is the data of legal.html.
+type siteImprintPageData struct {
+	SiteTitle    string
+	DefaultTheme string
+	Content      template.HTML
+	Nav          SiteNavContext
+}
 
 // generateImprintPage produces legal.html at the site root.
 // Does nothing if SiteImprint is empty.
@@ -1088,19 +1261,14 @@ func generateImprintPage(siteDir string, ch *channels.Channel, nav SiteNavContex
 	if defaultTheme == "" {
 		defaultTheme = "light"
 	}
-	var buf bytes.Buffer
-	if err := siteImprintTmpl.Execute(&buf, struct {
-		SiteTitle    string
-		DefaultTheme string
-		Content      template.HTML
-		Nav          SiteNavContext
-	}{
+	html, err := nav.templates.execute("legal.html", siteImprintTmpl, siteImprintPageData{
 		SiteTitle:    ch.SiteTitle,
 		DefaultTheme: defaultTheme,
 		Content:      markdownToHTML(ch.SiteImprint),
 		Nav:          nav,
-	}); err != nil {
+	})
+	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath.Join(siteDir, "legal.html"), buf.Bytes(), 0o644)
+	return os.WriteFile(filepath.Join(siteDir, "legal.html"), html, 0o644)
 }
@@ -0,0 +1,652 @@
+package apilibrary
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"huepattl.de/unterlumen/internal/channels"
+	"huepattl.de/unterlumen/internal/media"
+)
+
+// Browse pages live one folder below the site root, next to albums/:
+// years/index.html and years/2026.html, tags/index.html and
+// tags/harbour.html, map/index.html. All are derived from site.json and
+// regenerated together with the site index.
+const (
+	siteYearsDir = "years"
+	siteTagsDir  = "tags"
+	siteMapDir   = "map"
+)
+
+// SiteLocation is where a published photo was taken, rounded to
+// siteLocationDecimals so the map page never shows an exact position.
+type SiteLocation struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// siteLocationDecimals is the precision of SiteLocation: two decimals are
+// about 1.1 km of latitude.
+const siteLocationDecimals = 2
+
+// siteLocation returns the rounded position of a photo. Photos inside a
+// privacy zone get none, or the zone's fuzzed position when it asks for
+// fuzzing, as in exported GPS metadata.
+func siteLocation(lat, lon *float64, zones []media.PrivacyZone) *SiteLocation {
+	if lat == nil || lon == nil {
+		return nil
+	}
+	la, lo := *lat, *lon
+	if zone := media.MatchPrivacyZone(zones, la, lo); zone != nil {
+		if zone.Action != media.ZoneActionFuzz {
+			return nil
+		}
+		la, lo = media.FuzzLocation(la, lo)
+		if media.MatchPrivacyZone(zones, la, lo) != nil {
+			return nil
+		}
+	}
+	scale := math.Pow(10, siteLocationDecimals)
+	return &SiteLocation{Lat: math.Round(la*scale) / scale, Lon: math.Round(lo*scale) / scale}
+}
+
+// parseKeywords splits a "keywords" meta value ("harbour, boats; night")
+// into trimmed keywords, dropping case-insensitive duplicates.
+func parseKeywords(s string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, k := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		k = strings.TrimSpace(k)
+		if k == "" || seen[strings.ToLower(k)] {
+			continue
+		}
+		seen[strings.ToLower(k)] = true
+		out = append(out, k)
+	}
+	return out
+}
+
+// tagSlug returns the page name of a tag below tags/. Tags without any
+// Latin letters or digits get a name derived from their hash.
+func tagSlug(tag string) string {
+	s := slugify(tag)
+	if s == "album" && !strings.EqualFold(strings.TrimSpace(tag), "album") {
+		h := fnv.New32a()
+		h.Write([]byte(strings.ToLower(tag))) //nolint:errcheck
+		return fmt.Sprintf("tag-%08x", h.Sum32())
+	}
+	if s == "index" {
+		return "index-tag" // tags/index.html is the tag cloud
+	}
+	return s
+}
+
+// sitePhotoYear returns the capture year of sp, or the album's publish year
+// for photos without a capture date.
+func sitePhotoYear(album SiteAlbum, sp SitePhoto) string {
+	if len(sp.TakenAt) >= 4 {
+		if y := sp.TakenAt[:4]; strings.Trim(y, "0123456789") == "" {
+			return y
+		}
+	}
+	return album.PublishedAt.Format("2006")
+}
+
+// siteBrowseEntry is one published photo together with its album.
+type siteBrowseEntry struct {
+	album SiteAlbum
+	photo SitePhoto
+	page  string // photo page relative to the album folder
+}
+
+// siteBrowseEntries lists every photo of albums, newest album first and in
+// album order within each album.
+func siteBrowseEntries(albums []SiteAlbum) []siteBrowseEntry {
+	sorted := make([]SiteAlbum, len(albums))
+	copy(sorted, albums)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].PublishedAt.After(sorted[j].PublishedAt) })
+	var out []siteBrowseEntry
+	for _, a := range sorted {
+		pages := sitePhotoPageNames(sitePhotoFilenames(a.Photos))
+		for i, sp := range a.Photos {
+			out = append(out, siteBrowseEntry{album: a, photo: sp, page: pages[i]})
+		}
+	}
+	return out
+}
+
+// thumb returns the photo's thumbnail as seen from a browse page.
+func (e siteBrowseEntry) thumb() string {
+	name := e.photo.ThumbFilename
+	if name == "" {
+		name = e.photo.Filename
+	}
+	return e.albumPath() + urlPath(name)
+}
+
+// albumPath returns the album folder as seen from a browse page.
+func (e siteBrowseEntry) albumPath() string {
+	return "../albums/" + urlPath(albumFolderName(e.album)) + "/"
+}
+
+func (e siteBrowseEntry) title() string {
+	if e.photo.Title != "" {
+		return e.photo.Title
+	}
+	return e.album.Title
+}
+
+// alt is the photo's alt text, falling back to its title.
+func (e siteBrowseEntry) alt() string {
+	if e.photo.AltText != "" {
+		return e.photo.AltText
+	}
+	return e.title()
+}
+
+/* --- Data --- */
+
+// siteArchivePhoto is one thumbnail on a year or tag page.
+type siteArchivePhoto struct {
+	Thumb string // relative to the page
+	Page  string // photo page, relative to the page
+	Alt   string
+}
+
+// siteArchiveAlbum groups the photos of one album on a year or tag page.
+type siteArchiveAlbum struct {
+	Title  string
+	Link   string // album page, relative to the page
+	Photos []siteArchivePhoto
+}
+
+// siteArchivePageData is the data of years/<year>.html and tags/<tag>.html.
+type siteArchivePageData struct {
+	Kind         string // "year" or "tag"
+	Title        string // the year or the tag
+	PageTitle    string
+	DefaultTheme string
+	Description  string
+	PhotoCount   int
+	Albums       []siteArchiveAlbum
+	Nav          SiteNavContext
+}
+
+// siteYearEntry is one card on years/index.html.
+type siteYearEntry struct {
+	Year       string
+	Href       string
+	Cover      string // thumbnail of the year's first photo
+	PhotoCount int
+}
+
+// siteYearsPageData is the data of years/index.html.
+type siteYearsPageData struct {
+	SiteTitle    string
+	DefaultTheme string
+	Years        []siteYearEntry // newest first
+	Nav          SiteNavContext
+}
+
+// siteTagEntry is one tag of the tag cloud.
+type siteTagEntry struct {
+	Name       string
+	Href       string
+	PhotoCount int
+	Weight     int // 1–5, for the font size
+}
+
+// siteTagsPageData is the data of tags/index.html.
+type siteTagsPageData struct {
+	SiteTitle    string
+	DefaultTheme string
+	Tags         []siteTagEntry // alphabetical
+	Nav          SiteNavContext
+}
+
+// siteMapPhoto is one photo in a map marker's popup.
+type siteMapPhoto struct {
+	Title string `json:"title"`
+	Album string `json:"album"`
+	Thumb string `json:"thumb"`
+	Page  string `json:"page"`
+}
+
+// siteMapPoint is one map marker: all photos at the same rounded location.
+type siteMapPoint struct {
+	Lat    float64        `json:"lat"`
+	Lon    float64        `json:"lon"`
+	Photos []siteMapPhoto `json:"photos"`
+}
+
+// siteMapPageData is the data of map/index.html.
+type siteMapPageData struct {
+	SiteTitle    string
+	DefaultTheme string
+	PointsJSON   template.JS
+	PhotoCount   int
+	Nav          SiteNavContext
+}
+
+// siteArchiveAlbums groups entries by album, keeping their order.
+func siteArchiveAlbums(entries []siteBrowseEntry) []siteArchiveAlbum {
+	var out []siteArchiveAlbum
+	for _, e := range entries {
+		link := e.albumPath() + "index.html"
+		if len(out) == 0 || out[len(out)-1].Link != link {
+			out = append(out, siteArchiveAlbum{Title: e.album.Title, Link: link})
+		}
+		last := &out[len(out)-1]
+		last.Photos = append(last.Photos, siteArchivePhoto{
+			Thumb: e.thumb(),
+			Page:  e.albumPath() + urlPath(e.page),
+			Alt:   e.alt(),
+		})
+	}
+	return out
+}
+
+// siteTagGroup collects the photos of one tag; case variants share a page.
+type siteTagGroup struct {
+	name    string // as first seen
+	slug    string
+	entries []siteBrowseEntry
+}
+
+func groupSiteTags(entries []siteBrowseEntry) []siteTagGroup {
+	bySlug := map[string]*siteTagGroup{}
+	var order []string
+	for _, e := range entries {
+		seen := map[string]bool{}
+		for _, k := range e.photo.Keywords {
+			slug := tagSlug(k)
+			if seen[slug] {
+				continue
+			}
+			seen[slug] = true
+			g := bySlug[slug]
+			if g == nil {
+				g = &siteTagGroup{name: k, slug: slug}
+				bySlug[slug] = g
+				order = append(order, slug)
+			}
+			g.entries = append(g.entries, e)
+		}
+	}
+	out := make([]siteTagGroup, len(order))
+	for i, slug := range order {
+		out[i] = *bySlug[slug]
+	}
+	sort.SliceStable(out, func(i, j int) bool { return strings.ToLower(out[i].name) < strings.ToLower(out[j].name) })
+	return out
+}
+
+// tagWeight maps count onto 1–5 on a logarithmic scale up to max.
+func tagWeight(count, max int) int {
+	if max <= 1 {
+		return 1
+	}
+	return 1 + int(math.Round(4*math.Log(float64(count))/math.Log(float64(max))))
+}
+
+// siteMapPoints groups located entries by their rounded position.
+func siteMapPoints(entries []siteBrowseEntry) []siteMapPoint {
+	byLoc := map[SiteLocation]*siteMapPoint{}
+	var out []*siteMapPoint
+	for _, e := range entries {
+		loc := e.photo.Location
+		if loc == nil {
+			continue
+		}
+		pt := byLoc[*loc]
+		if pt == nil {
+			pt = &siteMapPoint{Lat: loc.Lat, Lon: loc.Lon}
+			byLoc[*loc] = pt
+			out = append(out, pt)
+		}
+		pt.Photos = append(pt.Photos, siteMapPhoto{
+			Title: e.title(),
+			Album: e.album.Title,
+			Thumb: e.thumb(),
+			Page:  e.albumPath() + urlPath(e.page),
+		})
+	}
+	points := make([]siteMapPoint, len(out))
+	for i, pt := range out {
+		points[i] = *pt
+	}
+	return points
+}
+
+/* --- Pages --- */
+
+// writeSiteBrowsePages regenerates the years/, tags/ and map/ folders of
+// siteDir from albums and sets the matching Has* flags on nav, which the
+// site index uses for its links. nav is the root-level context. Folders
+// without content are removed.
+func writeSiteBrowsePages(siteDir string, albums []SiteAlbum, ch *channels.Channel, nav *SiteNavContext) error {
+	defaultTheme := ch.SiteTheme
+	if defaultTheme == "" {
+		defaultTheme = "light"
+	}
+	siteTitle := ch.SiteTitle
+	if siteTitle == "" {
+		siteTitle = "Photo Albums"
+	}
+	entries := siteBrowseEntries(albums)
+
+	var years []string
+	byYear := map[string][]siteBrowseEntry{}
+	for _, e := range entries {
+		y := sitePhotoYear(e.album, e.photo)
+		if byYear[y] == nil {
+			years = append(years, y)
+		}
+		byYear[y] = append(byYear[y], e)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(years)))
+	tags := groupSiteTags(entries)
+	var points []siteMapPoint
+	if ch.SiteMap {
+		points = siteMapPoints(entries)
+	}
+
+	nav.HasYears, nav.HasTags, nav.HasMap = len(years) > 0, len(tags) > 0, len(points) > 0
+	for _, dir := range []string{siteYearsDir, siteTagsDir, siteMapDir} {
+		if err := os.RemoveAll(filepath.Join(siteDir, dir)); err != nil {
+			return err
+		}
+	}
+	pageNav := *nav
+	pageNav.LogoPath = "../" + nav.LogoPath
+
+	write := func(dir, name, tmplName string, builtin *template.Template, data any) error {
+		html, err := nav.templates.execute(tmplName, builtin, data)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Join(siteDir, dir), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(siteDir, dir, name), html, 0o644)
+	}
+	archive := func(kind, title, pageTitle, description string, entries []siteBrowseEntry) siteArchivePageData {
+		return siteArchivePageData{
+			Kind: kind, Title: title, PageTitle: pageTitle + " | " + siteTitle, DefaultTheme: defaultTheme,
+			Description: description, PhotoCount: len(entries), Albums: siteArchiveAlbums(entries), Nav: pageNav,
+		}
+	}
+
+	if len(years) > 0 {
+		index := siteYearsPageData{SiteTitle: siteTitle, DefaultTheme: defaultTheme, Nav: pageNav}
+		for _, y := range years {
+			es := byYear[y]
+			index.Years = append(index.Years, siteYearEntry{Year: y, Href: y + ".html", Cover: es[0].thumb(), PhotoCount: len(es)})
+			data := archive("year", y, y, fmt.Sprintf("%s taken in %s.", photoCountStr(len(es)), y), es)
+			if err := write(siteYearsDir, y+".html", "archive.html", siteArchiveTmpl, data); err != nil {
+				return err
+			}
+		}
+		if err := write(siteYearsDir, "index.html", "years.html", siteYearsTmpl, index); err != nil {
+			return err
+		}
+	}
+
+	if len(tags) > 0 {
+		index := siteTagsPageData{SiteTitle: siteTitle, DefaultTheme: defaultTheme, Nav: pageNav}
+		most := 0
+		for _, g := range tags {
+			most = max(most, len(g.entries))
+		}
+		for _, g := range tags {
+			index.Tags = append(index.Tags, siteTagEntry{Name: g.name, Href: g.slug + ".html", PhotoCount: len(g.entries), Weight: tagWeight(len(g.entries), most)})
+			data := archive("tag", g.name, "Tag: "+g.name, fmt.Sprintf("%s tagged “%s”.", photoCountStr(len(g.entries)), g.name), g.entries)
+			if err := write(siteTagsDir, g.slug+".html", "archive.html", siteArchiveTmpl, data); err != nil {
+				return err
+			}
+		}
+		if err := write(siteTagsDir, "index.html", "tags.html", siteTagsTmpl, index); err != nil {
+			return err
+		}
+	}
+
+	if len(points) > 0 {
+		pointsJSON, _ := json.Marshal(points)
+		located := 0
+		for _, pt := range points {
+			located += len(pt.Photos)
+		}
+		data := siteMapPageData{SiteTitle: siteTitle, DefaultTheme: defaultTheme, PointsJSON: template.JS(pointsJSON), PhotoCount: located, Nav: pageNav}
+		if err := write(siteMapDir, "index.html", "map.html", siteMapTmpl, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func photoCountStr(n int) string {
+	if n == 1 {
+		return "1 photo"
+	}
+	return fmt.Sprintf("%d photos", n)
+}
+
+/* --- Templates --- */
+
+// siteBrowseMasthead is the header shared by the browse pages, which all
+// sit one folder below the site root.
+const siteBrowseMasthead = `<header>
+  <div class="site-masthead">
+    <div class="site-brand">
+      {{- if .Nav.LogoExists}}
+      <img class="site-logo" src="{{.Nav.LogoPath}}" alt="" loading="eager">
+      {{- end}}
+      <a class="site-name" href="../index.html">{{.Nav.SiteName}}</a>
+    </div>
+    <div class="header-actions">
+      <nav class="site-nav">
+        <a href="../index.html">Albums</a>
+        {{- if .Nav.HasYears}}<a href="../years/index.html">Years</a>{{end}}
+        {{- if .Nav.HasTags}}<a href="../tags/index.html">Tags</a>{{end}}
+        {{- if .Nav.HasMap}}<a href="../map/index.html">Map</a>{{end}}
+      </nav>
+      <button id="theme-toggle" class="theme-btn">Dark</button>
+    </div>
+  </div>`
+
+// siteBrowseFooter is the footer shared by the browse pages.
+const siteBrowseFooter = `<footer>
+  <span>Built with <svg width="13" height="13" viewBox="0 0 24 24" fill="var(--accent)" aria-hidden="true" style="vertical-align:-1px"><path d="M12 21.35l-1.45-1.32C5.4 15.36 2 12.28 2 8.5 2 5.42 4.42 3 7.5 3c1.74 0 3.41.81 4.5 2.09C13.09 3.81 14.76 3 16.5 3 19.58 3 22 5.42 22 8.5c0 3.78-3.4 6.86-8.55 11.54L12 21.35z"/></svg> <a href="https://huepattl.de/products/unterlumen.html" target="_blank" rel="noopener">Unterlumen</a></span>
+  {{- if or .Nav.HasAbout .Nav.HasImprint .Nav.ContactEmail .Nav.ContactURL}}
+  <div class="footer-contact">
+    {{- if .Nav.HasAbout}}<a href="../about.html">About</a>{{end}}
+    {{- if .Nav.HasImprint}}<a href="../legal.html">Legal</a>{{end}}
+    {{- if .Nav.ContactEmail}}<a href="mailto:{{.Nav.ContactEmail}}">{{.Nav.ContactEmail}}</a>{{end}}
+    {{- if .Nav.ContactURL}}<a href="{{.Nav.ContactURL}}" target="_blank" rel="noopener">{{.Nav.ContactURL}}</a>{{end}}
+  </div>
+  {{- end}}
+</footer>`
+
+const siteBackIcon = `<svg width="20" height="20" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round" aria-hidden="true" style="vertical-align:-4px"><polyline points="15 18 9 12 15 6"/></svg>`
+
+const siteArchiveSrc = `<!DOCTYPE html>
+<html lang="en" data-default-theme="{{.DefaultTheme}}">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.PageTitle}}</title>
+<meta name="description" content="{{.Description}}">
+<link rel="stylesheet" href="../assets/style.css">` + siteFeedLinks + `
+<script src="../assets/toggle.js"></script>
+</head>
+<body>
+` + siteBrowseMasthead + `
+  <div class="page-title">
+    <a class="site-back" href="index.html" title="{{if eq .Kind "tag"}}All tags{{else}}All years{{end}}">` + siteBackIcon + `</a>{{if eq .Kind "tag"}}Tag: {{end}}{{.Title}}
+  </div>
+</header>
+
+<main class="browse">
+  <p class="browse-summary">{{.Description}}</p>
+{{- range .Albums}}
+  <section class="browse-album">
+    <h2><a href="{{.Link}}">{{.Title}}</a></h2>
+    <div class="browse-grid">
+      {{- range .Photos}}
+      <a href="{{.Page}}"><img src="{{.Thumb}}" alt="{{.Alt}}" loading="lazy"></a>
+      {{- end}}
+    </div>
+  </section>
+{{- end}}
+</main>
+
+` + siteBrowseFooter + `
+</body>
+</html>
+`
+
+var siteArchiveTmpl = template.Must(template.New("sitearchive").Parse(siteArchiveSrc))
+
+const siteYearsSrc = `<!DOCTYPE html>
+<html lang="en" data-default-theme="{{.DefaultTheme}}">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Years | {{.SiteTitle}}</title>
+<meta name="description" content="Photos by year from {{.SiteTitle}}.">
+<link rel="stylesheet" href="../assets/style.css">` + siteFeedLinks + `
+<script src="../assets/toggle.js"></script>
+</head>
+<body>
+` + siteBrowseMasthead + `
+  <div class="page-title">
+    <a class="site-back" href="../index.html" title="Back to albums">` + siteBackIcon + `</a>Years
+  </div>
+</header>
+
+<main class="albums">
+{{range .Years}}  <a class="album-card" href="{{.Href}}">
+    <img class="album-cover" src="{{.Cover}}" alt="{{.Year}}" loading="lazy">
+    <div class="album-title">{{.Year}}</div>
+    <div class="album-meta">{{.PhotoCount}} photo{{if ne .PhotoCount 1}}s{{end}}</div>
+  </a>
+{{end}}</main>
+
+` + siteBrowseFooter + `
+</body>
+</html>
+`
+
+var siteYearsTmpl = template.Must(template.New("siteyears").Parse(siteYearsSrc))
+
+const siteTagsSrc = `<!DOCTYPE html>
+<html lang="en" data-default-theme="{{.DefaultTheme}}">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Tags | {{.SiteTitle}}</title>
+<meta name="description" content="Photos by tag from {{.SiteTitle}}.">
+<link rel="stylesheet" href="../assets/style.css">` + siteFeedLinks + `
+<script src="../assets/toggle.js"></script>
+</head>
+<body>
+` + siteBrowseMasthead + `
+  <div class="page-title">
+    <a class="site-back" href="../index.html" title="Back to albums">` + siteBackIcon + `</a>Tags
+  </div>
+</header>
+
+<main>
+  <ul class="tag-cloud">
+  {{- range .Tags}}
+    <li><a class="tag-w{{.Weight}}" href="{{.Href}}">{{.Name}}</a> <span>{{.PhotoCount}}</span></li>
+  {{- end}}
+  </ul>
+</main>
+
+` + siteBrowseFooter + `
+</body>
+</html>
+`
+
+var siteTagsTmpl = template.Must(template.New("sitetags").Parse(siteTagsSrc))
+
+// siteMapSrc loads MapLibre and the OpenFreeMap style from the same
+// public URLs as the app's location map.
+const siteMapSrc = `<!DOCTYPE html>
+<html lang="en" data-default-theme="{{.DefaultTheme}}">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Map | {{.SiteTitle}}</title>
+<meta name="description" content="Where the photos of {{.SiteTitle}} were taken.">
+<link rel="stylesheet" href="../assets/style.css">` + siteFeedLinks + `
+<link rel="stylesheet" href="https://unpkg.com/maplibre-gl/dist/maplibre-gl.css">
+<script src="../assets/toggle.js"></script>
+<script src="https://unpkg.com/maplibre-gl/dist/maplibre-gl.js"></script>
+</head>
+<body>
+` + siteBrowseMasthead + `
+  <div class="page-title">
+    <a class="site-back" href="../index.html" title="Back to albums">` + siteBackIcon + `</a>Map
+  </div>
+</header>
+
+<main>
+  <p class="browse-summary">{{.PhotoCount}} photo{{if ne .PhotoCount 1}}s{{end}}; locations are approximate.</p>
+  <div id="site-map" class="site-map"></div>
+</main>
+
+` + siteBrowseFooter + `
+
+<script>
+(function () {
+  const points = {{.PointsJSON}};
+  if (typeof maplibregl === 'undefined') return;
+  const map = new maplibregl.Map({
+    container: 'site-map',
+    style: 'https://tiles.openfreemap.org/styles/liberty',
+    center: [0, 20],
+    zoom: 1,
+  });
+  map.addControl(new maplibregl.NavigationControl({ showCompass: false }));
+  const bounds = new maplibregl.LngLatBounds();
+  points.forEach(pt => {
+    const box = document.createElement('div');
+    box.className = 'map-popup';
+    const albums = [...new Set(pt.photos.map(p => p.album))];
+    const heading = document.createElement('div');
+    heading.className = 'map-popup-title';
+    heading.textContent = albums.join(', ');
+    box.appendChild(heading);
+    pt.photos.slice(0, 9).forEach(p => {
+      const a = document.createElement('a');
+      a.href = p.page;
+      const img = document.createElement('img');
+      img.src = p.thumb;
+      img.alt = p.title;
+      img.loading = 'lazy';
+      a.appendChild(img);
+      box.appendChild(a);
+    });
+    new maplibregl.Marker({ color: '#d35400' })
+      .setLngLat([pt.lon, pt.lat])
+      .setPopup(new maplibregl.Popup({ offset: 24 }).setDOMContent(box))
+      .addTo(map);
+    bounds.extend([pt.lon, pt.lat]);
+  });
+  if (points.length) map.fitBounds(bounds, { padding: 60, maxZoom: 12, duration: 0 });
+})();
+</script>
+</body>
+</html>
+`
+
+var siteMapTmpl = template.Must(template.New("sitemap").Parse(siteMapSrc))
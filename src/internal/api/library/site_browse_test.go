@@ -0,0 +1,129 @@
+package apilibrary
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"huepattl.de/unterlumen/internal/channels"
+	"huepattl.de/unterlumen/internal/media"
+)
+
+func TestSiteLocation(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	if loc := siteLocation(f(53.54812), f(9.98694), nil); loc == nil || *loc != (SiteLocation{53.55, 9.99}) {
+		t.Errorf("rounded = %+v", loc)
+	}
+	if loc := siteLocation(nil, f(9.9), nil); loc != nil {
+		t.Errorf("no GPS = %+v", loc)
+	}
+	home := media.PrivacyZone{Name: "Home", Latitude: 53.548, Longitude: 9.987, RadiusM: 200}
+	if loc := siteLocation(f(53.5481), f(9.9869), []media.PrivacyZone{home}); loc != nil {
+		t.Errorf("inside strip zone = %+v", loc)
+	}
+	home.Action = media.ZoneActionFuzz
+	loc := siteLocation(f(53.5481), f(9.9869), []media.PrivacyZone{home})
+	fLat, fLon := media.FuzzLocation(53.5481, 9.9869)
+	if loc == nil || loc.Lat != float64(int(fLat*100+0.5))/100 || loc.Lon != float64(int(fLon*100+0.5))/100 {
+		t.Errorf("inside fuzz zone = %+v, want near %v,%v", loc, fLat, fLon)
+	}
+}
+
+func TestParseKeywords(t *testing.T) {
+	got := parseKeywords(" harbour, Boats;boats ,, night ")
+	if strings.Join(got, "|") != "harbour|Boats|night" {
+		t.Errorf("keywords = %q", got)
+	}
+	for tag, want := range map[string]string{"Hamburg Hafen": "hamburg-hafen", "index": "index-tag", "album": "album"} {
+		if got := tagSlug(tag); got != want {
+			t.Errorf("tagSlug(%q) = %q, want %q", tag, got, want)
+		}
+	}
+	if a, b := tagSlug("東京"), tagSlug("大阪"); !strings.HasPrefix(a, "tag-") || a == b {
+		t.Errorf("non-Latin slugs = %q, %q", a, b)
+	}
+}
+
+func testBrowseAlbums() []SiteAlbum {
+	return []SiteAlbum{
+		{PostID: "p1", Slug: "summer", Title: "Summer", PublishedAt: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+			Photos: []SitePhoto{
+				{Filename: "one.jpg", ThumbFilename: "thumbs/one.jpg", Title: "Harbour", TakenAt: "2025-06-30T18:02:11",
+					Keywords: []string{"Harbour", "boats"}, Location: &SiteLocation{53.55, 9.99}},
+				{Filename: "two.jpg", ThumbFilename: "thumbs/two.jpg", Keywords: []string{"harbour"}, Location: &SiteLocation{53.55, 9.99}},
+			}},
+		{PostID: "p2", Slug: "winter", Title: "Winter", PublishedAt: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+			Photos: []SitePhoto{{Filename: "snow.jpg", ThumbFilename: "thumbs/snow.jpg", TakenAt: "2023-12-24T10:00:00"}}},
+	}
+}
+
+func TestWriteSiteBrowsePages(t *testing.T) {
+	siteDir := t.TempDir()
+	stale := filepath.Join(siteDir, siteYearsDir, "1999.html")
+	os.MkdirAll(filepath.Dir(stale), 0o755)
+	os.WriteFile(stale, []byte("old"), 0o644)
+
+	ch := &channels.Channel{SiteExport: true, SiteTitle: "My Photos", SiteMap: true}
+	nav := SiteNavContext{LogoPath: "assets/logo.jpg"}
+	if err := writeSiteBrowsePages(siteDir, testBrowseAlbums(), ch, &nav); err != nil {
+		t.Fatal(err)
+	}
+	if !nav.HasYears || !nav.HasTags || !nav.HasMap {
+		t.Errorf("nav flags = %+v", nav)
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(siteDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("missing %s", name)
+		}
+		return string(data)
+	}
+	for name, wants := range map[string][]string{
+		"years/index.html": {`href="2026.html"`, `href="2025.html"`, `href="2023.html"`, `src="../albums/summer/thumbs/one.jpg"`, "<title>Years | My Photos</title>"},
+		"years/2025.html": {"1 photo taken in 2025.", `<a href="../albums/summer/index.html">Summer</a>`,
+			`<a href="../albums/summer/photos/one.html"><img src="../albums/summer/thumbs/one.jpg" alt="Harbour"`},
+		"years/2023.html":   {`<a href="../albums/winter/photos/snow.html">`},
+		"years/2026.html":   {"1 photo taken in 2026.", `alt="Summer"`},
+		"tags/index.html":   {`<a class="tag-w5" href="harbour.html">Harbour</a> <span>2</span>`, `<a class="tag-w1" href="boats.html">boats</a>`},
+		"tags/harbour.html": {"<title>Tag: Harbour | My Photos</title>", `href="../map/index.html">Map</a>`},
+		"map/index.html":    {`"lat":53.55,"lon":9.99`, `"page":"../albums/summer/photos/two.html"`, "maplibre-gl.js"},
+	} {
+		html := read(name)
+		for _, want := range wants {
+			if !strings.Contains(html, want) {
+				t.Errorf("%s missing %s", name, want)
+			}
+		}
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("stale year page kept")
+	}
+
+	// Without the map option and keywords, map/ and tags/ disappear.
+	ch.SiteMap = false
+	albums := testBrowseAlbums()[1:]
+	nav = SiteNavContext{}
+	if err := writeSiteBrowsePages(siteDir, albums, ch, &nav); err != nil {
+		t.Fatal(err)
+	}
+	if !nav.HasYears || nav.HasTags || nav.HasMap {
+		t.Errorf("nav flags = %+v", nav)
+	}
+	for _, dir := range []string{siteTagsDir, siteMapDir} {
+		if _, err := os.Stat(filepath.Join(siteDir, dir)); !os.IsNotExist(err) {
+			t.Errorf("%s/ kept", dir)
+		}
+	}
+}
+
+func TestSiteIndexLinksBrowsePages(t *testing.T) {
+	html := mustHTML(GenerateSiteIndex("My Photos", "", "", testBrowseAlbums(), SiteNavContext{HasYears: true, HasMap: true}))
+	if !strings.Contains(html, `<a href="years/index.html">Years</a>`) || !strings.Contains(html, `<a href="map/index.html">Map</a>`) {
+		t.Errorf("index does not link browse pages:\n%s", html)
+	}
+	if strings.Contains(html, "tags/index.html") {
+		t.Error("index links tags without tag pages")
+	}
+}
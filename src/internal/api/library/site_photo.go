@@ -0,0 +1,404 @@
+package apilibrary
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"huepattl.de/unterlumen/internal/channels"
+	lib "huepattl.de/unterlumen/internal/library"
+	"huepattl.de/unterlumen/internal/media"
+)
+
+// photoPagesDir is the folder below an album that holds the per-photo pages.
+const photoPagesDir = "photos"
+
+// SitePhotoExif is the camera and exposure summary shown on photo pages,
+// formatted for display when the photo is published.
+type SitePhotoExif struct {
+	Camera         string `json:"camera,omitempty"`   // e.g. "FUJIFILM X-T5"
+	Lens           string `json:"lens,omitempty"`     // e.g. "XF23mmF1.4 R LM WR"
+	Aperture       string `json:"aperture,omitempty"` // e.g. "f/2.8"
+	Shutter        string `json:"shutter,omitempty"`  // e.g. "1/500 s"
+	ISO            string `json:"iso,omitempty"`      // e.g. "ISO 200"
+	FilmSimulation string `json:"filmSimulation,omitempty"`
+}
+
+func (e *SitePhotoExif) empty() bool {
+	return e == nil || *e == SitePhotoExif{}
+}
+
+// sitePhotoExif extracts the photo-page summary from indexed EXIF fields.
+func sitePhotoExif(tags map[string]string) *SitePhotoExif {
+	clean := func(k string) string { return strings.TrimSpace(strings.Trim(tags[k], `"`)) }
+	e := &SitePhotoExif{Lens: clean("LensModel"), FilmSimulation: clean("FilmSimulation")}
+	maker, model := clean("Make"), clean("Model")
+	if maker != "" && !strings.HasPrefix(strings.ToLower(model), strings.ToLower(maker)) {
+		e.Camera = strings.TrimSpace(maker + " " + model)
+	} else {
+		e.Camera = model
+	}
+	e.Aperture = media.FormatAperture(tags["FNumber"])
+	e.Shutter = media.FormatShutter(tags["ExposureTime"])
+	e.ISO = media.FormatISO(tags["ISOSpeedRatings"])
+	if e.empty() {
+		return nil
+	}
+	return e
+}
+
+// setSitePhotoDetails copies title, capture date, EXIF summary, keywords
+// and rounded location of a library photo into sp. It reports whether
+// anything changed.
+func setSitePhotoDetails(sp *SitePhoto, p *lib.Photo, zones []media.PrivacyZone) bool {
+	title := p.Meta["title"]
+	caption, alt := p.Meta["description"], p.Meta["alt"]
+	takenAt := strings.Trim(p.Exif["DateTaken"], `"`)
+	exif := sitePhotoExif(p.Exif)
+	keywords := parseKeywords(p.Meta["keywords"])
+	loc := siteLocation(p.Latitude, p.Longitude, zones)
+	changed := title != sp.Title || takenAt != sp.TakenAt ||
+		caption != sp.Caption || alt != sp.AltText ||
+		(exif == nil) != (sp.Exif == nil) || (exif != nil && *exif != *sp.Exif) ||
+		strings.Join(keywords, "\x00") != strings.Join(sp.Keywords, "\x00") ||
+		(loc == nil) != (sp.Location == nil) || (loc != nil && *loc != *sp.Location)
+	sp.Title, sp.TakenAt, sp.Exif = title, takenAt, exif
+	sp.Caption, sp.AltText = caption, alt
+	sp.Keywords, sp.Location = keywords, loc
+	return changed
+}
+
+// fillSitePhotoDetails sets the details of freshly published photos from the
+// library they were published from.
+func fillSitePhotoDetails(store *lib.Store, zones []media.PrivacyZone, photos []SitePhoto) {
+	for i := range photos {
+		if photos[i].PhotoID == "" {
+			continue
+		}
+		if p, err := store.GetPhoto(photos[i].PhotoID); err == nil && p != nil {
+			setSitePhotoDetails(&photos[i], p, zones)
+		}
+	}
+}
+
+// refreshSitePhotoDetails updates the details of every photo in albums from
+// whichever library holds it, so a rebuild picks up edited titles. Each
+// library store is opened once. It reports whether anything changed.
+func refreshSitePhotoDetails(mgr *lib.Manager, albums []SiteAlbum) bool {
+	if mgr == nil {
+		return false
+	}
+	libs, err := mgr.ListLibraries()
+	if err != nil {
+		return false
+	}
+	zones := mgr.PrivacyZones()
+	var stores []*lib.Store
+	for _, l := range libs {
+		if store, err := mgr.OpenStore(l.ID); err == nil {
+			stores = append(stores, store)
+			defer store.Close()
+		}
+	}
+	changed := false
+	for i := range albums {
+		for j := range albums[i].Photos {
+			sp := &albums[i].Photos[j]
+			if sp.PhotoID == "" {
+				continue
+			}
+			for _, store := range stores {
+				if p, err := store.GetPhoto(sp.PhotoID); err == nil && p != nil {
+					if setSitePhotoDetails(sp, p, zones) {
+						changed = true
+					}
+					break
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// sitePhotoPageNames returns the page path of each photo relative to the
+// album folder, e.g. "photos/DSCF1234.html". Names derive from the exported
+// filename so album pages and photo pages agree without shared state.
+func sitePhotoPageNames(filenames []string) []string {
+	pages := make([]string, len(filenames))
+	used := make(map[string]bool, len(filenames))
+	for i, f := range filenames {
+		stem := strings.TrimSuffix(f, path.Ext(f))
+		stem = strings.NewReplacer("/", "-", "\\", "-").Replace(stem)
+		if stem == "" {
+			stem = "photo"
+		}
+		name := stem
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", stem, n)
+		}
+		used[name] = true
+		pages[i] = photoPagesDir + "/" + name + ".html"
+	}
+	return pages
+}
+
+func sitePhotoFilenames(photos []SitePhoto) []string {
+	names := make([]string, len(photos))
+	for i, p := range photos {
+		names[i] = p.Filename
+	}
+	return names
+}
+
+// urlPath escapes each segment of a slash-separated relative path.
+func urlPath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, s := range parts {
+		parts[i] = url.PathEscape(s)
+	}
+	return strings.Join(parts, "/")
+}
+
+// formatTakenAt turns an EXIF capture date ("2026-07-01T09:15:00…") into a
+// display date and an ISO date for <time datetime>.
+func formatTakenAt(s string) (display, iso string) {
+	if len(s) < 10 {
+		return "", ""
+	}
+	t, err := time.Parse("2006-01-02", s[:10])
+	if err != nil {
+		return "", ""
+	}
+	return t.Format("2 January 2006"), s[:10]
+}
+
+type sitePhotoPageData struct {
+	Title        string
+	PageTitle    string
+	AlbumTitle   string
+	DefaultTheme string
+	Description  string
+	Full         string // relative to the page, e.g. "../DSCF1234.jpg"
+	Image        responsiveImage
+	Sizes        string
+	Width        int // 0 = omit width/height attrs
+	Height       int
+	Alt          string
+	Caption      string
+	DateStr      string
+	DateISO      string
+	Exif         *SitePhotoExif
+	Index, Total int
+	Prev, Next   string // sibling page names; "" at either end
+	SiteURL      string
+	PageURL      string
+	ImageURL     string
+	Nav          SiteNavContext
+}
+
+const sitePhotoSrc = `<!DOCTYPE html>
+<html lang="en" data-default-theme="{{.DefaultTheme}}">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0, viewport-fit=cover">
+<title>{{.PageTitle}}</title>
+<meta name="description" content="{{.Description}}">
+{{- if .SiteURL}}
+<link rel="canonical" href="{{.PageURL}}">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:image" content="{{.ImageURL}}">
+<meta property="og:image:alt" content="{{.Alt}}">
+<meta property="og:url" content="{{.PageURL}}">
+<meta property="og:type" content="article">
+<meta name="twitter:card" content="summary_large_image">
+{{- end}}
+{{- if .Prev}}
+<link rel="prev" href="{{.Prev}}">{{end}}
+{{- if .Next}}
+<link rel="next" href="{{.Next}}">{{end}}
+<link rel="stylesheet" href="../../../assets/style.css">` + siteFeedLinks + `
+<script src="../../../assets/toggle.js"></script>
+</head>
+<body>
+<header>
+  <div class="site-masthead">
+    <div class="site-brand">
+      {{- if .Nav.LogoExists}}
+      <img class="site-logo" src="{{.Nav.LogoPath}}" alt="" loading="eager">
+      {{- end}}
+      <a class="site-name" href="../../../index.html">{{.Nav.SiteName}}</a>
+    </div>
+    <div class="header-actions">
+      <button id="theme-toggle" class="theme-btn">Dark</button>
+    </div>
+  </div>
+  <div class="page-title">
+    <a class="site-back" href="../index.html" title="Back to {{.AlbumTitle}}"><svg width="20" height="20" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2" stroke-linecap="round" stroke-linejoin="round" aria-hidden="true" style="vertical-align:-4px"><polyline points="15 18 9 12 15 6"/></svg></a>{{.AlbumTitle}}
+  </div>
+</header>
+
+<main class="photo-detail">
+  <figure class="photo-frame"><a href="{{.Full}}">
+    {{- if .Image.Sources}}<picture>{{range .Image.Sources}}<source type="{{.Type}}" srcset="{{.Srcset}}" sizes="{{$.Sizes}}">{{end}}{{end -}}
+    <img src="{{.Full}}"{{if .Image.Srcset}} srcset="{{.Image.Srcset}}" sizes="{{.Sizes}}"{{end}} alt="{{.Alt}}"{{if .Width}} width="{{.Width}}" height="{{.Height}}"{{end}}>
+    {{- if .Image.Sources}}</picture>{{end}}</a></figure>
+  <div class="photo-info">
+    <h1 class="photo-title">{{.Title}}</h1>
+    {{- if .Caption}}
+    <p class="photo-caption">{{.Caption}}</p>
+    {{- end}}
+    {{- if .DateStr}}
+    <time class="photo-date" datetime="{{.DateISO}}">{{.DateStr}}</time>
+    {{- end}}
+    {{- with .Exif}}
+    <dl class="photo-exif">
+      {{- if .Camera}}<dt>Camera</dt><dd>{{.Camera}}</dd>{{end}}
+      {{- if .Lens}}<dt>Lens</dt><dd>{{.Lens}}</dd>{{end}}
+      {{- if .Aperture}}<dt>Aperture</dt><dd>{{.Aperture}}</dd>{{end}}
+      {{- if .Shutter}}<dt>Shutter</dt><dd>{{.Shutter}}</dd>{{end}}
+      {{- if .ISO}}<dt>ISO</dt><dd>{{.ISO}}</dd>{{end}}
+      {{- if .FilmSimulation}}<dt>Film simulation</dt><dd>{{.FilmSimulation}}</dd>{{end}}
+    </dl>
+    {{- end}}
+  </div>
+  <nav class="photo-nav">
+    {{if .Prev}}<a href="{{.Prev}}" id="photo-prev">&larr; Previous</a>{{else}}<span></span>{{end}}
+    <span class="photo-counter">{{.Index}} / {{.Total}}</span>
+    {{if .Next}}<a href="{{.Next}}" id="photo-next">Next &rarr;</a>{{else}}<span></span>{{end}}
+  </nav>
+</main>
+
+<footer>
+  <span>Built with <svg width="13" height="13" viewBox="0 0 24 24" fill="var(--accent)" aria-hidden="true" style="vertical-align:-1px"><path d="M12 21.35l-1.45-1.32C5.4 15.36 2 12.28 2 8.5 2 5.42 4.42 3 7.5 3c1.74 0 3.41.81 4.5 2.09C13.09 3.81 14.76 3 16.5 3 19.58 3 22 5.42 22 8.5c0 3.78-3.4 6.86-8.55 11.54L12 21.35z"/></svg> <a href="https://huepattl.de/products/unterlumen.html" target="_blank" rel="noopener">Unterlumen</a></span>
+  {{- if or .Nav.HasAbout .Nav.HasImprint .Nav.ContactEmail .Nav.ContactURL}}
+  <div class="footer-contact">
+    {{- if .Nav.HasAbout}}<a href="../../../about.html">About</a>{{end}}
+    {{- if .Nav.HasImprint}}<a href="../../../legal.html">Legal</a>{{end}}
+    {{- if .Nav.ContactEmail}}<a href="mailto:{{.Nav.ContactEmail}}">{{.Nav.ContactEmail}}</a>{{end}}
+    {{- if .Nav.ContactURL}}<a href="{{.Nav.ContactURL}}" target="_blank" rel="noopener">{{.Nav.ContactURL}}</a>{{end}}
+  </div>
+  {{- end}}
+</footer>
+
+<script>
+document.addEventListener('keydown', e => {
+  if (e.altKey || e.ctrlKey || e.metaKey) return;
+  const link = e.key === 'ArrowLeft' ? document.getElementById('photo-prev')
+             : e.key === 'ArrowRight' ? document.getElementById('photo-next')
+             : e.key === 'Escape' ? { href: '../index.html' } : null;
+  if (link) { e.preventDefault(); location.href = link.href; }
+});
+</script>
+</body>
+</html>
+`
+
+var sitePhotoTmpl = template.Must(template.New("sitephoto").Parse(sitePhotoSrc))
+
+// GenerateSitePhotoPage produces one photo's detail page. index is 0-based;
+// pages are the album's page names from sitePhotoPageNames.
+func GenerateSitePhotoPage(album SiteAlbum, index int, pages []string, showExif bool, defaultTheme, siteTitle, siteURL string, nav SiteNavContext) ([]byte, error) {
+	if defaultTheme == "" {
+		defaultTheme = "light"
+	}
+	sp := album.Photos[index]
+	total := len(album.Photos)
+	title := sp.Title
+	if title == "" {
+		title = fmt.Sprintf("%s – Photo %d of %d", album.Title, index+1, total)
+	}
+	pageTitle := title
+	if sp.Title != "" {
+		pageTitle += " | " + album.Title
+	}
+	if siteTitle != "" {
+		pageTitle += " | " + siteTitle
+	}
+	dateStr, dateISO := formatTakenAt(sp.TakenAt)
+	description := fmt.Sprintf("Photo %d of %d from %s", index+1, total, album.Title)
+	if dateStr != "" {
+		description += ", taken " + dateStr
+	}
+	description += "."
+	if sp.Caption != "" {
+		description = sp.Caption
+	}
+	alt := title
+	if sp.AltText != "" {
+		alt = sp.AltText
+	}
+
+	data := sitePhotoPageData{
+		Title:        title,
+		PageTitle:    pageTitle,
+		AlbumTitle:   album.Title,
+		DefaultTheme: defaultTheme,
+		Description:  description,
+		Full:         "../" + sp.Filename,
+		Image:        buildResponsiveImage(sp.Renditions, "../", fullCandidate(sp.Filename, sp.Width)),
+		Sizes:        photoSizes,
+		Width:        sp.Width,
+		Height:       sp.Height,
+		Alt:          alt,
+		Caption:      sp.Caption,
+		DateStr:      dateStr,
+		DateISO:      dateISO,
+		Index:        index + 1,
+		Total:        total,
+		Nav:          nav,
+	}
+	if showExif && !sp.Exif.empty() {
+		data.Exif = sp.Exif
+	}
+	if index > 0 {
+		data.Prev = path.Base(pages[index-1])
+	}
+	if index < total-1 {
+		data.Next = path.Base(pages[index+1])
+	}
+	if siteURL != "" {
+		albumURL := strings.TrimRight(siteURL, "/") + "/albums/" + url.PathEscape(albumFolderName(album)) + "/"
+		data.SiteURL = siteURL
+		data.PageURL = albumURL + urlPath(pages[index])
+		data.ImageURL = albumURL + urlPath(sp.Filename)
+	}
+	return nav.templates.execute("photo.html", sitePhotoTmpl, data)
+}
+
+// writeSitePhotoPages writes a detail page for every photo of album into
+// albumDir/photos/ and removes pages of photos no longer in the album.
+// albumNav is the navigation context for album pages; asset paths are
+// adjusted for the extra folder level.
+func writeSitePhotoPages(albumDir string, album SiteAlbum, ch *channels.Channel, albumNav SiteNavContext) error {
+	pagesDir := filepath.Join(albumDir, photoPagesDir)
+	if err := os.MkdirAll(pagesDir, 0o755); err != nil {
+		return err
+	}
+	nav := albumNav
+	nav.LogoPath = "../" + albumNav.LogoPath
+	pages := sitePhotoPageNames(sitePhotoFilenames(album.Photos))
+	keep := make(map[string]bool, len(pages))
+	for i, page := range pages {
+		html, err := GenerateSitePhotoPage(album, i, pages, ch.SitePhotoExif, ch.SiteTheme, ch.SiteTitle, ch.SiteURL, nav)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(albumDir, filepath.FromSlash(page)), html, 0o644); err != nil {
+			return err
+		}
+		keep[path.Base(page)] = true
+	}
+	entries, _ := os.ReadDir(pagesDir)
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".html") && !keep[e.Name()] {
+			os.Remove(filepath.Join(pagesDir, e.Name())) //nolint:errcheck
+		}
+	}
+	return nil
+}
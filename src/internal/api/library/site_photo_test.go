@@ -0,0 +1,139 @@
+package apilibrary
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"huepattl.de/unterlumen/internal/channels"
+)
+
+func TestSitePhotoExif(t *testing.T) {
+	e := sitePhotoExif(map[string]string{
+		"Make": `"FUJIFILM"`, "Model": `"X-T5"`, "LensModel": `"XF23mmF1.4 R LM WR"`,
+		"FNumber": "28/10", "ExposureTime": "1/500", "ISOSpeedRatings": "200", "FilmSimulation": `"Classic Chrome"`,
+	})
+	want := SitePhotoExif{Camera: "FUJIFILM X-T5", Lens: "XF23mmF1.4 R LM WR", Aperture: "f/2.8", Shutter: "1/500 s", ISO: "ISO 200", FilmSimulation: "Classic Chrome"}
+	if e == nil || *e != want {
+		t.Errorf("exif = %+v, want %+v", e, want)
+	}
+	if e := sitePhotoExif(map[string]string{"Make": "Canon", "Model": "Canon EOS R5"}); e == nil || e.Camera != "Canon EOS R5" {
+		t.Errorf("camera = %+v", e)
+	}
+	if e := sitePhotoExif(map[string]string{}); e != nil {
+		t.Errorf("empty tags = %+v", e)
+	}
+}
+
+func TestSitePhotoPageNames(t *testing.T) {
+	got := sitePhotoPageNames([]string{"a.jpg", "2026/b.jpg", "a.png"})
+	want := []string{"photos/a.html", "photos/2026-b.html", "photos/a-2.html"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+func testSiteAlbum() SiteAlbum {
+	return SiteAlbum{
+		PostID: "p1", Slug: "summer", Title: "Summer", PublishedAt: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		Photos: []SitePhoto{
+			{Filename: "one.jpg", Title: "Harbour", TakenAt: "2026-06-30T18:02:11",
+				Exif: &SitePhotoExif{Camera: "FUJIFILM X-T5", FilmSimulation: "Classic Chrome"}},
+			{Filename: "two by two.jpg", Caption: "Boats at dusk.", AltText: "Two sailing boats moored at a pier"},
+			{Filename: "three.jpg"},
+		},
+	}
+}
+
+func TestGenerateSitePhotoPage(t *testing.T) {
+	album := testSiteAlbum()
+	pages := sitePhotoPageNames(sitePhotoFilenames(album.Photos))
+
+	first := mustHTML(GenerateSitePhotoPage(album, 0, pages, true, "", "My Photos", "https://example.com", SiteNavContext{}))
+	for _, want := range []string{
+		"<title>Harbour | Summer | My Photos</title>",
+		`<img src="../one.jpg" alt="Harbour">`,
+		`<time class="photo-date" datetime="2026-06-30">30 June 2026</time>`,
+		"<dd>Classic Chrome</dd>",
+		`<link rel="canonical" href="https://example.com/albums/summer/photos/one.html">`,
+		`<meta property="og:image" content="https://example.com/albums/summer/one.jpg">`,
+		`href="two%20by%20two.html" id="photo-next"`,
+	} {
+		if !strings.Contains(first, want) {
+			t.Errorf("first page missing %s", want)
+		}
+	}
+	if strings.Contains(first, `id="photo-prev"`) {
+		t.Error("first page links a previous photo")
+	}
+
+	hidden := mustHTML(GenerateSitePhotoPage(album, 0, pages, false, "", "", "", SiteNavContext{}))
+	if strings.Contains(hidden, "photo-exif") || strings.Contains(hidden, "og:image") {
+		t.Error("EXIF or OG tags shown although disabled")
+	}
+
+	second := mustHTML(GenerateSitePhotoPage(album, 1, pages, true, "", "", "", SiteNavContext{}))
+	for _, want := range []string{
+		`alt="Two sailing boats moored at a pier"`,
+		`<p class="photo-caption">Boats at dusk.</p>`,
+		`<meta name="description" content="Boats at dusk.">`,
+	} {
+		if !strings.Contains(second, want) {
+			t.Errorf("second page missing %s", want)
+		}
+	}
+	if strings.Contains(first, "photo-caption") {
+		t.Error("caption shown for a photo without one")
+	}
+
+	last := mustHTML(GenerateSitePhotoPage(album, 2, pages, true, "", "", "", SiteNavContext{}))
+	for _, want := range []string{"<h1 class=\"photo-title\">Summer – Photo 3 of 3</h1>", `id="photo-prev"`, "3 / 3"} {
+		if !strings.Contains(last, want) {
+			t.Errorf("last page missing %s", want)
+		}
+	}
+}
+
+func TestWriteSitePhotoPagesRemovesStale(t *testing.T) {
+	albumDir := t.TempDir()
+	stale := filepath.Join(albumDir, "photos", "gone.html")
+	os.MkdirAll(filepath.Dir(stale), 0o755)
+	os.WriteFile(stale, []byte("old"), 0o644)
+
+	if err := writeSitePhotoPages(albumDir, testSiteAlbum(), &channels.Channel{SiteExport: true}, SiteNavContext{LogoPath: "../../assets/logo.jpg"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"one.html", "two by two.html", "three.html"} {
+		if _, err := os.Stat(filepath.Join(albumDir, "photos", name)); err != nil {
+			t.Errorf("missing page: %v", err)
+		}
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("stale page kept")
+	}
+}
+
+func TestGenerateSitemapListsPhotoPages(t *testing.T) {
+	siteDir := t.TempDir()
+	if err := generateSitemap(siteDir, []SiteAlbum{testSiteAlbum()}, "https://example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(siteDir, "sitemap.xml"))
+	for _, want := range []string{
+		"<url><loc>https://example.com/albums/summer/</loc></url>",
+		"<loc>https://example.com/albums/summer/photos/two%20by%20two.html</loc><image:image><image:loc>https://example.com/albums/summer/two%20by%20two.jpg</image:loc>",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("sitemap missing %s\n%s", want, data)
+		}
+	}
+}
+
+func TestSiteGalleryLinksPhotoPages(t *testing.T) {
+	html := mustHTML(GenerateSiteGallery("Summer", "", []GalleryItem{{Filename: "one.jpg", ThumbFilename: "thumbs/one.jpg"}}, GalleryOptions{}))
+	if !strings.Contains(html, `<a href="photos/one.html"><img src="thumbs/one.jpg"`) || !strings.Contains(html, `"page":"photos/one.html"`) {
+		t.Errorf("album page does not link photo pages:\n%s", html)
+	}
+}
@@ -0,0 +1,316 @@
+package apilibrary
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"huepattl.de/unterlumen/internal/channels"
+)
+
+// siteTemplateFile is one page template a channel's template dir can override.
+type siteTemplateFile struct {
+	Name   string     // file name in the template dir
+	Src    string     // built-in source, written by DumpSiteTemplates
+	sample func() any // representative data, used to validate overrides
+}
+
+// siteTemplateFiles lists the overridable templates. The data each receives
+// is documented in doc/site-templates.md.
+var siteTemplateFiles = []siteTemplateFile{
+	{"index.html", siteIndexSrc, func() any { return sampleSiteIndexPage() }},
+	{"album.html", siteAlbumSrc, func() any { return sampleSiteAlbumPage() }},
+	{"photo.html", sitePhotoSrc, func() any { return sampleSitePhotoPage() }},
+	{"about.html", siteAboutSrc, func() any { return sampleSiteAboutPage() }},
+	{"legal.html", siteImprintSrc, func() any { return sampleSiteImprintPage() }},
+	{"years.html", siteYearsSrc, func() any { return sampleSiteYearsPage() }},
+	{"archive.html", siteArchiveSrc, func() any { return sampleSiteArchivePage() }},
+	{"tags.html", siteTagsSrc, func() any { return sampleSiteTagsPage() }},
+	{"map.html", siteMapSrc, func() any { return sampleSiteMapPage() }},
+	{"gallery.html", galleryPageSrc, func() any { return sampleGalleryPage() }},
+}
+
+// siteAssetFiles are the built-in files written to the site's assets/ folder.
+var siteAssetFiles = map[string]string{
+	"style.css": siteCSS,
+	"toggle.js": siteToggleJS,
+}
+
+// siteTemplateAssetsDir is the folder below a template dir whose files are
+// copied over the built-in assets.
+const siteTemplateAssetsDir = "assets"
+
+// siteTemplates holds a channel's parsed template overrides. A nil
+// *siteTemplates stands for the built-in templates.
+type siteTemplates struct {
+	overrides map[string]*template.Template
+}
+
+// lookup returns the override for name, or builtin when there is none.
+func (t *siteTemplates) lookup(name string, builtin *template.Template) *template.Template {
+	if t != nil {
+		if tmpl := t.overrides[name]; tmpl != nil {
+			return tmpl
+		}
+	}
+	return builtin
+}
+
+// execute renders the page template name, the override or else builtin,
+// with data. Overrides are only validated against sample data, so errors
+// are prefixed with the template name to point at the file to fix.
+func (t *siteTemplates) execute(name string, builtin *template.Template, data any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.lookup(name, builtin).Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// loadSiteTemplates parses the page templates in dir and renders each with
+// sample data, so that syntax errors and unknown fields are reported with
+// file and line before any page is written. An empty dir yields the
+// built-ins. All problems are returned together.
+func loadSiteTemplates(dir string) (*siteTemplates, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("template dir: %w", err)
+	}
+	known := map[string]siteTemplateFile{}
+	names := make([]string, len(siteTemplateFiles))
+	for i, f := range siteTemplateFiles {
+		known[f.Name] = f
+		names[i] = f.Name
+	}
+	t := &siteTemplates{overrides: map[string]*template.Template{}}
+	var errs []error
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ".html" {
+			continue // assets/, notes, editor backups, …
+		}
+		f, ok := known[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown template; expected one of %s", name, strings.Join(names, ", ")))
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tmpl, err := template.New(name).Parse(string(src))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := tmpl.Execute(io.Discard, f.sample()); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		t.overrides[name] = tmpl
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// channelSiteTemplates returns the channel's template overrides. A template
+// dir that no longer loads falls back to the built-ins; publish and rebuild
+// validate it first and report the errors.
+func channelSiteTemplates(ch *channels.Channel) *siteTemplates {
+	t, err := loadSiteTemplates(ch.SiteTemplateDir)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// copySiteTemplateAssets copies the files below templateDir/assets into
+// assetsDir, replacing built-in assets of the same name.
+func copySiteTemplateAssets(templateDir, assetsDir string) error {
+	if templateDir == "" {
+		return nil
+	}
+	src := filepath.Join(templateDir, siteTemplateAssetsDir)
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(assetsDir, rel)
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0o755)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dst, data, 0o644)
+	})
+}
+
+// DumpSiteTemplates writes the built-in page templates and assets to dir as
+// a starting point for a channel's template dir. Existing files are never
+// overwritten.
+func DumpSiteTemplates(dir string) error {
+	files := map[string]string{}
+	for _, f := range siteTemplateFiles {
+		files[f.Name] = f.Src
+	}
+	for name, src := range siteAssetFiles {
+		files[filepath.Join(siteTemplateAssetsDir, name)] = src
+	}
+	for name := range files {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return fmt.Errorf("%s already exists", filepath.Join(dir, name))
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, siteTemplateAssetsDir), 0o755); err != nil {
+		return err
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* --- Sample data for validation --- */
+
+func sampleSiteNav() SiteNavContext {
+	return SiteNavContext{
+		HasAbout:     true,
+		HasImprint:   true,
+		ContactEmail: "jane@example.com",
+		ContactURL:   "https://example.com/contact",
+		LogoExists:   true,
+		LogoPath:     "assets/logo.jpg",
+		SiteName:     "Sample Site",
+		FeedBaseURL:  "https://example.com",
+		HasYears:     true,
+		HasTags:      true,
+		HasMap:       true,
+	}
+}
+
+func sampleSiteAlbum() SiteAlbum {
+	return SiteAlbum{
+		PostID: "sample", Slug: "sample", Title: "Sample Album",
+		PublishedAt: time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC), PhotoCount: 2, CoverFile: "cover.jpg", HasZip: true,
+		Photos: []SitePhoto{
+			{Filename: "one.jpg", ThumbFilename: "thumbs/one.jpg", Title: "One", TakenAt: "2026-06-30T18:02:11", Width: 2000, Height: 1333,
+				Exif:       &SitePhotoExif{Camera: "Camera", Lens: "Lens", Aperture: "f/2.8", Shutter: "1/500 s", ISO: "ISO 200", FilmSimulation: "Classic Chrome"},
+				Renditions: []SiteRendition{{Filename: "sizes/960/one.jpg", Format: "jpeg", Width: 960, Height: 640}, {Filename: "sizes/960/one.webp", Format: "webp", Width: 960, Height: 640}}},
+			{Filename: "two.jpg", ThumbFilename: "thumbs/two.jpg"},
+		},
+	}
+}
+
+func sampleGalleryFigures() []galleryFigureData {
+	return []galleryFigureData{{
+		Index: 0, Thumb: "thumbs/one.jpg", Full: "one.jpg", Page: "photos/one.html", Loading: "eager",
+		Alt: "Sample Album – Photo 1 of 1", ThumbWidth: 700, ThumbHeight: 467,
+		Srcset: "sizes/960/one.jpg 960w", Sizes: gridSizes,
+		Sources: []pictureSource{{Type: "image/webp", Srcset: "sizes/960/one.webp 960w"}},
+	}}
+}
+
+func sampleSiteIndexPage() siteIndexPageData {
+	return siteIndexPageData{
+		Title: "Sample Site", DefaultTheme: "light", Description: "Photography collection — 1 album.",
+		SiteURL: "https://example.com", LDJSON: "{}",
+		Albums: []siteAlbumData{{FolderName: "sample", Title: "Sample Album", DateStr: "July 2026", PhotoCount: 2, CoverFile: "cover.jpg", Loading: "eager"}},
+		Nav:    sampleSiteNav(),
+	}
+}
+
+func sampleSiteAlbumPage() siteAlbumPageData {
+	return siteAlbumPageData{
+		Title: "Sample Album", PageTitle: "Sample Album | Sample Site", DefaultTheme: "light",
+		Description: "A collection of 2 photos.", PhotosJSON: "[]", LDJSON: "{}", ZipFilename: "photos.zip",
+		Figures: sampleGalleryFigures(), SiteURL: "https://example.com",
+		AlbumURL: "https://example.com/albums/sample/", CoverURL: "https://example.com/albums/sample/cover.jpg",
+		Nav: sampleSiteNav(),
+	}
+}
+
+func sampleSitePhotoPage() sitePhotoPageData {
+	album := sampleSiteAlbum()
+	sp := album.Photos[0]
+	return sitePhotoPageData{
+		Title: sp.Title, PageTitle: "One | Sample Album | Sample Site", AlbumTitle: album.Title, DefaultTheme: "light",
+		Description: "Photo 1 of 2 from Sample Album.", Full: "../one.jpg",
+		Image: buildResponsiveImage(sp.Renditions, "../", fullCandidate(sp.Filename, sp.Width)), Sizes: photoSizes,
+		Width: sp.Width, Height: sp.Height, Alt: sp.Title, Caption: "Evening light over the harbour.", DateStr: "30 June 2026", DateISO: "2026-06-30",
+		Exif: sp.Exif, Index: 1, Total: 2, Prev: "two.html", Next: "two.html",
+		SiteURL: "https://example.com", PageURL: "https://example.com/albums/sample/photos/one.html",
+		ImageURL: "https://example.com/albums/sample/one.jpg", Nav: sampleSiteNav(),
+	}
+}
+
+func sampleSiteAboutPage() siteAboutPageData {
+	return siteAboutPageData{SiteTitle: "Sample Site", DefaultTheme: "light", Content: "<p>About</p>", AvatarExists: true, Nav: sampleSiteNav()}
+}
+
+func sampleSiteImprintPage() siteImprintPageData {
+	return siteImprintPageData{SiteTitle: "Sample Site", DefaultTheme: "light", Content: "<p>Legal</p>", Nav: sampleSiteNav()}
+}
+
+func sampleGalleryPage() galleryPageData {
+	return galleryPageData{
+		Title: "Sample Gallery", Description: "A collection of 1 photo.", LDJSON: "{}", PhotosJSON: "[]",
+		ZipFilename: "photos.zip", Figures: sampleGalleryFigures(),
+	}
+}
+
+func sampleSiteArchivePage() siteArchivePageData {
+	return siteArchivePageData{
+		Kind: "tag", Title: "harbour", PageTitle: "Tag: harbour | Sample Site", DefaultTheme: "light",
+		Description: "1 photo tagged “harbour”.", PhotoCount: 1,
+		Albums: siteArchiveAlbums(siteBrowseEntries([]SiteAlbum{sampleSiteAlbum()})[:1]),
+		Nav:    sampleSiteNav(),
+	}
+}
+
+func sampleSiteYearsPage() siteYearsPageData {
+	return siteYearsPageData{
+		SiteTitle: "Sample Site", DefaultTheme: "light",
+		Years: []siteYearEntry{{Year: "2026", Href: "2026.html", Cover: "../albums/sample/thumbs/one.jpg", PhotoCount: 2}},
+		Nav:   sampleSiteNav(),
+	}
+}
+
+func sampleSiteTagsPage() siteTagsPageData {
+	return siteTagsPageData{
+		SiteTitle: "Sample Site", DefaultTheme: "light",
+		Tags: []siteTagEntry{{Name: "boats", Href: "boats.html", PhotoCount: 1, Weight: 1}, {Name: "harbour", Href: "harbour.html", PhotoCount: 4, Weight: 5}},
+		Nav:  sampleSiteNav(),
+	}
+}
+
+func sampleSiteMapPage() siteMapPageData {
+	return siteMapPageData{
+		SiteTitle: "Sample Site", DefaultTheme: "light", PhotoCount: 1,
+		PointsJSON: `[{"lat":53.55,"lon":9.99,"photos":[{"title":"One","album":"Sample Album","thumb":"../albums/sample/thumbs/one.jpg","page":"../albums/sample/photos/one.html"}]}]`,
+		Nav:        sampleSiteNav(),
+	}
+}
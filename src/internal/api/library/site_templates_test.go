@@ -0,0 +1,113 @@
+package apilibrary
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"huepattl.de/unterlumen/internal/channels"
+)
+
+// mustHTML returns a generated page, panicking on a render error.
+func mustHTML(html []byte, err error) string {
+	if err != nil {
+		panic(err)
+	}
+	return string(html)
+}
+
+func TestDumpedSiteTemplatesLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := DumpSiteTemplates(dir); err != nil {
+		t.Fatal(err)
+	}
+	tmpls, err := loadSiteTemplates(dir)
+	if err != nil {
+		t.Fatalf("built-in templates do not validate: %v", err)
+	}
+	if len(tmpls.overrides) != len(siteTemplateFiles) {
+		t.Errorf("loaded %d templates, want %d", len(tmpls.overrides), len(siteTemplateFiles))
+	}
+	if err := DumpSiteTemplates(dir); err == nil {
+		t.Error("second dump overwrote existing files")
+	}
+}
+
+func TestSiteTemplateOverride(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "album.html"), []byte(`<h1>{{.Title}}</h1>{{range .Figures}}<img src="{{.Thumb}}">{{end}}`), 0o644)
+	tmpls, err := loadSiteTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []GalleryItem{{Filename: "one.jpg", ThumbFilename: "thumbs/one.jpg"}}
+	html := mustHTML(GenerateSiteGallery("Summer", "", items, GalleryOptions{Nav: SiteNavContext{templates: tmpls}}))
+	if html != `<h1>Summer</h1><img src="thumbs/one.jpg">` {
+		t.Errorf("album page = %q", html)
+	}
+	if index := mustHTML(GenerateSiteIndex("Site", "", "", nil, SiteNavContext{templates: tmpls})); !strings.Contains(index, "<!DOCTYPE html>") {
+		t.Error("index page not rendered from the built-in template")
+	}
+}
+
+func TestSiteTemplateOverrideReportsRenderErrors(t *testing.T) {
+	// Valid for the sample data, which has EXIF, but not for photos without.
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "photo.html"), []byte(`<p>{{.Exif.Camera}}</p>`), 0o644)
+	tmpls, err := loadSiteTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	albumDir := t.TempDir()
+	err = writeSitePhotoPages(albumDir, testSiteAlbum(), &channels.Channel{SiteExport: true}, SiteNavContext{templates: tmpls})
+	if err == nil || !strings.HasPrefix(err.Error(), "photo.html: ") {
+		t.Fatalf("err = %v, want a photo.html render error", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(albumDir, "photos", "one.html")); !os.IsNotExist(statErr) {
+		t.Error("page written despite the render error")
+	}
+}
+
+func TestLoadSiteTemplatesReportsErrors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>{{.Title}</h1>"), 0o644)
+	os.WriteFile(filepath.Join(dir, "photo.html"), []byte("<h1>{{.Subtitle}}</h1>"), 0o644)
+	os.WriteFile(filepath.Join(dir, "albums.html"), []byte(""), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644)
+
+	_, err := loadSiteTemplates(dir)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{"index.html:1", "photo.html:1", "can't evaluate field Subtitle", "albums.html: unknown template"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+	if _, err := loadSiteTemplates(filepath.Join(dir, "missing")); err == nil {
+		t.Error("missing template dir accepted")
+	}
+}
+
+func TestWriteSiteAssetsCopiesTemplateAssets(t *testing.T) {
+	tplDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tplDir, "assets", "fonts"), 0o755)
+	os.WriteFile(filepath.Join(tplDir, "assets", "style.css"), []byte("body{}"), 0o644)
+	os.WriteFile(filepath.Join(tplDir, "assets", "fonts", "a.woff2"), []byte("font"), 0o644)
+
+	assetsDir := filepath.Join(t.TempDir(), "assets")
+	if err := writeSiteAssets(assetsDir, tplDir); err != nil {
+		t.Fatal(err)
+	}
+	if css, _ := os.ReadFile(filepath.Join(assetsDir, "style.css")); string(css) != "body{}" {
+		t.Errorf("style.css = %q", css)
+	}
+	if _, err := os.Stat(filepath.Join(assetsDir, "toggle.js")); err != nil {
+		t.Error("built-in toggle.js missing")
+	}
+	if _, err := os.Stat(filepath.Join(assetsDir, "fonts", "a.woff2")); err != nil {
+		t.Error("extra asset not copied")
+	}
+}
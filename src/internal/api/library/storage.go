@@ -0,0 +1,92 @@
+package apilibrary
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"huepattl.de/unterlumen/internal/channels"
+	"huepattl.de/unterlumen/internal/objstore"
+)
+
+// channelBucket returns the object storage configured for a channel, or nil.
+// Config errors are reported when the channel is saved, so here they just
+// disable storage.
+func channelBucket(ch *channels.Channel) *objstore.Bucket {
+	b, err := objstore.FromConfig(ch.HandlerConfig)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// storageKey returns the object key of a file below the channel output dir.
+func storageKey(channelDir, path string) (string, bool) {
+	rel, err := filepath.Rel(channelDir, path)
+	if err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// uploadResults copies freshly exported photos to the channel's bucket and
+// sets each result's URL to the object URL. Export errors are left alone;
+// upload errors replace the result's error.
+func uploadResults(ctx context.Context, b *objstore.Bucket, channelDir string, results []publishResult) {
+	for i := range results {
+		res := &results[i]
+		if res.Error != "" || res.OutputPath == "" {
+			continue
+		}
+		key, ok := storageKey(channelDir, res.OutputPath)
+		if !ok {
+			res.Error = "storage: output outside the channel folder"
+			continue
+		}
+		if err := b.Put(ctx, key, res.OutputPath, objstore.ContentType(key)); err != nil {
+			res.Error = "storage: " + err.Error()
+			continue
+		}
+		res.URL = b.URL(key)
+	}
+}
+
+// syncChannelDir mirrors dir, a gallery or the site below the channel output
+// dir, to the same keys in the channel's bucket. Only files whose content
+// differs from the stored object (by ETag) are uploaded.
+func syncChannelDir(ctx context.Context, b *objstore.Bucket, channelDir, dir string, progress func(objstore.Progress)) (objstore.SyncResult, error) {
+	prefix := ""
+	if filepath.Clean(dir) != filepath.Clean(channelDir) {
+		key, ok := storageKey(channelDir, dir)
+		if !ok {
+			return objstore.SyncResult{}, fmt.Errorf("%s is outside the channel folder", dir)
+		}
+		prefix = key + "/"
+	}
+	return objstore.Sync(ctx, b, dir, prefix, objstore.SyncOptions{
+		Mirror:   true,
+		Exclude:  []string{"site.json", "gallery.json"}, // publish state, not served
+		Progress: progress,
+	})
+}
+
+// syncGalleryStorage syncs a gallery or site after a streamed publish,
+// reporting each file as a "storage" step and the outcome in the final
+// event (storage and storageURL, or storageError).
+func syncGalleryStorage(ctx context.Context, ch *channels.Channel, channelDir, dir string, emit func(any), done map[string]any) {
+	b := channelBucket(ch)
+	if b == nil {
+		return
+	}
+	res, err := syncChannelDir(ctx, b, channelDir, dir, func(p objstore.Progress) {
+		emit(map[string]any{"step": "storage", "done": p.Done, "total": p.Total, "file": p.File})
+	})
+	if err != nil {
+		done["storageError"] = err.Error()
+		return
+	}
+	done["storage"] = res
+	if key, ok := storageKey(channelDir, dir); ok {
+		done["storageURL"] = b.URL(key + "/index.html")
+	}
+}
@@ -0,0 +1,212 @@
+package location
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"huepattl.de/unterlumen/internal/media"
+	"huepattl.de/unterlumen/internal/pathguard"
+)
+
+const defaultMaxGap = 5 * time.Minute
+
+// geotagMatch is the preview/apply result for one photo.
+type geotagMatch struct {
+	File       string   `json:"file"`
+	DateTaken  string   `json:"dateTaken,omitempty"` // corrected capture time (UTC, RFC 3339)
+	Matched    bool     `json:"matched"`
+	Latitude   float64  `json:"latitude,omitempty"`
+	Longitude  float64  `json:"longitude,omitempty"`
+	Altitude   *float64 `json:"altitude,omitempty"`
+	GapSeconds float64  `json:"gapSeconds,omitempty"`
+	Success    bool     `json:"success,omitempty"` // only set when applied
+	Error      string   `json:"error,omitempty"`
+}
+
+type geotagResponse struct {
+	TrackPoints int           `json:"trackPoints"`
+	TrackStart  string        `json:"trackStart,omitempty"`
+	TrackEnd    string        `json:"trackEnd,omitempty"`
+	Applied     bool          `json:"applied"`
+	Results     []geotagMatch `json:"results"`
+}
+
+// geotagParams holds the parsed multipart form fields of a geotag request.
+type geotagParams struct {
+	files  []string
+	offset time.Duration // camera clock minus true time
+	maxGap time.Duration
+	loc    *time.Location
+	apply  bool
+}
+
+// handleGeotagGPX matches photos against uploaded GPS track logs.
+//
+// Multipart form fields:
+//   - track: one or more GPX/KML/GeoJSON files
+//   - files: JSON array of photo paths relative to root
+//   - offset: camera clock offset in seconds (camera time minus GPS time)
+//   - maxGap: max seconds between a photo and the nearest track point (default 300)
+//   - timezone: camera time zone (IANA name or ±HH:MM) for photos without OffsetTimeOriginal
+//   - apply: "true" writes GPS coordinates and altitude; otherwise only a preview is returned
+func handleGeotagGPX(root string, cache *media.ScanCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		const maxSize = 64 << 20
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+		if err := r.ParseMultipartForm(maxSize); err != nil {
+			http.Error(w, "File too large or invalid form", http.StatusBadRequest)
+			return
+		}
+		params, err := parseGeotagParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if params.apply && !media.CheckExiftool() {
+			http.Error(w, "exiftool is not available", http.StatusServiceUnavailable)
+			return
+		}
+		track, err := readTracks(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp := geotagResponse{
+			TrackPoints: len(track),
+			TrackStart:  track[0].Time.UTC().Format(time.RFC3339),
+			TrackEnd:    track[len(track)-1].Time.UTC().Format(time.RFC3339),
+			Applied:     params.apply,
+			Results:     matchFiles(root, track, params),
+		}
+		if params.apply {
+			applyGeotags(root, resp.Results, cache)
+		}
+		writeJSON(w, resp)
+	}
+}
+
+func parseGeotagParams(r *http.Request) (geotagParams, error) {
+	p := geotagParams{maxGap: defaultMaxGap, loc: time.Local}
+	if err := json.Unmarshal([]byte(r.FormValue("files")), &p.files); err != nil || len(p.files) == 0 {
+		return p, fmt.Errorf("No files specified")
+	}
+	if v := r.FormValue("offset"); v != "" {
+		secs, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return p, fmt.Errorf("Invalid offset")
+		}
+		p.offset = time.Duration(secs * float64(time.Second))
+	}
+	if v := r.FormValue("maxGap"); v != "" {
+		secs, err := strconv.ParseFloat(v, 64)
+		if err != nil || secs < 0 {
+			return p, fmt.Errorf("Invalid maxGap")
+		}
+		p.maxGap = time.Duration(secs * float64(time.Second))
+	}
+	if v := r.FormValue("timezone"); v != "" {
+		loc, err := parseTimezone(v)
+		if err != nil {
+			return p, fmt.Errorf("Invalid timezone")
+		}
+		p.loc = loc
+	}
+	p.apply = r.FormValue("apply") == "true"
+	return p, nil
+}
+
+// parseTimezone accepts an IANA zone name ("Europe/Berlin") or a fixed offset ("+02:00").
+func parseTimezone(v string) (*time.Location, error) {
+	if strings.HasPrefix(v, "+") || strings.HasPrefix(v, "-") {
+		t, err := time.Parse("-07:00", v)
+		if err != nil {
+			return nil, err
+		}
+		_, off := t.Zone()
+		return time.FixedZone(v, off), nil
+	}
+	return time.LoadLocation(v)
+}
+
+// readTracks parses every uploaded track file and merges them into one sorted track.
+func readTracks(r *http.Request) ([]media.TrackPoint, error) {
+	headers := r.MultipartForm.File["track"]
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("No track file uploaded")
+	}
+	var all []media.TrackPoint
+	for _, fh := range headers {
+		f, err := fh.Open()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %v", fh.Filename, err)
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %v", fh.Filename, err)
+		}
+		pts, err := media.ParseTrack(fh.Filename, data)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, pts...)
+	}
+	return media.SortTrack(all), nil
+}
+
+func matchFiles(root string, track []media.TrackPoint, p geotagParams) []geotagMatch {
+	results := make([]geotagMatch, 0, len(p.files))
+	for _, file := range p.files {
+		m := geotagMatch{File: file}
+		filePath, ok := pathguard.SafePath(root, file)
+		if !ok {
+			m.Error = "invalid path"
+			results = append(results, m)
+			continue
+		}
+		taken, err := media.CaptureTime(filePath, p.loc)
+		if err != nil {
+			m.Error = "no capture time: " + err.Error()
+			results = append(results, m)
+			continue
+		}
+		taken = taken.Add(-p.offset)
+		m.DateTaken = taken.UTC().Format(time.RFC3339)
+		if pos, ok := media.MatchTrack(track, taken, p.maxGap); ok {
+			m.Matched = true
+			m.Latitude, m.Longitude, m.Altitude = pos.Latitude, pos.Longitude, pos.Elevation
+			m.GapSeconds = pos.Gap.Seconds()
+		}
+		results = append(results, m)
+	}
+	return results
+}
+
+// applyGeotags writes the matched positions and invalidates the affected directories.
+func applyGeotags(root string, matches []geotagMatch, cache *media.ScanCache) {
+	var written []locationResult
+	for i := range matches {
+		m := &matches[i]
+		if !m.Matched {
+			continue
+		}
+		filePath, _ := pathguard.SafePath(root, m.File)
+		if err := media.WriteGPSLocationWithAltitude(filePath, m.Latitude, m.Longitude, m.Altitude); err != nil {
+			m.Error = err.Error()
+			continue
+		}
+		m.Success = true
+		written = append(written, locationResult{File: m.File, Success: true})
+	}
+	invalidateSuccessful(root, written, cache)
+}
@@ -33,6 +33,7 @@ type setLocationRequest struct {
 func Handle(mux *http.ServeMux, root string, cache *media.ScanCache) {
 	mux.HandleFunc("/api/set-location", handleSetLocation(root, cache))
 	mux.HandleFunc("/api/remove-location", handleRemoveLocation(root, cache))
+	mux.HandleFunc("/api/geotag-gpx", handleGeotagGPX(root, cache))
 }
 
 func handleRemoveLocation(root string, cache *media.ScanCache) http.HandlerFunc {
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"context"
 	"io/fs"
+	"log"
 	"net/http"
+	"path/filepath"
 	"strings"
 
 	"huepattl.de/unterlumen/internal/api/batchrename"
@@ -11,9 +14,12 @@ import (
 	apicrop "huepattl.de/unterlumen/internal/api/crop"
 	apiexport "huepattl.de/unterlumen/internal/api/export"
 	"huepattl.de/unterlumen/internal/api/fileops"
+	apijobs "huepattl.de/unterlumen/internal/api/jobs"
 	apilibrary "huepattl.de/unterlumen/internal/api/library"
 	"huepattl.de/unterlumen/internal/api/location"
 	"huepattl.de/unterlumen/internal/channels"
+	"huepattl.de/unterlumen/internal/channels/mastodon"
+	"huepattl.de/unterlumen/internal/jobs"
 	"huepattl.de/unterlumen/internal/library"
 	"huepattl.de/unterlumen/internal/media"
 )
@@ -37,19 +43,34 @@ func NewRouter(boundary, startPath, homePath string, webFS fs.FS, serverRole boo
 	mux.HandleFunc("/api/cache/clear", handleCacheClear())
 	mux.HandleFunc("/api/cache/evict", handleCacheEvict(boundary))
 
+	// Background jobs persist under the cache dir; without it, exports still
+	// work through the request-bound endpoints.
+	jobMgr, err := jobs.NewManager(filepath.Join(media.GetCacheDir(), "jobs"), jobs.DefaultRetention)
+	if err != nil {
+		log.Printf("Warning: job manager init failed: %v", err)
+		jobMgr = nil
+	}
+
 	browse.Handle(mux, boundary, cache, imageCache, libMgr)
-	apiexport.Handle(mux, boundary, serverRole)
+	apiexport.Handle(mux, boundary, serverRole, libMgr, jobMgr)
 	apicrop.Handle(mux, boundary, cache)
 	fileops.Handle(mux, boundary, cache, libMgr)
 	location.Handle(mux, boundary, cache)
 	batchrename.Handle(mux, boundary, cache, libMgr)
 
+	channels.RegisterHandler(mastodon.Name, mastodon.New())
 	if chStore != nil {
 		apichannels.Handle(mux, chStore)
 	}
 	if libMgr != nil {
 		apilibrary.Handle(mux, libMgr, imageCache, boundary, serverRole, chStore)
 	}
+	if jobMgr != nil {
+		music, _ := fs.Sub(webFS, "music")
+		apiexport.HandleSlideshow(mux, boundary, serverRole, jobMgr, music)
+		apijobs.Handle(mux, jobMgr)
+		jobMgr.Start(context.Background())
+	}
 
 	mux.Handle("/", noCacheAssets(http.FileServer(http.FS(webFS))))
 	return mux
@@ -15,6 +15,8 @@ func handleToolsCheck() http.HandlerFunc {
 			Available   bool `json:"available"`
 			HEIFSupport bool `json:"heifSupport,omitempty"`
 			WebPSupport bool `json:"webpSupport,omitempty"`
+			AVIFSupport bool `json:"avifSupport,omitempty"`
+			JXLSupport  bool `json:"jxlSupport,omitempty"`
 		}
 		resp := struct {
 			Platform      string     `json:"platform"`
@@ -22,14 +24,23 @@ func handleToolsCheck() http.HandlerFunc {
 			FFmpeg        toolStatus `json:"ffmpeg"`
 			Sips          toolStatus `json:"sips"`
 			HeifConvert   toolStatus `json:"heifConvert"`
+			Avifenc       toolStatus `json:"avifenc"`
+			Cjxl          toolStatus `json:"cjxl"`
 			WebPAvailable bool       `json:"webpAvailable"`
+			AVIFAvailable bool       `json:"avifAvailable"`
+			JXLAvailable  bool       `json:"jxlAvailable"`
 		}{
-			Platform:      runtime.GOOS,
-			Exiftool:      toolStatus{Available: media.CheckExiftool()},
-			FFmpeg:        toolStatus{Available: ffmpeg.Available, HEIFSupport: ffmpeg.HEIFSupport, WebPSupport: ffmpeg.WebPSupport},
+			Platform: runtime.GOOS,
+			Exiftool: toolStatus{Available: media.CheckExiftool()},
+			FFmpeg: toolStatus{Available: ffmpeg.Available, HEIFSupport: ffmpeg.HEIFSupport, WebPSupport: ffmpeg.WebPSupport,
+				AVIFSupport: ffmpeg.AVIFEncoder != "", JXLSupport: ffmpeg.JXLSupport},
 			Sips:          toolStatus{Available: media.CheckSips()},
 			HeifConvert:   toolStatus{Available: media.CheckHeifConvert()},
-			WebPAvailable: ffmpeg.WebPSupport || media.CheckCwebp(),
+			Avifenc:       toolStatus{Available: media.CheckAvifenc()},
+			Cjxl:          toolStatus{Available: media.CheckCjxl()},
+			WebPAvailable: media.WebPAvailable(),
+			AVIFAvailable: media.AVIFAvailable(),
+			JXLAvailable:  media.JXLAvailable(),
 		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(resp)
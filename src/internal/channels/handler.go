@@ -0,0 +1,127 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Handler uploads exported photos to a remote service (e.g. Mastodon). A
+// channel selects its handler by name in Channel.Handler; channels without a
+// handler only export files.
+type Handler interface {
+	// ValidateConfig checks a handler configuration: the channel's
+	// HandlerConfig merged with the publishing account's Config.
+	ValidateConfig(cfg map[string]string) error
+
+	// Publish posts the batch as one post (or thread) and reports where each
+	// item ended up. Items are exported files; Publish must not modify them.
+	// When it fails after part of the batch went online, it returns that
+	// part with the error; items not posted have an empty RemoteItem.
+	Publish(ctx context.Context, cfg map[string]string, batch Batch) (*RemotePost, error)
+}
+
+// Batch is a set of photos published together.
+type Batch struct {
+	Text  string      // post text, e.g. the album title or a caption
+	Items []BatchItem // in display order
+}
+
+// BatchItem is one exported photo in a batch.
+type BatchItem struct {
+	Path    string // exported file on disk
+	AltText string // image description for screen readers; may be empty
+	Title   string // the photo's title; may be empty
+	Caption string // longer description (XMP dc:description); may be empty
+}
+
+// RemotePost describes what a handler created.
+type RemotePost struct {
+	ID    string       // remote ID of the (first) post
+	URL   string       // public URL of the (first) post
+	Items []RemoteItem // one per batch item, in the same order
+}
+
+// RemoteItem locates one published photo.
+type RemoteItem struct {
+	PostID  string // remote ID of the post containing the photo
+	URL     string // public URL of that post
+	MediaID string // remote ID of the uploaded file
+}
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = map[string]Handler{}
+)
+
+// RegisterHandler makes a handler available under name. Handlers are
+// registered once at startup.
+func RegisterHandler(name string, h Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[name] = h
+}
+
+// LookupHandler returns the handler registered under name.
+func LookupHandler(name string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[name]
+	return h, ok
+}
+
+// HandlerNames returns the registered handler names, sorted.
+func HandlerNames() []string {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	names := make([]string, 0, len(handlers))
+	for n := range handlers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HandlerConfigFor returns the channel's HandlerConfig overlaid with the
+// config of the account with the given ID (if any), so that e.g. the
+// instance URL can be shared while each account has its own token.
+func (c *Channel) HandlerConfigFor(accountID string) map[string]string {
+	cfg := make(map[string]string, len(c.HandlerConfig))
+	for k, v := range c.HandlerConfig {
+		cfg[k] = v
+	}
+	if a := c.AccountByID(accountID); a != nil {
+		for k, v := range a.Config {
+			cfg[k] = v
+		}
+	}
+	return cfg
+}
+
+// validateHandler checks that the handler exists and accepts the config of
+// every account (or the channel's own config when there are no accounts).
+func (c *Channel) validateHandler() error {
+	if c.Handler == "" {
+		return nil
+	}
+	h, ok := LookupHandler(c.Handler)
+	if !ok {
+		return fmt.Errorf("unknown handler %q", c.Handler)
+	}
+	if c.GalleryExport || c.SiteExport {
+		return fmt.Errorf("handler %q cannot be combined with gallery or site export", c.Handler)
+	}
+	if len(c.Accounts) == 0 {
+		if err := h.ValidateConfig(c.HandlerConfigFor("")); err != nil {
+			return fmt.Errorf("handler config: %w", err)
+		}
+		return nil
+	}
+	for _, a := range c.Accounts {
+		if err := h.ValidateConfig(c.HandlerConfigFor(a.ID)); err != nil {
+			return fmt.Errorf("account %q: %w", a.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type tokenHandler struct{}
+
+func (tokenHandler) ValidateConfig(cfg map[string]string) error {
+	if cfg["token"] == "" {
+		return errors.New("token is required")
+	}
+	return nil
+}
+
+func (tokenHandler) Publish(context.Context, map[string]string, Batch) (*RemotePost, error) {
+	return &RemotePost{}, nil
+}
+
+func TestValidateHandler(t *testing.T) {
+	RegisterHandler("token", tokenHandler{})
+	shared := map[string]string{"token": "a", "instance": "https://example.social"}
+
+	for name, ch := range map[string]Channel{
+		"unknown":   {Format: "jpeg", Handler: "nope"},
+		"no config": {Format: "jpeg", Handler: "token"},
+		"account":   {Format: "jpeg", Handler: "token", HandlerConfig: shared, Accounts: []Account{{ID: "x", Config: map[string]string{"token": ""}}}},
+		"site":      {Format: "jpeg", Handler: "token", HandlerConfig: shared, SiteExport: true},
+	} {
+		if err := ch.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	ch := Channel{Format: "jpeg", Handler: "token", HandlerConfig: shared,
+		Accounts: []Account{{ID: "x", Config: map[string]string{"token": "b"}}}}
+	if err := ch.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if cfg := ch.HandlerConfigFor("x"); cfg["token"] != "b" || cfg["instance"] != "https://example.social" {
+		t.Errorf("HandlerConfigFor = %v", cfg)
+	}
+}
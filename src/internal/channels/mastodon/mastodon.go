@@ -0,0 +1,322 @@
+// Package mastodon publishes photos to a Mastodon instance using the media
+// upload and status APIs.
+package mastodon
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"huepattl.de/unterlumen/internal/channels"
+)
+
+// Name is the channel handler name ("handler": "mastodon").
+const Name = "mastodon"
+
+// Config keys, set in the channel's handler config or per account.
+const (
+	ConfigInstance   = "instance"   // base URL, e.g. https://mastodon.social
+	ConfigToken      = "token"      // access token with write:media and write:statuses
+	ConfigVisibility = "visibility" // public (default), unlisted, private or direct
+	ConfigLanguage   = "language"   // optional ISO 639 code of the post text
+)
+
+// MaxMediaPerStatus is the number of attachments Mastodon allows per status.
+// Larger batches are posted as a thread of replies.
+const MaxMediaPerStatus = 4
+
+// Handler implements channels.Handler for Mastodon.
+type Handler struct {
+	Client       *http.Client  // nil = http.DefaultClient with a 2 minute timeout
+	PollInterval time.Duration // wait between checks of an uploaded file still being processed; 0 = 1s
+	MaxPolls     int           // checks before giving up on processing; 0 = 60
+	RetryDelay   time.Duration // wait before resending a failed status, times the attempt; 0 = 2s
+}
+
+// statusAttempts is how often a status is sent before giving up. Retries
+// reuse the idempotency key, so the server posts it at most once.
+const statusAttempts = 3
+
+// New returns a Handler with default settings.
+func New() *Handler {
+	return &Handler{}
+}
+
+// ValidateConfig checks that an instance URL and token are set.
+func (h *Handler) ValidateConfig(cfg map[string]string) error {
+	inst := cfg[ConfigInstance]
+	if inst == "" {
+		return fmt.Errorf("%s is required", ConfigInstance)
+	}
+	u, err := url.Parse(inst)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%s must be an http(s) URL", ConfigInstance)
+	}
+	if cfg[ConfigToken] == "" {
+		return fmt.Errorf("%s is required", ConfigToken)
+	}
+	switch cfg[ConfigVisibility] {
+	case "", "public", "unlisted", "private", "direct":
+	default:
+		return fmt.Errorf("unknown %s %q", ConfigVisibility, cfg[ConfigVisibility])
+	}
+	return nil
+}
+
+// Publish uploads every item with its alt text and posts them with the batch
+// text; a single photo's caption is appended to it. Batches of more than
+// MaxMediaPerStatus photos become a thread; the returned post is the first
+// status. If the thread breaks off, the statuses already posted are returned
+// along with the error.
+func (h *Handler) Publish(ctx context.Context, cfg map[string]string, batch channels.Batch) (*channels.RemotePost, error) {
+	if err := h.ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+	if len(batch.Items) == 0 {
+		return nil, fmt.Errorf("nothing to publish")
+	}
+	c := &client{
+		h:     h,
+		base:  strings.TrimRight(cfg[ConfigInstance], "/"),
+		token: cfg[ConfigToken],
+	}
+
+	mediaIDs := make([]string, len(batch.Items))
+	for i, item := range batch.Items {
+		id, err := c.uploadMedia(ctx, item)
+		if err != nil {
+			return nil, fmt.Errorf("upload %s: %w", filepath.Base(item.Path), err)
+		}
+		mediaIDs[i] = id
+	}
+
+	if len(batch.Items) == 1 && batch.Items[0].Caption != "" {
+		batch.Text = strings.TrimSpace(batch.Text + "\n\n" + batch.Items[0].Caption)
+	}
+	post := &channels.RemotePost{Items: make([]channels.RemoteItem, len(batch.Items))}
+	batchKey := idempotencyKey()
+	replyTo := ""
+	for start := 0; start < len(mediaIDs); start += MaxMediaPerStatus {
+		end := min(start+MaxMediaPerStatus, len(mediaIDs))
+		text := batch.Text
+		if len(mediaIDs) > MaxMediaPerStatus {
+			n := (len(mediaIDs) + MaxMediaPerStatus - 1) / MaxMediaPerStatus
+			text = strings.TrimSpace(fmt.Sprintf("%s (%d/%d)", text, start/MaxMediaPerStatus+1, n))
+		}
+		key := fmt.Sprintf("%s-%d", batchKey, start/MaxMediaPerStatus)
+		st, err := c.postStatus(ctx, cfg, key, text, mediaIDs[start:end], replyTo)
+		if err != nil {
+			if post.ID == "" {
+				return nil, err
+			}
+			return post, err
+		}
+		if post.ID == "" {
+			post.ID, post.URL = st.ID, st.URL
+		}
+		for i := start; i < end; i++ {
+			post.Items[i] = channels.RemoteItem{PostID: st.ID, URL: st.URL, MediaID: mediaIDs[i]}
+		}
+		replyTo = st.ID
+	}
+	return post, nil
+}
+
+// client holds the state of one Publish call.
+type client struct {
+	h     *Handler
+	base  string
+	token string
+}
+
+type mediaAttachment struct {
+	ID  string  `json:"id"`
+	URL *string `json:"url"` // null while the server is still processing
+}
+
+type status struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// uploadMedia sends one file to POST /api/v2/media. The server may answer
+// 202 Accepted and process the file in the background; the attachment can
+// only be used in a status once GET /api/v1/media/{id} answers 200.
+func (c *client) uploadMedia(ctx context.Context, item channels.BatchItem) (string, error) {
+	f, err := os.Open(item.Path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", filepath.Base(item.Path))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return "", err
+	}
+	if item.AltText != "" {
+		mw.WriteField("description", item.AltText)
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	var m mediaAttachment
+	code, err := c.do(ctx, http.MethodPost, "/api/v2/media", mw.FormDataContentType(), &body, nil, &m)
+	if err != nil {
+		return "", err
+	}
+	if m.ID == "" {
+		return "", fmt.Errorf("server returned no media ID")
+	}
+	if code == http.StatusOK && m.URL != nil {
+		return m.ID, nil
+	}
+	return m.ID, c.waitProcessed(ctx, m.ID)
+}
+
+// waitProcessed polls an attachment until the server has processed it.
+func (c *client) waitProcessed(ctx context.Context, id string) error {
+	interval, polls := c.h.PollInterval, c.h.MaxPolls
+	if interval == 0 {
+		interval = time.Second
+	}
+	if polls == 0 {
+		polls = 60
+	}
+	for i := 0; i < polls; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		var m mediaAttachment
+		code, err := c.do(ctx, http.MethodGet, "/api/v1/media/"+url.PathEscape(id), "", nil, nil, &m)
+		if err != nil {
+			return err
+		}
+		if code == http.StatusOK {
+			return nil
+		}
+	}
+	return fmt.Errorf("media %s still processing after %d checks", id, polls)
+}
+
+// postStatus creates a status with the given attachments. Network errors,
+// rate limits and server errors are retried with the same idempotency key, so
+// a request that did reach the server (e.g. before a timeout) is not posted
+// twice.
+func (c *client) postStatus(ctx context.Context, cfg map[string]string, key, text string, mediaIDs []string, replyTo string) (status, error) {
+	form := url.Values{}
+	form.Set("status", text)
+	for _, id := range mediaIDs {
+		form.Add("media_ids[]", id)
+	}
+	vis := cfg[ConfigVisibility]
+	if vis == "" {
+		vis = "public"
+	}
+	form.Set("visibility", vis)
+	if lang := cfg[ConfigLanguage]; lang != "" {
+		form.Set("language", lang)
+	}
+	if replyTo != "" {
+		form.Set("in_reply_to_id", replyTo)
+	}
+	header := http.Header{"Idempotency-Key": {key}}
+	delay := c.h.RetryDelay
+	if delay == 0 {
+		delay = 2 * time.Second
+	}
+
+	var st status
+	for attempt := 1; ; attempt++ {
+		code, err := c.do(ctx, http.MethodPost, "/api/v1/statuses", "application/x-www-form-urlencoded",
+			strings.NewReader(form.Encode()), header, &st)
+		if err == nil {
+			break
+		}
+		retry := code == 0 || code == http.StatusTooManyRequests || code >= 500
+		if !retry || attempt == statusAttempts {
+			return status{}, err
+		}
+		select {
+		case <-ctx.Done():
+			return status{}, err
+		case <-time.After(time.Duration(attempt) * delay):
+		}
+	}
+	if st.ID == "" {
+		return status{}, fmt.Errorf("server returned no status ID")
+	}
+	return st, nil
+}
+
+// do sends an authorized request and decodes a 2xx JSON response into out.
+// It returns the status code; other codes are errors carrying the server's
+// error message.
+func (c *client) do(ctx context.Context, method, path, contentType string, body io.Reader, header http.Header, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	hc := c.h.Client
+	if hc == nil {
+		hc = &http.Client{Timeout: 2 * time.Minute}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			return resp.StatusCode, fmt.Errorf("%s %s: %s (%d)", method, path, e.Error, resp.StatusCode)
+		}
+		return resp.StatusCode, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		// Media still processing: GET /api/v1/media/{id} answers 206.
+		return resp.StatusCode, nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+	}
+	return resp.StatusCode, nil
+}
+
+func idempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,228 @@
+package mastodon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"huepattl.de/unterlumen/internal/channels"
+)
+
+// stub is a minimal Mastodon API: uploads are processed after one poll and
+// statuses are recorded.
+type stub struct {
+	mu         sync.Mutex
+	uploads    []string // alt texts, in upload order
+	polls      int
+	statuses   []url.Values
+	keys       []string    // Idempotency-Key of every status request
+	statusErrs map[int]int // status request number (from 1) → error code answered instead
+}
+
+func (s *stub) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	auth := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{"error": "The access token is invalid"})
+				return
+			}
+			next(w, r)
+		}
+	}
+	mux.HandleFunc("POST /api/v2/media", auth(func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("upload without file: %v", err)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		f.Close()
+		s.mu.Lock()
+		s.uploads = append(s.uploads, r.FormValue("description"))
+		id := fmt.Sprint(len(s.uploads))
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, `{"id":%q,"url":null}`, id)
+	}))
+	mux.HandleFunc("GET /api/v1/media/{id}", auth(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.polls++
+		first := s.polls%2 == 1
+		s.mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusPartialContent)
+			fmt.Fprintf(w, `{"id":%q,"url":null}`, r.PathValue("id"))
+			return
+		}
+		fmt.Fprintf(w, `{"id":%q,"url":"https://files.example/%s.jpg"}`, r.PathValue("id"), r.PathValue("id"))
+	}))
+	mux.HandleFunc("POST /api/v1/statuses", auth(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Idempotency-Key") == "" {
+			t.Error("status posted without Idempotency-Key")
+		}
+		r.ParseForm()
+		s.mu.Lock()
+		s.keys = append(s.keys, r.Header.Get("Idempotency-Key"))
+		if code := s.statusErrs[len(s.keys)]; code != 0 {
+			s.mu.Unlock()
+			w.WriteHeader(code)
+			json.NewEncoder(w).Encode(map[string]string{"error": http.StatusText(code)})
+			return
+		}
+		s.statuses = append(s.statuses, r.PostForm)
+		id := fmt.Sprintf("10%d", len(s.statuses))
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"id":%q,"url":"https://example.social/@me/%s"}`, id, id)
+	}))
+	return mux
+}
+
+func writeFiles(t *testing.T, n int) []channels.BatchItem {
+	t.Helper()
+	dir := t.TempDir()
+	items := make([]channels.BatchItem, n)
+	for i := range items {
+		p := filepath.Join(dir, fmt.Sprintf("p%d.jpg", i))
+		if err := os.WriteFile(p, []byte("jpeg"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		items[i] = channels.BatchItem{Path: p, AltText: fmt.Sprintf("alt %d", i)}
+	}
+	return items
+}
+
+func TestPublishThreadsLargeBatches(t *testing.T) {
+	s := &stub{}
+	srv := httptest.NewServer(s.handler(t))
+	defer srv.Close()
+
+	h := &Handler{PollInterval: time.Millisecond}
+	cfg := map[string]string{ConfigInstance: srv.URL + "/", ConfigToken: "secret", ConfigVisibility: "unlisted"}
+	post, err := h.Publish(context.Background(), cfg, channels.Batch{Text: "Autumn", Items: writeFiles(t, 6)})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if post.ID != "101" || post.URL != "https://example.social/@me/101" {
+		t.Errorf("post = %+v", post)
+	}
+	if len(s.uploads) != 6 || s.uploads[5] != "alt 5" {
+		t.Errorf("uploads = %v", s.uploads)
+	}
+	if len(s.statuses) != 2 {
+		t.Fatalf("posted %d statuses, want 2", len(s.statuses))
+	}
+	first, second := s.statuses[0], s.statuses[1]
+	if got := first["media_ids[]"]; len(got) != 4 || got[0] != "1" {
+		t.Errorf("first status media = %v", got)
+	}
+	if first.Get("status") != "Autumn (1/2)" || first.Get("visibility") != "unlisted" {
+		t.Errorf("first status = %v", first)
+	}
+	if second.Get("in_reply_to_id") != "101" || len(second["media_ids[]"]) != 2 {
+		t.Errorf("second status = %v", second)
+	}
+	if len(post.Items) != 6 || post.Items[3].PostID != "101" || post.Items[4].PostID != "102" || post.Items[5].MediaID != "6" {
+		t.Errorf("items = %+v", post.Items)
+	}
+}
+
+func TestPublishAppendsSingleCaption(t *testing.T) {
+	s := &stub{}
+	srv := httptest.NewServer(s.handler(t))
+	defer srv.Close()
+
+	h := &Handler{PollInterval: time.Millisecond}
+	cfg := map[string]string{ConfigInstance: srv.URL, ConfigToken: "secret"}
+	items := writeFiles(t, 1)
+	items[0].Caption = "Fog over the harbour at dawn."
+	if _, err := h.Publish(context.Background(), cfg, channels.Batch{Text: "Autumn", Items: items}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if got := s.statuses[0].Get("status"); got != "Autumn\n\nFog over the harbour at dawn." {
+		t.Errorf("status = %q", got)
+	}
+	if s.uploads[0] != "alt 0" {
+		t.Errorf("alt text = %q", s.uploads[0])
+	}
+}
+
+func TestPublishRetriesStatusWithSameKey(t *testing.T) {
+	s := &stub{statusErrs: map[int]int{1: http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(s.handler(t))
+	defer srv.Close()
+
+	h := &Handler{PollInterval: time.Millisecond, RetryDelay: time.Millisecond}
+	cfg := map[string]string{ConfigInstance: srv.URL, ConfigToken: "secret"}
+	if _, err := h.Publish(context.Background(), cfg, channels.Batch{Items: writeFiles(t, 1)}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(s.statuses) != 1 || len(s.keys) != 2 || s.keys[0] != s.keys[1] {
+		t.Errorf("statuses = %d, keys = %v; want one status sent twice with the same key", len(s.statuses), s.keys)
+	}
+}
+
+func TestPublishReturnsPartialThread(t *testing.T) {
+	// The first status goes through, the reply is rejected.
+	s := &stub{statusErrs: map[int]int{2: http.StatusUnprocessableEntity}}
+	srv := httptest.NewServer(s.handler(t))
+	defer srv.Close()
+
+	h := &Handler{PollInterval: time.Millisecond, RetryDelay: time.Millisecond}
+	cfg := map[string]string{ConfigInstance: srv.URL, ConfigToken: "secret"}
+	post, err := h.Publish(context.Background(), cfg, channels.Batch{Text: "Autumn", Items: writeFiles(t, 6)})
+	if err == nil {
+		t.Fatal("expected an error for the rejected reply")
+	}
+	if post == nil || post.ID != "101" || len(post.Items) != 6 {
+		t.Fatalf("partial post = %+v", post)
+	}
+	if post.Items[3].PostID != "101" || post.Items[4].PostID != "" {
+		t.Errorf("items = %+v", post.Items)
+	}
+	if len(s.keys) != 2 {
+		t.Errorf("rejected status sent %d times, want no retry", len(s.keys)-1)
+	}
+}
+
+func TestPublishReportsServerErrors(t *testing.T) {
+	srv := httptest.NewServer((&stub{}).handler(t))
+	defer srv.Close()
+
+	cfg := map[string]string{ConfigInstance: srv.URL, ConfigToken: "wrong"}
+	_, err := New().Publish(context.Background(), cfg, channels.Batch{Items: writeFiles(t, 1)})
+	if err == nil {
+		t.Fatal("expected an error for an invalid token")
+	}
+	if want := "The access token is invalid"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not mention %q", err, want)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	h := New()
+	for name, cfg := range map[string]map[string]string{
+		"no instance":  {ConfigToken: "t"},
+		"bad instance": {ConfigInstance: "mastodon.social", ConfigToken: "t"},
+		"no token":     {ConfigInstance: "https://mastodon.social"},
+		"visibility":   {ConfigInstance: "https://mastodon.social", ConfigToken: "t", ConfigVisibility: "friends"},
+	} {
+		if h.ValidateConfig(cfg) == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := h.ValidateConfig(map[string]string{ConfigInstance: "https://mastodon.social", ConfigToken: "t"}); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+}
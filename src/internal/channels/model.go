@@ -1,6 +1,11 @@
 package channels
 
-import "huepattl.de/unterlumen/internal/media"
+import (
+	"fmt"
+	"path/filepath"
+
+	"huepattl.de/unterlumen/internal/media"
+)
 
 // Account is one named account or destination within a channel (e.g. two Mastodon logins).
 type Account struct {
@@ -13,34 +18,90 @@ type Account struct {
 type Channel struct {
 	Slug          string            `json:"slug"`
 	Name          string            `json:"name"`
-	Handler       string            `json:"handler,omitempty"`       // "" = default (export only); future: "mastodon", "scp", …
+	Handler       string            `json:"handler,omitempty"`       // "" = export only; otherwise a registered Handler, e.g. "mastodon"
 	HandlerConfig map[string]string `json:"handlerConfig,omitempty"` // free-form config for the handler
 	Accounts      []Account         `json:"accounts,omitempty"`      // named sub-accounts; empty = single anonymous destination
-	Format        string            `json:"format"`                  // "jpeg", "png", "webp"
-	Quality       int               `json:"quality"`                 // 1–100
+	Format        string            `json:"format"`                  // "jpeg", "png", "webp", "avif", "jxl"
+	Quality       int               `json:"quality"`                 // 1–100; upper bound when MaxBytes is set
+	MaxBytes      int64             `json:"maxBytes,omitempty"`      // upload size cap; quality is searched to fit
+	AllowDownscale bool             `json:"allowDownscale,omitempty"` // MaxBytes may also reduce dimensions
 	Scale         media.ScaleOptions `json:"scale"`
-	ExifMode      string            `json:"exifMode"`                // "strip", "keep", "keep_no_gps"
+	ExifMode      string            `json:"exifMode"`                // "strip", "keep", "keep_no_gps", "keep_outside_zones"
+	ColorMode     string            `json:"colorMode,omitempty"`     // "srgb" (default) or "preserve"
+	Sharpen       string            `json:"sharpen,omitempty"`       // output sharpening preset, e.g. "screen_standard"
 	OutputMode    string            `json:"outputMode,omitempty"`    // "save" (default) or "download"
 	OutputPath    string            `json:"outputPath,omitempty"`    // custom save path; empty = ~/.unterlumen/channels/<slug>/
 	GalleryExport bool              `json:"galleryExport,omitempty"` // generate index.html gallery on publish
 	SiteExport    bool              `json:"siteExport,omitempty"`    // generate multi-album static website on publish
 	SiteTitle     string            `json:"siteTitle,omitempty"`     // displayed on the root site index.html
 	SiteTheme     string            `json:"siteTheme,omitempty"`     // "light" (default) or "dark"
-	SiteURL          string            `json:"siteURL,omitempty"`          // optional base URL e.g. "https://example.com"; enables canonical, OG, sitemap
+	SiteURL          string            `json:"siteURL,omitempty"`          // optional base URL e.g. "https://example.com"; enables canonical, OG, sitemap, feeds
 	SiteAbout        string            `json:"siteAbout,omitempty"`        // markdown text for about page; generates about.html when non-empty
 	SiteImprint      string            `json:"siteImprint,omitempty"`      // markdown text for legal/imprint page; generates legal.html when non-empty
 	SiteContactEmail string            `json:"siteContactEmail,omitempty"` // shown in footer of every site page
 	SiteContactURL   string            `json:"siteContactURL,omitempty"`   // shown in footer of every site page
+	SitePhotoExif    bool              `json:"sitePhotoExif,omitempty"`    // show camera and exposure on per-photo pages
+	SiteMap          bool              `json:"siteMap,omitempty"`          // add a map page of rounded photo locations
+	RenditionWidths  []int             `json:"renditionWidths,omitempty"`  // gallery/site: extra resized copies for srcset, e.g. 480, 960, 1600, 2560
+	SiteTemplateDir  string            `json:"siteTemplateDir,omitempty"`  // gallery/site: folder overriding built-in page templates and assets; must be absolute
+	Watermark        *media.Watermark  `json:"watermark,omitempty"`        // text or PNG logo overlay; ImagePath must be absolute
+	Artist           string            `json:"artist,omitempty"`           // written to EXIF Artist / XMP dc:creator
+	Copyright        string            `json:"copyright,omitempty"`        // written to EXIF Copyright / XMP dc:rights
+	UsageTerms       string            `json:"usageTerms,omitempty"`       // written to XMP xmpRights:UsageTerms
+	NamePattern      string            `json:"namePattern,omitempty"`      // batch-rename tokens, "/" for subfolders; empty = <slug>_<timestamp>_<name>
 }
 
-// ExportOptions returns the media.ExportOptions for this channel.
+// ExportOptions returns the media.ExportOptions for this channel. Privacy
+// zones are global settings and must be attached by the caller.
 func (c *Channel) ExportOptions() media.ExportOptions {
 	return media.ExportOptions{
 		Format:   c.Format,
 		Quality:  c.Quality,
 		Scale:    c.Scale,
 		ExifMode: c.ExifMode,
+
+		ColorMode:  c.ColorMode,
+		Sharpen:    c.Sharpen,
+		Watermark:  c.Watermark,
+		Artist:     c.Artist,
+		Copyright:  c.Copyright,
+		UsageTerms: c.UsageTerms,
+
+		MaxBytes:       c.MaxBytes,
+		AllowDownscale: c.AllowDownscale,
+	}
+}
+
+// MaxRenditionWidths caps RenditionWidths; every width is exported in up to
+// three formats per photo.
+const MaxRenditionWidths = 6
+
+// Validate checks settings that would otherwise only fail at publish time.
+func (c *Channel) Validate() error {
+	if err := media.ValidateResampling(c.ExportOptions()); err != nil {
+		return err
+	}
+	if c.MaxBytes < 0 {
+		return fmt.Errorf("maxBytes must not be negative")
+	}
+	if err := c.Watermark.Validate(); err != nil {
+		return err
+	}
+	if c.Watermark != nil && c.Watermark.ImagePath != "" && !filepath.IsAbs(c.Watermark.ImagePath) {
+		return fmt.Errorf("watermark: image path must be absolute")
+	}
+	if c.SiteTemplateDir != "" && !filepath.IsAbs(c.SiteTemplateDir) {
+		return fmt.Errorf("site template dir must be absolute")
+	}
+	if len(c.RenditionWidths) > MaxRenditionWidths {
+		return fmt.Errorf("at most %d rendition widths", MaxRenditionWidths)
+	}
+	for _, w := range c.RenditionWidths {
+		if w < 100 || w > 8000 {
+			return fmt.Errorf("rendition width %d out of range (100–8000)", w)
+		}
 	}
+	return c.validateHandler()
 }
 
 // AccountByID returns the account with the given ID, or nil.
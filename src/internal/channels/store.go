@@ -14,7 +14,8 @@ var builtinChannels = []*Channel{
 	{
 		Slug: "instagram", Name: "Instagram",
 		Format: "jpeg", Quality: 90, ExifMode: "keep_no_gps",
-		Scale: media.ScaleOptions{Mode: media.ScaleModeMaxDim, MaxDimension: "width", MaxValue: 1080},
+		Scale:   media.ScaleOptions{Mode: media.ScaleModeMaxDim, MaxDimension: "width", MaxValue: 1080, Filter: media.FilterLanczos3},
+		Sharpen: media.SharpenScreenStandard,
 	},
 	{
 		Slug: "mastodon", Name: "Mastodon",
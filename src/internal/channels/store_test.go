@@ -3,6 +3,8 @@ package channels
 import (
 	"path/filepath"
 	"testing"
+
+	"huepattl.de/unterlumen/internal/media"
 )
 
 func TestNewStoreSeparatesConfigAndOutputDirs(t *testing.T) {
@@ -62,3 +64,48 @@ func TestOutputDirFallsBackToBuiltinChannel(t *testing.T) {
 		t.Fatalf("OutputDir = %q, want %q", got, wantOutputDir)
 	}
 }
+
+func TestChannelExportOptionsCarryWatermarkAndRights(t *testing.T) {
+	ch := &Channel{
+		Format: "jpeg", Quality: 85, ExifMode: "strip",
+		Watermark: &media.Watermark{Text: "© Jane"},
+		Artist:    "Jane", Copyright: "© 2026 Jane", UsageTerms: "All rights reserved",
+	}
+	if err := ch.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	opts := ch.ExportOptions()
+	if opts.Watermark == nil || opts.Watermark.Text != "© Jane" || opts.Artist != "Jane" ||
+		opts.Copyright != "© 2026 Jane" || opts.UsageTerms != "All rights reserved" {
+		t.Fatalf("ExportOptions = %+v", opts)
+	}
+
+	ch.Watermark = &media.Watermark{ImagePath: "logo.png"}
+	if ch.Validate() == nil {
+		t.Fatal("expected error for relative watermark image path")
+	}
+}
+
+func TestValidateRenditionWidths(t *testing.T) {
+	ch := &Channel{Format: "jpeg", GalleryExport: true, RenditionWidths: []int{480, 960, 1600}}
+	if err := ch.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, widths := range [][]int{{50}, {9000}, {100, 200, 300, 400, 500, 600, 700}} {
+		ch.RenditionWidths = widths
+		if ch.Validate() == nil {
+			t.Errorf("%v: expected an error", widths)
+		}
+	}
+}
+
+func TestValidateSiteTemplateDir(t *testing.T) {
+	ch := &Channel{Format: "jpeg", SiteExport: true, SiteTemplateDir: "themes/mine"}
+	if ch.Validate() == nil {
+		t.Fatal("expected error for relative template dir")
+	}
+	ch.SiteTemplateDir = filepath.Join(t.TempDir(), "mine")
+	if err := ch.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+// Package deploy copies a generated static site to where it is served from:
+// a local or mounted directory, or a remote host over SFTP. A manifest of
+// content hashes stored next to the deployed files lets each run upload only
+// changed files and delete files that no longer exist locally.
+package deploy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ManifestName is the manifest file written at the root of the target.
+const ManifestName = ".unterlumen-deploy.json"
+
+// Config keys, read from a site channel's handler config.
+const (
+	ConfigTarget     = "deploy"             // "dir" or "sftp"
+	ConfigDir        = "deploy_dir"         // dir: absolute target directory
+	ConfigHost       = "deploy_host"        // sftp: host name
+	ConfigPort       = "deploy_port"        // sftp: port; default 22
+	ConfigUser       = "deploy_user"        // sftp: login; default from ssh config
+	ConfigPath       = "deploy_path"        // sftp: remote directory; default the login directory
+	ConfigKey        = "deploy_key"         // sftp: private key file
+	ConfigKnownHosts = "deploy_known_hosts" // sftp: known_hosts file; default ~/.ssh/known_hosts
+)
+
+// ErrNotConfigured is returned by FromConfig when no deploy target is set.
+var ErrNotConfigured = errors.New("no deploy target configured")
+
+// Manifest maps slash-separated paths relative to the site root to the hex
+// SHA-256 of their content.
+type Manifest map[string]string
+
+// Plan lists what a deploy changes on the target.
+type Plan struct {
+	Upload  []string // files new or changed locally, sorted
+	Delete  []string // files gone locally, sorted
+	Mkdirs  []string // directories needed by Upload, parents first
+	Rmdirs  []string // directories left empty by Delete, children first
+	Current Manifest // the local manifest, written to the target last
+}
+
+// Progress reports one step of a deploy.
+type Progress struct {
+	Step  string `json:"step"` // "upload" or "delete"
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+	File  string `json:"file"`
+}
+
+// Target is a place a site is deployed to.
+type Target interface {
+	// ReadManifest returns the manifest of the last deploy, or an empty
+	// manifest when the target has never been deployed to.
+	ReadManifest(ctx context.Context) (Manifest, error)
+
+	// Apply carries out the plan, copying files from srcDir, and then
+	// writes the plan's manifest. progress may be nil.
+	Apply(ctx context.Context, srcDir string, plan Plan, progress func(Progress)) error
+
+	// String describes the target for messages, e.g. "sftp://host/path".
+	String() string
+}
+
+// Result summarises a deploy.
+type Result struct {
+	Target   string `json:"target"`
+	Uploaded int    `json:"uploaded"`
+	Deleted  int    `json:"deleted"`
+	Files    int    `json:"files"` // files on the target afterwards
+}
+
+// FromConfig returns the target configured in cfg.
+func FromConfig(cfg map[string]string) (Target, error) {
+	switch cfg[ConfigTarget] {
+	case "":
+		return nil, ErrNotConfigured
+	case "dir":
+		dir := cfg[ConfigDir]
+		if dir == "" || !filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("%s must be an absolute directory", ConfigDir)
+		}
+		return &DirTarget{Dir: dir}, nil
+	case "sftp":
+		t := &SFTPTarget{
+			Host:       cfg[ConfigHost],
+			User:       cfg[ConfigUser],
+			Path:       cfg[ConfigPath],
+			KeyFile:    cfg[ConfigKey],
+			KnownHosts: cfg[ConfigKnownHosts],
+		}
+		if t.Host == "" || strings.ContainsAny(t.Host, " @/") || strings.HasPrefix(t.Host, "-") {
+			return nil, fmt.Errorf("%s must be a host name", ConfigHost)
+		}
+		if strings.ContainsAny(t.User, " @") || strings.HasPrefix(t.User, "-") {
+			return nil, fmt.Errorf("invalid %s", ConfigUser)
+		}
+		if p := cfg[ConfigPort]; p != "" {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 1 || n > 65535 {
+				return nil, fmt.Errorf("invalid %s %q", ConfigPort, p)
+			}
+			t.Port = n
+		}
+		if t.KeyFile != "" && !filepath.IsAbs(t.KeyFile) {
+			return nil, fmt.Errorf("%s must be an absolute path", ConfigKey)
+		}
+		if t.KnownHosts != "" && !filepath.IsAbs(t.KnownHosts) {
+			return nil, fmt.Errorf("%s must be an absolute path", ConfigKnownHosts)
+		}
+		return t, nil
+	default:
+		return nil, fmt.Errorf("unknown %s target %q (want dir or sftp)", ConfigTarget, cfg[ConfigTarget])
+	}
+}
+
+// Options tune a deploy.
+type Options struct {
+	Exclude  []string       // slash paths relative to the site root that are not deployed
+	Progress func(Progress) // may be nil
+}
+
+// Run deploys srcDir to t.
+func Run(ctx context.Context, srcDir string, t Target, opts Options) (Result, error) {
+	local, err := BuildManifest(srcDir)
+	if err != nil {
+		return Result{}, err
+	}
+	for _, name := range opts.Exclude {
+		delete(local, name)
+	}
+	remote, err := t.ReadManifest(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("read manifest from %s: %w", t, err)
+	}
+	plan := Diff(local, remote)
+	if err := t.Apply(ctx, srcDir, plan, opts.Progress); err != nil {
+		return Result{}, err
+	}
+	return Result{Target: t.String(), Uploaded: len(plan.Upload), Deleted: len(plan.Delete), Files: len(local)}, nil
+}
+
+// BuildManifest hashes every regular file below dir. Hidden files (names
+// starting with ".") are skipped, which also keeps the manifest itself out.
+func BuildManifest(dir string) (Manifest, error) {
+	m := Manifest{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		m[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	return m, err
+}
+
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Diff compares the local manifest with the target's.
+func Diff(local, remote Manifest) Plan {
+	p := Plan{Current: local}
+	for name, sum := range local {
+		if remote[name] != sum {
+			p.Upload = append(p.Upload, name)
+		}
+	}
+	for name := range remote {
+		if _, ok := local[name]; !ok {
+			p.Delete = append(p.Delete, name)
+		}
+	}
+	sort.Strings(p.Upload)
+	sort.Strings(p.Delete)
+
+	// Directories that exist on the target: those of remote files.
+	remoteDirs := map[string]bool{}
+	for name := range remote {
+		for d := path.Dir(name); d != "."; d = path.Dir(d) {
+			remoteDirs[d] = true
+		}
+	}
+	localDirs := map[string]bool{}
+	for name := range local {
+		for d := path.Dir(name); d != "."; d = path.Dir(d) {
+			localDirs[d] = true
+		}
+	}
+	for d := range localDirs {
+		if !remoteDirs[d] && usedBy(d, p.Upload) {
+			p.Mkdirs = append(p.Mkdirs, d)
+		}
+	}
+	for d := range remoteDirs {
+		if !localDirs[d] {
+			p.Rmdirs = append(p.Rmdirs, d)
+		}
+	}
+	// Parents sort before their children; removal runs the other way round.
+	sort.Strings(p.Mkdirs)
+	sort.Sort(sort.Reverse(sort.StringSlice(p.Rmdirs)))
+	return p
+}
+
+// usedBy reports whether any of files lies below dir.
+func usedBy(dir string, files []string) bool {
+	for _, f := range files {
+		if strings.HasPrefix(f, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeManifest(m Manifest) ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+func decodeManifest(data []byte) (Manifest, error) {
+	m := Manifest{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	for name := range m {
+		if !fs.ValidPath(name) || name == "." {
+			return nil, fmt.Errorf("invalid manifest: bad path %q", name)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,192 @@
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeSite(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// listFiles returns the files below dir (slash paths) with their content.
+func listFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	got := map[string]string{}
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, _ := os.ReadFile(p)
+		rel, _ := filepath.Rel(dir, p)
+		got[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	return got
+}
+
+func TestDiff(t *testing.T) {
+	local := Manifest{"index.html": "b", "albums/new/a.jpg": "1", "albums/keep/a.jpg": "1"}
+	remote := Manifest{"index.html": "a", "albums/old/thumbs/a.jpg": "1", "albums/keep/a.jpg": "1"}
+	p := Diff(local, remote)
+
+	if want := []string{"albums/new/a.jpg", "index.html"}; !reflect.DeepEqual(p.Upload, want) {
+		t.Errorf("Upload = %v, want %v", p.Upload, want)
+	}
+	if want := []string{"albums/old/thumbs/a.jpg"}; !reflect.DeepEqual(p.Delete, want) {
+		t.Errorf("Delete = %v, want %v", p.Delete, want)
+	}
+	if want := []string{"albums/new"}; !reflect.DeepEqual(p.Mkdirs, want) {
+		t.Errorf("Mkdirs = %v, want %v", p.Mkdirs, want)
+	}
+	if want := []string{"albums/old/thumbs", "albums/old"}; !reflect.DeepEqual(p.Rmdirs, want) {
+		t.Errorf("Rmdirs = %v, want %v", p.Rmdirs, want)
+	}
+}
+
+// deployTwice deploys a site, changes it (one edit, one removed album, one
+// new file) and deploys again, checking the target mirrors the site and the
+// second run only touches what changed.
+func deployTwice(t *testing.T, target Target, targetDir string) {
+	t.Helper()
+	src := t.TempDir()
+	writeSite(t, src, map[string]string{
+		"index.html":              "v1",
+		"albums/a/index.html":     "a",
+		"albums/old/index.html":   "old",
+		"albums/old/thumbs/1.jpg": "jpeg",
+		".DS_Store":               "ignored",
+		"site.json":               "state",
+	})
+	opts := Options{Exclude: []string{"site.json"}}
+	res, err := Run(context.Background(), src, target, opts)
+	if err != nil {
+		t.Fatalf("first deploy: %v", err)
+	}
+	if res.Uploaded != 4 || res.Deleted != 0 {
+		t.Errorf("first deploy = %+v", res)
+	}
+
+	os.WriteFile(filepath.Join(src, "index.html"), []byte("v2"), 0o644)
+	os.RemoveAll(filepath.Join(src, "albums", "old"))
+	writeSite(t, src, map[string]string{"albums/b/index.html": "b"})
+
+	var steps []Progress
+	opts.Progress = func(p Progress) { steps = append(steps, p) }
+	res, err = Run(context.Background(), src, target, opts)
+	if err != nil {
+		t.Fatalf("second deploy: %v", err)
+	}
+	if res.Uploaded != 2 || res.Deleted != 2 || res.Files != 3 {
+		t.Errorf("second deploy = %+v", res)
+	}
+	if len(steps) != 4 || steps[0] != (Progress{Step: "upload", Done: 1, Total: 2, File: "albums/b/index.html"}) ||
+		steps[3] != (Progress{Step: "delete", Done: 2, Total: 2, File: "albums/old/thumbs/1.jpg"}) {
+		t.Errorf("progress = %+v", steps)
+	}
+
+	got := listFiles(t, targetDir)
+	delete(got, ManifestName)
+	want := map[string]string{"index.html": "v2", "albums/a/index.html": "a", "albums/b/index.html": "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("target files = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "albums", "old")); !os.IsNotExist(err) {
+		t.Error("removed album directory still on the target")
+	}
+}
+
+func TestDirTarget(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "www")
+	deployTwice(t, &DirTarget{Dir: dst}, dst)
+}
+
+// fakeSFTP is a shell script standing in for the sftp client: it runs the
+// batch file against the local file system, echoing commands like sftp -b.
+const fakeSFTP = `#!/bin/sh
+batch=$2
+while IFS= read -r line; do
+	echo "sftp> $line"
+	ignore=false
+	case $line in -*) ignore=true; line=${line#-};; esac
+	eval "set -- $line"
+	cmd=$1; shift
+	case $cmd in
+	put|get) cp "$1" "$2" 2>/dev/null ;;
+	mkdir) mkdir "$1" 2>/dev/null ;;
+	rm) rm "$1" 2>/dev/null ;;
+	rmdir) rmdir "$1" 2>/dev/null ;;
+	rename) mv "$1" "$2" ;;
+	*) false ;;
+	esac
+	status=$?
+	if [ $status -ne 0 ] && [ $ignore = false ]; then
+		echo "command failed: $cmd" >&2
+		exit 1
+	fi
+done < "$batch"
+`
+
+func TestSFTPTarget(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake sftp client is a shell script")
+	}
+	prog := filepath.Join(t.TempDir(), "sftp")
+	if err := os.WriteFile(prog, []byte(fakeSFTP), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	remote := filepath.Join(t.TempDir(), "srv", "my site")
+	target := &SFTPTarget{Host: "example.org", Path: remote, Program: prog}
+	deployTwice(t, target, remote)
+}
+
+func TestSFTPArgs(t *testing.T) {
+	target := &SFTPTarget{Host: "example.org", Port: 2222, User: "deploy", KeyFile: "/keys/id_ed25519", KnownHosts: "/keys/known_hosts"}
+	got := strings.Join(target.args("/tmp/b"), " ")
+	want := "-b /tmp/b -o BatchMode=yes -P 2222 -i /keys/id_ed25519 -o IdentitiesOnly=yes -o UserKnownHostsFile=/keys/known_hosts -- deploy@example.org"
+	if got != want {
+		t.Errorf("args = %s\nwant   %s", got, want)
+	}
+	if q := sftpQuote(`a "b"\c`); q != `"a \"b\"\\c"` {
+		t.Errorf("sftpQuote = %s", q)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	if _, err := FromConfig(map[string]string{}); err != ErrNotConfigured {
+		t.Errorf("empty config: err = %v", err)
+	}
+	for name, cfg := range map[string]map[string]string{
+		"unknown":      {ConfigTarget: "ftp"},
+		"relative dir": {ConfigTarget: "dir", ConfigDir: "www"},
+		"no host":      {ConfigTarget: "sftp"},
+		"option host":  {ConfigTarget: "sftp", ConfigHost: "-oProxyCommand=x"},
+		"port":         {ConfigTarget: "sftp", ConfigHost: "h", ConfigPort: "ssh"},
+		"key":          {ConfigTarget: "sftp", ConfigHost: "h", ConfigKey: "id_rsa"},
+	} {
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	tg, err := FromConfig(map[string]string{ConfigTarget: "sftp", ConfigHost: "h", ConfigUser: "u", ConfigPort: "22", ConfigPath: "/var/www"})
+	if err != nil {
+		t.Fatalf("FromConfig: %v", err)
+	}
+	if s := tg.String(); s != "sftp://u@h:22/var/www" {
+		t.Errorf("String = %s", s)
+	}
+}
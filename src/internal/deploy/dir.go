@@ -0,0 +1,110 @@
+package deploy
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DirTarget deploys to a directory on this machine, e.g. a web server's
+// document root or an NFS mount.
+type DirTarget struct {
+	Dir string
+}
+
+func (t *DirTarget) String() string { return t.Dir }
+
+// ReadManifest reads the manifest left by the previous deploy.
+func (t *DirTarget) ReadManifest(ctx context.Context) (Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(t.Dir, ManifestName))
+	if os.IsNotExist(err) {
+		return Manifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeManifest(data)
+}
+
+// Apply copies changed files (via a temporary file and rename, so readers
+// never see a half-written file), deletes removed ones and writes the manifest.
+func (t *DirTarget) Apply(ctx context.Context, srcDir string, plan Plan, progress func(Progress)) error {
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return err
+	}
+	for i, name := range plan.Upload {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := copyFile(filepath.Join(srcDir, filepath.FromSlash(name)), filepath.Join(t.Dir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(Progress{Step: "upload", Done: i + 1, Total: len(plan.Upload), File: name})
+		}
+	}
+	for i, name := range plan.Delete {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(t.Dir, filepath.FromSlash(name))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if progress != nil {
+			progress(Progress{Step: "delete", Done: i + 1, Total: len(plan.Delete), File: name})
+		}
+	}
+	for _, d := range plan.Rmdirs {
+		os.Remove(filepath.Join(t.Dir, filepath.FromSlash(d))) //nolint:errcheck // not empty: other files live there
+	}
+	data, err := encodeManifest(plan.Current)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(filepath.Join(t.Dir, ManifestName), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return writeAtomic(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+}
+
+func writeAtomic(dst string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(dst), ".deploy-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package deploy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SFTPTarget deploys over SFTP with the OpenSSH sftp client, which must be
+// installed. Authentication is by key only: the client runs in batch mode, so
+// it never prompts for passwords or unknown host keys.
+type SFTPTarget struct {
+	Host       string
+	Port       int    // 0 = 22
+	User       string // "" = from ssh config
+	Path       string // remote directory; "" = the login directory
+	KeyFile    string // private key; "" = ssh defaults and agent
+	KnownHosts string // known_hosts file; "" = ~/.ssh/known_hosts
+
+	// Program is the sftp executable; "" = "sftp". Tests substitute a fake.
+	Program string
+}
+
+func (t *SFTPTarget) String() string {
+	host := t.Host
+	if t.User != "" {
+		host = t.User + "@" + host
+	}
+	if t.Port != 0 {
+		host += ":" + strconv.Itoa(t.Port)
+	}
+	return "sftp://" + host + "/" + strings.TrimPrefix(t.Path, "/")
+}
+
+// ReadManifest downloads the manifest of the previous deploy. A missing
+// manifest (first deploy) yields an empty one, so everything is uploaded.
+func (t *SFTPTarget) ReadManifest(ctx context.Context) (Manifest, error) {
+	tmp, err := os.MkdirTemp("", "unterlumen-deploy-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	local := filepath.Join(tmp, "manifest.json")
+	script := "-get " + sftpQuote(t.remote(ManifestName)) + " " + sftpQuote(local) + "\n"
+	if err := t.run(ctx, script, nil); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(local)
+	if os.IsNotExist(err) {
+		return Manifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeManifest(data)
+}
+
+// Apply runs the whole plan as one sftp batch, so there is a single SSH
+// connection per deploy. Progress is taken from the commands sftp echoes.
+func (t *SFTPTarget) Apply(ctx context.Context, srcDir string, plan Plan, progress func(Progress)) error {
+	tmp, err := os.MkdirTemp("", "unterlumen-deploy-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	data, err := encodeManifest(plan.Current)
+	if err != nil {
+		return err
+	}
+	manifest := filepath.Join(tmp, ManifestName)
+	if err := os.WriteFile(manifest, data, 0o600); err != nil {
+		return err
+	}
+	script, steps := t.script(srcDir, plan, manifest)
+	return t.run(ctx, script, func(n int) {
+		if progress != nil && n < len(steps) && steps[n].Step != "" {
+			progress(steps[n])
+		}
+	})
+}
+
+// script builds the sftp batch for a plan. Commands prefixed with "-" may
+// fail without aborting the batch (existing directories, files already
+// gone). Uploads go to a temporary name first and are renamed into place,
+// so the web server never serves a half-written file. steps holds the
+// progress to report after each command, by index.
+func (t *SFTPTarget) script(srcDir string, plan Plan, manifest string) (string, []Progress) {
+	var b strings.Builder
+	var steps []Progress
+	add := func(p Progress, format string, args ...any) {
+		fmt.Fprintf(&b, format+"\n", args...)
+		steps = append(steps, p)
+	}
+	if t.Path != "" {
+		// Create the root and its parents; sftp has no mkdir -p.
+		var parts []string
+		for d := strings.TrimSuffix(t.Path, "/"); d != "" && d != "/" && d != "."; d = path.Dir(d) {
+			parts = append(parts, d)
+		}
+		for i := len(parts) - 1; i >= 0; i-- {
+			add(Progress{}, "-mkdir %s", sftpQuote(parts[i]))
+		}
+	}
+	for _, d := range plan.Mkdirs {
+		add(Progress{}, "-mkdir %s", sftpQuote(t.remote(d)))
+	}
+	for i, name := range plan.Upload {
+		part := t.remote(path.Join(path.Dir(name), ".deploy-"+path.Base(name)))
+		add(Progress{}, "put %s %s", sftpQuote(filepath.Join(srcDir, filepath.FromSlash(name))), sftpQuote(part))
+		add(Progress{}, "-rm %s", sftpQuote(t.remote(name)))
+		add(Progress{Step: "upload", Done: i + 1, Total: len(plan.Upload), File: name}, "rename %s %s", sftpQuote(part), sftpQuote(t.remote(name)))
+	}
+	for i, name := range plan.Delete {
+		add(Progress{Step: "delete", Done: i + 1, Total: len(plan.Delete), File: name}, "-rm %s", sftpQuote(t.remote(name)))
+	}
+	for _, d := range plan.Rmdirs {
+		add(Progress{}, "-rmdir %s", sftpQuote(t.remote(d)))
+	}
+	add(Progress{}, "put %s %s", sftpQuote(manifest), sftpQuote(t.remote(ManifestName)))
+	return b.String(), steps
+}
+
+// remote returns the remote path of a site-relative name.
+func (t *SFTPTarget) remote(name string) string {
+	if t.Path == "" {
+		return name
+	}
+	return path.Join(t.Path, name)
+}
+
+func (t *SFTPTarget) args(batch string) []string {
+	args := []string{"-b", batch, "-o", "BatchMode=yes"}
+	if t.Port != 0 {
+		args = append(args, "-P", strconv.Itoa(t.Port))
+	}
+	if t.KeyFile != "" {
+		args = append(args, "-i", t.KeyFile, "-o", "IdentitiesOnly=yes")
+	}
+	if t.KnownHosts != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+t.KnownHosts)
+	}
+	host := t.Host
+	if t.User != "" {
+		host = t.User + "@" + host
+	}
+	return append(args, "--", host)
+}
+
+// run executes a batch script. In batch mode sftp echoes every command as
+// "sftp> <command>" before running it; step is called with the index of the
+// previous command each time a new one starts, and once more at the end.
+func (t *SFTPTarget) run(ctx context.Context, script string, step func(n int)) error {
+	f, err := os.CreateTemp("", "unterlumen-sftp-*.batch")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(script); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	prog := t.Program
+	if prog == "" {
+		prog = "sftp"
+	}
+	cmd := exec.CommandContext(ctx, prog, t.args(f.Name())...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("sftp client not found; install OpenSSH to deploy over SFTP")
+		}
+		return err
+	}
+	n := -1
+	sc := bufio.NewScanner(stdout)
+	for sc.Scan() {
+		if strings.HasPrefix(sc.Text(), "sftp> ") {
+			if n >= 0 && step != nil {
+				step(n)
+			}
+			n++
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+			msg = msg[i+1:]
+		}
+		return fmt.Errorf("sftp %s: %w: %s", t, err, msg)
+	}
+	if n >= 0 && step != nil {
+		step(n)
+	}
+	return nil
+}
+
+// sftpQuote quotes an argument for an sftp batch file, which splits on
+// spaces and understands double quotes with backslash escapes.
+func sftpQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
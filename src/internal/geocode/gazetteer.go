@@ -0,0 +1,202 @@
+// Package geocode provides offline reverse geocoding against a local GeoNames
+// gazetteer. No network calls are made; without a gazetteer on disk every
+// lookup simply reports no result.
+package geocode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MaxDistanceKm is the search radius for Lookup. Coordinates further than this
+// from every known place (open sea, polar regions) are left unresolved rather
+// than attributed to a distant town.
+const MaxDistanceKm = 50.0
+
+const earthRadiusKm = 6371.0
+
+// Place is the resolved location of a coordinate.
+type Place struct {
+	City        string `json:"city"`
+	Region      string `json:"region,omitempty"`  // first-level administrative division (state, province)
+	Country     string `json:"country,omitempty"` // country name; falls back to the ISO code
+	CountryCode string `json:"countryCode,omitempty"`
+}
+
+type city struct {
+	name        string
+	lat, lon    float64
+	countryCode string
+	admin1      string
+}
+
+type cellKey struct{ lat, lon int }
+
+// Gazetteer is an in-memory nearest-place index. A nil *Gazetteer is valid and
+// resolves nothing, so callers need no special case when no dump is installed.
+type Gazetteer struct {
+	cells     map[cellKey][]city
+	regions   map[string]string // "CC.admin1" → name
+	countries map[string]string // "CC" → name
+	size      int
+}
+
+// Len returns the number of places in the gazetteer.
+func (g *Gazetteer) Len() int {
+	if g == nil {
+		return 0
+	}
+	return g.size
+}
+
+// Load reads a GeoNames dump from dir. It uses the most detailed cities file
+// present (cities500.txt before cities1000.txt, cities5000.txt, cities15000.txt)
+// and, when available, admin1CodesASCII.txt and countryInfo.txt for region and
+// country names. Returns (nil, nil) when dir contains no cities file.
+func Load(dir string) (*Gazetteer, error) {
+	citiesPath := findCitiesFile(dir)
+	if citiesPath == "" {
+		return nil, nil
+	}
+	f, err := os.Open(citiesPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	g := &Gazetteer{cells: make(map[cellKey][]city)}
+	if err := g.readCities(f); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(citiesPath), err)
+	}
+	g.regions = readCodeNames(filepath.Join(dir, "admin1CodesASCII.txt"), 0, 1)
+	g.countries = readCodeNames(filepath.Join(dir, "countryInfo.txt"), 0, 4)
+	return g, nil
+}
+
+func findCitiesFile(dir string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "cities*.txt"))
+	best, bestPop := "", math.MaxInt
+	for _, m := range matches {
+		pop, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), "cities"), ".txt"))
+		if err == nil && pop < bestPop {
+			best, bestPop = m, pop
+		}
+	}
+	return best
+}
+
+// readCities parses the GeoNames "geoname" table (tab-separated, 19 columns):
+// 1 name, 4 latitude, 5 longitude, 8 country code, 10 admin1 code.
+func (g *Gazetteer) readCities(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		cols := strings.Split(sc.Text(), "\t")
+		if len(cols) < 11 {
+			continue
+		}
+		lat, err1 := strconv.ParseFloat(cols[4], 64)
+		lon, err2 := strconv.ParseFloat(cols[5], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		c := city{name: cols[1], lat: lat, lon: lon, countryCode: cols[8], admin1: cols[10]}
+		k := cellFor(lat, lon)
+		g.cells[k] = append(g.cells[k], c)
+		g.size++
+	}
+	return sc.Err()
+}
+
+// readCodeNames reads a tab-separated lookup file into code → name, skipping
+// '#' comment lines. Missing files yield an empty map.
+func readCodeNames(path string, codeCol, nameCol int) map[string]string {
+	out := make(map[string]string)
+	f, err := os.Open(path)
+	if err != nil {
+		return out
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if len(cols) > nameCol && cols[codeCol] != "" {
+			out[cols[codeCol]] = cols[nameCol]
+		}
+	}
+	return out
+}
+
+func cellFor(lat, lon float64) cellKey {
+	return cellKey{int(math.Floor(lat)), int(math.Floor(lon))}
+}
+
+// Lookup returns the place nearest to (lat, lon) within MaxDistanceKm.
+func (g *Gazetteer) Lookup(lat, lon float64) (Place, bool) {
+	if g == nil || g.size == 0 {
+		return Place{}, false
+	}
+	best, bestDist := city{}, math.Inf(1)
+	k := cellFor(lat, lon)
+	// One-degree cells are ≥ ~111 km tall, so the 3×3 neighbourhood always
+	// covers MaxDistanceKm in latitude; longitude cells shrink towards the
+	// poles, so widen the search accordingly.
+	lonSpan := 180
+	if c := math.Cos(lat * math.Pi / 180); c > 0.01 {
+		lonSpan = min(180, int(math.Ceil(MaxDistanceKm/(111.0*c))))
+	}
+	for dLat := -1; dLat <= 1; dLat++ {
+		for dLon := -lonSpan; dLon <= lonSpan; dLon++ {
+			cell := cellKey{k.lat + dLat, wrapLon(k.lon + dLon)}
+			for _, c := range g.cells[cell] {
+				if d := haversineKm(lat, lon, c.lat, c.lon); d < bestDist {
+					best, bestDist = c, d
+				}
+			}
+		}
+	}
+	if bestDist > MaxDistanceKm {
+		return Place{}, false
+	}
+	return g.placeFor(best), true
+}
+
+func (g *Gazetteer) placeFor(c city) Place {
+	p := Place{City: c.name, CountryCode: c.countryCode, Country: c.countryCode}
+	if name, ok := g.countries[c.countryCode]; ok {
+		p.Country = name
+	}
+	if name, ok := g.regions[c.countryCode+"."+c.admin1]; ok {
+		p.Region = name
+	}
+	return p
+}
+
+func wrapLon(l int) int {
+	for l < -180 {
+		l += 360
+	}
+	for l >= 180 {
+		l -= 360
+	}
+	return l
+}
+
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,92 @@
+package geocode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// geonames rows: id, name, asciiname, altnames, lat, lon, fclass, fcode, cc, cc2, admin1, ...
+const testCities = "2867714\tMunich\tMunich\t\t48.13743\t11.57549\tP\tPPLA\tDE\t\t02\t\t\t\t1260391\t\t524\tEurope/Berlin\t2023-01-01\n" +
+	"2950159\tBerlin\tBerlin\t\t52.52437\t13.41053\tP\tPPLC\tDE\t\t16\t\t\t\t3426354\t\t74\tEurope/Berlin\t2023-01-01\n" +
+	"2193733\tAuckland\tAuckland\t\t-36.84853\t174.76349\tP\tPPLA\tNZ\t\tE7\t\t\t\t417910\t\t26\tPacific/Auckland\t2023-01-01\n" +
+	"4032243\tNuku'alofa\tNuku'alofa\t\t-21.13938\t-175.2018\tP\tPPLC\tTO\t\t\t\t\t\t22400\t\t\tPacific/Tongatapu\t2023-01-01\n"
+
+func writeDump(t *testing.T, withNames bool) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cities15000.txt"), []byte(testCities), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if withNames {
+		admin := "DE.02\tBavaria\tBavaria\t2951839\nDE.16\tBerlin\tBerlin\t2950157\n"
+		country := "#ISO\tISO3\tISO-Numeric\tfips\tCountry\n" +
+			"DE\tDEU\t276\tGM\tGermany\n"
+		os.WriteFile(filepath.Join(dir, "admin1CodesASCII.txt"), []byte(admin), 0o600) //nolint:errcheck
+		os.WriteFile(filepath.Join(dir, "countryInfo.txt"), []byte(country), 0o600)    //nolint:errcheck
+	}
+	return dir
+}
+
+func TestLoad_NoDump(t *testing.T) {
+	g, err := Load(t.TempDir())
+	if err != nil || g != nil {
+		t.Fatalf("expected nil gazetteer without error, got %v, %v", g, err)
+	}
+	if _, ok := g.Lookup(48, 11); ok {
+		t.Error("nil gazetteer must not resolve")
+	}
+}
+
+func TestLookup_NearestWithNames(t *testing.T) {
+	g, err := Load(writeDump(t, true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.Len() != 4 {
+		t.Fatalf("expected 4 places, got %d", g.Len())
+	}
+	p, ok := g.Lookup(48.2, 11.6) // a few km north of Munich
+	if !ok {
+		t.Fatal("expected a match near Munich")
+	}
+	want := Place{City: "Munich", Region: "Bavaria", Country: "Germany", CountryCode: "DE"}
+	if p != want {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+}
+
+func TestLookup_CodeFallback(t *testing.T) {
+	g, _ := Load(writeDump(t, false))
+	p, ok := g.Lookup(52.5, 13.4)
+	if !ok || p.City != "Berlin" || p.Country != "DE" || p.Region != "" {
+		t.Errorf("unexpected place %+v (ok=%v)", p, ok)
+	}
+}
+
+func TestLookup_TooFar(t *testing.T) {
+	g, _ := Load(writeDump(t, false))
+	if p, ok := g.Lookup(45.0, -30.0); ok { // mid-Atlantic
+		t.Errorf("expected no match, got %+v", p)
+	}
+}
+
+func TestLookup_Antimeridian(t *testing.T) {
+	g, _ := Load(writeDump(t, false))
+	// Just west of the antimeridian; Nuku'alofa sits at -175.2 — make sure the
+	// search wraps rather than stopping at ±180.
+	p, ok := g.Lookup(-21.2, -175.0)
+	if !ok || p.CountryCode != "TO" {
+		t.Errorf("unexpected place %+v (ok=%v)", p, ok)
+	}
+}
+
+func TestFindCitiesFile_PrefersMostDetailed(t *testing.T) {
+	dir := t.TempDir()
+	for _, n := range []string{"cities15000.txt", "cities500.txt", "cities1000.txt"} {
+		os.WriteFile(filepath.Join(dir, n), nil, 0o600) //nolint:errcheck
+	}
+	if got := filepath.Base(findCitiesFile(dir)); got != "cities500.txt" {
+		t.Errorf("got %s, want cities500.txt", got)
+	}
+}
@@ -0,0 +1,470 @@
+// Package jobs runs long-lived background tasks (e.g. large exports) whose
+// state is persisted on disk, so they survive restarts and closed browser tabs.
+//
+// Each job lives in its own directory <dir>/<id>/ holding job.json plus any
+// files the runner produces. Runners checkpoint their progress; a job that was
+// queued or running when the process stopped is resumed from its last
+// checkpoint on Start.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job statuses.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// DefaultRetention is how long finished jobs and their results are kept.
+const DefaultRetention = 7 * 24 * time.Hour
+
+// maxConcurrent is the number of jobs running at once. Runners parallelise
+// internally, so further jobs queue instead of competing for the CPU.
+const maxConcurrent = 1
+
+var (
+	ErrNotFound = errors.New("job not found")
+	ErrState    = errors.New("operation not allowed in the job's current state")
+)
+
+// Job is the persisted state of a background task.
+type Job struct {
+	ID         string          `json:"id"`
+	Kind       string          `json:"kind"`
+	Status     string          `json:"status"`
+	Params     json.RawMessage `json:"params"`
+	Total      int             `json:"total"`
+	Done       int             `json:"done"`
+	Current    string          `json:"current,omitempty"` // item last processed
+	State      json.RawMessage `json:"state,omitempty"`   // runner checkpoint, used to resume
+	Result     string          `json:"result,omitempty"`  // result file name inside the job dir
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
+	FinishedAt *time.Time      `json:"finishedAt,omitempty"`
+	ExpiresAt  *time.Time      `json:"expiresAt,omitempty"` // set once finished
+}
+
+func (j *Job) finished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+// Runner executes a job of one kind. It must return promptly with ctx.Err()
+// when ctx is cancelled, and should call Run.Checkpoint after each unit of work
+// so a resumed run can skip what is already done.
+type Runner func(ctx context.Context, run *Run) error
+
+// Manager owns all jobs under one directory.
+type Manager struct {
+	dir       string
+	retention time.Duration
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	runners map[string]Runner
+	active  map[string]*activeJob
+	slots   chan struct{}
+}
+
+type activeJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewManager loads the jobs persisted under dir.
+func NewManager(dir string, retention time.Duration) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	m := &Manager{
+		dir:       dir,
+		retention: retention,
+		jobs:      make(map[string]*Job),
+		runners:   make(map[string]Runner),
+		active:    make(map[string]*activeJob),
+		slots:     make(chan struct{}, maxConcurrent),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name(), "job.json"))
+		if err != nil {
+			continue
+		}
+		var j Job
+		if json.Unmarshal(data, &j) == nil && j.ID == e.Name() {
+			m.jobs[j.ID] = &j
+		}
+	}
+	return m, nil
+}
+
+// Register installs the runner for jobs of kind. Call before Start.
+func (m *Manager) Register(kind string, r Runner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runners[kind] = r
+}
+
+// Start resumes jobs interrupted by a shutdown, removes expired jobs and
+// prunes hourly until ctx is done.
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	var interrupted []*Job
+	for _, j := range m.jobs {
+		if !j.finished() {
+			interrupted = append(interrupted, j)
+		}
+	}
+	sort.Slice(interrupted, func(a, b int) bool { return interrupted[a].CreatedAt.Before(interrupted[b].CreatedAt) })
+	for _, j := range interrupted {
+		m.startLocked(j)
+	}
+	m.mu.Unlock()
+
+	m.Prune(time.Now())
+	go func() {
+		t := time.NewTicker(time.Hour)
+		defer t.Stop()
+		for {
+			select {
+			case now := <-t.C:
+				m.Prune(now)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Submit creates a job of kind with params and queues it.
+func (m *Manager) Submit(kind string, params any) (Job, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return Job{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.runners[kind] == nil {
+		return Job{}, fmt.Errorf("unknown job kind %q", kind)
+	}
+	now := time.Now().UTC()
+	j := &Job{ID: newID(), Kind: kind, Status: StatusQueued, Params: raw, CreatedAt: now, UpdatedAt: now}
+	if err := os.MkdirAll(m.jobDir(j.ID), 0o700); err != nil {
+		return Job{}, err
+	}
+	m.jobs[j.ID] = j
+	if err := m.saveLocked(j); err != nil {
+		delete(m.jobs, j.ID)
+		os.RemoveAll(m.jobDir(j.ID))
+		return Job{}, err
+	}
+	m.startLocked(j)
+	return *j, nil
+}
+
+// List returns all jobs, newest first.
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		out = append(out, *j)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].CreatedAt.After(out[b].CreatedAt) })
+	return out
+}
+
+// Get returns the job with id.
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return *j, nil
+}
+
+// Cancel stops a queued or running job. Its checkpoint is kept, so it can be
+// resumed later.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	j, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return ErrNotFound
+	}
+	if j.finished() {
+		m.mu.Unlock()
+		return ErrState
+	}
+	a := m.active[id]
+	if a == nil {
+		// Interrupted by a shutdown and not restarted yet.
+		j.Status = StatusCancelled
+		m.finishLocked(j)
+	}
+	m.mu.Unlock()
+	if a != nil {
+		a.cancel()
+		<-a.done
+	}
+	return nil
+}
+
+// Resume restarts a cancelled or failed job from its last checkpoint.
+func (m *Manager) Resume(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if j.Status != StatusCancelled && j.Status != StatusFailed {
+		return ErrState
+	}
+	j.Status = StatusQueued
+	j.Error = ""
+	j.FinishedAt, j.ExpiresAt = nil, nil
+	m.saveLocked(j) //nolint:errcheck
+	m.startLocked(j)
+	return nil
+}
+
+// Delete cancels the job if needed and removes it with all its files.
+func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	if _, ok := m.jobs[id]; !ok {
+		m.mu.Unlock()
+		return ErrNotFound
+	}
+	a := m.active[id]
+	m.mu.Unlock()
+	if a != nil {
+		a.cancel()
+		<-a.done
+	}
+	m.mu.Lock()
+	delete(m.jobs, id)
+	m.mu.Unlock()
+	return os.RemoveAll(m.jobDir(id))
+}
+
+// ResultPath returns the absolute path of a finished job's result file.
+func (m *Manager) ResultPath(id string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return "", ErrNotFound
+	}
+	if j.Status != StatusDone || j.Result == "" {
+		return "", ErrState
+	}
+	return filepath.Join(m.jobDir(id), j.Result), nil
+}
+
+// Prune deletes finished jobs whose retention period ended before now.
+func (m *Manager) Prune(now time.Time) {
+	m.mu.Lock()
+	var expired []string
+	for id, j := range m.jobs {
+		if j.finished() && j.ExpiresAt != nil && now.After(*j.ExpiresAt) {
+			expired = append(expired, id)
+		}
+	}
+	m.mu.Unlock()
+	for _, id := range expired {
+		m.Delete(id) //nolint:errcheck
+	}
+}
+
+// startLocked launches j's runner; it waits for a free slot while queued.
+func (m *Manager) startLocked(j *Job) {
+	runner := m.runners[j.Kind]
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &activeJob{cancel: cancel, done: make(chan struct{})}
+	m.active[j.ID] = a
+	if runner == nil {
+		j.Status = StatusFailed
+		j.Error = fmt.Sprintf("unknown job kind %q", j.Kind)
+		m.finishLocked(j)
+		delete(m.active, j.ID)
+		close(a.done)
+		cancel()
+		return
+	}
+
+	go func() {
+		defer close(a.done)
+		defer cancel()
+		select {
+		case m.slots <- struct{}{}:
+		case <-ctx.Done():
+			m.complete(j.ID, ctx.Err())
+			return
+		}
+		defer func() { <-m.slots }()
+
+		m.mu.Lock()
+		j.Status = StatusRunning
+		j.UpdatedAt = time.Now().UTC()
+		m.saveLocked(j) //nolint:errcheck
+		m.mu.Unlock()
+
+		err := runner(ctx, &Run{m: m, job: j})
+		if err == nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		m.complete(j.ID, err)
+	}()
+}
+
+func (m *Manager) complete(id string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.active, id)
+	j, ok := m.jobs[id]
+	if !ok {
+		return
+	}
+	switch {
+	case err == nil:
+		j.Status = StatusDone
+	case errors.Is(err, context.Canceled):
+		j.Status = StatusCancelled
+	default:
+		j.Status = StatusFailed
+		j.Error = err.Error()
+	}
+	m.finishLocked(j)
+}
+
+func (m *Manager) finishLocked(j *Job) {
+	now := time.Now().UTC()
+	expires := now.Add(m.retention)
+	j.UpdatedAt = now
+	j.FinishedAt = &now
+	j.ExpiresAt = &expires
+	m.saveLocked(j) //nolint:errcheck
+}
+
+// saveLocked writes job.json atomically (temp file + rename), so a crash
+// mid-write never leaves a truncated file behind.
+func (m *Manager) saveLocked(j *Job) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(m.jobDir(j.ID), "job.json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (m *Manager) jobDir(id string) string {
+	return filepath.Join(m.dir, id)
+}
+
+// Run is the runner's handle on its job.
+type Run struct {
+	m   *Manager
+	job *Job
+}
+
+// ID returns the job ID.
+func (r *Run) ID() string { return r.job.ID }
+
+// Dir returns the job's private directory for intermediate and result files.
+func (r *Run) Dir() string { return r.m.jobDir(r.job.ID) }
+
+// Params decodes the parameters the job was submitted with into v.
+func (r *Run) Params(v any) error {
+	return json.Unmarshal(r.job.Params, v)
+}
+
+// State decodes the last checkpoint into v. It reports false if the job has
+// not checkpointed yet (a fresh run).
+func (r *Run) State(v any) (bool, error) {
+	r.m.mu.Lock()
+	state := r.job.State
+	r.m.mu.Unlock()
+	if len(state) == 0 {
+		return false, nil
+	}
+	return true, json.Unmarshal(state, v)
+}
+
+// SetTotal sets the number of work items, for progress reporting.
+func (r *Run) SetTotal(total int) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.job.Total = total
+	r.job.UpdatedAt = time.Now().UTC()
+	r.m.saveLocked(r.job) //nolint:errcheck
+}
+
+// Checkpoint records progress (done items, the item just finished) and the
+// runner state needed to resume, and persists the job.
+func (r *Run) Checkpoint(done int, current string, state any) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.job.Done = done
+	r.job.Current = current
+	r.job.State = raw
+	r.job.UpdatedAt = time.Now().UTC()
+	return r.m.saveLocked(r.job)
+}
+
+// SetProgress updates the progress shown for the job without a checkpoint,
+// for work that cannot be resumed part-way (e.g. a single encoder run). It is
+// not persisted.
+func (r *Run) SetProgress(done int, current string) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.job.Done = done
+	r.job.Current = current
+	r.job.UpdatedAt = time.Now().UTC()
+}
+
+// SetResult names the result file (relative to Dir) offered for download.
+func (r *Run) SetResult(name string) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.job.Result = name
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,194 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func waitStatus(t *testing.T, m *Manager, id string, want string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		j, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if j.Status == want {
+			return j
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	j, _ := m.Get(id)
+	t.Fatalf("job %s: status %q, want %q", id, j.Status, want)
+	return j
+}
+
+type countParams struct {
+	N int `json:"n"`
+}
+
+type countState struct {
+	Next int `json:"next"`
+}
+
+// countRunner checkpoints after each item and writes result.txt when done.
+// It blocks on gate (if non-nil) before each item.
+func countRunner(gate chan struct{}, seen *[]int) Runner {
+	return func(ctx context.Context, run *Run) error {
+		var p countParams
+		if err := run.Params(&p); err != nil {
+			return err
+		}
+		var st countState
+		if _, err := run.State(&st); err != nil {
+			return err
+		}
+		run.SetTotal(p.N)
+		for i := st.Next; i < p.N; i++ {
+			if gate != nil {
+				select {
+				case <-gate:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			*seen = append(*seen, i)
+			st.Next = i + 1
+			if err := run.Checkpoint(st.Next, "", st); err != nil {
+				return err
+			}
+		}
+		if err := os.WriteFile(filepath.Join(run.Dir(), "result.txt"), []byte("ok"), 0o600); err != nil {
+			return err
+		}
+		run.SetResult("result.txt")
+		return nil
+	}
+}
+
+func TestSubmitRunsToCompletion(t *testing.T) {
+	m, err := NewManager(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen []int
+	m.Register("count", countRunner(nil, &seen))
+	j, err := m.Submit("count", countParams{N: 3})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	done := waitStatus(t, m, j.ID, StatusDone)
+	if done.Done != 3 || done.Total != 3 || done.ExpiresAt == nil {
+		t.Errorf("unexpected job state: %+v", done)
+	}
+	path, err := m.ResultPath(j.ID)
+	if err != nil {
+		t.Fatalf("ResultPath: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "ok" {
+		t.Errorf("result = %q", data)
+	}
+	if _, err := m.Submit("nope", nil); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
+
+func TestCancelAndResume(t *testing.T) {
+	m, err := NewManager(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gate := make(chan struct{})
+	var seen []int
+	m.Register("count", countRunner(gate, &seen))
+	j, _ := m.Submit("count", countParams{N: 4})
+	gate <- struct{}{}
+	gate <- struct{}{}
+	waitStatus(t, m, j.ID, StatusRunning)
+	if err := m.Cancel(j.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	cancelled := waitStatus(t, m, j.ID, StatusCancelled)
+	if cancelled.Done != 2 {
+		t.Errorf("done after cancel = %d, want 2", cancelled.Done)
+	}
+	if _, err := m.ResultPath(j.ID); !errors.Is(err, ErrState) {
+		t.Errorf("ResultPath of cancelled job: %v", err)
+	}
+
+	close(gate)
+	if err := m.Resume(j.ID); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	waitStatus(t, m, j.ID, StatusDone)
+	if len(seen) != 4 || seen[2] != 2 {
+		t.Errorf("items processed = %v, want each once", seen)
+	}
+	if err := m.Resume(j.ID); !errors.Is(err, ErrState) {
+		t.Errorf("Resume of done job: %v", err)
+	}
+}
+
+func TestInterruptedJobResumesOnStart(t *testing.T) {
+	dir := t.TempDir()
+	m, _ := NewManager(dir, time.Hour)
+	gate := make(chan struct{})
+	var seen []int
+	m.Register("count", countRunner(gate, &seen))
+	j, _ := m.Submit("count", countParams{N: 3})
+	gate <- struct{}{}
+	waitStatus(t, m, j.ID, StatusRunning)
+	for {
+		if cur, _ := m.Get(j.ID); cur.Done == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Simulate a restart: a second manager loads the same directory while the
+	// first one's job is still marked running on disk.
+	m2, err := NewManager(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen2 []int
+	m2.Register("count", countRunner(nil, &seen2))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m2.Start(ctx)
+	waitStatus(t, m2, j.ID, StatusDone)
+	if len(seen2) != 2 || seen2[0] != 1 {
+		t.Errorf("resumed run processed %v, want [1 2]", seen2)
+	}
+	m.Cancel(j.ID) //nolint:errcheck
+}
+
+func TestPruneAndDelete(t *testing.T) {
+	m, _ := NewManager(t.TempDir(), time.Minute)
+	var seen []int
+	m.Register("count", countRunner(nil, &seen))
+	a, _ := m.Submit("count", countParams{N: 1})
+	b, _ := m.Submit("count", countParams{N: 1})
+	waitStatus(t, m, a.ID, StatusDone)
+	waitStatus(t, m, b.ID, StatusDone)
+
+	if err := m.Delete(a.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(m.jobDir(a.ID)); !os.IsNotExist(err) {
+		t.Errorf("job dir still present after Delete")
+	}
+
+	m.Prune(time.Now())
+	if _, err := m.Get(b.ID); err != nil {
+		t.Errorf("job pruned before retention ended")
+	}
+	m.Prune(time.Now().Add(2 * time.Minute))
+	if _, err := m.Get(b.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("job not pruned after retention: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package library
+
+import (
+	"math"
+	"sort"
+)
+
+// GeoBBox restricts results to photos inside a latitude/longitude box.
+// MinLon > MaxLon denotes a box crossing the antimeridian.
+type GeoBBox struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+// GeoRadius restricts results to photos within Km of (Lat, Lon).
+type GeoRadius struct {
+	Lat, Lon, Km float64
+}
+
+// GeoCluster is one map marker: either a single photo (Count == 1) or an
+// aggregate of all matching photos in one grid cell at the requested zoom.
+type GeoCluster struct {
+	Lat       float64 `json:"lat"` // mean position of the clustered photos
+	Lon       float64 `json:"lon"`
+	Count     int     `json:"count"`
+	LibraryID string  `json:"libraryID"` // library of PhotoID
+	PhotoID   string  `json:"photoID"`   // most recent photo in the cluster; usable as cover thumbnail
+	DateTaken string  `json:"dateTaken,omitempty"`
+	cellLat   int
+	cellLon   int
+}
+
+const earthRadiusKm = 6371.0
+
+// geoConditions returns the WHERE fragments (on photos p) for opts.BBox and opts.Radius.
+func geoConditions(opts ListPhotosOpts) ([]string, []any) {
+	var where []string
+	var args []any
+	if b := opts.BBox; b != nil {
+		where = append(where, `p.lat BETWEEN ? AND ?`)
+		args = append(args, b.MinLat, b.MaxLat)
+		if b.MinLon <= b.MaxLon {
+			where = append(where, `p.lon BETWEEN ? AND ?`)
+			args = append(args, b.MinLon, b.MaxLon)
+		} else {
+			where = append(where, `(p.lon >= ? OR p.lon <= ?)`)
+			args = append(args, b.MinLon, b.MaxLon)
+		}
+	}
+	if r := opts.Radius; r != nil {
+		// Cheap bounding-box prefilter (uses photos_geo_idx), then exact great-circle distance.
+		dLat := r.Km / 111.0
+		where = append(where, `p.lat BETWEEN ? AND ?`)
+		args = append(args, r.Lat-dLat, r.Lat+dLat)
+		if c := math.Cos(r.Lat * math.Pi / 180); c > 0.01 && r.Km/(111.0*c) < 180 {
+			dLon := r.Km / (111.0 * c)
+			where = append(where, `(p.lon BETWEEN ? AND ? OR p.lon BETWEEN ? AND ? OR p.lon BETWEEN ? AND ?)`)
+			args = append(args, r.Lon-dLon, r.Lon+dLon, r.Lon-dLon+360, r.Lon+dLon+360, r.Lon-dLon-360, r.Lon+dLon-360)
+		}
+		where = append(where, `2 * ? * asin(min(1, sqrt(
+			power(sin(radians(p.lat - ?) / 2), 2) +
+			cos(radians(?)) * cos(radians(p.lat)) * power(sin(radians(p.lon - ?) / 2), 2)))) <= ?`)
+		args = append(args, earthRadiusKm, r.Lat, r.Lat, r.Lon, r.Km)
+	}
+	return where, args
+}
+
+// ClusterCellDeg returns the grid cell size in degrees for a web-map zoom level
+// (0 = whole world in one 256px tile). Cells are roughly 64px wide on screen.
+func ClusterCellDeg(zoom int) float64 {
+	zoom = max(0, min(zoom, 22))
+	return 360.0 / float64(int(1)<<zoom) / 4
+}
+
+// GeoClusters groups matching geotagged photos into grid cells of cellDeg
+// degrees. Filters, BBox and Radius in opts apply; Offset and Limit are ignored.
+func (s *Store) GeoClusters(opts ListPhotosOpts, cellDeg float64) ([]GeoCluster, error) {
+	fromSQL, whereSQL, args := buildPhotoFilter(opts)
+	// SQLite returns the bare columns (p.id, p.date_taken) from the row that
+	// holds MAX(...), so each cluster carries its most recent photo.
+	q := `SELECT CAST(floor(p.lat / ?) AS INTEGER) AS cy, CAST(floor(p.lon / ?) AS INTEGER) AS cx,
+		COUNT(*), AVG(p.lat), AVG(p.lon), MAX(COALESCE(p.date_taken, '')), p.id
+		` + fromSQL + ` WHERE p.lat IS NOT NULL AND p.lon IS NOT NULL AND ` + whereSQL + `
+		GROUP BY cy, cx`
+	rows, err := s.db.Query(q, append([]any{cellDeg, cellDeg}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []GeoCluster
+	for rows.Next() {
+		var c GeoCluster
+		if err := rows.Scan(&c.cellLat, &c.cellLon, &c.Count, &c.Lat, &c.Lon, &c.DateTaken, &c.PhotoID); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// mergeGeoClusters combines per-library clusters that fall into the same grid
+// cell, weighting the mean position by count and keeping the most recent photo.
+func mergeGeoClusters(clusters []GeoCluster) []GeoCluster {
+	type key struct{ lat, lon int }
+	byCell := make(map[key]*GeoCluster)
+	var order []key
+	for _, c := range clusters {
+		k := key{c.cellLat, c.cellLon}
+		m, ok := byCell[k]
+		if !ok {
+			cp := c
+			byCell[k] = &cp
+			order = append(order, k)
+			continue
+		}
+		total := float64(m.Count + c.Count)
+		m.Lat = (m.Lat*float64(m.Count) + c.Lat*float64(c.Count)) / total
+		m.Lon = (m.Lon*float64(m.Count) + c.Lon*float64(c.Count)) / total
+		m.Count += c.Count
+		if c.DateTaken > m.DateTaken {
+			m.PhotoID, m.LibraryID, m.DateTaken = c.PhotoID, c.LibraryID, c.DateTaken
+		}
+	}
+	out := make([]GeoCluster, 0, len(order))
+	for _, k := range order {
+		out = append(out, *byCell[k])
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Count > out[j].Count })
+	return out
+}
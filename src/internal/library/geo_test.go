@@ -0,0 +1,138 @@
+package library
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func insertGeoPhoto(t *testing.T, s *Store, id string, lat, lon float64, dateTaken string) {
+	t.Helper()
+	exifJSON := fmt.Sprintf(`{"latitude":%f,"longitude":%f}`, lat, lon)
+	if err := s.UpsertPhoto(id, "", id+".jpg", 0, time.Now(), exifJSON, "", dateTaken, "jpeg"); err != nil {
+		t.Fatalf("UpsertPhoto %s: %v", id, err)
+	}
+}
+
+func geoTestStore(t *testing.T) *Store {
+	s := newTestStore(t)
+	insertGeoPhoto(t, s, "munich", 48.137, 11.575, "2026-01-01T10:00:00")
+	insertGeoPhoto(t, s, "munich2", 48.150, 11.560, "2026-02-01T10:00:00")
+	insertGeoPhoto(t, s, "berlin", 52.520, 13.405, "2026-03-01T10:00:00")
+	insertGeoPhoto(t, s, "fiji", -17.0, 179.9, "")
+	insertGeoPhoto(t, s, "samoa", -13.8, -171.8, "")
+	if err := s.UpsertPhoto("nogps", "", "nogps.jpg", 0, time.Now(), "{}", "", "", "jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func listIDs(t *testing.T, s *Store, opts ListPhotosOpts) map[string]bool {
+	t.Helper()
+	opts.Limit = 100
+	res, err := s.ListPhotos(opts)
+	if err != nil {
+		t.Fatalf("ListPhotos: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, p := range res.Photos {
+		ids[p.ID] = true
+	}
+	return ids
+}
+
+func TestListPhotosBBox(t *testing.T) {
+	s := geoTestStore(t)
+	ids := listIDs(t, s, ListPhotosOpts{BBox: &GeoBBox{MinLat: 47, MinLon: 10, MaxLat: 49, MaxLon: 12}})
+	if len(ids) != 2 || !ids["munich"] || !ids["munich2"] {
+		t.Errorf("unexpected bbox result %v", ids)
+	}
+}
+
+func TestListPhotosBBoxAntimeridian(t *testing.T) {
+	s := geoTestStore(t)
+	ids := listIDs(t, s, ListPhotosOpts{BBox: &GeoBBox{MinLat: -20, MinLon: 170, MaxLat: -10, MaxLon: -170}})
+	if len(ids) != 2 || !ids["fiji"] || !ids["samoa"] {
+		t.Errorf("unexpected antimeridian bbox result %v", ids)
+	}
+}
+
+func TestListPhotosRadius(t *testing.T) {
+	s := geoTestStore(t)
+	ids := listIDs(t, s, ListPhotosOpts{Radius: &GeoRadius{Lat: 48.14, Lon: 11.58, Km: 5}})
+	if len(ids) != 2 || !ids["munich"] || !ids["munich2"] {
+		t.Errorf("unexpected 5 km result %v", ids)
+	}
+	// Munich–Berlin is ~505 km.
+	ids = listIDs(t, s, ListPhotosOpts{Radius: &GeoRadius{Lat: 48.14, Lon: 11.58, Km: 520}})
+	if len(ids) != 3 || !ids["berlin"] {
+		t.Errorf("unexpected 520 km result %v", ids)
+	}
+}
+
+func TestListPhotosReturnsCoordinates(t *testing.T) {
+	s := geoTestStore(t)
+	res, err := s.ListPhotos(ListPhotosOpts{Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range res.Photos {
+		switch p.ID {
+		case "nogps":
+			if p.Latitude != nil {
+				t.Errorf("nogps: expected nil latitude")
+			}
+		case "berlin":
+			if p.Latitude == nil || *p.Latitude != 52.52 {
+				t.Errorf("berlin: unexpected latitude %v", p.Latitude)
+			}
+		}
+	}
+}
+
+func TestGeoClusters(t *testing.T) {
+	s := geoTestStore(t)
+
+	// Zoom 0: cells are 90°, so both Munich photos and Berlin share one cell.
+	clusters, err := s.GeoClusters(ListPhotosOpts{}, ClusterCellDeg(0))
+	if err != nil {
+		t.Fatalf("GeoClusters: %v", err)
+	}
+	total := 0
+	for _, c := range clusters {
+		total += c.Count
+	}
+	if total != 5 {
+		t.Errorf("expected 5 geotagged photos across clusters, got %d", total)
+	}
+
+	// Zoom 12 inside a Munich viewport: the two Munich photos are ~2 km apart
+	// and must stay separate; the most recent photo is the representative.
+	opts := ListPhotosOpts{BBox: &GeoBBox{MinLat: 47, MinLon: 10, MaxLat: 49, MaxLon: 12}}
+	clusters, err = s.GeoClusters(opts, ClusterCellDeg(12))
+	if err != nil {
+		t.Fatalf("GeoClusters: %v", err)
+	}
+	if len(clusters) != 2 {
+		t.Errorf("expected 2 clusters at zoom 12, got %+v", clusters)
+	}
+	clusters, _ = s.GeoClusters(opts, ClusterCellDeg(6))
+	if len(clusters) != 1 || clusters[0].Count != 2 || clusters[0].PhotoID != "munich2" {
+		t.Errorf("expected one Munich cluster with munich2 as cover at zoom 6, got %+v", clusters)
+	}
+}
+
+func TestMergeGeoClusters(t *testing.T) {
+	merged := mergeGeoClusters([]GeoCluster{
+		{Lat: 10, Lon: 10, Count: 1, PhotoID: "a", LibraryID: "l1", DateTaken: "2026-01-01", cellLat: 1, cellLon: 1},
+		{Lat: 20, Lon: 20, Count: 3, PhotoID: "b", LibraryID: "l2", DateTaken: "2026-05-01", cellLat: 1, cellLon: 1},
+		{Lat: 50, Lon: 50, Count: 1, PhotoID: "c", LibraryID: "l1", cellLat: 9, cellLon: 9},
+	})
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 merged clusters, got %d", len(merged))
+	}
+	m := merged[0]
+	if m.Count != 4 || m.Lat != 17.5 || m.PhotoID != "b" || m.LibraryID != "l2" {
+		t.Errorf("unexpected merged cluster %+v", m)
+	}
+}
@@ -17,6 +17,7 @@ import (
 
 	_ "image/png"
 
+	"huepattl.de/unterlumen/internal/geocode"
 	"huepattl.de/unterlumen/internal/media"
 )
 
@@ -36,6 +37,7 @@ type Indexer struct {
 	libDir     string
 	sourcePath string
 	newPhotos  int
+	geo        *geocode.Gazetteer // optional; nil disables reverse geocoding
 }
 
 // NewIndexer creates an Indexer for the given store and source path.
@@ -43,6 +45,12 @@ func NewIndexer(store *Store, libDir, sourcePath string) *Indexer {
 	return &Indexer{store: store, libDir: libDir, sourcePath: sourcePath}
 }
 
+// WithGazetteer enables reverse geocoding of GPS coordinates into place fields.
+func (idx *Indexer) WithGazetteer(g *geocode.Gazetteer) *Indexer {
+	idx.geo = g
+	return idx
+}
+
 // IndexFile indexes a single file. Safe to call concurrently with other indexers
 // on the same library, but not with a concurrent full scan on the same Indexer.
 func (idx *Indexer) IndexFile(absPath string) error {
@@ -94,6 +102,7 @@ func (idx *Indexer) Run(ctx context.Context, progress chan<- Progress) {
 	}
 
 	idx.store.PurgeMissingPhotos() //nolint:errcheck
+	idx.backfillPlaces()
 
 	now := time.Now().UTC().Format(time.RFC3339)
 	idx.store.SetProp("last_indexed", now) //nolint:errcheck
@@ -153,6 +162,7 @@ func (idx *Indexer) RunScanNewInFolder(ctx context.Context, progress chan<- Prog
 			continue
 		}
 	}
+	idx.backfillPlaces()
 
 	now := time.Now().UTC().Format(time.RFC3339)
 	idx.store.SetProp("last_indexed", now) //nolint:errcheck
@@ -233,6 +243,7 @@ func (idx *Indexer) indexFile(absPath string) error {
 			dateTaken = *exifData.DateTaken
 			exifFields["DateTaken"] = dateTaken
 		}
+		idx.addPlaceFields(exifFields, exifData)
 		if b, err := json.Marshal(exifData); err == nil {
 			exifJSON = string(b)
 		}
@@ -289,6 +300,7 @@ func (idx *Indexer) forceReindexFile(absPath string) error {
 			dateTaken = *exifData.DateTaken
 			exifFields["DateTaken"] = dateTaken
 		}
+		idx.addPlaceFields(exifFields, exifData)
 		if b, err := json.Marshal(exifData); err == nil {
 			exifJSON = string(b)
 		}
@@ -398,12 +410,13 @@ func hashFile(path string) (string, error) {
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
-// indexSidecar reads XMP sidecar publications and title for absPath and upserts them into photo_meta.
+// indexSidecar reads XMP sidecar publications, title, caption and alt text for absPath
+// and upserts them into photo_meta.
 // Non-fatal: errors are silently ignored (sidecar may not exist).
 func (idx *Indexer) indexSidecar(absPath, photoID string) {
 	pubs, _ := media.ReadSidecar(absPath)
-	title, _ := media.ReadTitle(absPath)
-	if len(pubs) == 0 && title == "" {
+	captions, _ := media.ReadCaptions(absPath)
+	if len(pubs) == 0 && captions == (media.Captions{}) {
 		return
 	}
 
@@ -442,8 +455,14 @@ func (idx *Indexer) indexSidecar(absPath, photoID string) {
 		}
 	}
 
-	if title != "" {
-		idx.store.UpsertMeta(photoID, "title", title) //nolint:errcheck
+	if captions.Title != "" {
+		idx.store.UpsertMeta(photoID, "title", captions.Title) //nolint:errcheck
+	}
+	if captions.Description != "" {
+		idx.store.UpsertMeta(photoID, "description", captions.Description) //nolint:errcheck
+	}
+	if captions.AltText != "" {
+		idx.store.UpsertMeta(photoID, "alt", captions.AltText) //nolint:errcheck
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
@@ -12,6 +13,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"huepattl.de/unterlumen/internal/geocode"
 )
 
 // Manager manages the set of libraries rooted at a base directory.
@@ -25,6 +28,10 @@ type Manager struct {
 	exifRangesCache   sync.Map // map[cacheKey]map[string]ExifRange — invalidated on scan start/end
 	exifValuesCache   sync.Map // map[cacheKey+"|"+field][]string — invalidated on scan start/end
 	folderStatsCache  sync.Map // map["<libID>|<absPath>"]*LibraryFolderStats — invalidated on scan start/end
+	gazetteerOnce     sync.Once
+	gazetteer         *geocode.Gazetteer // nil when no GeoNames dump is installed
+	presetsMu         sync.Mutex         // serialises read-modify-write of export-presets.json
+	scheduleMu        sync.Mutex         // serialises read-modify-write of publish-queue.json
 }
 
 func statsCacheKey(ids []string, pathPrefix string) string {
@@ -164,6 +171,33 @@ func (m *Manager) LibDir(id string) string {
 	return filepath.Join(m.root, "libraries", id)
 }
 
+// GeoNamesDir returns the directory scanned for an offline GeoNames dump
+// (cities*.txt plus optional admin1CodesASCII.txt and countryInfo.txt).
+func (m *Manager) GeoNamesDir() string {
+	return filepath.Join(m.root, "geonames")
+}
+
+// Gazetteer returns the offline reverse-geocoding index, loading it from
+// GeoNamesDir on first use. Returns nil when no dump is installed; a dump added
+// later is picked up after a restart.
+func (m *Manager) Gazetteer() *geocode.Gazetteer {
+	m.gazetteerOnce.Do(func() {
+		g, err := geocode.Load(m.GeoNamesDir())
+		if err != nil {
+			log.Printf("Warning: GeoNames gazetteer could not be loaded: %v", err)
+			return
+		}
+		m.gazetteer = g
+	})
+	return m.gazetteer
+}
+
+// NewIndexer creates an Indexer for library id with reverse geocoding enabled
+// when a gazetteer is installed.
+func (m *Manager) NewIndexer(store *Store, id, sourcePath string) *Indexer {
+	return NewIndexer(store, m.LibDir(id), sourcePath).WithGazetteer(m.Gazetteer())
+}
+
 // getDB returns a cached *sql.DB for the library, opening and migrating it on first access.
 func (m *Manager) getDB(id string) (*sql.DB, error) {
 	if db, ok := m.openDBs.Load(id); ok {
@@ -443,7 +477,7 @@ func (m *Manager) TriggerScanNewBackground(id string) {
 	}()
 	go func() {
 		defer m.EndScan(id)
-		idx := NewIndexer(store, m.LibDir(id), libInfo.SourcePath)
+		idx := m.NewIndexer(store, id, libInfo.SourcePath)
 		idx.RunScanNew(context.Background(), rawCh)
 	}()
 }
@@ -466,7 +500,7 @@ func (m *Manager) IndexFilesSync(id string, absPaths []string) bool {
 	if err != nil || libInfo.SourcePath == "" {
 		return false
 	}
-	idx := NewIndexer(store, m.LibDir(id), libInfo.SourcePath)
+	idx := m.NewIndexer(store, id, libInfo.SourcePath)
 	for _, p := range absPaths {
 		_ = idx.IndexFile(p)
 	}
@@ -502,7 +536,7 @@ func (m *Manager) TriggerScanNewInFolderBackground(id, subfolder string) {
 	}()
 	go func() {
 		defer m.EndScan(id)
-		idx := NewIndexer(store, m.LibDir(id), libInfo.SourcePath)
+		idx := m.NewIndexer(store, id, libInfo.SourcePath)
 		idx.RunScanNewInFolder(context.Background(), rawCh, subfolder)
 	}()
 }
@@ -536,7 +570,7 @@ func (m *Manager) TriggerCleanupInFolderBackground(id, subfolder string) {
 	}()
 	go func() {
 		defer m.EndScan(id)
-		idx := NewIndexer(store, m.LibDir(id), libInfo.SourcePath)
+		idx := m.NewIndexer(store, id, libInfo.SourcePath)
 		idx.RunCleanupInFolder(context.Background(), rawCh, subfolder)
 	}()
 }
@@ -965,6 +999,34 @@ func (m *Manager) filterLibraries(ids []string) ([]*Library, error) {
 	return filtered, nil
 }
 
+// GeoClusters returns map markers for geotagged photos across the requested
+// libraries (or all if ids is nil), clustered on a grid of cellDeg degrees.
+// Clusters from different libraries in the same cell are merged.
+func (m *Manager) GeoClusters(ids []string, opts ListPhotosOpts, cellDeg float64) ([]GeoCluster, error) {
+	libs, err := m.filterLibraries(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []GeoCluster
+	for _, l := range libs {
+		store, err := m.OpenStore(l.ID)
+		if err != nil {
+			continue
+		}
+		clusters, err := store.GeoClusters(opts, cellDeg)
+		store.Close()
+		if err != nil {
+			return nil, err
+		}
+		for i := range clusters {
+			clusters[i].LibraryID = l.ID
+		}
+		all = append(all, clusters...)
+	}
+	return mergeGeoClusters(all), nil
+}
+
 // Statistics returns aggregated statistics across the requested libraries (or all if ids is nil).
 // pathPrefix, when non-empty, restricts each library's results to photos whose path starts with that prefix.
 func (m *Manager) Statistics(ids []string, pathPrefix string) (*LibraryStatistics, error) {
@@ -1003,8 +1065,12 @@ func (m *Manager) Statistics(ids []string, pathPrefix string) (*LibraryStatistic
 		Apertures:      []ValueCount{},
 		ISOs:           []ValueCount{},
 		CameraLens:     []CameraLensCount{},
+		Countries:      []NameCount{},
+		Cities:         []NameCount{},
 		ShootingDays:   make(map[string]int),
 	}
+	countryMap := make(map[string]int)
+	cityMap := make(map[string]int)
 	fmtMap    := make(map[string]int)
 	filmMap   := make(map[string]int)
 	clMap     := make(map[[2]string]int)
@@ -1041,6 +1107,12 @@ func (m *Manager) Statistics(ids []string, pathPrefix string) (*LibraryStatistic
 		for _, clc := range st.CameraLens {
 			clMap[[2]string{clc.Camera, clc.Lens}] += clc.Count
 		}
+		for _, nc := range st.Countries {
+			countryMap[nc.Name] += nc.Count
+		}
+		for _, nc := range st.Cities {
+			cityMap[nc.Name] += nc.Count
+		}
 		for h, n := range st.ShootingHours {
 			merged.ShootingHours[h] += n
 		}
@@ -1059,6 +1131,18 @@ func (m *Manager) Statistics(ids []string, pathPrefix string) (*LibraryStatistic
 	}
 	sortNameCounts(merged.FilmSims)
 
+	for name, count := range countryMap {
+		merged.Countries = append(merged.Countries, NameCount{Name: name, Count: count})
+	}
+	sortNameCounts(merged.Countries)
+	for name, count := range cityMap {
+		merged.Cities = append(merged.Cities, NameCount{Name: name, Count: count})
+	}
+	sortNameCounts(merged.Cities)
+	if len(merged.Cities) > 50 {
+		merged.Cities = merged.Cities[:50]
+	}
+
 	merged.FocalLengths   = mapToValueCounts(focalMap)
 	merged.FocalLengths35 = mapToValueCounts(focal35Map)
 	merged.Apertures      = mapToValueCounts(aperMap)
@@ -24,6 +24,8 @@ type Photo struct {
 	IndexedAt time.Time         `json:"indexedAt"`
 	DateTaken string            `json:"dateTaken,omitempty"`
 	Status    string            `json:"status"`
+	Latitude  *float64          `json:"latitude,omitempty"` // decimal degrees; nil when not geotagged
+	Longitude *float64          `json:"longitude,omitempty"`
 	Exif      map[string]string `json:"exif,omitempty"`
 	Meta      map[string]string `json:"meta,omitempty"`
 }
@@ -97,6 +99,8 @@ type LibraryStatistics struct {
 	Apertures      []ValueCount      `json:"apertures"`
 	ISOs           []ValueCount      `json:"isos"`
 	CameraLens     []CameraLensCount `json:"cameraLens"`
+	Countries      []NameCount       `json:"countries"` // reverse-geocoded; empty without a gazetteer
+	Cities         []NameCount       `json:"cities"`    // top 50, labelled "City, CC"
 	ShootingHours  [24]int           `json:"shootingHours"` // index = hour 0–23
 	ShootingDays   map[string]int    `json:"shootingDays"`  // "YYYY-MM-DD": count
 }
@@ -0,0 +1,118 @@
+package library
+
+import (
+	"time"
+
+	"huepattl.de/unterlumen/internal/geocode"
+	"huepattl.de/unterlumen/internal/media"
+)
+
+// Reverse-geocoded place fields stored in exif_index alongside the real EXIF
+// tags, so the existing text filters, value suggestions and statistics work on
+// them unchanged.
+const (
+	FieldCountry     = "Country"
+	FieldCountryCode = "CountryCode"
+	FieldRegion      = "Region"
+	FieldCity        = "City"
+)
+
+var placeFieldNames = []string{FieldCountry, FieldCountryCode, FieldRegion, FieldCity}
+
+// placeFields resolves lat/lon against g and returns the exif_index rows to
+// store. Returns nil when g is nil or nothing is within range.
+func placeFields(g *geocode.Gazetteer, lat, lon float64) map[string]string {
+	p, ok := g.Lookup(lat, lon)
+	if !ok {
+		return nil
+	}
+	fields := map[string]string{FieldCity: p.City, FieldCountry: p.Country, FieldCountryCode: p.CountryCode}
+	if p.Region != "" {
+		fields[FieldRegion] = p.Region
+	}
+	return fields
+}
+
+// addPlaceFields merges reverse-geocoded place fields into an indexer's EXIF field map.
+func (idx *Indexer) addPlaceFields(fields map[string]string, d *media.ExifData) {
+	if d == nil || d.Latitude == nil || d.Longitude == nil {
+		return
+	}
+	for k, v := range placeFields(idx.geo, *d.Latitude, *d.Longitude) {
+		fields[k] = v
+	}
+}
+
+// backfillPlaces geocodes photos that have GPS coordinates but no place fields —
+// photos indexed before a gazetteer was installed, or unchanged files skipped
+// by the mtime fast path.
+func (idx *Indexer) backfillPlaces() {
+	if idx.geo == nil {
+		return
+	}
+	coords, err := idx.store.PhotosMissingPlace()
+	if err != nil {
+		return
+	}
+	for id, c := range coords {
+		if fields := placeFields(idx.geo, c[0], c[1]); fields != nil {
+			idx.store.SetPlaceFields(id, fields) //nolint:errcheck
+		} else {
+			idx.store.MarkPlaceChecked(id) //nolint:errcheck
+		}
+	}
+}
+
+// PhotosMissingPlace returns id → {lat, lon} for photos with GPS coordinates
+// but no reverse-geocoded place fields, skipping photos for which an earlier
+// lookup found nothing in range.
+func (s *Store) PhotosMissingPlace() (map[string][2]float64, error) {
+	rows, err := s.db.Query(`
+		SELECT p.id, p.lat, p.lon
+		FROM photos p
+		WHERE p.status='ok'
+		  AND p.lat IS NOT NULL AND p.lon IS NOT NULL
+		  AND p.place_checked_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM exif_index e WHERE e.photo_id=p.id AND e.field=?)`, FieldCountryCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string][2]float64)
+	for rows.Next() {
+		var id string
+		var lat, lon float64
+		if err := rows.Scan(&id, &lat, &lon); err != nil {
+			return nil, err
+		}
+		out[id] = [2]float64{lat, lon}
+	}
+	return out, rows.Err()
+}
+
+// MarkPlaceChecked records that reverse geocoding found no place for the
+// photo. The mark is cleared when its coordinates change or on a forced re-index.
+func (s *Store) MarkPlaceChecked(photoID string) error {
+	_, err := s.db.Exec(`UPDATE photos SET place_checked_at=? WHERE id=?`, time.Now().UTC().Format(time.RFC3339), photoID)
+	return err
+}
+
+// SetPlaceFields replaces the place fields of one photo without touching its other EXIF rows.
+func (s *Store) SetPlaceFields(photoID string, fields map[string]string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, f := range placeFieldNames {
+		if _, err := tx.Exec(`DELETE FROM exif_index WHERE photo_id=? AND field=?`, photoID, f); err != nil {
+			return err
+		}
+	}
+	for k, v := range fields {
+		if _, err := tx.Exec(`INSERT INTO exif_index(photo_id,field,value) VALUES(?,?,?)`, photoID, k, v); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,118 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"huepattl.de/unterlumen/internal/geocode"
+)
+
+func loadTestGazetteer(t *testing.T) *geocode.Gazetteer {
+	t.Helper()
+	dir := t.TempDir()
+	cities := "2867714\tMunich\tMunich\t\t48.13743\t11.57549\tP\tPPLA\tDE\t\t02\t\t\t\t1260391\t\t524\tEurope/Berlin\t2023-01-01\n"
+	if err := os.WriteFile(filepath.Join(dir, "cities15000.txt"), []byte(cities), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "countryInfo.txt"), []byte("DE\tDEU\t276\tGM\tGermany\n"), 0o600) //nolint:errcheck
+	g, err := geocode.Load(dir)
+	if err != nil || g == nil {
+		t.Fatalf("load gazetteer: %v", err)
+	}
+	return g
+}
+
+// TestBackfillPlaces verifies that photos indexed without place fields are
+// geocoded after the fact and become filterable and countable by country.
+func TestBackfillPlaces(t *testing.T) {
+	s := newTestStore(t)
+	gpsJSON := `{"latitude":48.14,"longitude":11.58}`
+	if err := s.UpsertPhoto("gps", "", "gps.jpg", 0, time.Now(), gpsJSON, "", "", "jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpsertPhoto("nogps", "", "nogps.jpg", 0, time.Now(), "{}", "", "", "jpeg"); err != nil {
+		t.Fatal(err)
+	}
+
+	missing, err := s.PhotosMissingPlace()
+	if err != nil {
+		t.Fatalf("PhotosMissingPlace: %v", err)
+	}
+	if len(missing) != 1 {
+		t.Fatalf("expected 1 photo missing a place, got %d", len(missing))
+	}
+
+	idx := NewIndexer(s, t.TempDir(), "").WithGazetteer(loadTestGazetteer(t))
+	idx.backfillPlaces()
+
+	if missing, _ := s.PhotosMissingPlace(); len(missing) != 0 {
+		t.Errorf("expected no photos missing a place after backfill, got %d", len(missing))
+	}
+
+	res, err := s.ListPhotos(ListPhotosOpts{Filters: map[string]string{FieldCountry: "Germany"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListPhotos: %v", err)
+	}
+	if res.Total != 1 || res.Photos[0].ID != "gps" {
+		t.Errorf("expected only the geotagged photo, got %+v", res)
+	}
+
+	st, err := s.Statistics("")
+	if err != nil {
+		t.Fatalf("Statistics: %v", err)
+	}
+	if len(st.Countries) != 1 || st.Countries[0].Name != "Germany" || st.Countries[0].Count != 1 {
+		t.Errorf("unexpected countries %+v", st.Countries)
+	}
+	if len(st.Cities) != 1 || st.Cities[0].Name != "Munich, DE" {
+		t.Errorf("unexpected cities %+v", st.Cities)
+	}
+}
+
+func TestBackfillPlacesWithoutGazetteer(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.UpsertPhoto("gps", "", "gps.jpg", 0, time.Now(), `{"latitude":48.14,"longitude":11.58}`, "", "", "jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	NewIndexer(s, t.TempDir(), "").backfillPlaces()
+	if missing, _ := s.PhotosMissingPlace(); len(missing) != 1 {
+		t.Errorf("expected photo to stay unresolved without a gazetteer")
+	}
+}
+
+// TestBackfillPlacesSkipsPhotosOutOfRange verifies that a photo far from any
+// gazetteer entry is looked up once, and again only after it moved.
+func TestBackfillPlacesSkipsPhotosOutOfRange(t *testing.T) {
+	s := newTestStore(t)
+	atSea := `{"latitude":0.5,"longitude":-30.0}`
+	if err := s.UpsertPhoto("sea", "", "sea.jpg", 0, time.Now(), atSea, "", "", "jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	idx := NewIndexer(s, t.TempDir(), "").WithGazetteer(loadTestGazetteer(t))
+	idx.backfillPlaces()
+	if missing, _ := s.PhotosMissingPlace(); len(missing) != 0 {
+		t.Fatalf("out-of-range photo still pending after lookup: %v", missing)
+	}
+
+	// Re-indexing with the same coordinates keeps the mark.
+	if err := s.UpsertPhoto("sea", "", "sea.jpg", 0, time.Now(), atSea, "", "", "jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if missing, _ := s.PhotosMissingPlace(); len(missing) != 0 {
+		t.Errorf("unchanged photo pending again: %v", missing)
+	}
+
+	// New coordinates are looked up again.
+	if err := s.UpsertPhoto("sea", "", "sea.jpg", 0, time.Now(), `{"latitude":48.14,"longitude":11.58}`, "", "", "jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if missing, _ := s.PhotosMissingPlace(); len(missing) != 1 {
+		t.Fatalf("moved photo not pending: %v", missing)
+	}
+	idx.backfillPlaces()
+	if missing, _ := s.PhotosMissingPlace(); len(missing) != 0 {
+		t.Errorf("moved photo not geocoded: %v", missing)
+	}
+}
@@ -0,0 +1,184 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"huepattl.de/unterlumen/internal/media"
+)
+
+var (
+	// ErrPresetNotFound is returned for an unknown export preset name.
+	ErrPresetNotFound = errors.New("export preset not found")
+	// ErrPresetExists is returned when creating or renaming onto a taken name.
+	ErrPresetExists = errors.New("export preset already exists")
+)
+
+// ExportPreset is a named, reusable export configuration shared by the export
+// dialog and headless API clients.
+type ExportPreset struct {
+	Name        string              `json:"name"`
+	Options     media.ExportOptions `json:"options"`
+	NamePattern string              `json:"namePattern,omitempty"` // batch-rename tokens, "/" for subfolders
+	Destination string              `json:"destination,omitempty"` // default folder for /api/export/save
+}
+
+// Validate checks the preset name and options.
+func (p *ExportPreset) Validate() error {
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		return fmt.Errorf("preset name is required")
+	}
+	if strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("preset name must not contain slashes")
+	}
+	switch p.Options.Format {
+	case "", "jpeg", "png", "webp", "avif", "jxl":
+	default:
+		return fmt.Errorf("unknown format %q", p.Options.Format)
+	}
+	if p.Options.Quality < 0 || p.Options.Quality > 100 {
+		return fmt.Errorf("quality must be between 1 and 100")
+	}
+	if err := media.ValidateResampling(p.Options); err != nil {
+		return err
+	}
+	if p.Options.MaxBytes < 0 {
+		return fmt.Errorf("maxBytes must not be negative")
+	}
+	return p.Options.Watermark.Validate()
+}
+
+func (m *Manager) presetsPath() string {
+	return filepath.Join(m.root, "export-presets.json")
+}
+
+// ListPresets returns all export presets sorted by name. A missing file yields
+// no presets.
+func (m *Manager) ListPresets() ([]ExportPreset, error) {
+	m.presetsMu.Lock()
+	defer m.presetsMu.Unlock()
+	return m.readPresets()
+}
+
+// GetPreset returns the preset called name.
+func (m *Manager) GetPreset(name string) (*ExportPreset, error) {
+	presets, err := m.ListPresets()
+	if err != nil {
+		return nil, err
+	}
+	for i := range presets {
+		if presets[i].Name == name {
+			return &presets[i], nil
+		}
+	}
+	return nil, ErrPresetNotFound
+}
+
+// SavePreset creates or replaces the preset with p.Name.
+func (m *Manager) SavePreset(p ExportPreset) error {
+	return m.putPreset("", p, true)
+}
+
+// CreatePreset adds p, failing with ErrPresetExists if the name is taken.
+func (m *Manager) CreatePreset(p ExportPreset) error {
+	return m.putPreset("", p, false)
+}
+
+// UpdatePreset replaces the preset called name with p, renaming it when
+// p.Name differs. Renaming onto another preset fails with ErrPresetExists.
+func (m *Manager) UpdatePreset(name string, p ExportPreset) error {
+	return m.putPreset(name, p, false)
+}
+
+// putPreset stores p in a single read-modify-write. With name set, that
+// preset must exist and is replaced by p; otherwise an existing p.Name is
+// only replaced when overwrite is set.
+func (m *Manager) putPreset(name string, p ExportPreset, overwrite bool) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	p.Options.PrivacyZones = nil
+	m.presetsMu.Lock()
+	defer m.presetsMu.Unlock()
+	presets, err := m.readPresets()
+	if err != nil {
+		return err
+	}
+	old, taken := -1, -1
+	for i := range presets {
+		if name != "" && presets[i].Name == name {
+			old = i
+		}
+		if presets[i].Name == p.Name {
+			taken = i
+		}
+	}
+	switch {
+	case name != "" && old < 0:
+		return ErrPresetNotFound
+	case name != "" && taken >= 0 && taken != old:
+		return fmt.Errorf("%w: %s", ErrPresetExists, p.Name)
+	case name != "":
+		presets[old] = p
+	case taken >= 0 && !overwrite:
+		return fmt.Errorf("%w: %s", ErrPresetExists, p.Name)
+	case taken >= 0:
+		presets[taken] = p
+	default:
+		presets = append(presets, p)
+	}
+	return m.writePresets(presets)
+}
+
+// DeletePreset removes the preset called name.
+func (m *Manager) DeletePreset(name string) error {
+	m.presetsMu.Lock()
+	defer m.presetsMu.Unlock()
+	presets, err := m.readPresets()
+	if err != nil {
+		return err
+	}
+	for i := range presets {
+		if presets[i].Name == name {
+			return m.writePresets(append(presets[:i], presets[i+1:]...))
+		}
+	}
+	return ErrPresetNotFound
+}
+
+func (m *Manager) readPresets() ([]ExportPreset, error) {
+	data, err := os.ReadFile(m.presetsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return []ExportPreset{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var presets []ExportPreset
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("parse export presets: %w", err)
+	}
+	sort.Slice(presets, func(a, b int) bool { return presets[a].Name < presets[b].Name })
+	return presets, nil
+}
+
+// writePresets replaces the presets file atomically (temp file + rename).
+func (m *Manager) writePresets(presets []ExportPreset) error {
+	data, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := m.presetsPath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
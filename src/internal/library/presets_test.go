@@ -0,0 +1,99 @@
+package library
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"huepattl.de/unterlumen/internal/media"
+)
+
+func TestExportPresetsCRUD(t *testing.T) {
+	m, err := NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if ps, err := m.ListPresets(); err != nil || len(ps) != 0 {
+		t.Fatalf("ListPresets on empty dir = %v, %v", ps, err)
+	}
+
+	web := ExportPreset{Name: "Web", Options: media.ExportOptions{Format: "webp", Quality: 80}, NamePattern: "{YYYY}/{original}"}
+	if err := m.SavePreset(web); err != nil {
+		t.Fatalf("SavePreset: %v", err)
+	}
+	if err := m.SavePreset(ExportPreset{Name: "Archive", Options: media.ExportOptions{Format: "png"}}); err != nil {
+		t.Fatalf("SavePreset: %v", err)
+	}
+	web.Options.Quality = 70
+	if err := m.SavePreset(web); err != nil {
+		t.Fatalf("SavePreset update: %v", err)
+	}
+
+	ps, _ := m.ListPresets()
+	if len(ps) != 2 || ps[0].Name != "Archive" || ps[1].Name != "Web" {
+		t.Fatalf("ListPresets = %+v", ps)
+	}
+	got, err := m.GetPreset("Web")
+	if err != nil || got.Options.Quality != 70 || got.NamePattern != "{YYYY}/{original}" {
+		t.Errorf("GetPreset = %+v, %v", got, err)
+	}
+
+	if err := m.DeletePreset("Web"); err != nil {
+		t.Fatalf("DeletePreset: %v", err)
+	}
+	if _, err := m.GetPreset("Web"); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("GetPreset after delete: %v", err)
+	}
+	if err := m.DeletePreset("Web"); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("DeletePreset twice: %v", err)
+	}
+}
+
+func TestExportPresetsCreateAndRename(t *testing.T) {
+	m, err := NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	web := ExportPreset{Name: "Web", Options: media.ExportOptions{Format: "webp"}}
+	if err := m.CreatePreset(web); err != nil {
+		t.Fatalf("CreatePreset: %v", err)
+	}
+	if err := m.CreatePreset(web); !errors.Is(err, ErrPresetExists) {
+		t.Errorf("CreatePreset twice: %v", err)
+	}
+	m.CreatePreset(ExportPreset{Name: "Print", Options: media.ExportOptions{Format: "png"}}) //nolint:errcheck
+
+	web.Name = "Print"
+	if err := m.UpdatePreset("Web", web); !errors.Is(err, ErrPresetExists) {
+		t.Errorf("rename onto existing: %v", err)
+	}
+	web.Name = "Social"
+	if err := m.UpdatePreset("Web", web); err != nil {
+		t.Fatalf("UpdatePreset rename: %v", err)
+	}
+	ps, _ := m.ListPresets()
+	if len(ps) != 2 || ps[0].Name != "Print" || ps[1].Name != "Social" || ps[1].Options.Format != "webp" {
+		t.Errorf("after rename = %+v", ps)
+	}
+	if err := m.UpdatePreset("Web", web); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("UpdatePreset unknown: %v", err)
+	}
+	if _, err := os.Stat(m.presetsPath() + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+}
+
+func TestExportPresetValidate(t *testing.T) {
+	bad := []ExportPreset{
+		{Name: " "},
+		{Name: "a/b"},
+		{Name: "x", Options: media.ExportOptions{Format: "bmp"}},
+		{Name: "x", Options: media.ExportOptions{Quality: 101}},
+		{Name: "x", Options: media.ExportOptions{Watermark: &media.Watermark{Text: "c", Opacity: 2}}},
+	}
+	for _, p := range bad {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", p)
+		}
+	}
+}
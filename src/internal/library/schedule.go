@@ -0,0 +1,246 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Scheduled post statuses.
+const (
+	ScheduleQueued     = "scheduled"
+	SchedulePublishing = "publishing"
+	ScheduleFailed     = "failed"
+)
+
+var (
+	// ErrScheduledNotFound is returned for an unknown scheduled post ID.
+	ErrScheduledNotFound = errors.New("scheduled post not found")
+	// ErrScheduledBusy is returned when changing a post that is being published.
+	ErrScheduledBusy = errors.New("scheduled post is being published")
+)
+
+// ScheduledPost is a publication queued for a future time. The fields mirror
+// the body of POST /api/library/{id}/publish. Posts are removed from the queue
+// once published; failed ones stay until rescheduled or cancelled.
+type ScheduledPost struct {
+	ID           string    `json:"id"`
+	LibraryID    string    `json:"libraryID"`
+	PhotoIDs     []string  `json:"photoIDs"`
+	Channel      string    `json:"channel"`
+	Account      string    `json:"account,omitempty"`
+	GalleryTitle string    `json:"galleryTitle,omitempty"`
+	TargetPostID string    `json:"targetPostID,omitempty"`
+	Caption      string    `json:"caption,omitempty"`
+	RecordXMP    *bool     `json:"recordXMP,omitempty"`
+	OutputPath   string    `json:"outputPath,omitempty"`
+	ScheduledAt  time.Time `json:"scheduledAt"`
+	Status       string    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func (m *Manager) schedulePath() string {
+	return filepath.Join(m.root, "publish-queue.json")
+}
+
+// ListScheduled returns all queued posts, earliest first. A missing file
+// yields an empty queue.
+func (m *Manager) ListScheduled() ([]ScheduledPost, error) {
+	m.scheduleMu.Lock()
+	defer m.scheduleMu.Unlock()
+	return m.readSchedule()
+}
+
+// AddScheduled queues p with a new ID and returns the stored entry.
+func (m *Manager) AddScheduled(p ScheduledPost) (ScheduledPost, error) {
+	if len(p.PhotoIDs) == 0 || p.Channel == "" || p.LibraryID == "" {
+		return ScheduledPost{}, fmt.Errorf("libraryID, photoIDs and channel required")
+	}
+	id, err := newUUID()
+	if err != nil {
+		return ScheduledPost{}, err
+	}
+	now := time.Now().UTC()
+	p.ID = id
+	p.ScheduledAt = p.ScheduledAt.UTC()
+	p.Status = ScheduleQueued
+	p.Error = ""
+	p.CreatedAt, p.UpdatedAt = now, now
+
+	m.scheduleMu.Lock()
+	defer m.scheduleMu.Unlock()
+	posts, err := m.readSchedule()
+	if err != nil {
+		return ScheduledPost{}, err
+	}
+	if err := m.writeSchedule(append(posts, p)); err != nil {
+		return ScheduledPost{}, err
+	}
+	return p, nil
+}
+
+// RescheduleScheduled moves the post to at. A failed post is queued again.
+func (m *Manager) RescheduleScheduled(id string, at time.Time) (ScheduledPost, error) {
+	var out ScheduledPost
+	err := m.updateScheduled(id, func(p *ScheduledPost) error {
+		if p.Status == SchedulePublishing {
+			return ErrScheduledBusy
+		}
+		p.ScheduledAt = at.UTC()
+		p.Status = ScheduleQueued
+		p.Error = ""
+		out = *p
+		return nil
+	})
+	return out, err
+}
+
+// CancelScheduled removes the post from the queue.
+func (m *Manager) CancelScheduled(id string) error {
+	m.scheduleMu.Lock()
+	defer m.scheduleMu.Unlock()
+	posts, err := m.readSchedule()
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		if posts[i].ID == id {
+			if posts[i].Status == SchedulePublishing {
+				return ErrScheduledBusy
+			}
+			return m.writeSchedule(append(posts[:i], posts[i+1:]...))
+		}
+	}
+	return ErrScheduledNotFound
+}
+
+// ClaimDueScheduled marks queued posts due at or before now as publishing and
+// returns them, so they are not picked up twice.
+func (m *Manager) ClaimDueScheduled(now time.Time) ([]ScheduledPost, error) {
+	m.scheduleMu.Lock()
+	defer m.scheduleMu.Unlock()
+	posts, err := m.readSchedule()
+	if err != nil {
+		return nil, err
+	}
+	var due []ScheduledPost
+	for i := range posts {
+		if posts[i].Status == ScheduleQueued && !posts[i].ScheduledAt.After(now) {
+			posts[i].Status = SchedulePublishing
+			posts[i].UpdatedAt = time.Now().UTC()
+			due = append(due, posts[i])
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	if err := m.writeSchedule(posts); err != nil {
+		return nil, err
+	}
+	return due, nil
+}
+
+// FinishScheduled records the outcome of publishing a claimed post: it is
+// removed on success and marked failed with the error otherwise.
+func (m *Manager) FinishScheduled(id string, pubErr error) error {
+	if pubErr == nil {
+		m.scheduleMu.Lock()
+		defer m.scheduleMu.Unlock()
+		posts, err := m.readSchedule()
+		if err != nil {
+			return err
+		}
+		for i := range posts {
+			if posts[i].ID == id {
+				return m.writeSchedule(append(posts[:i], posts[i+1:]...))
+			}
+		}
+		return ErrScheduledNotFound
+	}
+	return m.updateScheduled(id, func(p *ScheduledPost) error {
+		p.Status = ScheduleFailed
+		p.Error = pubErr.Error()
+		return nil
+	})
+}
+
+// RecoverScheduled marks posts left publishing by a shutdown as failed. They
+// are not retried automatically, since part of the batch may already be
+// online; rescheduling them is up to the user.
+func (m *Manager) RecoverScheduled() error {
+	m.scheduleMu.Lock()
+	defer m.scheduleMu.Unlock()
+	posts, err := m.readSchedule()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i := range posts {
+		if posts[i].Status == SchedulePublishing {
+			posts[i].Status = ScheduleFailed
+			posts[i].Error = "interrupted by shutdown"
+			posts[i].UpdatedAt = time.Now().UTC()
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return m.writeSchedule(posts)
+}
+
+func (m *Manager) updateScheduled(id string, fn func(*ScheduledPost) error) error {
+	m.scheduleMu.Lock()
+	defer m.scheduleMu.Unlock()
+	posts, err := m.readSchedule()
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		if posts[i].ID == id {
+			if err := fn(&posts[i]); err != nil {
+				return err
+			}
+			posts[i].UpdatedAt = time.Now().UTC()
+			return m.writeSchedule(posts)
+		}
+	}
+	return ErrScheduledNotFound
+}
+
+func (m *Manager) readSchedule() ([]ScheduledPost, error) {
+	data, err := os.ReadFile(m.schedulePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return []ScheduledPost{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var posts []ScheduledPost
+	if err := json.Unmarshal(data, &posts); err != nil {
+		return nil, fmt.Errorf("parse publish queue: %w", err)
+	}
+	sort.SliceStable(posts, func(a, b int) bool { return posts[a].ScheduledAt.Before(posts[b].ScheduledAt) })
+	return posts, nil
+}
+
+// writeSchedule replaces the queue file atomically (temp file + rename), so a
+// crash mid-write never loses queued posts.
+func (m *Manager) writeSchedule(posts []ScheduledPost) error {
+	data, err := json.MarshalIndent(posts, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := m.schedulePath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
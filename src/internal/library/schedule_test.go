@@ -0,0 +1,88 @@
+package library
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduledPostsLifecycle(t *testing.T) {
+	m, err := NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if ps, err := m.ListScheduled(); err != nil || len(ps) != 0 {
+		t.Fatalf("ListScheduled on empty dir = %v, %v", ps, err)
+	}
+	if _, err := m.AddScheduled(ScheduledPost{LibraryID: "lib"}); err == nil {
+		t.Error("post without photos accepted")
+	}
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	later, err := m.AddScheduled(ScheduledPost{LibraryID: "lib", PhotoIDs: []string{"a"}, Channel: "web", ScheduledAt: now.Add(2 * time.Hour)})
+	if err != nil {
+		t.Fatalf("AddScheduled: %v", err)
+	}
+	soon, _ := m.AddScheduled(ScheduledPost{LibraryID: "lib", PhotoIDs: []string{"b"}, Channel: "web", ScheduledAt: now.Add(time.Hour)})
+	if later.ID == "" || later.Status != ScheduleQueued {
+		t.Errorf("added = %+v", later)
+	}
+	ps, _ := m.ListScheduled()
+	if len(ps) != 2 || ps[0].ID != soon.ID {
+		t.Fatalf("ListScheduled = %+v, want earliest first", ps)
+	}
+
+	// Only due posts are claimed, and only once.
+	due, err := m.ClaimDueScheduled(now.Add(90 * time.Minute))
+	if err != nil || len(due) != 1 || due[0].ID != soon.ID {
+		t.Fatalf("ClaimDueScheduled = %+v, %v", due, err)
+	}
+	if again, _ := m.ClaimDueScheduled(now.Add(90 * time.Minute)); len(again) != 0 {
+		t.Errorf("claimed twice: %+v", again)
+	}
+	if err := m.CancelScheduled(soon.ID); !errors.Is(err, ErrScheduledBusy) {
+		t.Errorf("CancelScheduled while publishing: %v", err)
+	}
+	if err := m.FinishScheduled(soon.ID, errors.New("instance unreachable")); err != nil {
+		t.Fatalf("FinishScheduled: %v", err)
+	}
+	ps, _ = m.ListScheduled()
+	if ps[0].Status != ScheduleFailed || ps[0].Error != "instance unreachable" {
+		t.Errorf("failed post = %+v", ps[0])
+	}
+
+	// Rescheduling queues a failed post again.
+	moved, err := m.RescheduleScheduled(soon.ID, now.Add(3*time.Hour))
+	if err != nil || moved.Status != ScheduleQueued || moved.Error != "" || !moved.ScheduledAt.Equal(now.Add(3*time.Hour)) {
+		t.Errorf("RescheduleScheduled = %+v, %v", moved, err)
+	}
+	if ps, _ = m.ListScheduled(); ps[0].ID != later.ID {
+		t.Errorf("order after reschedule = %+v", ps)
+	}
+
+	due, _ = m.ClaimDueScheduled(now.Add(4 * time.Hour))
+	if len(due) != 2 {
+		t.Fatalf("claimed %d posts, want 2", len(due))
+	}
+	if err := m.FinishScheduled(later.ID, nil); err != nil {
+		t.Fatalf("FinishScheduled: %v", err)
+	}
+	// A restart fails the post that was still publishing.
+	if err := m.RecoverScheduled(); err != nil {
+		t.Fatalf("RecoverScheduled: %v", err)
+	}
+	ps, _ = m.ListScheduled()
+	if len(ps) != 1 || ps[0].ID != soon.ID || ps[0].Status != ScheduleFailed || ps[0].Error != "interrupted by shutdown" {
+		t.Fatalf("after recover = %+v", ps)
+	}
+
+	if err := m.CancelScheduled(soon.ID); err != nil {
+		t.Fatalf("CancelScheduled: %v", err)
+	}
+	if err := m.CancelScheduled(soon.ID); !errors.Is(err, ErrScheduledNotFound) {
+		t.Errorf("CancelScheduled twice: %v", err)
+	}
+	if _, err := m.RescheduleScheduled("nope", now); !errors.Is(err, ErrScheduledNotFound) {
+		t.Errorf("RescheduleScheduled unknown: %v", err)
+	}
+}
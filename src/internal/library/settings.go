@@ -5,11 +5,14 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+
+	"huepattl.de/unterlumen/internal/media"
 )
 
 // GlobalSettings holds user preferences that are not tied to a specific library.
 type GlobalSettings struct {
-	LibrarySortMode string `json:"librarySortMode,omitempty"`
+	LibrarySortMode string              `json:"librarySortMode,omitempty"`
+	PrivacyZones    []media.PrivacyZone `json:"privacyZones,omitempty"` // honoured by the "keep_outside_zones" EXIF mode
 }
 
 func (m *Manager) settingsPath() string {
@@ -40,3 +43,22 @@ func (m *Manager) SaveSettings(s *GlobalSettings) error {
 	}
 	return os.WriteFile(m.settingsPath(), data, 0o600)
 }
+
+// PrivacyZones returns the configured privacy zones. Errors reading settings
+// yield no zones.
+func (m *Manager) PrivacyZones() []media.PrivacyZone {
+	s, err := m.GetSettings()
+	if err != nil {
+		return nil
+	}
+	return s.PrivacyZones
+}
+
+// WithPrivacyZones returns opts with the configured privacy zones attached.
+// A nil Manager leaves opts unchanged.
+func (m *Manager) WithPrivacyZones(opts media.ExportOptions) media.ExportOptions {
+	if m != nil && opts.ExifMode == media.ExifModeKeepOutsideZones {
+		opts.PrivacyZones = m.PrivacyZones()
+	}
+	return opts
+}
@@ -131,6 +131,15 @@ func openDB(dbPath string) (*sql.DB, error) {
 	db.Exec(`CREATE INDEX IF NOT EXISTS photos_ext_idx ON photos(status, ext)`)
 	// Migration: compound (status, indexed_at) index for sorted pagination in ListPhotos.
 	db.Exec(`CREATE INDEX IF NOT EXISTS photos_status_indexed_at_idx ON photos(status, indexed_at)`)
+	// Migration: normalized decimal lat/lon columns for geo filters and map clustering.
+	db.Exec(`ALTER TABLE photos ADD COLUMN lat REAL`)
+	db.Exec(`ALTER TABLE photos ADD COLUMN lon REAL`)
+	db.Exec(`UPDATE photos SET lat = json_extract(exif_json,'$.latitude'), lon = json_extract(exif_json,'$.longitude')
+		WHERE lat IS NULL AND json_valid(exif_json)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS photos_geo_idx ON photos(status, lat, lon)`)
+	// Migration: when reverse geocoding last found no place near the photo, so
+	// scans do not look it up again until its coordinates change.
+	db.Exec(`ALTER TABLE photos ADD COLUMN place_checked_at TEXT`)
 	return db, nil
 }
 
@@ -196,8 +205,10 @@ func (s *Store) PhotoExists(id string) (bool, error) {
 // UpsertPhoto inserts or updates a photo record.
 func (s *Store) UpsertPhoto(id, pathHint, filename string, fileSize int64, indexedAt time.Time, exifJSON, thumbPath, dateTaken, ext string) error {
 	_, err := s.db.Exec(
-		`INSERT INTO photos(id,path_hint,filename,file_size,indexed_at,exif_json,thumb_path,status,date_taken,ext)
-		 VALUES(?,?,?,?,?,?,?,'ok',?,?)
+		`INSERT INTO photos(id,path_hint,filename,file_size,indexed_at,exif_json,thumb_path,status,date_taken,ext,lat,lon)
+		 VALUES(?1,?2,?3,?4,?5,?6,?7,'ok',?8,?9,
+		        CASE WHEN json_valid(?6) THEN json_extract(?6,'$.latitude') END,
+		        CASE WHEN json_valid(?6) THEN json_extract(?6,'$.longitude') END)
 		 ON CONFLICT(id) DO UPDATE SET
 		   path_hint=excluded.path_hint,
 		   filename=excluded.filename,
@@ -207,7 +218,11 @@ func (s *Store) UpsertPhoto(id, pathHint, filename string, fileSize int64, index
 		   thumb_path=excluded.thumb_path,
 		   status='ok',
 		   date_taken=excluded.date_taken,
-		   ext=excluded.ext`,
+		   ext=excluded.ext,
+		   lat=excluded.lat,
+		   lon=excluded.lon,
+		   place_checked_at=CASE WHEN photos.lat IS excluded.lat AND photos.lon IS excluded.lon
+		                         THEN photos.place_checked_at END`,
 		id, pathHint, filename, fileSize, indexedAt.UTC(), exifJSON, thumbPath, dateTaken, ext,
 	)
 	return err
@@ -415,8 +430,8 @@ func (s *Store) GetPhoto(id string) (*Photo, error) {
 	var p Photo
 	var indexedAt string
 	err := s.db.QueryRow(
-		`SELECT id, path_hint, filename, file_size, indexed_at, status FROM photos WHERE id=?`, id,
-	).Scan(&p.ID, &p.PathHint, &p.Filename, &p.FileSize, &indexedAt, &p.Status)
+		`SELECT id, path_hint, filename, file_size, indexed_at, status, lat, lon FROM photos WHERE id=?`, id,
+	).Scan(&p.ID, &p.PathHint, &p.Filename, &p.FileSize, &indexedAt, &p.Status, &p.Latitude, &p.Longitude)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -489,7 +504,10 @@ func (s *Store) SetPhotoThumbPath(id, thumbPath string) error {
 // UpdatePhotoExif replaces the stored EXIF JSON and date_taken for a photo.
 // Used by forced re-index to pick up EXIF changes made by external tools.
 func (s *Store) UpdatePhotoExif(id, exifJSON, dateTaken string) error {
-	_, err := s.db.Exec(`UPDATE photos SET exif_json=?, date_taken=? WHERE id=?`, exifJSON, dateTaken, id)
+	_, err := s.db.Exec(`UPDATE photos SET exif_json=?1, date_taken=?2,
+		lat=CASE WHEN json_valid(?1) THEN json_extract(?1,'$.latitude') END,
+		lon=CASE WHEN json_valid(?1) THEN json_extract(?1,'$.longitude') END,
+		place_checked_at=NULL WHERE id=?3`, exifJSON, dateTaken, id)
 	return err
 }
 
@@ -526,12 +544,75 @@ type ListPhotosOpts struct {
 	MetaExists     []string                // photo_meta keys that must exist (any value)
 	AlbumTitle     string                  // match photos with any published:*:title = value
 	ExtFilter      string                  // file extension (photos.ext)
+	BBox           *GeoBBox                // photos.lat/lon inside the box
+	Radius         *GeoRadius              // photos.lat/lon within Km of a point
 	Offset         int
 	Limit          int
 }
 
 // ListPhotos returns a filtered, paginated list of photos.
 func (s *Store) ListPhotos(opts ListPhotosOpts) (ListPhotosResult, error) {
+	fromSQL, whereSQL, allArgs := buildPhotoFilter(opts)
+
+	var total int
+	countArgs := append([]any{}, allArgs...)
+	if err := s.db.QueryRow(
+		`SELECT COUNT(p.id) `+fromSQL+` WHERE `+whereSQL, countArgs...,
+	).Scan(&total); err != nil {
+		return ListPhotosResult{}, err
+	}
+
+	pageArgs := append(allArgs, opts.Limit, opts.Offset)
+	rows, err := s.db.Query(
+		`SELECT p.id, p.path_hint, p.filename, p.file_size, p.indexed_at, p.status, p.date_taken,
+		        (SELECT value FROM exif_index WHERE photo_id=p.id AND field='GPSLatitude' LIMIT 1),
+		        (SELECT value FROM exif_index WHERE photo_id=p.id AND field='FilmSimulation' LIMIT 1),
+		        p.lat, p.lon
+		 `+fromSQL+` WHERE `+whereSQL+
+			` ORDER BY CASE WHEN p.date_taken IS NULL OR p.date_taken = '' THEN 1 ELSE 0 END, p.date_taken DESC LIMIT ? OFFSET ?`,
+		pageArgs...,
+	)
+	if err != nil {
+		return ListPhotosResult{}, err
+	}
+	defer rows.Close()
+
+	var photos []Photo
+	for rows.Next() {
+		var p Photo
+		var indexedAt string
+		var dateTaken sql.NullString
+		var gpsLat, filmSim *string
+		if err := rows.Scan(&p.ID, &p.PathHint, &p.Filename, &p.FileSize, &indexedAt, &p.Status, &dateTaken, &gpsLat, &filmSim, &p.Latitude, &p.Longitude); err != nil {
+			return ListPhotosResult{}, err
+		}
+		p.IndexedAt, _ = time.Parse(time.RFC3339, indexedAt)
+		if dateTaken.Valid {
+			p.DateTaken = dateTaken.String
+		}
+		if gpsLat != nil || filmSim != nil {
+			p.Exif = make(map[string]string)
+			if gpsLat != nil {
+				p.Exif["GPSLatitude"] = *gpsLat
+			}
+			if filmSim != nil {
+				p.Exif["FilmSimulation"] = *filmSim
+			}
+		}
+		photos = append(photos, p)
+	}
+	if err := rows.Err(); err != nil {
+		return ListPhotosResult{}, err
+	}
+	if photos == nil {
+		photos = []Photo{}
+	}
+	return ListPhotosResult{Photos: photos, Total: total}, nil
+}
+
+// buildPhotoFilter translates opts (minus pagination) into a FROM clause with
+// EXIF joins, a WHERE clause on photos p, and the bound arguments for both.
+func buildPhotoFilter(opts ListPhotosOpts) (fromSQL, whereSQL string, args []any) {
 	var joinClauses []string
 	var joinArgs []any
 	var whereArgs []any
@@ -602,68 +683,19 @@ func (s *Store) ListPhotos(opts ListPhotosOpts) (ListPhotosResult, error) {
 		whereArgs = append(whereArgs, opts.AlbumTitle)
 	}
 
+	geoWhere, geoArgs := geoConditions(opts)
+	where = append(where, geoWhere...)
+	whereArgs = append(whereArgs, geoArgs...)
+
 	joinSQL := strings.Join(joinClauses, " ")
-	whereSQL := strings.Join(where, " AND ")
+	whereSQL = strings.Join(where, " AND ")
 	allArgs := append(joinArgs, whereArgs...)
 
-	fromSQL := "FROM photos p"
+	fromSQL = "FROM photos p"
 	if joinSQL != "" {
 		fromSQL += " " + joinSQL
 	}
-
-	var total int
-	countArgs := append([]any{}, allArgs...)
-	if err := s.db.QueryRow(
-		`SELECT COUNT(p.id) `+fromSQL+` WHERE `+whereSQL, countArgs...,
-	).Scan(&total); err != nil {
-		return ListPhotosResult{}, err
-	}
-
-	pageArgs := append(allArgs, opts.Limit, opts.Offset)
-	rows, err := s.db.Query(
-		`SELECT p.id, p.path_hint, p.filename, p.file_size, p.indexed_at, p.status, p.date_taken,
-		        (SELECT value FROM exif_index WHERE photo_id=p.id AND field='GPSLatitude' LIMIT 1),
-		        (SELECT value FROM exif_index WHERE photo_id=p.id AND field='FilmSimulation' LIMIT 1)
-		 `+fromSQL+` WHERE `+whereSQL+
-			` ORDER BY CASE WHEN p.date_taken IS NULL OR p.date_taken = '' THEN 1 ELSE 0 END, p.date_taken DESC LIMIT ? OFFSET ?`,
-		pageArgs...,
-	)
-	if err != nil {
-		return ListPhotosResult{}, err
-	}
-	defer rows.Close()
-
-	var photos []Photo
-	for rows.Next() {
-		var p Photo
-		var indexedAt string
-		var dateTaken sql.NullString
-		var gpsLat, filmSim *string
-		if err := rows.Scan(&p.ID, &p.PathHint, &p.Filename, &p.FileSize, &indexedAt, &p.Status, &dateTaken, &gpsLat, &filmSim); err != nil {
-			return ListPhotosResult{}, err
-		}
-		p.IndexedAt, _ = time.Parse(time.RFC3339, indexedAt)
-		if dateTaken.Valid {
-			p.DateTaken = dateTaken.String
-		}
-		if gpsLat != nil || filmSim != nil {
-			p.Exif = make(map[string]string)
-			if gpsLat != nil {
-				p.Exif["GPSLatitude"] = *gpsLat
-			}
-			if filmSim != nil {
-				p.Exif["FilmSimulation"] = *filmSim
-			}
-		}
-		photos = append(photos, p)
-	}
-	if err := rows.Err(); err != nil {
-		return ListPhotosResult{}, err
-	}
-	if photos == nil {
-		photos = []Photo{}
-	}
-	return ListPhotosResult{Photos: photos, Total: total}, nil
+	return fromSQL, whereSQL, allArgs
 }
 
 // PhotoInfo holds the fields needed to render the info panel for a library photo.
@@ -1039,6 +1071,8 @@ func (s *Store) Statistics(pathPrefix string) (*LibraryStatistics, error) {
 		Apertures:      []ValueCount{},
 		ISOs:           []ValueCount{},
 		CameraLens:     []CameraLensCount{},
+		Countries:      []NameCount{},
+		Cities:         []NameCount{},
 		ShootingDays:   make(map[string]int),
 	}
 
@@ -1244,6 +1278,17 @@ func (s *Store) Statistics(pathPrefix string) (*LibraryStatistics, error) {
 		rows.Close()
 	}
 
+	// Place distribution (reverse-geocoded fields; empty without a gazetteer).
+	{
+		var err error
+		if st.Countries, err = s.placeCounts(`e.value`, FieldCountry, exifPathCond, exifPathArgs, 0); err != nil {
+			return nil, err
+		}
+		if st.Cities, err = s.placeCounts(`e.value || COALESCE(', ' || cc.value, '')`, FieldCity, exifPathCond, exifPathArgs, 50); err != nil {
+			return nil, err
+		}
+	}
+
 	// Shooting hours distribution.
 	{
 		rows, err := s.db.Query(`
@@ -1294,6 +1339,34 @@ func (s *Store) Statistics(pathPrefix string) (*LibraryStatistics, error) {
 	return st, nil
 }
 
+// placeCounts groups photos by a place field. label is the SQL expression used
+// as the name; the country code is joined as cc so city labels can be
+// disambiguated. limit 0 means no limit.
+func (s *Store) placeCounts(label, field, pathCond string, pathArgs []any, limit int) ([]NameCount, error) {
+	q := `SELECT ` + label + ` AS name, COUNT(*) AS n
+		FROM exif_index e
+		LEFT JOIN exif_index cc ON cc.photo_id = e.photo_id AND cc.field = '` + FieldCountryCode + `'
+		WHERE e.field = ?` + pathCond + `
+		GROUP BY name ORDER BY n DESC`
+	if limit > 0 {
+		q += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := s.db.Query(q, append([]any{field}, pathArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []NameCount{}
+	for rows.Next() {
+		var nc NameCount
+		if err := rows.Scan(&nc.Name, &nc.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, nc)
+	}
+	return out, rows.Err()
+}
+
 func sortNameCounts(s []NameCount) {
 	for i := 1; i < len(s); i++ {
 		for j := i; j > 0 && s[j].Count > s[j-1].Count; j-- {
@@ -2,6 +2,7 @@ package library
 
 import (
 	"math"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -144,3 +145,35 @@ func TestPurgeMissingPhotos(t *testing.T) {
 		t.Error("orphan exif_index rows remain after purge")
 	}
 }
+
+func TestOpenDBCompletesInterruptedGeoMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.db")
+	db, err := openDB(path)
+	if err != nil {
+		t.Fatalf("openDB: %v", err)
+	}
+	s := newStore(db, t.TempDir())
+	if err := s.UpsertPhoto("p1", "", "p1.jpg", 0, time.Now(), `{"latitude":47.5,"longitude":11.25}`, "", "", "jpeg"); err != nil {
+		t.Fatalf("UpsertPhoto: %v", err)
+	}
+	// Simulate a crash right after the lat column was added.
+	for _, q := range []string{`DROP INDEX photos_geo_idx`, `ALTER TABLE photos DROP COLUMN lon`, `UPDATE photos SET lat = NULL`} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+	db.Close()
+
+	db, err = openDB(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	var lat, lon float64
+	if err := db.QueryRow(`SELECT lat, lon FROM photos WHERE id = 'p1'`).Scan(&lat, &lon); err != nil {
+		t.Fatalf("geo columns after reopen: %v", err)
+	}
+	if lat != 47.5 || lon != 11.25 {
+		t.Errorf("lat, lon = %v, %v", lat, lon)
+	}
+}
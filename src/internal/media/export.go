@@ -2,6 +2,7 @@ package media
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image"
 	"image/jpeg"
@@ -10,6 +11,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"golang.org/x/image/draw"
 )
@@ -33,14 +35,39 @@ type ScaleOptions struct {
 	MaintainAR   bool      `json:"maintainAR,omitempty"`   // ScaleModePixels: fit within box
 	MaxDimension string    `json:"maxDimension,omitempty"` // "width" or "height", ScaleModeMaxDim
 	MaxValue     int       `json:"maxValue,omitempty"`     // ScaleModeMaxDim
+	Filter       string    `json:"filter,omitempty"`       // Filter* constants; "" = CatmullRom in Go, lanczos in ffmpeg
 }
 
 // ExportOptions controls how an image should be exported.
 type ExportOptions struct {
-	Format   string      `json:"format"`   // "jpeg", "png", "webp"
+	Format   string      `json:"format"`   // "jpeg", "png", "webp", "avif", "jxl"
 	Quality  int         `json:"quality"`  // 1–100, ignored for PNG
 	Scale    ScaleOptions `json:"scale"`
-	ExifMode string      `json:"exifMode"` // "strip", "keep", "keep_no_gps"
+	ExifMode string      `json:"exifMode"` // "strip", "keep", "keep_no_gps", "keep_outside_zones"
+
+	// PrivacyZones apply to ExifModeKeepOutsideZones. Filled in server-side
+	// from the global settings, never from client requests.
+	PrivacyZones []PrivacyZone `json:"-"`
+
+	ColorMode string     `json:"colorMode,omitempty"` // "srgb" (default) or "preserve"
+	Sharpen   string     `json:"sharpen,omitempty"`   // Sharpen* preset, applied after scaling
+	Watermark *Watermark `json:"watermark,omitempty"` // rendered after scaling and sharpening
+
+	// MaxBytes caps the output size: Quality becomes the upper bound of a
+	// search for the highest quality that fits. With AllowDownscale the
+	// dimensions are reduced as well when the lowest quality is not enough.
+	MaxBytes       int64 `json:"maxBytes,omitempty"`
+	AllowDownscale bool  `json:"allowDownscale,omitempty"`
+
+	// Rights metadata written into the export regardless of ExifMode.
+	Artist     string `json:"artist,omitempty"`     // EXIF Artist, XMP dc:creator
+	Copyright  string `json:"copyright,omitempty"`  // EXIF Copyright, XMP dc:rights
+	UsageTerms string `json:"usageTerms,omitempty"` // XMP xmpRights:UsageTerms
+}
+
+// hasRights reports whether any rights metadata is set.
+func (o ExportOptions) hasRights() bool {
+	return o.Artist != "" || o.Copyright != "" || o.UsageTerms != ""
 }
 
 // ExportedName returns the output filename for srcName with the given format.
@@ -54,6 +81,10 @@ func ExportedName(srcName, format string) string {
 		return base + ".png"
 	case "webp":
 		return base + ".webp"
+	case "avif":
+		return base + ".avif"
+	case "jxl":
+		return base + ".jxl"
 	default:
 		return base + ".jpg"
 	}
@@ -68,6 +99,10 @@ func ExportMIMEType(format string) string {
 		return "image/png"
 	case "webp":
 		return "image/webp"
+	case "avif":
+		return "image/avif"
+	case "jxl":
+		return "image/jxl"
 	default:
 		return "image/jpeg"
 	}
@@ -150,41 +185,83 @@ func getImageDims(srcPath string) (int, int) {
 // ExportImage converts an image file to the specified format with the given options.
 // This is the reusable core function — safe to call from any context.
 func ExportImage(srcPath string, opts ExportOptions) ([]byte, error) {
+	res, err := ExportImageResult(srcPath, opts)
+	return res.Data, err
+}
+
+// ExportImageResult is ExportImage, additionally reporting the settings that
+// were used. With opts.MaxBytes set, the quality (and, if allowed, the size)
+// is searched to fit the limit; see exportToSize.
+func ExportImageResult(srcPath string, opts ExportOptions) (ExportResult, error) {
 	if opts.Quality <= 0 || opts.Quality > 100 {
 		opts.Quality = 85
 	}
 	if opts.Format == "" {
 		opts.Format = "jpeg"
 	}
+	if opts.MaxBytes > 0 {
+		return exportToSize(srcPath, opts)
+	}
+	data, err := exportImage(srcPath, opts)
+	if err != nil {
+		return ExportResult{}, err
+	}
+	return ExportResult{Data: data, Quality: opts.Quality, Scale: 1}, nil
+}
 
-	if opts.Format == "webp" {
+// exportImage exports srcPath once with opts, which must be normalised.
+func exportImage(srcPath string, opts ExportOptions) ([]byte, error) {
+	switch opts.Format {
+	case "webp":
 		return exportWebP(srcPath, opts)
+	case "avif":
+		return exportAVIF(srcPath, opts)
+	case "jxl":
+		return exportJXL(srcPath, opts)
 	}
 
-	img, err := decodeSourceImage(srcPath)
+	img, icc, err := renderImage(srcPath, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	scaled := scaleImage(img, opts.Scale)
-
-	encoded, err := encodeToFormat(scaled, opts)
+	encoded, err := encodeToFormat(img, opts)
 	if err != nil {
 		return nil, err
 	}
+	if opts.ColorMode == ColorModePreserve {
+		encoded = embedICC(encoded, opts.Format, icc)
+	}
+
+	return applyMetadata(srcPath, encoded, opts.Format, opts), nil
+}
 
-	if opts.ExifMode == "keep" || opts.ExifMode == "keep_no_gps" {
-		if patched, err := injectExif(srcPath, encoded, opts.Format, opts.ExifMode); err == nil {
-			encoded = patched
+// renderImage decodes srcPath and applies colour conversion, scaling, output
+// sharpening and the watermark. It also returns the source ICC profile (nil if none).
+func renderImage(srcPath string, opts ExportOptions) (image.Image, []byte, error) {
+	img, icc, err := decodeSourceImage(srcPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.ColorMode != ColorModePreserve {
+		img = convertToSRGB(img, icc)
+	}
+	img = scaleImage(img, opts.Scale)
+	if s, ok := SharpenPreset(opts.Sharpen); ok {
+		img = sharpenImage(img, s)
+	}
+	if opts.Watermark.Enabled() {
+		if img, err = applyWatermark(img, opts.Watermark); err != nil {
+			return nil, nil, err
 		}
 	}
-
-	return encoded, nil
+	return img, icc, nil
 }
 
 // decodeSourceImage opens and decodes a source image, applying EXIF orientation.
 // For HEIF, uses full-resolution decode to avoid low-res embedded previews.
-func decodeSourceImage(srcPath string) (image.Image, error) {
+// The embedded ICC profile is returned alongside (nil if none).
+func decodeSourceImage(srcPath string) (image.Image, []byte, error) {
 	if IsHEIF(srcPath) {
 		// Use full-resolution decode (not the embedded preview used by the viewer).
 		// ConvertHEIFToJPEG prefers the embedded JPEG stream which may be a low-res
@@ -192,30 +269,30 @@ func decodeSourceImage(srcPath string) (image.Image, error) {
 		// max-dimension scaling to operate on the wrong base dimensions.
 		jpegBytes, err := convertHEIFExport(srcPath)
 		if err != nil {
-			return nil, fmt.Errorf("HEIF full-res decode: %w", err)
+			return nil, nil, fmt.Errorf("HEIF full-res decode: %w", err)
 		}
 		img, _, err := image.Decode(bytes.NewReader(jpegBytes))
 		if err != nil {
-			return nil, fmt.Errorf("decode converted HEIF: %w", err)
+			return nil, nil, fmt.Errorf("decode converted HEIF: %w", err)
 		}
 		// Orientation already applied by convertHEIFExport.
-		return img, nil
+		return img, jpegICC(withHEIFProfile(srcPath, jpegBytes)), nil
 	}
 
 	f, err := os.Open(srcPath)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer f.Close()
 
 	img, _, err := image.Decode(f)
 	if err != nil {
-		return nil, fmt.Errorf("decode image: %w", err)
+		return nil, nil, fmt.Errorf("decode image: %w", err)
 	}
 	if orientation := ExtractOrientation(srcPath); orientation > 1 {
 		img = applyOrientation(img, orientation)
 	}
-	return img, nil
+	return img, sourceICC(srcPath), nil
 }
 
 func scaleImage(img image.Image, scale ScaleOptions) image.Image {
@@ -226,7 +303,7 @@ func scaleImage(img image.Image, scale ScaleOptions) image.Image {
 		return img
 	}
 	dst := image.NewRGBA(image.Rect(0, 0, targetW, targetH))
-	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+	resampler(scale.Filter).Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
 	return dst
 }
 
@@ -248,18 +325,72 @@ func encodeToFormat(img image.Image, opts ExportOptions) ([]byte, error) {
 }
 
 // exportWebP converts an image to WebP, using ffmpeg when libwebp is available
-// and falling back to cwebp (brew install webp) otherwise.
+// and falling back to cwebp (brew install webp) otherwise. Neither converts
+// colour spaces nor draws watermarks, and cwebp can neither choose a resampling
+// filter nor sharpen, so when any of that is needed the image is rendered in Go
+// first and the encoder is fed the resulting PNG.
 func exportWebP(srcPath string, opts ExportOptions) ([]byte, error) {
-	if !CheckFFmpeg().WebPSupport && CheckCwebp() {
-		return exportWebPCwebp(srcPath, opts)
-	}
 	inputPath := srcPath
+	icc := sourceICC(srcPath)
+	useCwebp := !CheckFFmpeg().WebPSupport && CheckCwebp()
+	if opts.Watermark.Enabled() || (opts.ColorMode != ColorModePreserve && needsSRGBConversion(icc)) ||
+		(useCwebp && (opts.Scale.Filter != "" || opts.Sharpen != SharpenNone)) {
+		tmpPath, err := renderIntermediatePNG(srcPath, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmpPath)
+		inputPath = tmpPath
+		opts.Scale = ScaleOptions{Mode: ScaleModeNone}
+		opts.Sharpen = SharpenNone
+	}
+
+	var encoded []byte
+	var err error
+	if useCwebp {
+		encoded, err = exportWebPCwebp(inputPath, opts)
+	} else {
+		encoded, err = exportWebPFFmpeg(inputPath, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.ColorMode == ColorModePreserve {
+		encoded = embedICC(encoded, "webp", icc)
+	}
+	return applyMetadata(srcPath, encoded, "webp", opts), nil
+}
+
+// renderIntermediatePNG renders srcPath in Go (see renderImage) and writes the
+// result to a temporary PNG. The caller removes the file.
+func renderIntermediatePNG(srcPath string, opts ExportOptions) (string, error) {
+	img, _, err := renderImage(srcPath, opts)
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp("", "unterlumen-wm-*.png")
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+	if err := png.Encode(tmp, img); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("PNG encode: %w", err)
+	}
+	return tmp.Name(), nil
+}
+
+// exportWebPFFmpeg encodes inputPath to WebP with ffmpeg, without metadata.
+func exportWebPFFmpeg(inputPath string, opts ExportOptions) ([]byte, error) {
 
 	var ffArgs []string
 
-	scaleFilter := buildFFmpegScaleFilter(srcPath, opts.Scale)
-	if scaleFilter != "" {
-		ffArgs = append(ffArgs, "-vf", scaleFilter)
+	filters := buildFFmpegScaleFilter(inputPath, opts.Scale)
+	if unsharp := ffmpegUnsharp(opts.Sharpen); unsharp != "" {
+		filters = strings.TrimPrefix(filters+","+unsharp, ",")
+	}
+	if filters != "" {
+		ffArgs = append(ffArgs, "-vf", filters)
 	}
 
 	ffArgs = append(ffArgs,
@@ -276,18 +407,11 @@ func exportWebP(srcPath string, opts ExportOptions) ([]byte, error) {
 		}
 		return nil, fmt.Errorf("WebP encode via ffmpeg: %w", err)
 	}
-
-	// EXIF injection (non-fatal).
-	if opts.ExifMode == "keep" || opts.ExifMode == "keep_no_gps" {
-		if patched, err := injectExif(srcPath, encoded, "webp", opts.ExifMode); err == nil {
-			encoded = patched
-		}
-	}
-
 	return encoded, nil
 }
 
-// exportWebPCwebp converts an image to WebP using cwebp (brew install webp).
+// exportWebPCwebp converts an image to WebP using cwebp (brew install webp),
+// without metadata.
 // ffmpeg decodes the source to a full-resolution temp PNG; cwebp encodes and
 // scales in one step. Scaling is done by cwebp (-resize) rather than ffmpeg's
 // -vf filter because HEIC multi-tile files use a complex filtergraph internally,
@@ -309,7 +433,7 @@ func exportWebPCwebp(srcPath string, opts ExportOptions) ([]byte, error) {
 	decodeCmd := exec.Command("ffmpeg", ffArgs...)
 	decodeCmd.Stderr = &decodeStderr
 	if err := decodeCmd.Run(); err != nil {
-		return nil, fmt.Errorf("WebP via cwebp: decode: %v: %s", err, decodeStderr.String())
+		return nil, fmt.Errorf("WebP via cwebp: decode: %w: %s", err, decodeStderr.String())
 	}
 
 	// Build cwebp args; handle scaling via -resize instead of ffmpeg -vf.
@@ -336,17 +460,10 @@ func exportWebPCwebp(srcPath string, opts ExportOptions) ([]byte, error) {
 	encCmd.Stdout = &out
 	encCmd.Stderr = &encStderr
 	if err := encCmd.Run(); err != nil {
-		return nil, fmt.Errorf("cwebp encode: %v: %s", err, encStderr.String())
-	}
-
-	encoded := out.Bytes()
-	if opts.ExifMode == "keep" || opts.ExifMode == "keep_no_gps" {
-		if patched, err := injectExif(srcPath, encoded, "webp", opts.ExifMode); err == nil {
-			encoded = patched
-		}
+		return nil, fmt.Errorf("cwebp encode: %w: %s", err, encStderr.String())
 	}
 
-	return encoded, nil
+	return out.Bytes(), nil
 }
 
 // buildFFmpegScaleFilter returns the ffmpeg -vf scale filter string.
@@ -374,12 +491,27 @@ func buildFFmpegScaleFilter(srcPath string, scale ScaleOptions) string {
 		return ""
 	}
 
-	return fmt.Sprintf("scale=%d:%d:flags=lanczos", targetW, targetH)
+	return fmt.Sprintf("scale=%d:%d:flags=%s", targetW, targetH, ffmpegScaleFlags(scale.Filter))
+}
+
+// applyMetadata copies source metadata and writes rights fields into the
+// encoded export as configured by opts. Failures are non-fatal: the image is
+// returned without the metadata.
+func applyMetadata(srcPath string, encoded []byte, format string, opts ExportOptions) []byte {
+	if !exifKept(opts.ExifMode) && !opts.hasRights() {
+		return encoded
+	}
+	if patched, err := injectExif(srcPath, encoded, format, opts); err == nil {
+		return patched
+	}
+	return encoded
 }
 
 // injectExif copies EXIF from srcPath into data using exiftool.
-// mode: "keep" copies all metadata; "keep_no_gps" copies all except GPS.
-func injectExif(srcPath string, data []byte, format, mode string) ([]byte, error) {
+// "keep" copies all metadata; "keep_no_gps" copies all except GPS;
+// "keep_outside_zones" strips or fuzzes GPS only inside a privacy zone.
+// Rights fields in opts are written in every mode.
+func injectExif(srcPath string, data []byte, format string, opts ExportOptions) ([]byte, error) {
 	if !CheckExiftool() {
 		return nil, fmt.Errorf("exiftool not available")
 	}
@@ -404,12 +536,14 @@ func injectExif(srcPath string, data []byte, format, mode string) ([]byte, error
 
 	// -n interprets tag values as raw numerics; required so -Orientation=1 is written
 	// as the integer 1 ("Horizontal/normal") rather than silently ignored.
-	var args []string
-	if mode == "keep_no_gps" {
-		args = []string{"-n", "-TagsFromFile", srcPath, "-GPS:All=", "-Orientation=1", "-overwrite_original", tmpPath}
-	} else {
-		args = []string{"-n", "-TagsFromFile", srcPath, "-Orientation=1", "-overwrite_original", tmpPath}
+	args := []string{"-n"}
+	if exifKept(opts.ExifMode) {
+		args = append(args, "-TagsFromFile", srcPath)
+		args = append(args, gpsArgs(srcPath, opts)...)
+		args = append(args, "-Orientation=1")
 	}
+	args = append(args, rightsArgs(opts)...)
+	args = append(args, "-overwrite_original", tmpPath)
 
 	cmd := exec.Command("exiftool", args...)
 	cmd.Stderr = &bytes.Buffer{}
@@ -420,6 +554,21 @@ func injectExif(srcPath string, data []byte, format, mode string) ([]byte, error
 	return os.ReadFile(tmpPath)
 }
 
+// rightsArgs returns exiftool assignments for the rights fields in opts.
+func rightsArgs(opts ExportOptions) []string {
+	var args []string
+	if opts.Artist != "" {
+		args = append(args, "-EXIF:Artist="+opts.Artist, "-XMP-dc:Creator="+opts.Artist)
+	}
+	if opts.Copyright != "" {
+		args = append(args, "-EXIF:Copyright="+opts.Copyright, "-XMP-dc:Rights="+opts.Copyright)
+	}
+	if opts.UsageTerms != "" {
+		args = append(args, "-XMP-xmpRights:UsageTerms="+opts.UsageTerms)
+	}
+	return args
+}
+
 // ScaleImageToJPEG decodes image data (JPEG, PNG, or any format supported by the
 // standard library), applies EXIF orientation when present, scales the image to fit
 // within maxPx×maxPx while preserving aspect ratio, and re-encodes as JPEG.
@@ -479,12 +628,38 @@ func EstimateSize(srcPath string, opts ExportOptions) (inputBytes, outputBytes i
 	case "webp":
 		// WebP is roughly 25–35% smaller than JPEG at equivalent quality
 		outputBytes = pixels * 3 * q / 100 / 11
+	case "avif":
+		// AVIF is roughly half the size of JPEG at equivalent quality
+		outputBytes = pixels * 3 * q / 100 / 16
+	case "jxl":
+		// JPEG XL lands between WebP and AVIF for photographic content
+		outputBytes = pixels * 3 * q / 100 / 13
 	case "png":
 		// Lossless PNG: roughly 25% of raw pixel data for photos
 		outputBytes = pixels * 3 / 4
 	default:
 		outputBytes = pixels * 3 * q / 100 / 8
 	}
+	// A size-limited export searches down to the limit; assume it gets there.
+	if opts.MaxBytes > 0 && outputBytes > opts.MaxBytes {
+		outputBytes = opts.MaxBytes
+	}
 
 	return inputBytes, outputBytes, origW, origH, outW, outH, nil
 }
+
+// IsTransient reports whether an export error is worth retrying: an external
+// tool (ffmpeg, heif-convert, cwebp, …) was killed by a signal, e.g. under
+// memory pressure during a parallel batch, or the process ran out of file
+// descriptors. A tool exiting with an error status, decode errors and missing
+// encoders are permanent.
+func IsTransient(err error) bool {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		ws, ok := exitErr.Sys().(syscall.WaitStatus)
+		return ok && ws.Signaled()
+	}
+	return errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.EMFILE) ||
+		errors.Is(err, syscall.ENFILE)
+}
@@ -0,0 +1,167 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// AVIF and JPEG XL are encoded by external tools. The image is always rendered
+// in Go first (orientation, colour conversion, scaling, watermark) and handed
+// to the encoder as a temporary PNG, so every encoder sees identical input.
+
+// WebPAvailable reports whether any WebP encoder is installed.
+func WebPAvailable() bool {
+	return CheckFFmpeg().WebPSupport || CheckCwebp()
+}
+
+// AVIFAvailable reports whether any AVIF encoder is installed.
+func AVIFAvailable() bool {
+	return CheckAvifenc() || CheckFFmpeg().AVIFEncoder != ""
+}
+
+// JXLAvailable reports whether any JPEG XL encoder is installed.
+func JXLAvailable() bool {
+	return CheckCjxl() || CheckFFmpeg().JXLSupport
+}
+
+// avifQuality maps the JPEG-like 1–100 export quality to libavif's quality
+// scale. AVIF reaches comparable visual quality at lower settings, so the
+// scale is shifted down: 85 → 75, 100 stays lossless-ish at 100.
+func avifQuality(q int) int {
+	if q >= 100 {
+		return 100
+	}
+	return max(1, int(math.Round(float64(q)*0.9-1.5)))
+}
+
+// avifCRF maps the libavif quality to an AV1 CRF (0 best – 63 worst) for ffmpeg.
+func avifCRF(q int) int {
+	return int(math.Round(float64(100-avifQuality(q)) * 63 / 100))
+}
+
+// jxlDistance maps the export quality to a butteraugli distance using the same
+// curve as cjxl's -q option (90 → 1.0, visually lossless).
+func jxlDistance(q int) float64 {
+	if q >= 100 {
+		return 0
+	}
+	if q >= 30 {
+		return 0.1 + float64(100-q)*0.09
+	}
+	return 6.4 + math.Pow(2.5, float64(30-q)/5)/6.25
+}
+
+func exportAVIF(srcPath string, opts ExportOptions) ([]byte, error) {
+	if !AVIFAvailable() {
+		return nil, errAVIFUnavailable
+	}
+	return exportViaTool(srcPath, "avif", opts, avifEncoder(opts.Quality))
+}
+
+func exportJXL(srcPath string, opts ExportOptions) ([]byte, error) {
+	if !JXLAvailable() {
+		return nil, errJXLUnavailable
+	}
+	return exportViaTool(srcPath, "jxl", opts, jxlEncoder(opts.Quality))
+}
+
+var (
+	errAVIFUnavailable = fmt.Errorf("AVIF encoder not found — install libavif (avifenc) or ffmpeg with libaom/libsvtav1")
+	errJXLUnavailable  = fmt.Errorf("JPEG XL encoder not found — install libjxl (cjxl) or ffmpeg with libjxl")
+)
+
+// toolEncoder builds the command that encodes pngPath to outPath.
+type toolEncoder func(pngPath, outPath string) *exec.Cmd
+
+// avifEncoder returns the AVIF encoder command for export quality q.
+func avifEncoder(q int) toolEncoder {
+	return func(pngPath, outPath string) *exec.Cmd {
+		if CheckAvifenc() {
+			return exec.Command("avifenc", "-q", fmt.Sprint(avifQuality(q)), "-s", "6", pngPath, outPath)
+		}
+		args := append([]string{"-i", pngPath}, ffmpegAVIFArgs(CheckFFmpeg().AVIFEncoder, q)...)
+		return exec.Command("ffmpeg", append(args, "-map_metadata", "-1", "-y", outPath)...)
+	}
+}
+
+// ffmpegAVIFArgs returns the ffmpeg codec options for encoder. libsvtav1 only
+// takes 4:2:0 input and has no still-picture mode; libaom gets full chroma.
+func ffmpegAVIFArgs(encoder string, q int) []string {
+	args := []string{"-c:v", encoder, "-crf", fmt.Sprint(avifCRF(q))}
+	if encoder == "libsvtav1" {
+		return append(args, "-pix_fmt", "yuv420p")
+	}
+	return append(args, "-still-picture", "1", "-pix_fmt", "yuv444p")
+}
+
+// jxlEncoder returns the JPEG XL encoder command for export quality q.
+func jxlEncoder(q int) toolEncoder {
+	return func(pngPath, outPath string) *exec.Cmd {
+		if CheckCjxl() {
+			return exec.Command("cjxl", pngPath, outPath, "-q", fmt.Sprint(min(q, 100)), "-e", "7")
+		}
+		return exec.Command("ffmpeg", "-i", pngPath,
+			"-c:v", "libjxl", "-distance", fmt.Sprintf("%.2f", jxlDistance(q)),
+			"-map_metadata", "-1", "-y", outPath)
+	}
+}
+
+// exportViaTool renders srcPath to a temporary PNG, runs the command built by
+// encode to produce the output, and applies metadata.
+func exportViaTool(srcPath, format string, opts ExportOptions, encode toolEncoder) ([]byte, error) {
+	img, icc, err := renderImage(srcPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp("", "unterlumen-"+format+"-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	pngPath, err := writeToolInput(tmpDir, img, icc, opts)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := runToolEncoder(tmpDir, pngPath, format, encode)
+	if err != nil {
+		return nil, err
+	}
+	return applyMetadata(srcPath, encoded, format, opts), nil
+}
+
+// writeToolInput writes img as in.png into dir. In preserve colour mode the
+// source profile is embedded; avifenc and cjxl carry it over.
+func writeToolInput(dir string, img image.Image, icc []byte, opts ExportOptions) (string, error) {
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, img); err != nil {
+		return "", err
+	}
+	pngData := pngBuf.Bytes()
+	if opts.ColorMode == ColorModePreserve {
+		pngData = embedICC(pngData, "png", icc)
+	}
+	pngPath := filepath.Join(dir, "in.png")
+	return pngPath, os.WriteFile(pngPath, pngData, 0o600)
+}
+
+// runToolEncoder encodes pngPath into dir/out.<format> and returns the result.
+func runToolEncoder(dir, pngPath, format string, encode toolEncoder) ([]byte, error) {
+	outPath := filepath.Join(dir, "out."+format)
+	var stderr bytes.Buffer
+	cmd := encode(pngPath, outPath)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s encode via %s: %w: %s", format, cmd.Args[0], err, stderr.String())
+	}
+	encoded, err := os.ReadFile(outPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s encode via %s: %w", format, cmd.Args[0], err)
+	}
+	return encoded, nil
+}
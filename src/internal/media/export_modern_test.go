@@ -0,0 +1,85 @@
+package media
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestExportedNameModernFormats(t *testing.T) {
+	cases := map[string][2]string{
+		"avif": {"IMG_1.avif", "image/avif"},
+		"jxl":  {"IMG_1.jxl", "image/jxl"},
+	}
+	for format, want := range cases {
+		if got := ExportedName("IMG_1.HEIC", format); got != want[0] {
+			t.Errorf("ExportedName(%s) = %q, want %q", format, got, want[0])
+		}
+		if got := ExportMIMEType(format); got != want[1] {
+			t.Errorf("ExportMIMEType(%s) = %q, want %q", format, got, want[1])
+		}
+	}
+}
+
+func TestAVIFQualityMapping(t *testing.T) {
+	if q := avifQuality(100); q != 100 {
+		t.Errorf("avifQuality(100) = %d, want 100", q)
+	}
+	if q := avifQuality(85); q != 75 {
+		t.Errorf("avifQuality(85) = %d, want 75", q)
+	}
+	if q := avifQuality(1); q != 1 {
+		t.Errorf("avifQuality(1) = %d, want 1", q)
+	}
+	if crf := avifCRF(100); crf != 0 {
+		t.Errorf("avifCRF(100) = %d, want 0", crf)
+	}
+	prev := -1
+	for q := 100; q >= 1; q-- {
+		crf := avifCRF(q)
+		if crf < prev || crf > 63 {
+			t.Fatalf("avifCRF(%d) = %d, not monotonic within 0–63", q, crf)
+		}
+		prev = crf
+	}
+}
+
+func TestFFmpegAVIFArgs(t *testing.T) {
+	aom := strings.Join(ffmpegAVIFArgs("libaom-av1", 85), " ")
+	if !strings.Contains(aom, "-still-picture 1") || !strings.Contains(aom, "-pix_fmt yuv444p") {
+		t.Errorf("libaom-av1 args = %q", aom)
+	}
+	svt := strings.Join(ffmpegAVIFArgs("libsvtav1", 85), " ")
+	if strings.Contains(svt, "-still-picture") || !strings.Contains(svt, "-pix_fmt yuv420p") {
+		t.Errorf("libsvtav1 args = %q", svt)
+	}
+}
+
+func TestJXLDistanceMapping(t *testing.T) {
+	cases := map[int]float64{100: 0, 90: 1.0, 30: 6.4}
+	for q, want := range cases {
+		if got := jxlDistance(q); math.Abs(got-want) > 1e-9 {
+			t.Errorf("jxlDistance(%d) = %v, want %v", q, got, want)
+		}
+	}
+	if jxlDistance(1) <= jxlDistance(29) {
+		t.Error("jxlDistance should grow as quality drops below 30")
+	}
+}
+
+func TestExportModernFormatsMissingEncoder(t *testing.T) {
+	// The encoder check runs before the source is read.
+	src := "missing.jpg"
+	if !AVIFAvailable() {
+		_, err := ExportImage(src, ExportOptions{Format: "avif"})
+		if err == nil || !strings.Contains(err.Error(), "avifenc") {
+			t.Errorf("expected install hint for AVIF, got %v", err)
+		}
+	}
+	if !JXLAvailable() {
+		_, err := ExportImage(src, ExportOptions{Format: "jxl"})
+		if err == nil || !strings.Contains(err.Error(), "cjxl") {
+			t.Errorf("expected install hint for JPEG XL, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+)
+
+// ErrSizeUnreachable is returned when an export cannot be made to fit
+// ExportOptions.MaxBytes.
+var ErrSizeUnreachable = errors.New("output size limit unreachable")
+
+const (
+	// minSizeQuality is the lowest quality the size search goes down to;
+	// below it, artefacts are worse than a smaller image.
+	minSizeQuality = 30
+	// maxDownscaleSteps bounds how often the dimensions are reduced.
+	maxDownscaleSteps = 6
+)
+
+// ExportResult is an export together with the settings that produced it.
+type ExportResult struct {
+	Data    []byte
+	Quality int     // quality used; for size-limited exports the one found by the search
+	Scale   float64 // extra downscale applied to fit MaxBytes (1 = none)
+}
+
+// sizeEncoder encodes an already rendered image at quality q, including
+// colour profile and metadata, exactly as the final export will be written.
+type sizeEncoder func(img image.Image, q int) ([]byte, error)
+
+// exportToSize renders srcPath once and binary-searches the quality between
+// minSizeQuality and opts.Quality for the largest output within opts.MaxBytes.
+// Output size grows monotonically with quality for all encoders used here
+// closely enough for a binary search. If even minSizeQuality is too large and
+// opts.AllowDownscale is set, the rendered image is shrunk by the square root
+// of the overshoot (bytes scale with area) and the search repeats.
+func exportToSize(srcPath string, opts ExportOptions) (ExportResult, error) {
+	img, icc, err := renderImage(srcPath, opts)
+	if err != nil {
+		return ExportResult{}, err
+	}
+	encode, cleanup, err := newSizeEncoder(srcPath, opts, icc)
+	if err != nil {
+		return ExportResult{}, err
+	}
+	defer cleanup()
+
+	cur, factor := img, 1.0
+	for step := 0; ; step++ {
+		data, q, smallest, err := searchQuality(cur, opts, encode)
+		if err != nil {
+			return ExportResult{}, err
+		}
+		if data != nil {
+			return ExportResult{Data: data, Quality: q, Scale: factor}, nil
+		}
+		if !opts.AllowDownscale || step == maxDownscaleSteps {
+			return ExportResult{}, fmt.Errorf("%w: smallest output is %d bytes, limit %d", ErrSizeUnreachable, smallest, opts.MaxBytes)
+		}
+		factor *= min(0.9, 0.95*math.Sqrt(float64(opts.MaxBytes)/float64(smallest)))
+		b := img.Bounds()
+		cur = scaleImage(img, ScaleOptions{
+			Mode:   ScaleModePixels,
+			Width:  max(1, int(float64(b.Dx())*factor)),
+			Height: max(1, int(float64(b.Dy())*factor)),
+			Filter: opts.Scale.Filter,
+		})
+	}
+}
+
+// searchQuality returns the output at the highest quality in
+// [minSizeQuality, opts.Quality] that fits opts.MaxBytes, or nil data and the
+// size at the lowest quality tried. PNG has no quality setting and is encoded
+// once.
+func searchQuality(img image.Image, opts ExportOptions, encode sizeEncoder) (data []byte, quality int, smallest int64, err error) {
+	fits := func(b []byte) bool { return int64(len(b)) <= opts.MaxBytes }
+
+	top, err := encode(img, opts.Quality)
+	if err != nil || fits(top) {
+		return top, opts.Quality, int64(len(top)), err
+	}
+	smallest = int64(len(top))
+	if opts.Format == "png" {
+		return nil, 0, smallest, nil
+	}
+
+	lo, hi := minSizeQuality, opts.Quality-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		out, err := encode(img, mid)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if fits(out) {
+			data, quality = out, mid
+			lo = mid + 1
+		} else {
+			smallest = min(smallest, int64(len(out)))
+			hi = mid - 1
+		}
+	}
+	return data, quality, smallest, nil
+}
+
+// newSizeEncoder returns the encoder for opts.Format. Tool-based formats get a
+// scratch directory for their PNG input, removed by cleanup.
+func newSizeEncoder(srcPath string, opts ExportOptions, icc []byte) (sizeEncoder, func(), error) {
+	finish := func(encoded []byte, format string, q int) []byte {
+		if opts.ColorMode == ColorModePreserve && format != "avif" && format != "jxl" {
+			encoded = embedICC(encoded, format, icc)
+		}
+		o := opts
+		o.Quality = q
+		return applyMetadata(srcPath, encoded, format, o)
+	}
+
+	switch opts.Format {
+	case "jpeg", "png":
+		return func(img image.Image, q int) ([]byte, error) {
+			o := opts
+			o.Quality = q
+			encoded, err := encodeToFormat(img, o)
+			if err != nil {
+				return nil, err
+			}
+			return finish(encoded, opts.Format, q), nil
+		}, func() {}, nil
+	}
+
+	var tool func(q int) toolEncoder
+	switch opts.Format {
+	case "webp":
+		if !CheckFFmpeg().WebPSupport && !CheckCwebp() {
+			return nil, nil, fmt.Errorf("WebP encoder not found — ffmpeg must be built with libwebp support, or install cwebp")
+		}
+	case "avif":
+		if !AVIFAvailable() {
+			return nil, nil, errAVIFUnavailable
+		}
+		tool = avifEncoder
+	case "jxl":
+		if !JXLAvailable() {
+			return nil, nil, errJXLUnavailable
+		}
+		tool = jxlEncoder
+	default:
+		return nil, nil, fmt.Errorf("unsupported format: %s", opts.Format)
+	}
+
+	dir, err := os.MkdirTemp("", "unterlumen-size-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	// The PNG input is rewritten only when the image changes (downscaling).
+	var written image.Image
+	var pngPath string
+	return func(img image.Image, q int) ([]byte, error) {
+		if img != written {
+			o := opts
+			if opts.Format == "webp" {
+				o.ColorMode = "" // WebP gets the profile embedded after encoding
+			}
+			p, err := writeToolInput(dir, img, icc, o)
+			if err != nil {
+				return nil, err
+			}
+			written, pngPath = img, p
+		}
+		var encoded []byte
+		var err error
+		if opts.Format == "webp" {
+			o := opts
+			o.Quality = q
+			o.Scale = ScaleOptions{Mode: ScaleModeNone}
+			o.Sharpen = SharpenNone
+			if !CheckFFmpeg().WebPSupport {
+				encoded, err = exportWebPCwebp(pngPath, o)
+			} else {
+				encoded, err = exportWebPFFmpeg(pngPath, o)
+			}
+		} else {
+			encoded, err = runToolEncoder(dir, pngPath, opts.Format, tool(q))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return finish(encoded, opts.Format, q), nil
+	}, cleanup, nil
+}
@@ -0,0 +1,94 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeNoisyJPEG writes a w×h JPEG of random noise, which compresses poorly
+// and so makes output size depend strongly on quality.
+func writeNoisyJPEG(t *testing.T, w, h int) string {
+	t.Helper()
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255})
+		}
+	}
+	path := filepath.Join(t.TempDir(), "noise.jpg")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExportToSizeFindsQuality(t *testing.T) {
+	src := writeNoisyJPEG(t, 200, 200)
+	full, err := ExportImage(src, ExportOptions{Format: "jpeg", Quality: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit := int64(len(full)) * 2 / 3
+
+	res, err := ExportImageResult(src, ExportOptions{Format: "jpeg", Quality: 90, MaxBytes: limit})
+	if err != nil {
+		t.Fatalf("ExportImageResult: %v", err)
+	}
+	if int64(len(res.Data)) > limit {
+		t.Errorf("output %d bytes exceeds limit %d", len(res.Data), limit)
+	}
+	if res.Quality >= 90 || res.Quality < minSizeQuality {
+		t.Errorf("chosen quality = %d, want in [%d, 90)", res.Quality, minSizeQuality)
+	}
+	if res.Scale != 1 {
+		t.Errorf("scale = %v, want 1 without downscaling", res.Scale)
+	}
+	// The next quality up must not fit, or the search stopped too early.
+	above, _ := ExportImage(src, ExportOptions{Format: "jpeg", Quality: res.Quality + 1})
+	if int64(len(above)) <= limit {
+		t.Errorf("quality %d also fits (%d bytes)", res.Quality+1, len(above))
+	}
+}
+
+func TestExportToSizeKeepsQualityWhenItFits(t *testing.T) {
+	src := writeNoisyJPEG(t, 64, 64)
+	res, err := ExportImageResult(src, ExportOptions{Format: "jpeg", Quality: 80, MaxBytes: 10 << 20})
+	if err != nil || res.Quality != 80 {
+		t.Errorf("got quality %d, %v; want 80", res.Quality, err)
+	}
+}
+
+func TestExportToSizeDownscale(t *testing.T) {
+	src := writeNoisyJPEG(t, 300, 200)
+	opts := ExportOptions{Format: "png", MaxBytes: 40_000}
+
+	if _, err := ExportImageResult(src, opts); !errors.Is(err, ErrSizeUnreachable) {
+		t.Fatalf("without downscaling: err = %v, want ErrSizeUnreachable", err)
+	}
+
+	opts.AllowDownscale = true
+	res, err := ExportImageResult(src, opts)
+	if err != nil {
+		t.Fatalf("with downscaling: %v", err)
+	}
+	if int64(len(res.Data)) > opts.MaxBytes || res.Scale >= 1 {
+		t.Errorf("got %d bytes at scale %.2f", len(res.Data), res.Scale)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(res.Data))
+	if err != nil || cfg.Width >= 300 || cfg.Width != int(300*res.Scale) {
+		t.Errorf("output width %d, scale %.3f, err %v", cfg.Width, res.Scale, err)
+	}
+}
@@ -1,6 +1,11 @@
 package media
 
-import "testing"
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"testing"
+)
 
 func TestComputeTargetDims_None(t *testing.T) {
 	w, h := computeTargetDims(3000, 2000, ScaleOptions{Mode: ScaleModeNone})
@@ -69,3 +74,22 @@ func TestComputeTargetDims_MaxDimZero(t *testing.T) {
 		t.Errorf("MaxValue=0 should be no-op, got %dx%d", w, h)
 	}
 }
+
+func TestIsTransient(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 1").Run()
+	if exitErr == nil {
+		t.Fatal("expected exit error")
+	}
+	if IsTransient(fmt.Errorf("ffmpeg failed: %w", exitErr)) {
+		t.Error("non-zero exit must not be transient")
+	}
+	killed := exec.Command("sh", "-c", "kill -9 $$").Run()
+	if !IsTransient(fmt.Errorf("ffmpeg failed: %w", killed)) {
+		t.Errorf("killed tool should be transient: %v", killed)
+	}
+	for _, err := range []error{syscall.EAGAIN, syscall.EMFILE, syscall.ENFILE} {
+		if !IsTransient(fmt.Errorf("heif-convert: %w", err)) {
+			t.Errorf("%v should be transient", err)
+		}
+	}
+}
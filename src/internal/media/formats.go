@@ -41,6 +41,8 @@ type FFmpegStatus struct {
 	Available    bool
 	HEIFSupport  bool
 	WebPSupport  bool
+	AVIFEncoder  string // "libaom-av1", "libsvtav1" or "" when ffmpeg cannot encode AVIF
+	JXLSupport   bool   // libjxl encoder
 	ErrorMessage string
 }
 
@@ -86,7 +88,15 @@ func CheckFFmpeg() FFmpegStatus {
 		encCmd.Stdout = &encOut
 		encCmd.Stderr = &bytes.Buffer{}
 		if err := encCmd.Run(); err == nil {
-			ffmpegStatus.WebPSupport = strings.Contains(encOut.String(), "webp")
+			encoders := encOut.String()
+			ffmpegStatus.WebPSupport = strings.Contains(encoders, "webp")
+			for _, enc := range []string{"libaom-av1", "libsvtav1"} {
+				if strings.Contains(encoders, enc) {
+					ffmpegStatus.AVIFEncoder = enc
+					break
+				}
+			}
+			ffmpegStatus.JXLSupport = strings.Contains(encoders, "libjxl")
 		}
 	})
 
@@ -124,6 +134,38 @@ func CheckCwebp() bool {
 	return cwebpAvailable
 }
 
+var (
+	avifencAvailable     bool
+	avifencAvailableOnce sync.Once
+)
+
+// CheckAvifenc returns true if avifenc (from libavif / brew install libavif) is available.
+// Preferred AVIF encoder; ffmpeg with libaom/libsvtav1 is the fallback.
+// The result is cached for the lifetime of the process.
+func CheckAvifenc() bool {
+	avifencAvailableOnce.Do(func() {
+		path, err := exec.LookPath("avifenc")
+		avifencAvailable = err == nil && path != ""
+	})
+	return avifencAvailable
+}
+
+var (
+	cjxlAvailable     bool
+	cjxlAvailableOnce sync.Once
+)
+
+// CheckCjxl returns true if cjxl (from libjxl / brew install jpeg-xl) is available.
+// Preferred JPEG XL encoder; ffmpeg with libjxl is the fallback.
+// The result is cached for the lifetime of the process.
+func CheckCjxl() bool {
+	cjxlAvailableOnce.Do(func() {
+		path, err := exec.LookPath("cjxl")
+		cjxlAvailable = err == nil && path != ""
+	})
+	return cjxlAvailable
+}
+
 var (
 	heifConvertAvailable     bool
 	heifConvertAvailableOnce sync.Once
@@ -241,7 +283,7 @@ func EvictFile(srcPath string) {
 // and finally to HEVC decoding for simple HEIF files without previews.
 // Results are cached to disk.
 func ConvertHEIFToJPEG(ctx context.Context, path string) ([]byte, error) {
-	key := cacheKey(path, "full-v5")
+	key := cacheKey(path, "full-v6")
 	if cached := readCache(key); cached != nil {
 		return cached, nil
 	}
@@ -255,6 +297,7 @@ func ConvertHEIFToJPEG(ctx context.Context, path string) ([]byte, error) {
 		if err != nil {
 			return thumbnailWorkResult{err: err}
 		}
+		data = withHEIFProfile(path, data)
 
 		writeCache(key, data)
 		return thumbnailWorkResult{data: data}
@@ -324,7 +367,7 @@ func extractHEIFPreview(path string, maxDim int) ([]byte, error) {
 	streams, err := probeHEIFJPEGStreams(path)
 	if err == nil {
 		if stream, ok := chooseHEIFJPEGPreviewStream(streams, maxDim); ok {
-			key := cacheKey(path, fmt.Sprintf("preview-v5-%d-q80", stream.Index))
+			key := cacheKey(path, fmt.Sprintf("preview-v6-%d-q80", stream.Index))
 			if cached := readCache(key); cached != nil {
 				return cached, nil
 			}
@@ -336,6 +379,7 @@ func extractHEIFPreview(path string, maxDim int) ([]byte, error) {
 					ori = heifOrientation(path)
 				}
 				data, _ = applyOrientationJPEG(data, ori, 80)
+				data = withHEIFProfile(path, data)
 				writeCache(key, data)
 				return data, nil
 			}
@@ -343,7 +387,7 @@ func extractHEIFPreview(path string, maxDim int) ([]byte, error) {
 	}
 
 	if data, err := extractPreviewFallbackJPEG(path); err == nil && len(data) > 0 {
-		return data, nil
+		return withHEIFProfile(path, data), nil
 	}
 
 	return nil, fmt.Errorf("failed to extract HEIF preview")
@@ -451,7 +495,7 @@ func sipsConvert(path string) ([]byte, error) {
 	cmd := exec.Command("sips", "-s", "format", "jpeg", "-s", "formatOptions", "92", path, "--out", tmpPath)
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("sips failed: %v: %s", err, stderr.String())
+		return nil, fmt.Errorf("sips failed: %w: %s", err, stderr.String())
 	}
 
 	return os.ReadFile(tmpPath)
@@ -491,7 +535,7 @@ func heifConvert(path string) ([]byte, error) {
 		}
 	}
 	if runErr != nil {
-		return nil, fmt.Errorf("heif-convert failed: %v: %s", runErr, stderr.String())
+		return nil, fmt.Errorf("heif-convert failed: %w: %s", runErr, stderr.String())
 	}
 	return nil, fmt.Errorf("heif-convert produced no output")
 }
@@ -531,7 +575,7 @@ func ffmpegRun(inputPath string, args ...string) ([]byte, error) {
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("ffmpeg failed: %v: %s", err, stderr.String())
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, stderr.String())
 	}
 
 	return out.Bytes(), nil
@@ -0,0 +1,526 @@
+package media
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+// Colour handling modes for ExportOptions.ColorMode.
+const (
+	ColorModeSRGB     = "srgb"     // default: convert pixels to sRGB, embed no profile
+	ColorModePreserve = "preserve" // keep pixel values, re-embed the source profile
+)
+
+// maxICCScan bounds how much of a source file is read when looking for an
+// embedded profile. Profiles live in the file header in every supported format.
+const maxICCScan = 4 << 20
+
+// iccProfile is a parsed RGB matrix/TRC ("matrix-shaper") ICC profile, the
+// kind used by Display P3, Adobe RGB, ProPhoto and sRGB. LUT-based profiles are
+// not supported; images using them are left untouched.
+type iccProfile struct {
+	toXYZ  [3][3]float64 // linear RGB → PCS XYZ (D50); columns are the r, g, b colorants
+	curves [3]func(float64) float64
+}
+
+// sRGB colorants (D50-adapted, as in the ICC sRGB profile) and the inverse
+// matrix from PCS XYZ (D50) to linear sRGB.
+var (
+	srgbColorants = [3][3]float64{
+		{0.4360747, 0.3850649, 0.1430804},
+		{0.2225045, 0.7168786, 0.0606169},
+		{0.0139322, 0.0971045, 0.7141733},
+	}
+	xyzD50ToSRGB = [3][3]float64{
+		{3.1338561, -1.6168667, -0.4906146},
+		{-0.9787684, 1.9161415, 0.0334540},
+		{0.0719453, -0.2289914, 1.4052427},
+	}
+)
+
+var errUnsupportedICC = errors.New("unsupported ICC profile")
+
+// parseICC parses the colorant and tone-curve tags of an RGB ICC profile.
+func parseICC(data []byte) (*iccProfile, error) {
+	if len(data) < 132 || string(data[36:40]) != "acsp" {
+		return nil, errors.New("not an ICC profile")
+	}
+	if string(data[16:20]) != "RGB " || string(data[20:24]) != "XYZ " {
+		return nil, errUnsupportedICC
+	}
+	tags := make(map[string][]byte)
+	n := int(binary.BigEndian.Uint32(data[128:132]))
+	for i := 0; i < n && 132+12*(i+1) <= len(data); i++ {
+		e := data[132+12*i:]
+		off, size := int(binary.BigEndian.Uint32(e[4:8])), int(binary.BigEndian.Uint32(e[8:12]))
+		if off >= 0 && size >= 0 && off+size <= len(data) {
+			tags[string(e[0:4])] = data[off : off+size]
+		}
+	}
+	p := &iccProfile{}
+	for c, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		xyz, ok := parseXYZTag(tags[sig])
+		if !ok {
+			return nil, errUnsupportedICC
+		}
+		for r := 0; r < 3; r++ {
+			p.toXYZ[r][c] = xyz[r]
+		}
+	}
+	for c, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		curve, ok := parseCurveTag(tags[sig])
+		if !ok {
+			return nil, errUnsupportedICC
+		}
+		p.curves[c] = curve
+	}
+	return p, nil
+}
+
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+func parseXYZTag(b []byte) ([3]float64, bool) {
+	if len(b) < 20 || string(b[0:4]) != "XYZ " {
+		return [3]float64{}, false
+	}
+	return [3]float64{s15Fixed16(b[8:]), s15Fixed16(b[12:]), s15Fixed16(b[16:])}, true
+}
+
+// parseCurveTag returns the tone response curve (encoded → linear) of a
+// 'curv' or 'para' tag.
+func parseCurveTag(b []byte) (func(float64) float64, bool) {
+	if len(b) < 12 {
+		return nil, false
+	}
+	switch string(b[0:4]) {
+	case "curv":
+		count := int(binary.BigEndian.Uint32(b[8:12]))
+		switch {
+		case count == 0:
+			return func(x float64) float64 { return x }, true
+		case count == 1 && len(b) >= 14:
+			g := float64(binary.BigEndian.Uint16(b[12:14])) / 256
+			return func(x float64) float64 { return math.Pow(x, g) }, true
+		case len(b) >= 12+2*count:
+			table := make([]float64, count)
+			for i := range table {
+				table[i] = float64(binary.BigEndian.Uint16(b[12+2*i:])) / 65535
+			}
+			return func(x float64) float64 {
+				pos := x * float64(count-1)
+				i := min(int(pos), count-2)
+				f := pos - float64(i)
+				return table[i]*(1-f) + table[i+1]*f
+			}, true
+		}
+	case "para":
+		fn := binary.BigEndian.Uint16(b[8:10])
+		nParams := map[uint16]int{0: 1, 1: 3, 2: 4, 3: 5, 4: 7}[fn]
+		if nParams == 0 && fn != 0 || len(b) < 12+4*nParams {
+			return nil, false
+		}
+		var q [7]float64
+		q[1] = 1 // a
+		for i := 0; i < nParams; i++ {
+			q[i] = s15Fixed16(b[12+4*i:])
+		}
+		g, a, bb, c, d, e, f := q[0], q[1], q[2], q[3], q[4], q[5], q[6]
+		// Embedded profiles are untrusted: a zero or negative exponent or
+		// a = 0 would make the curve yield NaN or Inf.
+		for _, v := range q {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, false
+			}
+		}
+		if g <= 0 || a == 0 {
+			return nil, false
+		}
+		pow := func(v float64) float64 { return math.Pow(math.Max(v, 0), g) }
+		switch fn {
+		case 0:
+			return func(x float64) float64 { return pow(x) }, true
+		case 1:
+			return func(x float64) float64 {
+				if x >= -bb/a {
+					return pow(a*x + bb)
+				}
+				return 0
+			}, true
+		case 2:
+			return func(x float64) float64 {
+				if x >= -bb/a {
+					return pow(a*x+bb) + c
+				}
+				return c
+			}, true
+		case 3:
+			return func(x float64) float64 {
+				if x >= d {
+					return pow(a*x + bb)
+				}
+				return c * x
+			}, true
+		case 4:
+			return func(x float64) float64 {
+				if x >= d {
+					return pow(a*x+bb) + e
+				}
+				return c*x + f
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// isSRGB reports whether the profile's colorants match sRGB closely enough that
+// converting would be a visual no-op.
+func (p *iccProfile) isSRGB() bool {
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			if math.Abs(p.toXYZ[r][c]-srgbColorants[r][c]) > 0.002 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// convertToSRGB converts img from the colour space described by icc to sRGB.
+// The image is returned unchanged when icc is empty, already sRGB or not a
+// supported matrix/TRC RGB profile.
+func convertToSRGB(img image.Image, icc []byte) image.Image {
+	if len(icc) == 0 {
+		return img
+	}
+	p, err := parseICC(icc)
+	if err != nil || p.isSRGB() {
+		return img
+	}
+	return p.toSRGB(img)
+}
+
+// needsSRGBConversion reports whether icc describes a supported non-sRGB space.
+func needsSRGBConversion(icc []byte) bool {
+	if len(icc) == 0 {
+		return false
+	}
+	p, err := parseICC(icc)
+	return err == nil && !p.isSRGB()
+}
+
+func (p *iccProfile) toSRGB(img image.Image) *image.NRGBA {
+	var in [3][256]float64
+	for c := 0; c < 3; c++ {
+		for v := 0; v < 256; v++ {
+			in[c][v] = p.curves[c](float64(v) / 255)
+		}
+	}
+	var m [3][3]float64
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			for k := 0; k < 3; k++ {
+				m[r][c] += xyzD50ToSRGB[r][k] * p.toXYZ[k][c]
+			}
+		}
+	}
+	const outSteps = 4096
+	var out [outSteps]uint8
+	for i := range out {
+		out[i] = uint8(math.Round(srgbEncode(float64(i)/(outSteps-1)) * 255))
+	}
+
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	for i := 0; i+3 < len(dst.Pix); i += 4 {
+		px := dst.Pix[i : i+3 : i+3]
+		lr, lg, lb := in[0][px[0]], in[1][px[1]], in[2][px[2]]
+		for c := 0; c < 3; c++ {
+			v := m[c][0]*lr + m[c][1]*lg + m[c][2]*lb
+			if !(v >= 0) { // also catches NaN
+				v = 0
+			} else if v > 1 {
+				v = 1
+			}
+			px[c] = out[int(v*(outSteps-1)+0.5)]
+		}
+	}
+	return dst
+}
+
+func srgbEncode(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// --- Extraction ---
+
+// sourceICC returns the ICC profile embedded in the image file at path
+// (JPEG APP2, WebP ICCP chunk or HEIF colr box), or nil.
+func sourceICC(path string) []byte {
+	if IsHEIF(path) {
+		return heifICC(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	head, _ := io.ReadAll(io.LimitReader(f, maxICCScan))
+	switch {
+	case len(head) > 2 && head[0] == 0xFF && head[1] == 0xD8:
+		return jpegICC(head)
+	case len(head) > 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return webpICC(head)
+	}
+	return nil
+}
+
+const jpegICCMarker = "ICC_PROFILE\x00"
+
+// jpegICC reassembles an ICC profile from JPEG APP2 segments.
+func jpegICC(data []byte) []byte {
+	type chunk struct {
+		seq  byte
+		data []byte
+	}
+	var chunks []chunk
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image
+			break
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + size
+		if size < 2 || end > len(data) {
+			break
+		}
+		seg := data[i+4 : end]
+		if marker == 0xE2 && len(seg) > len(jpegICCMarker)+2 && string(seg[:len(jpegICCMarker)]) == jpegICCMarker {
+			chunks = append(chunks, chunk{seg[len(jpegICCMarker)], seg[len(jpegICCMarker)+2:]})
+		}
+		i = end
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	sort.Slice(chunks, func(a, b int) bool { return chunks[a].seq < chunks[b].seq })
+	var out []byte
+	for _, c := range chunks {
+		out = append(out, c.data...)
+	}
+	return out
+}
+
+// webpICC returns the payload of the ICCP chunk of an extended WebP file.
+func webpICC(data []byte) []byte {
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if i+8+size > len(data) {
+			break
+		}
+		if string(data[i:i+4]) == "ICCP" {
+			return data[i+8 : i+8+size]
+		}
+		i += 8 + size + size&1
+	}
+	return nil
+}
+
+// heifICC returns the ICC profile from a HEIF colr box of type 'prof' or 'rICC'.
+// Like ExtractHEIFOrientation it scans the container header for the box rather
+// than walking the full box tree.
+func heifICC(path string) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	buf, _ := io.ReadAll(io.LimitReader(f, 512*1024))
+	for i := 4; i+8 <= len(buf); i++ {
+		if string(buf[i:i+4]) != "colr" {
+			continue
+		}
+		size := int(binary.BigEndian.Uint32(buf[i-4:]))
+		kind := string(buf[i+4 : i+8])
+		if (kind == "prof" || kind == "rICC") && size > 12 && i-4+size <= len(buf) {
+			return buf[i+8 : i-4+size]
+		}
+	}
+	return nil
+}
+
+// withHEIFProfile embeds the HEIF container's ICC profile into a JPEG decoded
+// from it when the decoder did not carry the profile over itself.
+func withHEIFProfile(path string, jpegData []byte) []byte {
+	if jpegICC(jpegData) != nil {
+		return jpegData
+	}
+	if icc := heifICC(path); icc != nil {
+		return embedICC(jpegData, "jpeg", icc)
+	}
+	return jpegData
+}
+
+// --- Embedding ---
+
+// embedICC embeds icc into encoded image data of the given format ("jpeg",
+// "png" or "webp"). Data is returned unchanged for other formats or when the
+// container cannot be parsed.
+func embedICC(data []byte, format string, icc []byte) []byte {
+	if len(icc) == 0 {
+		return data
+	}
+	switch format {
+	case "jpeg":
+		return embedJPEGICC(data, icc)
+	case "png":
+		return embedPNGICC(data, icc)
+	case "webp":
+		return embedWebPICC(data, icc)
+	}
+	return data
+}
+
+// embedJPEGICC inserts icc as APP2 segments after the leading APP0/APP1 segments.
+func embedJPEGICC(data, icc []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return data
+	}
+	at := 2
+	for at+4 <= len(data) && data[at] == 0xFF && (data[at+1] == 0xE0 || data[at+1] == 0xE1) {
+		at += 2 + int(binary.BigEndian.Uint16(data[at+2:]))
+	}
+	if at > len(data) {
+		return data
+	}
+	const maxChunk = 65535 - 2 - len(jpegICCMarker) - 2
+	count := (len(icc) + maxChunk - 1) / maxChunk
+	if count > 255 {
+		return data
+	}
+	var seg bytes.Buffer
+	for i := 0; i < count; i++ {
+		part := icc[i*maxChunk : min(len(icc), (i+1)*maxChunk)]
+		seg.Write([]byte{0xFF, 0xE2})
+		binary.Write(&seg, binary.BigEndian, uint16(2+len(jpegICCMarker)+2+len(part)))
+		seg.WriteString(jpegICCMarker)
+		seg.Write([]byte{byte(i + 1), byte(count)})
+		seg.Write(part)
+	}
+	out := make([]byte, 0, len(data)+seg.Len())
+	out = append(out, data[:at]...)
+	out = append(out, seg.Bytes()...)
+	return append(out, data[at:]...)
+}
+
+// embedPNGICC inserts an iCCP chunk directly after IHDR.
+func embedPNGICC(data, icc []byte) []byte {
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+	if len(data) < ihdrEnd || string(data[12:16]) != "IHDR" {
+		return data
+	}
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(icc)
+	zw.Close()
+	body := append([]byte("ICC profile\x00\x00"), z.Bytes()...)
+
+	var chunk bytes.Buffer
+	binary.Write(&chunk, binary.BigEndian, uint32(len(body)))
+	typed := append([]byte("iCCP"), body...)
+	chunk.Write(typed)
+	binary.Write(&chunk, binary.BigEndian, crc32.ChecksumIEEE(typed))
+
+	out := make([]byte, 0, len(data)+chunk.Len())
+	out = append(out, data[:ihdrEnd]...)
+	out = append(out, chunk.Bytes()...)
+	return append(out, data[ihdrEnd:]...)
+}
+
+// embedWebPICC adds an ICCP chunk to a WebP file, converting a simple
+// (VP8/VP8L) file to the extended VP8X layout when needed.
+func embedWebPICC(data, icc []byte) []byte {
+	if len(data) < 20 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return data
+	}
+	var body []byte // chunks after "WEBP"
+	switch string(data[12:16]) {
+	case "VP8X":
+		if len(data) < 30 || webpICC(data) != nil {
+			return data
+		}
+		vp8x := append([]byte(nil), data[12:30]...)
+		vp8x[8] |= 0x20 // ICC flag
+		body = append(vp8x, webpChunk("ICCP", icc)...)
+		body = append(body, data[30:]...)
+	case "VP8 ", "VP8L":
+		w, h, ok := webpCanvasSize(data[12:])
+		if !ok {
+			return data
+		}
+		vp8x := make([]byte, 10)
+		vp8x[0] = 0x20
+		putUint24LE(vp8x[4:], uint32(w-1))
+		putUint24LE(vp8x[7:], uint32(h-1))
+		body = append(webpChunk("VP8X", vp8x), webpChunk("ICCP", icc)...)
+		body = append(body, data[12:]...)
+	default:
+		return data
+	}
+	out := make([]byte, 12, 12+len(body))
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], uint32(4+len(body)))
+	copy(out[8:], "WEBP")
+	return append(out, body...)
+}
+
+func webpChunk(fourcc string, payload []byte) []byte {
+	c := make([]byte, 8, 8+len(payload)+1)
+	copy(c, fourcc)
+	binary.LittleEndian.PutUint32(c[4:], uint32(len(payload)))
+	c = append(c, payload...)
+	if len(payload)%2 == 1 {
+		c = append(c, 0)
+	}
+	return c
+}
+
+// webpCanvasSize reads the image dimensions from a VP8 or VP8L chunk.
+func webpCanvasSize(chunk []byte) (int, int, bool) {
+	switch string(chunk[0:4]) {
+	case "VP8 ":
+		// Frame tag (3 bytes), start code 9d 01 2a, then 14-bit width and height.
+		if len(chunk) < 18 || chunk[11] != 0x9d || chunk[12] != 0x01 || chunk[13] != 0x2a {
+			return 0, 0, false
+		}
+		w := int(binary.LittleEndian.Uint16(chunk[14:]) & 0x3fff)
+		h := int(binary.LittleEndian.Uint16(chunk[16:]) & 0x3fff)
+		return w, h, w > 0 && h > 0
+	case "VP8L":
+		if len(chunk) < 13 || chunk[8] != 0x2f {
+			return 0, 0, false
+		}
+		bits := binary.LittleEndian.Uint32(chunk[9:])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, true
+	}
+	return 0, 0, false
+}
+
+func putUint24LE(b []byte, v uint32) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
@@ -0,0 +1,253 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Adobe RGB (1998) colorants, D50-adapted, with its 563/256 gamma.
+var adobeColorants = [3][3]float64{
+	{0.6097559, 0.2052401, 0.1492240},
+	{0.3111242, 0.6256560, 0.0632197},
+	{0.0194811, 0.0608902, 0.7448387},
+}
+
+// buildTestICC assembles a minimal matrix/TRC RGB profile.
+func buildTestICC(colorants [3][3]float64, gamma float64) []byte {
+	curv := []byte("curv\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00")
+	binary.BigEndian.PutUint16(curv[12:], uint16(gamma*256))
+	return buildTestICCWithTRC(colorants, curv)
+}
+
+// buildTestICCWithTRC is buildTestICC with trc as the tag data of all three
+// tone response curves.
+func buildTestICCWithTRC(colorants [3][3]float64, trc []byte) []byte {
+	type tag struct {
+		sig  string
+		data []byte
+	}
+	fixed := func(v float64) []byte {
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, uint32(int32(v*65536)))
+		return b
+	}
+	var tags []tag
+	for c, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		d := append([]byte("XYZ \x00\x00\x00\x00"), fixed(colorants[0][c])...)
+		d = append(d, fixed(colorants[1][c])...)
+		d = append(d, fixed(colorants[2][c])...)
+		tags = append(tags, tag{sig, d})
+	}
+	for _, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		tags = append(tags, tag{sig, trc})
+	}
+
+	header := make([]byte, 128)
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	copy(header[36:], "acsp")
+	table := make([]byte, 4+12*len(tags))
+	binary.BigEndian.PutUint32(table, uint32(len(tags)))
+	var body []byte
+	off := 128 + len(table)
+	for i, t := range tags {
+		e := table[4+12*i:]
+		copy(e, t.sig)
+		binary.BigEndian.PutUint32(e[4:], uint32(off+len(body)))
+		binary.BigEndian.PutUint32(e[8:], uint32(len(t.data)))
+		body = append(body, t.data...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	out := append(append(header, table...), body...)
+	binary.BigEndian.PutUint32(out[0:], uint32(len(out)))
+	return out
+}
+
+func TestParseICC(t *testing.T) {
+	p, err := parseICC(buildTestICC(adobeColorants, 563.0/256))
+	if err != nil {
+		t.Fatalf("parseICC: %v", err)
+	}
+	if p.isSRGB() {
+		t.Error("Adobe RGB must not be treated as sRGB")
+	}
+	if got := p.curves[0](0.5); got < 0.21 || got > 0.22 { // 0.5^2.2 ≈ 0.218
+		t.Errorf("gamma curve(0.5) = %v", got)
+	}
+	srgb, err := parseICC(buildTestICC(srgbColorants, 2.2))
+	if err != nil || !srgb.isSRGB() {
+		t.Errorf("expected sRGB profile to be detected, err=%v", err)
+	}
+	if _, err := parseICC([]byte("garbage")); err == nil {
+		t.Error("expected error for garbage")
+	}
+}
+
+func TestParseICCRejectsMalformedParametricCurve(t *testing.T) {
+	para := func(fn uint16, params ...float64) []byte {
+		b := []byte("para\x00\x00\x00\x00\x00\x00\x00\x00")
+		binary.BigEndian.PutUint16(b[8:], fn)
+		for _, v := range params {
+			b = binary.BigEndian.AppendUint32(b, uint32(int32(v*65536)))
+		}
+		return b
+	}
+	if _, err := parseICC(buildTestICCWithTRC(adobeColorants, para(0, 2.2))); err != nil {
+		t.Fatalf("valid parametric curve: %v", err)
+	}
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.RGBA{128, 128, 128, 255})
+	src.Set(1, 0, color.RGBA{255, 0, 0, 255})
+	for name, trc := range map[string][]byte{
+		"zero gamma":     para(0, 0),
+		"negative gamma": para(3, -2.4, 1/1.055, 0.055/1.055, 1/12.92, 0.04045),
+		"zero slope":     para(1, 2.2, 0, 0),
+	} {
+		icc := buildTestICCWithTRC(adobeColorants, trc)
+		if _, err := parseICC(icc); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		// Must not panic; the image is left as is.
+		if convertToSRGB(src, icc) != image.Image(src) {
+			t.Errorf("%s: image should be returned unchanged", name)
+		}
+	}
+}
+
+func TestConvertToSRGB(t *testing.T) {
+	icc := buildTestICC(adobeColorants, 563.0/256)
+	src := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	src.Set(0, 0, color.RGBA{128, 128, 128, 255})
+	src.Set(1, 0, color.RGBA{0, 255, 0, 255})
+	src.Set(2, 0, color.RGBA{255, 255, 255, 255})
+
+	out := convertToSRGB(src, icc)
+	grey := color.NRGBAModel.Convert(out.At(0, 0)).(color.NRGBA)
+	if d := int(grey.R) - int(grey.B); d < -1 || d > 1 || grey.R < 120 || grey.R > 136 {
+		t.Errorf("neutral grey should stay neutral, got %v", grey)
+	}
+	green := color.NRGBAModel.Convert(out.At(1, 0)).(color.NRGBA)
+	if green.G != 255 || green.R != 0 {
+		// Adobe RGB green lies outside sRGB: it clips to full sRGB green.
+		t.Errorf("Adobe green: got %v", green)
+	}
+	white := color.NRGBAModel.Convert(out.At(2, 0)).(color.NRGBA)
+	if white.R < 254 || white.G < 254 || white.B < 254 {
+		t.Errorf("white should stay white, got %v", white)
+	}
+
+	if convertToSRGB(src, nil) != image.Image(src) {
+		t.Error("no profile: image should be returned unchanged")
+	}
+	if convertToSRGB(src, buildTestICC(srgbColorants, 2.2)) != image.Image(src) {
+		t.Error("sRGB profile: image should be returned unchanged")
+	}
+}
+
+func testJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestJPEGICCRoundTrip(t *testing.T) {
+	for _, size := range []int{600, 70000} { // single and multi-segment
+		icc := bytes.Repeat([]byte{0xAB, 0xCD, 0xEF}, size/3)
+		data := embedICC(testJPEG(t), "jpeg", icc)
+		if got := jpegICC(data); !bytes.Equal(got, icc) {
+			t.Errorf("size %d: round trip mismatch (%d bytes)", size, len(got))
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+			t.Errorf("size %d: JPEG no longer decodes: %v", size, err)
+		}
+	}
+	if jpegICC(testJPEG(t)) != nil {
+		t.Error("plain JPEG should have no profile")
+	}
+}
+
+func TestPNGEmbedICC(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	data := embedICC(buf.Bytes(), "png", []byte("profile"))
+	if !bytes.Contains(data, []byte("iCCP")) {
+		t.Error("missing iCCP chunk")
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("PNG no longer decodes: %v", err)
+	}
+}
+
+func TestWebPEmbedICC(t *testing.T) {
+	// Minimal lossless WebP: VP8L chunk with a 5-byte header for a 300×200 canvas.
+	vp8l := []byte{0x2f, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(vp8l[1:], uint32(299)|uint32(199)<<14)
+	body := append([]byte("WEBP"), webpChunk("VP8L", vp8l)...)
+	data := append([]byte("RIFF\x00\x00\x00\x00"), body...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(body)))
+
+	icc := []byte("abc") // odd length exercises chunk padding
+	out := embedICC(data, "webp", icc)
+	if string(out[12:16]) != "VP8X" || out[20]&0x20 == 0 {
+		t.Fatalf("expected VP8X with ICC flag, got %q flags=%x", out[12:16], out[20])
+	}
+	w := int(out[24]) | int(out[25])<<8 | int(out[26])<<16
+	h := int(out[27]) | int(out[28])<<8 | int(out[29])<<16
+	if w+1 != 300 || h+1 != 200 {
+		t.Errorf("canvas = %dx%d, want 300x200", w+1, h+1)
+	}
+	if got := webpICC(out); !bytes.Equal(got, icc) {
+		t.Errorf("webpICC = %q", got)
+	}
+	if int(binary.LittleEndian.Uint32(out[4:])) != len(out)-8 {
+		t.Error("RIFF size not updated")
+	}
+}
+
+func TestExportImageColorModes(t *testing.T) {
+	icc := buildTestICC(adobeColorants, 563.0/256)
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = []byte{40, 160, 60, 255}[i%4]
+	}
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100})
+	srcPath := filepath.Join(t.TempDir(), "adobe.jpg")
+	os.WriteFile(srcPath, embedICC(buf.Bytes(), "jpeg", icc), 0o644)
+
+	preserved, err := ExportImage(srcPath, ExportOptions{Format: "jpeg", Quality: 95, ColorMode: ColorModePreserve})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(jpegICC(preserved), icc) {
+		t.Error("preserve: source profile not re-embedded")
+	}
+
+	converted, err := ExportImage(srcPath, ExportOptions{Format: "jpeg", Quality: 95})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jpegICC(converted) != nil {
+		t.Error("srgb: no profile expected")
+	}
+	p, _ := jpeg.Decode(bytes.NewReader(preserved))
+	c, _ := jpeg.Decode(bytes.NewReader(converted))
+	pr, _, _, _ := p.At(8, 8).RGBA()
+	cr, _, _, _ := c.At(8, 8).RGBA()
+	if pr>>8 == cr>>8 {
+		t.Errorf("srgb conversion should change pixel values (R %d vs %d)", pr>>8, cr>>8)
+	}
+}
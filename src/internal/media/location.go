@@ -73,3 +73,45 @@ func WriteGPSLocation(absPath string, lat, lon float64) error {
 	}
 	return nil
 }
+
+// WriteGPSLocationWithAltitude writes GPS coordinates and, when alt is non-nil,
+// the GPS altitude (metres above sea level; negative values are written with
+// the below-sea-level reference) to the image file at absPath using exiftool.
+func WriteGPSLocationWithAltitude(absPath string, lat, lon float64, alt *float64) error {
+	if alt == nil {
+		return WriteGPSLocation(absPath, lat, lon)
+	}
+	if !CheckExiftool() {
+		return fmt.Errorf("exiftool is not available")
+	}
+
+	latRef := "N"
+	if lat < 0 {
+		latRef = "S"
+	}
+	lonRef := "E"
+	if lon < 0 {
+		lonRef = "W"
+	}
+	altRef := "0"
+	if *alt < 0 {
+		altRef = "1"
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("exiftool",
+		fmt.Sprintf("-GPSLatitude=%f", math.Abs(lat)),
+		fmt.Sprintf("-GPSLatitudeRef=%s", latRef),
+		fmt.Sprintf("-GPSLongitude=%f", math.Abs(lon)),
+		fmt.Sprintf("-GPSLongitudeRef=%s", lonRef),
+		fmt.Sprintf("-GPSAltitude=%.1f", math.Abs(*alt)),
+		fmt.Sprintf("-GPSAltitudeRef#=%s", altRef),
+		"-overwrite_original",
+		absPath,
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("exiftool GPS write failed: %v: %s", err, stderr.String())
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package media
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -84,6 +85,45 @@ func ParseISO(s string) (float64, bool) {
 	return v, true
 }
 
+// FormatAperture formats an EXIF FNumber value for display, e.g. "f/2.8" or
+// "f/8". It returns "" when the value cannot be parsed.
+func FormatAperture(s string) string {
+	v, ok := ParseFNumber(s)
+	if !ok {
+		return ""
+	}
+	return "f/" + trimZeroDecimal(v)
+}
+
+// FormatShutter formats an EXIF ExposureTime value for display: fractions of
+// a second as "1/250 s", longer exposures as "2 s" or "2.5 s". It returns ""
+// when the value cannot be parsed.
+func FormatShutter(s string) string {
+	v, ok := ParseExposureSeconds(s)
+	if !ok {
+		return ""
+	}
+	if v < 1 {
+		return fmt.Sprintf("1/%.0f s", 1/v)
+	}
+	return trimZeroDecimal(v) + " s"
+}
+
+// FormatISO formats an EXIF ISOSpeedRatings value for display, e.g.
+// "ISO 400". It returns "" when the value cannot be parsed.
+func FormatISO(s string) string {
+	v, ok := ParseISO(s)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("ISO %.0f", v)
+}
+
+// trimZeroDecimal formats v with one decimal, dropping a trailing ".0".
+func trimZeroDecimal(v float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", v), ".0")
+}
+
 // cleanNumericTag strips surrounding quotes that goexif adds to string tags.
 func cleanNumericTag(s string) string {
 	return strings.Trim(s, `"`)
@@ -136,6 +136,30 @@ func TestParseISO(t *testing.T) {
 	}
 }
 
+func TestFormatExposure(t *testing.T) {
+	cases := []struct {
+		format func(string) string
+		in     string
+		want   string
+	}{
+		{FormatAperture, `"28/10"`, "f/2.8"},
+		{FormatAperture, "8", "f/8"},
+		{FormatAperture, "0", ""},
+		{FormatShutter, `"1/250"`, "1/250 s"},
+		{FormatShutter, "0.004", "1/250 s"},
+		{FormatShutter, "2/1", "2 s"},
+		{FormatShutter, "5/2", "2.5 s"},
+		{FormatShutter, "0", ""},
+		{FormatISO, `"400"`, "ISO 400"},
+		{FormatISO, "abc", ""},
+	}
+	for _, c := range cases {
+		if got := c.format(c.in); got != c.want {
+			t.Errorf("format(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
 func TestNormalizeExifNumbers(t *testing.T) {
 	tags := map[string]string{
 		"ExposureTime":          `"1/500"`,
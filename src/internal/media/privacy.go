@@ -0,0 +1,135 @@
+package media
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ExifModeKeepOutsideZones keeps all metadata, but strips or fuzzes GPS for
+// photos taken inside one of ExportOptions.PrivacyZones.
+const ExifModeKeepOutsideZones = "keep_outside_zones"
+
+// Privacy zone actions.
+const (
+	ZoneActionStrip = "strip" // remove GPS entirely
+	ZoneActionFuzz  = "fuzz"  // snap GPS to the centre of a FuzzGridDeg grid cell
+)
+
+// FuzzGridDeg is the grid size used by ZoneActionFuzz (0.05° ≈ 5.5 km of latitude).
+// Snapping is deterministic, so repeated exports of the same photo cannot be
+// averaged to recover the true position.
+const FuzzGridDeg = 0.05
+
+// PrivacyZone is a named circular area (e.g. "Home") whose exact location must
+// not leak through exported GPS metadata.
+type PrivacyZone struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	RadiusM   float64 `json:"radiusM"` // metres
+	Action    string  `json:"action"`  // "strip" (default) or "fuzz"
+}
+
+// Contains reports whether (lat, lon) lies within the zone.
+func (z PrivacyZone) Contains(lat, lon float64) bool {
+	return distanceMetres(z.Latitude, z.Longitude, lat, lon) <= z.RadiusM
+}
+
+// Validate checks the zone's coordinates, radius and action.
+func (z PrivacyZone) Validate() error {
+	switch {
+	case strings.TrimSpace(z.Name) == "":
+		return fmt.Errorf("privacy zone: name is required")
+	case z.Latitude < -90 || z.Latitude > 90 || z.Longitude < -180 || z.Longitude > 180:
+		return fmt.Errorf("privacy zone %q: coordinates out of range", z.Name)
+	case z.RadiusM <= 0:
+		return fmt.Errorf("privacy zone %q: radius must be positive", z.Name)
+	case z.Action != "" && z.Action != ZoneActionStrip && z.Action != ZoneActionFuzz:
+		return fmt.Errorf("privacy zone %q: unknown action %q", z.Name, z.Action)
+	}
+	return nil
+}
+
+// MatchPrivacyZone returns the first zone containing (lat, lon), or nil.
+func MatchPrivacyZone(zones []PrivacyZone, lat, lon float64) *PrivacyZone {
+	for i := range zones {
+		if zones[i].Contains(lat, lon) {
+			return &zones[i]
+		}
+	}
+	return nil
+}
+
+// FuzzLocation snaps (lat, lon) to the centre of its FuzzGridDeg grid cell.
+func FuzzLocation(lat, lon float64) (float64, float64) {
+	snap := func(v float64) float64 {
+		c := (math.Floor(v/FuzzGridDeg) + 0.5) * FuzzGridDeg
+		return math.Round(c*1e6) / 1e6
+	}
+	return snap(lat), snap(lon)
+}
+
+// exifKept reports whether mode copies source metadata into the export.
+func exifKept(mode string) bool {
+	return mode == "keep" || mode == "keep_no_gps" || mode == ExifModeKeepOutsideZones
+}
+
+// gpsArgs returns the exiftool arguments that adjust GPS tags copied from
+// srcPath according to opts.ExifMode and opts.PrivacyZones.
+func gpsArgs(srcPath string, opts ExportOptions) []string {
+	switch opts.ExifMode {
+	case "keep_no_gps":
+		return []string{"-GPS:All="}
+	case ExifModeKeepOutsideZones:
+	default:
+		return nil
+	}
+	if len(opts.PrivacyZones) == 0 {
+		return nil
+	}
+	// XMP may carry a second copy of the position; clear it too.
+	strip := []string{"-GPS:All=", "-XMP-exif:GPS*="}
+	exifData, err := ExtractAllEXIF(srcPath)
+	if err != nil || exifData == nil || exifData.Latitude == nil || exifData.Longitude == nil {
+		// No readable position: exiftool may still find a GPS block we could
+		// not parse, so err on the side of privacy.
+		return strip
+	}
+	lat, lon := *exifData.Latitude, *exifData.Longitude
+	zone := MatchPrivacyZone(opts.PrivacyZones, lat, lon)
+	if zone == nil {
+		return nil
+	}
+	if zone.Action != ZoneActionFuzz {
+		return strip
+	}
+	fLat, fLon := FuzzLocation(lat, lon)
+	if MatchPrivacyZone(opts.PrivacyZones, fLat, fLon) != nil {
+		// A zone larger than a grid cell (or a neighbouring one) would
+		// still contain the snapped point.
+		return strip
+	}
+	latRef, lonRef := "N", "E"
+	if fLat < 0 {
+		latRef = "S"
+	}
+	if fLon < 0 {
+		lonRef = "W"
+	}
+	return append(strip,
+		fmt.Sprintf("-GPSLatitude=%f", math.Abs(fLat)),
+		"-GPSLatitudeRef="+latRef,
+		fmt.Sprintf("-GPSLongitude=%f", math.Abs(fLon)),
+		"-GPSLongitudeRef="+lonRef,
+	)
+}
+
+func distanceMetres(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * 6371000 * math.Asin(math.Min(1, math.Sqrt(a)))
+}
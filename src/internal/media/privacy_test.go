@@ -0,0 +1,74 @@
+package media
+
+import (
+	"slices"
+	"testing"
+)
+
+var testZones = []PrivacyZone{
+	{Name: "Home", Latitude: 48.137, Longitude: 11.575, RadiusM: 500, Action: ZoneActionFuzz},
+	{Name: "School", Latitude: 48.200, Longitude: 11.600, RadiusM: 300},
+}
+
+func TestPrivacyZoneContains(t *testing.T) {
+	if !testZones[0].Contains(48.139, 11.577) { // ~270 m away
+		t.Error("expected point inside Home")
+	}
+	if testZones[0].Contains(48.147, 11.575) { // ~1.1 km away
+		t.Error("expected point outside Home")
+	}
+	if z := MatchPrivacyZone(testZones, 48.2005, 11.6); z == nil || z.Name != "School" {
+		t.Errorf("expected School, got %v", z)
+	}
+	if z := MatchPrivacyZone(testZones, 52.5, 13.4); z != nil {
+		t.Errorf("expected no zone, got %v", z)
+	}
+}
+
+func TestPrivacyZoneValidate(t *testing.T) {
+	for _, z := range testZones {
+		if err := z.Validate(); err != nil {
+			t.Errorf("%s: %v", z.Name, err)
+		}
+	}
+	bad := []PrivacyZone{
+		{Name: "", Latitude: 1, Longitude: 1, RadiusM: 1},
+		{Name: "x", Latitude: 91, Longitude: 1, RadiusM: 1},
+		{Name: "x", Latitude: 1, Longitude: 1, RadiusM: 0},
+		{Name: "x", Latitude: 1, Longitude: 1, RadiusM: 1, Action: "blur"},
+	}
+	for _, z := range bad {
+		if z.Validate() == nil {
+			t.Errorf("expected error for %+v", z)
+		}
+	}
+}
+
+func TestFuzzLocation(t *testing.T) {
+	lat, lon := FuzzLocation(48.137, 11.575)
+	// 48.137 → cell [48.10, 48.15) → 48.125; 11.575 → cell [11.55, 11.60) → 11.575
+	if lat != 48.125 || lon != 11.575 {
+		t.Errorf("got %v,%v", lat, lon)
+	}
+	lat, lon = FuzzLocation(-33.87, -151.21)
+	if lat != -33.875 || lon != -151.225 {
+		t.Errorf("southern/western: got %v,%v", lat, lon)
+	}
+}
+
+func TestGPSArgsModes(t *testing.T) {
+	if args := gpsArgs("missing.jpg", ExportOptions{ExifMode: "keep"}); args != nil {
+		t.Errorf("keep: expected no GPS args, got %v", args)
+	}
+	if args := gpsArgs("missing.jpg", ExportOptions{ExifMode: "keep_no_gps"}); !slices.Contains(args, "-GPS:All=") {
+		t.Errorf("keep_no_gps: expected GPS strip, got %v", args)
+	}
+	if args := gpsArgs("missing.jpg", ExportOptions{ExifMode: ExifModeKeepOutsideZones}); args != nil {
+		t.Errorf("no zones configured: expected GPS kept, got %v", args)
+	}
+	// Unreadable source with zones configured: strip to be safe.
+	args := gpsArgs("missing.jpg", ExportOptions{ExifMode: ExifModeKeepOutsideZones, PrivacyZones: testZones})
+	if !slices.Contains(args, "-GPS:All=") || !slices.Contains(args, "-XMP-exif:GPS*=") {
+		t.Errorf("unreadable source: expected GPS strip, got %v", args)
+	}
+}
@@ -0,0 +1,194 @@
+package media
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+// Resampling filters for ScaleOptions.Filter.
+const (
+	FilterLanczos3   = "lanczos3"
+	FilterCatmullRom = "catmullrom" // default in the Go path
+	FilterBilinear   = "bilinear"
+)
+
+// Output sharpening presets for ExportOptions.Sharpen.
+const (
+	SharpenNone           = ""
+	SharpenScreenLow      = "screen_low"
+	SharpenScreenStandard = "screen_standard"
+	SharpenScreenHigh     = "screen_high"
+)
+
+// Sharpening holds unsharp-mask parameters: Amount is the strength (1 = 100 %),
+// Radius the Gaussian sigma in pixels, Threshold the minimum per-channel
+// difference (0–255) that is sharpened, which keeps flat areas and noise smooth.
+type Sharpening struct {
+	Amount    float64
+	Radius    float64
+	Threshold int
+}
+
+// sharpenPresets are tuned for downscaled output viewed on screen.
+var sharpenPresets = map[string]Sharpening{
+	SharpenScreenLow:      {Amount: 0.35, Radius: 0.5, Threshold: 2},
+	SharpenScreenStandard: {Amount: 0.6, Radius: 0.6, Threshold: 2},
+	SharpenScreenHigh:     {Amount: 1.0, Radius: 0.7, Threshold: 3},
+}
+
+// SharpenPreset returns the parameters of the named preset. ok is false for
+// SharpenNone and unknown names.
+func SharpenPreset(name string) (s Sharpening, ok bool) {
+	s, ok = sharpenPresets[name]
+	return s, ok
+}
+
+// ValidateResampling checks the resampling filter and sharpening preset of opts.
+func ValidateResampling(opts ExportOptions) error {
+	switch opts.Scale.Filter {
+	case "", FilterLanczos3, FilterCatmullRom, FilterBilinear:
+	default:
+		return fmt.Errorf("unknown resampling filter %q", opts.Scale.Filter)
+	}
+	if _, ok := sharpenPresets[opts.Sharpen]; opts.Sharpen != SharpenNone && !ok {
+		return fmt.Errorf("unknown sharpening preset %q", opts.Sharpen)
+	}
+	return nil
+}
+
+// lanczos3 is the Lanczos kernel with a = 3.
+var lanczos3 = &draw.Kernel{Support: 3, At: func(t float64) float64 {
+	if t == 0 {
+		return 1
+	}
+	if t >= 3 {
+		return 0
+	}
+	x := math.Pi * t
+	return 3 * math.Sin(x) * math.Sin(x/3) / (x * x)
+}}
+
+// resampler returns the scaler for a ScaleOptions.Filter value.
+func resampler(filter string) draw.Scaler {
+	switch filter {
+	case FilterLanczos3:
+		return lanczos3
+	case FilterBilinear:
+		return draw.BiLinear
+	}
+	return draw.CatmullRom
+}
+
+// ffmpegScaleFlags returns the ffmpeg scale filter flags for a filter. The
+// default is lanczos, which the ffmpeg path has always used.
+func ffmpegScaleFlags(filter string) string {
+	switch filter {
+	case FilterCatmullRom:
+		return "bicubic:param0=0:param1=0.5"
+	case FilterBilinear:
+		return "bilinear"
+	}
+	return "lanczos"
+}
+
+// ffmpegUnsharp returns the ffmpeg unsharp filter for the named preset, or ""
+// if none. ffmpeg's unsharp has no threshold; the radius maps to the odd
+// matrix size covering ±2σ.
+func ffmpegUnsharp(preset string) string {
+	s, ok := SharpenPreset(preset)
+	if !ok {
+		return ""
+	}
+	size := min(23, max(3, 2*int(math.Ceil(2*s.Radius))+1))
+	return fmt.Sprintf("unsharp=%d:%d:%.2f:%d:%d:0", size, size, s.Amount, size, size)
+}
+
+// sharpenImage applies an unsharp mask to img: each channel is pushed away
+// from its Gaussian-blurred value by Amount where the difference exceeds
+// Threshold. Alpha is left unchanged.
+func sharpenImage(img image.Image, s Sharpening) image.Image {
+	if s.Amount <= 0 || s.Radius <= 0 {
+		return img
+	}
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	blurred := gaussianBlur(src, s.Radius)
+
+	dst := image.NewRGBA(src.Rect)
+	for i := 0; i < len(src.Pix); i += 4 {
+		for c := 0; c < 3; c++ {
+			orig := int(src.Pix[i+c])
+			diff := orig - int(blurred[i+c])
+			if diff <= s.Threshold && diff >= -s.Threshold {
+				dst.Pix[i+c] = src.Pix[i+c]
+				continue
+			}
+			v := float64(orig) + s.Amount*float64(diff)
+			// Keep premultiplied RGBA valid: colour may not exceed alpha.
+			dst.Pix[i+c] = uint8(math.Round(math.Max(0, math.Min(float64(src.Pix[i+3]), v))))
+		}
+		dst.Pix[i+3] = src.Pix[i+3]
+	}
+	return dst
+}
+
+// gaussianBlur returns the RGB channels of img blurred with sigma, as a Pix
+// slice in img's layout. The blur is separable: horizontal, then vertical,
+// with edge pixels clamped. The horizontal pass is rounded to 8 bits so the
+// intermediate buffer is no larger than the image itself.
+func gaussianBlur(img *image.RGBA, sigma float64) []uint8 {
+	r := max(1, int(math.Ceil(3*sigma)))
+	kernel := make([]float64, 2*r+1)
+	var sum float64
+	for i := range kernel {
+		x := float64(i - r)
+		kernel[i] = math.Exp(-x * x / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	tmp := make([]uint8, len(img.Pix))
+	for y := 0; y < h; y++ {
+		row := y * img.Stride
+		for x := 0; x < w; x++ {
+			var acc [3]float64
+			for k, kv := range kernel {
+				sx := min(w-1, max(0, x+k-r))
+				p := row + sx*4
+				acc[0] += kv * float64(img.Pix[p])
+				acc[1] += kv * float64(img.Pix[p+1])
+				acc[2] += kv * float64(img.Pix[p+2])
+			}
+			p := row + x*4
+			for c := 0; c < 3; c++ {
+				tmp[p+c] = uint8(math.Round(math.Min(255, acc[c])))
+			}
+		}
+	}
+
+	out := make([]uint8, len(img.Pix))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var acc [3]float64
+			for k, kv := range kernel {
+				sy := min(h-1, max(0, y+k-r))
+				p := sy*img.Stride + x*4
+				acc[0] += kv * float64(tmp[p])
+				acc[1] += kv * float64(tmp[p+1])
+				acc[2] += kv * float64(tmp[p+2])
+			}
+			p := y*img.Stride + x*4
+			for c := 0; c < 3; c++ {
+				out[p+c] = uint8(math.Round(math.Min(255, acc[c])))
+			}
+		}
+	}
+	return out
+}
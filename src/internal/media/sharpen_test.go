@@ -0,0 +1,99 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// edgeImage returns a w×h image that is dark on the left half and light on the
+// right half.
+func edgeImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(80)
+			if x >= w/2 {
+				v = 160
+			}
+			img.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+func TestSharpenImageIncreasesEdgeContrast(t *testing.T) {
+	src := edgeImage(20, 4)
+	s, _ := SharpenPreset(SharpenScreenHigh)
+	out := sharpenImage(src, s).(*image.RGBA)
+
+	dark := out.RGBAAt(9, 2).R
+	light := out.RGBAAt(10, 2).R
+	if dark >= 80 || light <= 160 {
+		t.Errorf("edge after sharpening = %d/%d, want <80 and >160", dark, light)
+	}
+	// Flat areas away from the edge stay untouched.
+	if got := out.RGBAAt(1, 2).R; got != 80 {
+		t.Errorf("flat area changed to %d", got)
+	}
+	if got := out.RGBAAt(5, 2).A; got != 255 {
+		t.Errorf("alpha changed to %d", got)
+	}
+}
+
+func TestSharpenThresholdKeepsSmallDifferences(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 10, 1))
+	for x := 0; x < 10; x++ {
+		v := uint8(100 + x%2) // 1-level noise
+		src.Set(x, 0, color.RGBA{v, v, v, 255})
+	}
+	out := sharpenImage(src, Sharpening{Amount: 1, Radius: 0.6, Threshold: 2}).(*image.RGBA)
+	for x := 0; x < 10; x++ {
+		if out.RGBAAt(x, 0) != src.RGBAAt(x, 0) {
+			t.Fatalf("pixel %d changed below threshold: %v → %v", x, src.RGBAAt(x, 0), out.RGBAAt(x, 0))
+		}
+	}
+}
+
+func TestScaleImageFilters(t *testing.T) {
+	src := edgeImage(64, 32)
+	for _, f := range []string{"", FilterLanczos3, FilterCatmullRom, FilterBilinear} {
+		out := scaleImage(src, ScaleOptions{Mode: ScaleModePercent, Percent: 50, Filter: f})
+		if b := out.Bounds(); b.Dx() != 32 || b.Dy() != 16 {
+			t.Errorf("filter %q: size %v", f, b)
+		}
+		if r, _, _, _ := out.At(2, 8).RGBA(); r>>8 < 78 || r>>8 > 82 {
+			t.Errorf("filter %q: flat area = %d, want ~80", f, r>>8)
+		}
+	}
+}
+
+func TestFFmpegResamplingFilters(t *testing.T) {
+	if got := ffmpegScaleFlags(""); got != "lanczos" {
+		t.Errorf("default flags = %q, want lanczos", got)
+	}
+	if got := ffmpegScaleFlags(FilterBilinear); got != "bilinear" {
+		t.Errorf("bilinear flags = %q", got)
+	}
+	if got := ffmpegUnsharp(SharpenNone); got != "" {
+		t.Errorf("no sharpening = %q, want empty", got)
+	}
+	got := ffmpegUnsharp(SharpenScreenStandard)
+	if !strings.HasPrefix(got, "unsharp=5:5:0.60:") {
+		t.Errorf("screen_standard = %q", got)
+	}
+}
+
+func TestValidateResampling(t *testing.T) {
+	ok := ExportOptions{Scale: ScaleOptions{Filter: FilterLanczos3}, Sharpen: SharpenScreenLow}
+	if err := ValidateResampling(ok); err != nil {
+		t.Errorf("valid options: %v", err)
+	}
+	if err := ValidateResampling(ExportOptions{Scale: ScaleOptions{Filter: "nearest"}}); err == nil {
+		t.Error("unknown filter accepted")
+	}
+	if err := ValidateResampling(ExportOptions{Sharpen: "print"}); err == nil {
+		t.Error("unknown sharpening preset accepted")
+	}
+}
@@ -0,0 +1,234 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Slideshow transitions.
+const (
+	TransitionCut       = "cut"       // hard cut between slides
+	TransitionCrossfade = "crossfade" // slides blend into each other
+	TransitionKenBurns  = "kenburns"  // slow zoom on each slide, crossfaded
+)
+
+// MaxSlides caps the number of photos in one slideshow video. Every slide is
+// an ffmpeg input and part of one filter graph, which must stay within the
+// operating system's argument length limit.
+const MaxSlides = 200
+
+// SlideshowOptions describes a slideshow video.
+type SlideshowOptions struct {
+	Width              int     `json:"width"`              // output frame width in pixels
+	Height             int     `json:"height"`             // output frame height in pixels
+	SlideDuration      float64 `json:"slideDuration"`      // seconds each slide is shown, including its transition
+	Transition         string  `json:"transition"`         // Transition* constant; "" = crossfade
+	TransitionDuration float64 `json:"transitionDuration"` // crossfade length in seconds; 0 = min(1, SlideDuration/2)
+	FPS                int     `json:"fps,omitempty"`      // 0 = 30
+	Audio              string  `json:"-"`                  // audio file looped under the video; "" = silent
+}
+
+// Validate checks the options and fills in defaults.
+func (o *SlideshowOptions) Validate() error {
+	if o.Width < 160 || o.Height < 120 || o.Width > 7680 || o.Height > 4320 {
+		return fmt.Errorf("resolution %dx%d out of range", o.Width, o.Height)
+	}
+	// libx264 with yuv420p needs even dimensions.
+	if o.Width%2 != 0 || o.Height%2 != 0 {
+		return fmt.Errorf("resolution %dx%d must have even width and height", o.Width, o.Height)
+	}
+	if o.SlideDuration < 1 || o.SlideDuration > 60 {
+		return fmt.Errorf("slide duration must be between 1 and 60 seconds")
+	}
+	switch o.Transition {
+	case "":
+		o.Transition = TransitionCrossfade
+	case TransitionCut, TransitionCrossfade, TransitionKenBurns:
+	default:
+		return fmt.Errorf("unknown transition %q", o.Transition)
+	}
+	if o.Transition == TransitionCut {
+		o.TransitionDuration = 0
+	} else {
+		if o.TransitionDuration == 0 {
+			o.TransitionDuration = min(1, o.SlideDuration/2)
+		}
+		if o.TransitionDuration < 0 || o.TransitionDuration > o.SlideDuration/2 {
+			return fmt.Errorf("transition duration must be at most half the slide duration")
+		}
+	}
+	if o.FPS == 0 {
+		o.FPS = 30
+	}
+	if o.FPS < 10 || o.FPS > 60 {
+		return fmt.Errorf("frame rate must be between 10 and 60")
+	}
+	return nil
+}
+
+// Duration returns the length in seconds of a video of n slides. Crossfades
+// overlap neighbouring slides, so each one shortens the video.
+func (o SlideshowOptions) Duration(n int) float64 {
+	if n <= 0 {
+		return 0
+	}
+	return float64(n)*o.SlideDuration - float64(n-1)*o.TransitionDuration
+}
+
+// PrepareSlide writes srcPath as an sRGB JPEG at dst, oriented and scaled to
+// fit within maxW×maxH. ffmpeg then only has to place the image in the frame,
+// and every format the export pipeline reads (HEIF included) works.
+func PrepareSlide(srcPath, dst string, maxW, maxH int) error {
+	data, err := ExportImage(srcPath, ExportOptions{
+		Format:   "jpeg",
+		Quality:  92,
+		ExifMode: "strip",
+		Scale:    ScaleOptions{Mode: ScaleModePixels, Width: maxW, Height: maxH, MaintainAR: true},
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o600)
+}
+
+// RenderSlideshow encodes slides (JPEG paths, in order) into an H.264/AAC MP4
+// at out. progress, if not nil, is called with the number of seconds encoded
+// so far.
+func RenderSlideshow(ctx context.Context, slides []string, opts SlideshowOptions, out string, progress func(seconds float64)) error {
+	if len(slides) == 0 {
+		return fmt.Errorf("no slides")
+	}
+	if !CheckFFmpeg().Available {
+		return fmt.Errorf("ffmpeg is required for slideshow videos")
+	}
+	cmd := exec.CommandContext(ctx, "ffmpeg", slideshowArgs(slides, opts, out)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	readFFmpegProgress(stdout, progress)
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, lastLines(stderr.String(), 5))
+	}
+	return nil
+}
+
+// slideshowArgs builds the ffmpeg command line. Each slide is a looped still
+// image input lasting SlideDuration; the filter graph fits every slide into
+// the frame (or zooms it for Ken Burns) and joins them with concat or a chain
+// of xfade filters. The audio track is looped and faded out at the end.
+func slideshowArgs(slides []string, opts SlideshowOptions, out string) []string {
+	total := opts.Duration(len(slides))
+	args := []string{"-hide_banner", "-nostats", "-y", "-progress", "pipe:1"}
+	for _, s := range slides {
+		args = append(args, "-loop", "1", "-framerate", strconv.Itoa(opts.FPS), "-t", ffNum(opts.SlideDuration), "-i", s)
+	}
+	if opts.Audio != "" {
+		args = append(args, "-stream_loop", "-1", "-i", opts.Audio)
+	}
+
+	var g strings.Builder
+	for i := range slides {
+		fmt.Fprintf(&g, "[%d:v]%s[v%d];", i, slideFilter(i, opts), i)
+	}
+	if len(slides) == 1 {
+		g.WriteString("[v0]null[vout]")
+	} else if opts.Transition == TransitionCut {
+		for i := range slides {
+			fmt.Fprintf(&g, "[v%d]", i)
+		}
+		fmt.Fprintf(&g, "concat=n=%d:v=1:a=0[vout]", len(slides))
+	} else {
+		prev := "v0"
+		for i := 1; i < len(slides); i++ {
+			label := fmt.Sprintf("x%d", i)
+			if i == len(slides)-1 {
+				label = "vout"
+			}
+			offset := float64(i) * (opts.SlideDuration - opts.TransitionDuration)
+			fmt.Fprintf(&g, "[%s][v%d]xfade=transition=fade:duration=%s:offset=%s[%s];",
+				prev, i, ffNum(opts.TransitionDuration), ffNum(offset), label)
+			prev = label
+		}
+	}
+	graph := strings.TrimSuffix(g.String(), ";")
+	if opts.Audio != "" {
+		fade := min(2, total/2)
+		graph += fmt.Sprintf(";[%d:a]afade=t=out:st=%s:d=%s[aout]", len(slides), ffNum(total-fade), ffNum(fade))
+	}
+
+	args = append(args, "-filter_complex", graph, "-map", "[vout]")
+	if opts.Audio != "" {
+		args = append(args, "-map", "[aout]", "-c:a", "aac", "-b:a", "192k")
+	}
+	args = append(args,
+		"-c:v", "libx264", "-preset", "medium", "-crf", "20", "-pix_fmt", "yuv420p",
+		"-r", strconv.Itoa(opts.FPS), "-t", ffNum(total), "-movflags", "+faststart",
+		"-f", "mp4", out)
+	return args
+}
+
+// slideFilter returns the filter chain for slide i. Plain slides are fitted
+// and letterboxed; Ken Burns slides fill the frame and zoom in and out by
+// 15% on alternate slides.
+func slideFilter(i int, opts SlideshowOptions) string {
+	w, h := opts.Width, opts.Height
+	if opts.Transition != TransitionKenBurns {
+		return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease:flags=lanczos,"+
+			"pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=black,setsar=1,fps=%d,format=yuv420p", w, h, w, h, opts.FPS)
+	}
+	// zoompan works on whole pixels; zooming a 2× upscale keeps the motion
+	// from visibly stepping.
+	frames := int(opts.SlideDuration * float64(opts.FPS))
+	zoom := fmt.Sprintf("1+0.15*on/%d", frames)
+	if i%2 == 1 {
+		zoom = fmt.Sprintf("1.15-0.15*on/%d", frames)
+	}
+	return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase:flags=lanczos,crop=%d:%d,"+
+		"zoompan=z='%s':x='iw/2-(iw/zoom/2)':y='ih/2-(ih/zoom/2)':d=1:s=%dx%d:fps=%d,setsar=1,format=yuv420p",
+		2*w, 2*h, 2*w, 2*h, zoom, w, h, opts.FPS)
+}
+
+// readFFmpegProgress parses the key=value lines that ffmpeg writes with
+// -progress and reports the encoded time. out_time_ms is, despite its name,
+// in microseconds, like out_time_us.
+func readFFmpegProgress(r io.Reader, progress func(seconds float64)) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), "=")
+		if !ok || progress == nil || (key != "out_time_us" && key != "out_time_ms") {
+			continue
+		}
+		if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+			progress(float64(us) / 1e6)
+		}
+	}
+}
+
+// ffNum formats seconds for ffmpeg options and filter arguments.
+func ffNum(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
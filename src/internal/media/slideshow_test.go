@@ -0,0 +1,88 @@
+package media
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSlideshowOptionsValidate(t *testing.T) {
+	o := SlideshowOptions{Width: 1920, Height: 1080, SlideDuration: 5}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if o.Transition != TransitionCrossfade || o.TransitionDuration != 1 || o.FPS != 30 {
+		t.Errorf("defaults = %q %v %d", o.Transition, o.TransitionDuration, o.FPS)
+	}
+	if got := o.Duration(3); got != 13 {
+		t.Errorf("Duration(3) = %v, want 13 (3×5 minus two 1 s crossfades)", got)
+	}
+
+	cut := SlideshowOptions{Width: 1280, Height: 720, SlideDuration: 4, Transition: TransitionCut, TransitionDuration: 1}
+	if err := cut.Validate(); err != nil || cut.Duration(3) != 12 {
+		t.Errorf("cut: err %v, duration %v", err, cut.Duration(3))
+	}
+
+	for name, bad := range map[string]SlideshowOptions{
+		"odd width":  {Width: 1281, Height: 720, SlideDuration: 5},
+		"tiny":       {Width: 100, Height: 100, SlideDuration: 5},
+		"duration":   {Width: 1280, Height: 720, SlideDuration: 0.5},
+		"transition": {Width: 1280, Height: 720, SlideDuration: 5, Transition: "wipe"},
+		"long fade":  {Width: 1280, Height: 720, SlideDuration: 4, TransitionDuration: 3},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestSlideshowArgsCrossfadeWithAudio(t *testing.T) {
+	o := SlideshowOptions{Width: 1280, Height: 720, SlideDuration: 5, Audio: "music.mp3"}
+	o.Validate()
+	args := slideshowArgs([]string{"a.jpg", "b.jpg", "c.jpg"}, o, "out.mp4")
+
+	graph := args[slices.Index(args, "-filter_complex")+1]
+	for _, want := range []string{
+		"[v0][v1]xfade=transition=fade:duration=1:offset=4[x1]",
+		"[x1][v2]xfade=transition=fade:duration=1:offset=8[vout]",
+		"[3:a]afade=t=out:st=11:d=2[aout]",
+		"pad=1280:720",
+	} {
+		if !strings.Contains(graph, want) {
+			t.Errorf("filter graph lacks %q:\n%s", want, graph)
+		}
+	}
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "-stream_loop -1 -i music.mp3") || !strings.Contains(joined, "-t 13 ") {
+		t.Errorf("args = %s", joined)
+	}
+	if args[len(args)-1] != "out.mp4" {
+		t.Errorf("output is not last: %v", args[len(args)-1])
+	}
+}
+
+func TestSlideshowArgsCutAndKenBurns(t *testing.T) {
+	cut := SlideshowOptions{Width: 640, Height: 480, SlideDuration: 2, Transition: TransitionCut}
+	cut.Validate()
+	args := slideshowArgs([]string{"a.jpg", "b.jpg"}, cut, "out.mp4")
+	graph := args[slices.Index(args, "-filter_complex")+1]
+	if !strings.HasSuffix(graph, "[v0][v1]concat=n=2:v=1:a=0[vout]") || slices.Contains(args, "[aout]") {
+		t.Errorf("cut graph = %s", graph)
+	}
+
+	kb := SlideshowOptions{Width: 640, Height: 480, SlideDuration: 2, Transition: TransitionKenBurns}
+	kb.Validate()
+	joined := strings.Join(slideshowArgs([]string{"a.jpg"}, kb, "out.mp4"), " ")
+	if !strings.Contains(joined, "zoompan=z='1+0.15*on/60'") || !strings.Contains(joined, "[v0]null[vout]") {
+		t.Errorf("Ken Burns args = %s", joined)
+	}
+}
+
+func TestReadFFmpegProgress(t *testing.T) {
+	in := "frame=10\nout_time_us=1500000\nprogress=continue\nout_time_ms=3000000\nout_time_us=N/A\nprogress=end\n"
+	var got []float64
+	readFFmpegProgress(strings.NewReader(in), func(s float64) { got = append(got, s) })
+	if !slices.Equal(got, []float64{1.5, 3}) {
+		t.Errorf("progress = %v", got)
+	}
+}
@@ -14,7 +14,7 @@ import (
 	"golang.org/x/image/draw"
 )
 
-const thumbnailCacheVersion = "v4"
+const thumbnailCacheVersion = "v5"
 
 // GenerateThumbnail creates a thumbnail by decoding and resizing the image.
 // Orientation is applied after decoding and before resizing.
@@ -136,7 +136,7 @@ func GenerateHEIFThumbnail(ctx context.Context, path string, maxDim int) ([]byte
 		if err != nil {
 			return thumbnailWorkResult{err: err}
 		}
-		thumb, err := ResizeJPEGBytes(jpegData, maxDim)
+		thumb, err := ResizeJPEGBytes(withHEIFProfile(path, jpegData), maxDim)
 		if err != nil {
 			return thumbnailWorkResult{err: err}
 		}
@@ -175,7 +175,10 @@ func resizeImage(img image.Image, path string, maxDim, w, h int) ([]byte, string
 	return encodeImage(dst, path)
 }
 
+// encodeImage encodes a re-rendered thumbnail of path, converting it to sRGB
+// first since the source's ICC profile is not carried over.
 func encodeImage(img image.Image, path string) ([]byte, string, error) {
+	img = convertToSRGB(img, sourceICC(path))
 	var buf bytes.Buffer
 	if strings.HasSuffix(strings.ToLower(path), ".png") {
 		if err := png.Encode(&buf, img); err != nil {
@@ -190,6 +193,8 @@ func encodeImage(img image.Image, path string) ([]byte, string, error) {
 }
 
 // ResizeJPEGBytes takes JPEG image data and resizes it to fit within maxDim.
+// A resized image is converted to sRGB using the embedded ICC profile; data
+// that already fits is returned unchanged, profile included.
 func ResizeJPEGBytes(data []byte, maxDim int) ([]byte, error) {
 	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
 	if err != nil {
@@ -218,7 +223,7 @@ func ResizeJPEGBytes(data []byte, maxDim int) ([]byte, error) {
 	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
 
 	var buf bytes.Buffer
-	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+	if err := jpeg.Encode(&buf, convertToSRGB(dst, jpegICC(data)), &jpeg.Options{Quality: 85}); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
@@ -74,12 +74,26 @@ func (c *thumbnailWorkCoordinator) run(ctx context.Context, key string, fn func(
 }
 
 func defaultThumbnailWorkLimit() int {
+	return workLimit(4)
+}
+
+// DefaultExportWorkers returns the number of concurrent exports for batch
+// export. Exports are not latency-sensitive like thumbnails, so they may use
+// more of a large machine.
+func DefaultExportWorkers() int {
+	return workLimit(8)
+}
+
+// workLimit returns half the usable CPUs, clamped to [2, maxLimit]. External
+// tools (ffmpeg, heif-convert) are multi-threaded themselves, so one worker
+// per core would oversubscribe the machine.
+func workLimit(maxLimit int) int {
 	limit := runtime.GOMAXPROCS(0) / 2
 	if limit < 2 {
 		return 2
 	}
-	if limit > 4 {
-		return 4
+	if limit > maxLimit {
+		return maxLimit
 	}
 	return limit
 }
@@ -0,0 +1,404 @@
+package media
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrackPoint is one timestamped position from a GPS track log.
+type TrackPoint struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+	Elevation *float64 // nil when the log has no altitude for this point
+}
+
+// TrackMatch is the interpolated track position for one capture time.
+type TrackMatch struct {
+	Latitude  float64
+	Longitude float64
+	Elevation *float64
+	Gap       time.Duration // distance in time to the nearest real track point
+}
+
+// ParseTrack parses a GPX, KML or GeoJSON track log. The format is chosen by
+// file extension, falling back to content sniffing. Points without a timestamp
+// are dropped since they cannot be matched against capture times.
+func ParseTrack(name string, data []byte) ([]TrackPoint, error) {
+	var pts []TrackPoint
+	var err error
+	switch trackFormat(name, data) {
+	case "gpx":
+		pts, err = parseGPX(data)
+	case "kml":
+		pts, err = parseKML(data)
+	case "geojson":
+		pts, err = parseGeoJSONTrack(data)
+	default:
+		return nil, fmt.Errorf("unsupported track format: %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(pts) == 0 {
+		return nil, fmt.Errorf("no timestamped points in %s", name)
+	}
+	return pts, nil
+}
+
+func trackFormat(name string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gpx":
+		return "gpx"
+	case ".kml":
+		return "kml"
+	case ".geojson", ".json":
+		return "geojson"
+	}
+	head := string(data[:min(len(data), 512)])
+	switch {
+	case strings.Contains(head, "<gpx"):
+		return "gpx"
+	case strings.Contains(head, "<kml"):
+		return "kml"
+	case strings.HasPrefix(strings.TrimSpace(head), "{"):
+		return "geojson"
+	}
+	return ""
+}
+
+// parseGPX reads <trkpt> and <rtept> elements with their <ele> and <time> children.
+// Waypoints (<wpt>) are skipped: they mark places, not the recorded path, and
+// their times would skew interpolation.
+func parseGPX(data []byte) ([]TrackPoint, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var pts []TrackPoint
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse GPX: %w", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || (se.Name.Local != "trkpt" && se.Name.Local != "rtept") {
+			continue
+		}
+		var p struct {
+			Lat  float64  `xml:"lat,attr"`
+			Lon  float64  `xml:"lon,attr"`
+			Ele  *float64 `xml:"ele"`
+			Time string   `xml:"time"`
+		}
+		if err := dec.DecodeElement(&p, &se); err != nil {
+			return nil, fmt.Errorf("parse GPX point: %w", err)
+		}
+		ts, err := time.Parse(time.RFC3339, strings.TrimSpace(p.Time))
+		if err != nil {
+			continue
+		}
+		pts = append(pts, TrackPoint{Time: ts, Latitude: p.Lat, Longitude: p.Lon, Elevation: p.Ele})
+	}
+	return pts, nil
+}
+
+// parseKML reads gx:Track elements (paired <when>/<gx:coord>) and timestamped
+// Placemark points (<TimeStamp><when> with a <Point><coordinates>).
+func parseKML(data []byte) ([]TrackPoint, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var pts []TrackPoint
+	var whens []time.Time
+	var coords []string
+	var field string
+	var placemarkWhen *time.Time
+	var inPoint bool
+
+	flushTrack := func() {
+		for i := 0; i < len(whens) && i < len(coords); i++ {
+			if p, ok := parseKMLCoord(coords[i], " "); ok {
+				p.Time = whens[i]
+				pts = append(pts, p)
+			}
+		}
+		whens, coords = nil, nil
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse KML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			field = t.Name.Local
+			switch t.Name.Local {
+			case "Placemark":
+				placemarkWhen = nil
+			case "Point":
+				inPoint = true
+			}
+		case xml.EndElement:
+			field = ""
+			switch t.Name.Local {
+			case "Track":
+				flushTrack()
+			case "Point":
+				inPoint = false
+			}
+		case xml.CharData:
+			val := strings.TrimSpace(string(t))
+			if val == "" {
+				continue
+			}
+			switch field {
+			case "when":
+				ts, err := time.Parse(time.RFC3339, val)
+				if err != nil {
+					continue
+				}
+				whens = append(whens, ts)
+				placemarkWhen = &ts
+			case "coord":
+				coords = append(coords, val)
+			case "coordinates":
+				if inPoint && placemarkWhen != nil {
+					if p, ok := parseKMLCoord(val, ","); ok {
+						p.Time = *placemarkWhen
+						pts = append(pts, p)
+					}
+					whens = nil
+				}
+			}
+		}
+	}
+	return pts, nil
+}
+
+// parseKMLCoord parses "lon<sep>lat[<sep>alt]".
+func parseKMLCoord(s, sep string) (TrackPoint, bool) {
+	parts := strings.Split(strings.TrimSpace(s), sep)
+	if len(parts) < 2 {
+		return TrackPoint{}, false
+	}
+	lon, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lat, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err1 != nil || err2 != nil {
+		return TrackPoint{}, false
+	}
+	p := TrackPoint{Latitude: lat, Longitude: lon}
+	if len(parts) >= 3 {
+		if ele, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64); err == nil {
+			p.Elevation = &ele
+		}
+	}
+	return p, true
+}
+
+type geoJSONFeature struct {
+	Geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+// parseGeoJSONTrack reads LineString/MultiLineString features whose timestamps
+// are stored in the "coordTimes" (or "times") property, as written by
+// togeojson and most GPS-log converters. Point features use a "time" property.
+func parseGeoJSONTrack(data []byte) ([]TrackPoint, error) {
+	var root struct {
+		Type     string           `json:"type"`
+		Features []geoJSONFeature `json:"features"`
+	}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse GeoJSON: %w", err)
+	}
+	if root.Type == "Feature" {
+		var f geoJSONFeature
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("parse GeoJSON: %w", err)
+		}
+		root.Features = []geoJSONFeature{f}
+	}
+	var pts []TrackPoint
+	for _, f := range root.Features {
+		pts = append(pts, geoJSONFeaturePoints(f)...)
+	}
+	return pts, nil
+}
+
+func geoJSONFeaturePoints(f geoJSONFeature) []TrackPoint {
+	var lines [][][]float64
+	var times [][]string
+	switch f.Geometry.Type {
+	case "Point":
+		var c []float64
+		var ts string
+		if json.Unmarshal(f.Geometry.Coordinates, &c) != nil || json.Unmarshal(f.Properties["time"], &ts) != nil {
+			return nil
+		}
+		lines, times = [][][]float64{{c}}, [][]string{{ts}}
+	case "LineString":
+		var c [][]float64
+		if json.Unmarshal(f.Geometry.Coordinates, &c) != nil {
+			return nil
+		}
+		lines = [][][]float64{c}
+		times = [][]string{geoJSONTimes(f.Properties)}
+	case "MultiLineString":
+		if json.Unmarshal(f.Geometry.Coordinates, &lines) != nil {
+			return nil
+		}
+		raw := f.Properties["coordTimes"]
+		if raw == nil {
+			raw = f.Properties["times"]
+		}
+		if json.Unmarshal(raw, &times) != nil {
+			return nil
+		}
+	}
+
+	var pts []TrackPoint
+	for i, line := range lines {
+		if i >= len(times) {
+			break
+		}
+		for j, c := range line {
+			if j >= len(times[i]) || len(c) < 2 {
+				break
+			}
+			ts, err := time.Parse(time.RFC3339, times[i][j])
+			if err != nil {
+				continue
+			}
+			p := TrackPoint{Time: ts, Latitude: c[1], Longitude: c[0]}
+			if len(c) >= 3 {
+				ele := c[2]
+				p.Elevation = &ele
+			}
+			pts = append(pts, p)
+		}
+	}
+	return pts
+}
+
+func geoJSONTimes(props map[string]json.RawMessage) []string {
+	for _, key := range []string{"coordTimes", "times"} {
+		var ts []string
+		if json.Unmarshal(props[key], &ts) == nil && len(ts) > 0 {
+			return ts
+		}
+	}
+	return nil
+}
+
+// SortTrack sorts points by time and drops exact-duplicate timestamps, so that
+// several uploaded logs can be merged into one searchable track.
+func SortTrack(pts []TrackPoint) []TrackPoint {
+	sort.SliceStable(pts, func(i, j int) bool { return pts[i].Time.Before(pts[j].Time) })
+	out := pts[:0]
+	for i, p := range pts {
+		if i > 0 && p.Time.Equal(out[len(out)-1].Time) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// MatchTrack returns the position at time t, linearly interpolated between the
+// two surrounding track points. The match fails when t lies outside the track
+// by more than maxGap, or falls between two points that are both further than
+// maxGap away (i.e. the logger was off). pts must be sorted (see SortTrack).
+func MatchTrack(pts []TrackPoint, t time.Time, maxGap time.Duration) (TrackMatch, bool) {
+	if len(pts) == 0 {
+		return TrackMatch{}, false
+	}
+	i := sort.Search(len(pts), func(i int) bool { return !pts[i].Time.Before(t) })
+	switch {
+	case i < len(pts) && pts[i].Time.Equal(t):
+		return TrackMatch{Latitude: pts[i].Latitude, Longitude: pts[i].Longitude, Elevation: pts[i].Elevation}, true
+	case i == 0:
+		return nearestMatch(pts[0], pts[0].Time.Sub(t), maxGap)
+	case i == len(pts):
+		last := pts[len(pts)-1]
+		return nearestMatch(last, t.Sub(last.Time), maxGap)
+	}
+
+	a, b := pts[i-1], pts[i]
+	gapA, gapB := t.Sub(a.Time), b.Time.Sub(t)
+	gap := min(gapA, gapB)
+	if gap > maxGap {
+		return TrackMatch{}, false
+	}
+	f := float64(gapA) / float64(b.Time.Sub(a.Time))
+	m := TrackMatch{
+		Latitude:  a.Latitude + (b.Latitude-a.Latitude)*f,
+		Longitude: interpolateLongitude(a.Longitude, b.Longitude, f),
+		Gap:       gap,
+	}
+	switch {
+	case a.Elevation != nil && b.Elevation != nil:
+		ele := *a.Elevation + (*b.Elevation-*a.Elevation)*f
+		m.Elevation = &ele
+	case gapA <= gapB:
+		m.Elevation = a.Elevation
+	default:
+		m.Elevation = b.Elevation
+	}
+	return m, true
+}
+
+func nearestMatch(p TrackPoint, gap, maxGap time.Duration) (TrackMatch, bool) {
+	if gap > maxGap {
+		return TrackMatch{}, false
+	}
+	return TrackMatch{Latitude: p.Latitude, Longitude: p.Longitude, Elevation: p.Elevation, Gap: gap}, true
+}
+
+// interpolateLongitude interpolates along the shorter arc so tracks crossing
+// the antimeridian don't swing around the globe.
+func interpolateLongitude(a, b, f float64) float64 {
+	d := b - a
+	if d > 180 {
+		d -= 360
+	} else if d < -180 {
+		d += 360
+	}
+	lon := a + d*f
+	if lon > 180 {
+		lon -= 360
+	} else if lon < -180 {
+		lon += 360
+	}
+	return lon
+}
+
+// CaptureTime returns the DateTimeOriginal of the image at path as an absolute
+// time. When the file carries OffsetTimeOriginal that offset is used; otherwise
+// the wall-clock value is interpreted in loc (the camera's time zone).
+func CaptureTime(path string, loc *time.Location) (time.Time, error) {
+	data, err := ExtractAllEXIF(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if data.DateTaken == nil {
+		return time.Time{}, fmt.Errorf("no DateTimeOriginal")
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05Z07:00", *data.DateTaken); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05", *data.DateTaken, loc)
+}
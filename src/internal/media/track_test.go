@@ -0,0 +1,153 @@
+package media
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const testGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk><trkseg>
+    <trkpt lat="47.0" lon="11.0"><ele>1000</ele><time>2026-08-01T10:00:00Z</time></trkpt>
+    <trkpt lat="47.1" lon="11.2"><ele>1200</ele><time>2026-08-01T10:10:00Z</time></trkpt>
+    <trkpt lat="48.0" lon="12.0"><time>2026-08-01T12:00:00Z</time></trkpt>
+  </trkseg></trk>
+</gpx>`
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestParseTrack_GPX(t *testing.T) {
+	pts, err := ParseTrack("hike.gpx", []byte(testGPX))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pts) != 3 {
+		t.Fatalf("expected 3 points, got %d", len(pts))
+	}
+	if pts[1].Elevation == nil || *pts[1].Elevation != 1200 {
+		t.Errorf("expected elevation 1200, got %v", pts[1].Elevation)
+	}
+	if pts[2].Elevation != nil {
+		t.Errorf("expected nil elevation for point without <ele>")
+	}
+}
+
+func TestParseTrack_GPXSkipsWaypoints(t *testing.T) {
+	gpx := `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+  <wpt lat="46.0" lon="10.0"><name>Hut</name><time>2026-08-01T10:05:00Z</time></wpt>
+  <trk><trkseg>
+    <trkpt lat="47.0" lon="11.0"><time>2026-08-01T10:00:00Z</time></trkpt>
+    <trkpt lat="47.1" lon="11.2"><time>2026-08-01T10:10:00Z</time></trkpt>
+  </trkseg></trk>
+</gpx>`
+	pts, err := ParseTrack("hike.gpx", []byte(gpx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pts) != 2 {
+		t.Fatalf("expected 2 track points, got %+v", pts)
+	}
+	m, ok := MatchTrack(pts, mustTime(t, "2026-08-01T10:05:00Z"), time.Hour)
+	if !ok || math.Abs(m.Latitude-47.05) > 1e-6 {
+		t.Errorf("match between track points = %+v, %v", m, ok)
+	}
+}
+
+func TestParseTrack_KMLGxTrack(t *testing.T) {
+	kml := `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">
+  <Placemark><gx:Track>
+    <when>2026-08-01T10:00:00Z</when>
+    <when>2026-08-01T10:01:00Z</when>
+    <gx:coord>11.0 47.0 500</gx:coord>
+    <gx:coord>11.1 47.1 510</gx:coord>
+  </gx:Track></Placemark>
+</kml>`
+	pts, err := ParseTrack("track.kml", []byte(kml))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pts) != 2 || pts[1].Latitude != 47.1 || pts[1].Longitude != 11.1 {
+		t.Fatalf("unexpected points: %+v", pts)
+	}
+}
+
+func TestParseTrack_GeoJSON(t *testing.T) {
+	gj := `{"type":"FeatureCollection","features":[{"type":"Feature",
+	  "geometry":{"type":"LineString","coordinates":[[11.0,47.0,900],[11.5,47.5,950]]},
+	  "properties":{"coordTimes":["2026-08-01T10:00:00Z","2026-08-01T11:00:00Z"]}}]}`
+	pts, err := ParseTrack("track.geojson", []byte(gj))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pts) != 2 || pts[0].Elevation == nil || *pts[0].Elevation != 900 {
+		t.Fatalf("unexpected points: %+v", pts)
+	}
+}
+
+func TestParseTrack_NoTimestamps(t *testing.T) {
+	gpx := `<gpx><trk><trkseg><trkpt lat="1" lon="2"/></trkseg></trk></gpx>`
+	if _, err := ParseTrack("x.gpx", []byte(gpx)); err == nil {
+		t.Fatal("expected error for track without timestamps")
+	}
+}
+
+func TestMatchTrack_Interpolates(t *testing.T) {
+	pts, _ := ParseTrack("hike.gpx", []byte(testGPX))
+	m, ok := MatchTrack(pts, mustTime(t, "2026-08-01T10:05:00Z"), 5*time.Minute)
+	if !ok {
+		t.Fatal("expected match")
+	}
+	if math.Abs(m.Latitude-47.05) > 1e-9 || math.Abs(m.Longitude-11.1) > 1e-9 {
+		t.Errorf("unexpected position %f,%f", m.Latitude, m.Longitude)
+	}
+	if m.Elevation == nil || math.Abs(*m.Elevation-1100) > 1e-9 {
+		t.Errorf("expected elevation 1100, got %v", m.Elevation)
+	}
+}
+
+func TestMatchTrack_MaxGap(t *testing.T) {
+	pts, _ := ParseTrack("hike.gpx", []byte(testGPX))
+	// 11:05 is 55 minutes from both neighbours — logger gap, no match.
+	if _, ok := MatchTrack(pts, mustTime(t, "2026-08-01T11:05:00Z"), 5*time.Minute); ok {
+		t.Error("expected no match inside a track gap")
+	}
+	if _, ok := MatchTrack(pts, mustTime(t, "2026-08-01T09:58:00Z"), 5*time.Minute); !ok {
+		t.Error("expected match shortly before track start")
+	}
+	if _, ok := MatchTrack(pts, mustTime(t, "2026-08-01T09:00:00Z"), 5*time.Minute); ok {
+		t.Error("expected no match long before track start")
+	}
+}
+
+func TestMatchTrack_Antimeridian(t *testing.T) {
+	pts := []TrackPoint{
+		{Time: mustTime(t, "2026-08-01T10:00:00Z"), Latitude: 0, Longitude: 179},
+		{Time: mustTime(t, "2026-08-01T10:02:00Z"), Latitude: 0, Longitude: -179},
+	}
+	m, ok := MatchTrack(pts, mustTime(t, "2026-08-01T10:01:00Z"), time.Minute)
+	if !ok {
+		t.Fatal("expected match")
+	}
+	if math.Abs(math.Abs(m.Longitude)-180) > 1e-9 {
+		t.Errorf("expected longitude ±180, got %f", m.Longitude)
+	}
+}
+
+func TestSortTrack_MergesLogs(t *testing.T) {
+	a := TrackPoint{Time: mustTime(t, "2026-08-01T10:00:00Z")}
+	b := TrackPoint{Time: mustTime(t, "2026-08-01T09:00:00Z")}
+	got := SortTrack([]TrackPoint{a, b, a})
+	if len(got) != 2 || !got[0].Time.Equal(b.Time) {
+		t.Fatalf("unexpected merge result: %+v", got)
+	}
+}
@@ -0,0 +1,206 @@
+package media
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Watermark positions.
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right" // default
+	WatermarkCenter      = "center"
+)
+
+// Watermark is a text or PNG logo overlay rendered onto the exported image
+// after scaling. When both Text and ImagePath are set, the logo wins.
+type Watermark struct {
+	Text      string  `json:"text,omitempty"`
+	ImagePath string  `json:"imagePath,omitempty"` // PNG logo, absolute path on the server
+	Position  string  `json:"position,omitempty"`  // see Watermark* constants; default bottom-right
+	Opacity   float64 `json:"opacity,omitempty"`   // 0–1; 0 = default 0.6
+	Scale     float64 `json:"scale,omitempty"`     // width as fraction of output width; 0 = default 0.2
+}
+
+// Enabled reports whether w renders anything.
+func (w *Watermark) Enabled() bool {
+	return w != nil && (strings.TrimSpace(w.Text) != "" || w.ImagePath != "")
+}
+
+// Validate checks position, opacity and scale.
+func (w *Watermark) Validate() error {
+	if w == nil {
+		return nil
+	}
+	switch w.Position {
+	case "", WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight, WatermarkCenter:
+	default:
+		return fmt.Errorf("watermark: unknown position %q", w.Position)
+	}
+	if w.Opacity < 0 || w.Opacity > 1 {
+		return fmt.Errorf("watermark: opacity must be between 0 and 1")
+	}
+	if w.Scale < 0 || w.Scale > 1 {
+		return fmt.Errorf("watermark: scale must be between 0 and 1")
+	}
+	if w.ImagePath == "" && w.Text != "" {
+		return checkWatermarkText(w.Text)
+	}
+	return nil
+}
+
+// applyWatermark draws w onto img and returns the composited image.
+func applyWatermark(img image.Image, w *Watermark) (image.Image, error) {
+	var mark image.Image
+	if w.ImagePath != "" {
+		logo, err := loadPNG(w.ImagePath)
+		if err != nil {
+			return nil, fmt.Errorf("watermark logo: %w", err)
+		}
+		mark = logo
+	} else {
+		text, err := renderText(w.Text)
+		if err != nil {
+			return nil, err
+		}
+		mark = text
+	}
+
+	b := img.Bounds()
+	scale := w.Scale
+	if scale <= 0 {
+		scale = 0.2
+	}
+	mw := max(1, int(float64(b.Dx())*scale))
+	mh := max(1, mw*mark.Bounds().Dy()/mark.Bounds().Dx())
+	scaled := image.NewRGBA(image.Rect(0, 0, mw, mh))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), mark, mark.Bounds(), draw.Src, nil)
+
+	opacity := w.Opacity
+	if opacity <= 0 {
+		opacity = 0.6
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	at := watermarkOrigin(dst.Bounds(), mw, mh, w.Position)
+	mask := image.NewUniform(color.Alpha{A: uint8(opacity * 255)})
+	draw.DrawMask(dst, image.Rect(at.X, at.Y, at.X+mw, at.Y+mh), scaled, image.Point{}, mask, image.Point{}, draw.Over)
+	return dst, nil
+}
+
+// watermarkOrigin returns the top-left corner for a mw×mh mark inside bounds,
+// keeping a margin of 2% of the shorter image side.
+func watermarkOrigin(bounds image.Rectangle, mw, mh int, position string) image.Point {
+	w, h := bounds.Dx(), bounds.Dy()
+	m := min(w, h) / 50
+	switch position {
+	case WatermarkTopLeft:
+		return image.Pt(m, m)
+	case WatermarkTopRight:
+		return image.Pt(w-mw-m, m)
+	case WatermarkBottomLeft:
+		return image.Pt(m, h-mh-m)
+	case WatermarkCenter:
+		return image.Pt((w-mw)/2, (h-mh)/2)
+	default:
+		return image.Pt(w-mw-m, h-mh-m)
+	}
+}
+
+// watermarkTextSize is the pixel size text watermarks are rendered at before
+// being scaled to the target width; large enough for crisp glyph edges.
+const watermarkTextSize = 64
+
+var (
+	watermarkFontOnce sync.Once
+	watermarkFont     *sfnt.Font
+	watermarkFace     font.Face
+	watermarkFontErr  error
+)
+
+// loadWatermarkFace parses the embedded Go Regular font, which covers Latin-1
+// (e.g. "©", umlauts) and more, on first use.
+func loadWatermarkFace() (*sfnt.Font, font.Face, error) {
+	watermarkFontOnce.Do(func() {
+		f, err := opentype.Parse(goregular.TTF)
+		if err != nil {
+			watermarkFontErr = err
+			return
+		}
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: watermarkTextSize, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			watermarkFontErr = err
+			return
+		}
+		watermarkFont, watermarkFace = f, face
+	})
+	return watermarkFont, watermarkFace, watermarkFontErr
+}
+
+// checkWatermarkText returns an error naming the first character of s the
+// watermark font has no glyph for.
+func checkWatermarkText(s string) error {
+	f, _, err := loadWatermarkFace()
+	if err != nil {
+		return fmt.Errorf("watermark font: %w", err)
+	}
+	var buf sfnt.Buffer
+	for _, r := range s {
+		if idx, err := f.GlyphIndex(&buf, r); err != nil || idx == 0 {
+			return fmt.Errorf("watermark: text contains unsupported character %q", r)
+		}
+	}
+	return nil
+}
+
+// renderText draws s in white with a dark shadow. Characters without a glyph
+// are rejected rather than drawn as replacement boxes.
+func renderText(s string) (image.Image, error) {
+	if err := checkWatermarkText(s); err != nil {
+		return nil, err
+	}
+	_, face, _ := loadWatermarkFace()
+	return drawText(face, s), nil
+}
+
+// drawText renders s with face onto a transparent image sized to fit.
+func drawText(face font.Face, s string) image.Image {
+	const shadow = 2
+	d := &font.Drawer{Face: face}
+	tw := d.MeasureString(s).Ceil() + shadow
+	th := face.Metrics().Height.Ceil() + shadow
+	img := image.NewRGBA(image.Rect(0, 0, max(tw, 1), th))
+	ascent := face.Metrics().Ascent.Ceil()
+	for _, layer := range []struct {
+		c  color.Color
+		dx int
+	}{{color.RGBA{A: 160}, shadow}, {color.White, 0}} {
+		d := &font.Drawer{Dst: img, Src: image.NewUniform(layer.c), Face: face,
+			Dot: fixed.P(layer.dx, ascent+layer.dx)}
+		d.DrawString(s)
+	}
+	return img
+}
+
+func loadPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
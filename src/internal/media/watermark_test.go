@@ -0,0 +1,172 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func solidImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// changedIn reports whether any pixel in r differs from the black background.
+func changedIn(img image.Image, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if cr, cg, cb, _ := img.At(x, y).RGBA(); cr|cg|cb != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestWatermarkValidate(t *testing.T) {
+	var nilWM *Watermark
+	if nilWM.Enabled() || nilWM.Validate() != nil {
+		t.Error("nil watermark should be disabled and valid")
+	}
+	if (&Watermark{Text: "  "}).Enabled() {
+		t.Error("blank text should not enable the watermark")
+	}
+	for _, w := range []Watermark{{Position: "middle"}, {Opacity: 1.5}, {Scale: -0.1}} {
+		if w.Validate() == nil {
+			t.Errorf("expected error for %+v", w)
+		}
+	}
+}
+
+func TestWatermarkOrigin(t *testing.T) {
+	b := image.Rect(0, 0, 1000, 500) // margin = 10
+	cases := map[string]image.Point{
+		WatermarkTopLeft:     {10, 10},
+		WatermarkTopRight:    {790, 10},
+		WatermarkBottomLeft:  {10, 440},
+		"":                   {790, 440},
+		WatermarkBottomRight: {790, 440},
+		WatermarkCenter:      {400, 225},
+	}
+	for pos, want := range cases {
+		if got := watermarkOrigin(b, 200, 50, pos); got != want {
+			t.Errorf("%q: got %v, want %v", pos, got, want)
+		}
+	}
+}
+
+func TestApplyWatermarkText(t *testing.T) {
+	src := solidImage(400, 300, color.Black)
+	out, err := applyWatermark(src, &Watermark{Text: "© Jane", Opacity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Bounds() != src.Bounds() {
+		t.Fatalf("bounds changed: %v", out.Bounds())
+	}
+	if !changedIn(out, image.Rect(200, 200, 400, 300)) {
+		t.Error("expected text in the bottom-right corner")
+	}
+	if changedIn(out, image.Rect(0, 0, 200, 150)) {
+		t.Error("top-left corner should be untouched")
+	}
+	if changedIn(src, src.Bounds()) {
+		t.Error("source image must not be modified")
+	}
+}
+
+// TestRenderTextCopyrightGlyph verifies that "©" has a real glyph rather than
+// the replacement box drawn for characters the font lacks.
+func TestRenderTextCopyrightGlyph(t *testing.T) {
+	_, face, err := loadWatermarkFace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyright := drawText(face, "©").(*image.RGBA)
+	fallback := drawText(face, "\U0001F600").(*image.RGBA) // no glyph: drawn as .notdef
+	if copyright.Bounds() == fallback.Bounds() && bytes.Equal(copyright.Pix, fallback.Pix) {
+		t.Error("© is rendered as the fallback glyph")
+	}
+	if _, err := renderText("© Jane Müller"); err != nil {
+		t.Errorf("Latin-1 text rejected: %v", err)
+	}
+	if _, err := renderText("Jane \U0001F600"); err == nil {
+		t.Error("text with an unsupported character accepted")
+	}
+	if (&Watermark{Text: "Jane \U0001F600"}).Validate() == nil {
+		t.Error("Validate accepted an unsupported character")
+	}
+}
+
+func TestApplyWatermarkLogo(t *testing.T) {
+	logoPath := filepath.Join(t.TempDir(), "logo.png")
+	var buf bytes.Buffer
+	png.Encode(&buf, solidImage(20, 10, color.White))
+	os.WriteFile(logoPath, buf.Bytes(), 0o644)
+
+	src := solidImage(200, 200, color.Black)
+	out, err := applyWatermark(src, &Watermark{ImagePath: logoPath, Position: WatermarkTopLeft, Scale: 0.5, Opacity: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Logo is 100×50 at (4,4); half-opaque white over black ≈ mid grey.
+	r, _, _, _ := out.At(50, 25).RGBA()
+	if r>>8 < 100 || r>>8 > 155 {
+		t.Errorf("expected ~50%% grey inside logo, got %d", r>>8)
+	}
+	if changedIn(out, image.Rect(0, 100, 200, 200)) {
+		t.Error("bottom half should be untouched")
+	}
+
+	if _, err := applyWatermark(src, &Watermark{ImagePath: filepath.Join(t.TempDir(), "missing.png")}); err == nil {
+		t.Error("expected error for missing logo")
+	}
+}
+
+func TestExportImageWithWatermark(t *testing.T) {
+	srcPath := filepath.Join(t.TempDir(), "src.png")
+	var buf bytes.Buffer
+	png.Encode(&buf, solidImage(300, 200, color.Black))
+	os.WriteFile(srcPath, buf.Bytes(), 0o644)
+
+	data, err := ExportImage(srcPath, ExportOptions{
+		Format:    "png",
+		Scale:     ScaleOptions{Mode: ScaleModePercent, Percent: 50},
+		Watermark: &Watermark{Text: "x", Opacity: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 150 || img.Bounds().Dy() != 100 {
+		t.Errorf("unexpected size %v", img.Bounds())
+	}
+	if !changedIn(img, image.Rect(75, 50, 150, 100)) {
+		t.Error("expected watermark rendered after scaling")
+	}
+}
+
+func TestRightsArgs(t *testing.T) {
+	if args := rightsArgs(ExportOptions{}); len(args) != 0 {
+		t.Errorf("expected no args, got %v", args)
+	}
+	args := rightsArgs(ExportOptions{Artist: "Jane", Copyright: "© 2026 Jane", UsageTerms: "CC BY 4.0"})
+	for _, want := range []string{"-EXIF:Artist=Jane", "-XMP-dc:Creator=Jane", "-EXIF:Copyright=© 2026 Jane", "-XMP-dc:Rights=© 2026 Jane", "-XMP-xmpRights:UsageTerms=CC BY 4.0"} {
+		if !slices.Contains(args, want) {
+			t.Errorf("missing %q in %v", want, args)
+		}
+	}
+}
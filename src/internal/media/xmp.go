@@ -20,6 +20,9 @@ type Publication struct {
 	PostID       string    // shared ID for photos published together in one action
 	GalleryTitle string    // title of the gallery/album this publish belongs to; empty for bare exports
 	PublishedAt  time.Time
+
+	RemoteID string // ID of the post on the remote service, when a channel handler uploaded the photo
+	URL      string // public URL of that post
 }
 
 // SidecarPath returns the XMP sidecar path for the given photo file.
@@ -117,6 +120,10 @@ func parseSidecarPublications(data []byte) ([]Publication, error) {
 					current.GalleryTitle = val
 				case "PublishedAt":
 					current.PublishedAt, _ = time.Parse(time.RFC3339, val)
+				case "RemoteID":
+					current.RemoteID = val
+				case "URL":
+					current.URL = val
 				}
 			}
 		}
@@ -151,6 +158,12 @@ func renderULBlock(pubs []Publication) string {
 			items.WriteString("\n          <ul:GalleryTitle>" + xmlEscapeStr(p.GalleryTitle) + "</ul:GalleryTitle>")
 		}
 		items.WriteString("\n          <ul:PublishedAt>" + p.PublishedAt.UTC().Format(time.RFC3339) + "</ul:PublishedAt>")
+		if p.RemoteID != "" {
+			items.WriteString("\n          <ul:RemoteID>" + xmlEscapeStr(p.RemoteID) + "</ul:RemoteID>")
+		}
+		if p.URL != "" {
+			items.WriteString("\n          <ul:URL>" + xmlEscapeStr(p.URL) + "</ul:URL>")
+		}
 		items.WriteString("\n        </rdf:li>")
 	}
 	return `    <rdf:Description rdf:about="" xmlns:ul="https://unterlumen.app/xmp/1.0/">
@@ -198,43 +211,117 @@ func mergeULBlock(existing []byte, pubs []Publication) []byte {
 	return []byte(s[:descStart] + newBlock + s[descEnd:])
 }
 
+// XMP namespaces of the descriptive fields unterlumen reads and writes.
+const (
+	dcNamespace   = "http://purl.org/dc/elements/1.1/"
+	iptcNamespace = "http://iptc.org/std/Iptc4xmpCore/1.0/xmlns/"
+	rdfNamespace  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// Captions are the descriptive fields of a sidecar: dc:title, dc:description
+// and Iptc4xmpCore:AltTextAccessibility, all language alternatives of which
+// only the default is used.
+type Captions struct {
+	Title       string
+	Description string
+	AltText     string
+}
+
+// ReadTitle returns the dc:title of the sidecar alongside photoPath, or "".
 func ReadTitle(photoPath string) (string, error) {
+	c, err := ReadCaptions(photoPath)
+	return c.Title, err
+}
+
+// ReadDescription returns the dc:description caption of the sidecar
+// alongside photoPath, or "".
+func ReadDescription(photoPath string) (string, error) {
+	c, err := ReadCaptions(photoPath)
+	return c.Description, err
+}
+
+// ReadAltText returns the Iptc4xmpCore:AltTextAccessibility of the sidecar
+// alongside photoPath, or "".
+func ReadAltText(photoPath string) (string, error) {
+	c, err := ReadCaptions(photoPath)
+	return c.AltText, err
+}
+
+// WriteTitle sets dc:title in the sidecar alongside photoPath; "" removes it.
+// The other descriptive fields and the unterlumen block are kept.
+func WriteTitle(photoPath, title string) error {
+	return updateCaptions(photoPath, func(c *Captions) { c.Title = title })
+}
+
+// WriteDescription sets the dc:description caption; "" removes it.
+func WriteDescription(photoPath, description string) error {
+	return updateCaptions(photoPath, func(c *Captions) { c.Description = description })
+}
+
+// WriteAltText sets Iptc4xmpCore:AltTextAccessibility; "" removes it.
+func WriteAltText(photoPath, altText string) error {
+	return updateCaptions(photoPath, func(c *Captions) { c.AltText = altText })
+}
+
+// ReadCaptions returns title, caption and alt text of the sidecar alongside
+// photoPath with a single read. Missing fields, or a missing sidecar, are "".
+func ReadCaptions(photoPath string) (Captions, error) {
 	data, err := os.ReadFile(SidecarPath(photoPath))
 	if os.IsNotExist(err) {
-		return "", nil
+		return Captions{}, nil
 	}
 	if err != nil {
-		return "", err
+		return Captions{}, err
 	}
-	return parseDCTitle(data)
+	return parseCaptions(data), nil
 }
 
-func WriteTitle(photoPath, title string) error {
+// updateCaptions applies change to the sidecar's descriptive fields. Only
+// the elements of fields that changed are rewritten, in place, so other
+// fields, language alternatives and namespaces written by other tools are
+// kept.
+func updateCaptions(photoPath string, change func(*Captions)) error {
 	sidecarPath := SidecarPath(photoPath)
 	data, err := os.ReadFile(sidecarPath)
 	if os.IsNotExist(err) {
-		if title == "" {
+		var c Captions
+		change(&c)
+		blocks := renderCaptionBlocks(c)
+		if blocks == "" {
 			return nil
 		}
-		return os.WriteFile(sidecarPath, []byte(renderFreshXMPWithDC(title)), 0o644)
+		return os.WriteFile(sidecarPath, []byte(renderFreshXMPWithBlocks(blocks)), 0o644)
 	}
 	if err != nil {
 		return err
 	}
-	var result []byte
-	if title == "" {
-		result = clearDCBlock(data)
-	} else {
-		result = mergeDCBlock(data, title)
+	old := parseCaptions(data)
+	c := old
+	change(&c)
+	s := string(data)
+	if c.Title != old.Title {
+		s = setLangAlt(s, "dc:title", dcMarker, c.Title)
 	}
-	return os.WriteFile(sidecarPath, result, 0o644)
+	if c.Description != old.Description {
+		s = setLangAlt(s, "dc:description", dcMarker, c.Description)
+	}
+	if c.AltText != old.AltText {
+		s = setLangAlt(s, "Iptc4xmpCore:AltTextAccessibility", iptcMarker, c.AltText)
+	}
+	return os.WriteFile(sidecarPath, []byte(s), 0o644)
 }
 
-func parseDCTitle(data []byte) (string, error) {
-	const dcNS = "http://purl.org/dc/elements/1.1/"
-	const rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+// parseCaptions reads the default language alternative of each descriptive field.
+func parseCaptions(data []byte) Captions {
+	var c Captions
+	fields := map[xml.Name]*string{
+		{Space: dcNamespace, Local: "title"}:                  &c.Title,
+		{Space: dcNamespace, Local: "description"}:            &c.Description,
+		{Space: iptcNamespace, Local: "AltTextAccessibility"}: &c.AltText,
+	}
 	dec := xml.NewDecoder(bytes.NewReader(data))
-	var inDCTitle, inAltLi bool
+	var field *string
+	var inAltLi bool
 
 	for {
 		tok, err := dec.Token()
@@ -247,103 +334,210 @@ func parseDCTitle(data []byte) (string, error) {
 		switch t := tok.(type) {
 		case xml.StartElement:
 			switch {
-			case t.Name.Space == dcNS && t.Name.Local == "title":
-				inDCTitle = true
-			case inDCTitle && t.Name.Space == rdfNS && t.Name.Local == "li":
+			case fields[t.Name] != nil:
+				field = fields[t.Name]
+			case field != nil && t.Name.Space == rdfNamespace && t.Name.Local == "li":
 				inAltLi = true
 			}
 		case xml.EndElement:
 			switch {
-			case t.Name.Space == dcNS && t.Name.Local == "title":
-				inDCTitle = false
-			case inDCTitle && t.Name.Space == rdfNS && t.Name.Local == "li":
+			case fields[t.Name] != nil:
+				field = nil
+			case field != nil && t.Name.Space == rdfNamespace && t.Name.Local == "li":
 				inAltLi = false
 			}
 		case xml.CharData:
-			if inAltLi {
-				return strings.TrimSpace(string(t)), nil
+			if inAltLi && *field == "" {
+				*field = strings.TrimSpace(string(t))
 			}
 		}
 	}
-	return "", nil
+	return c
 }
 
-func renderDCBlock(title string) string {
-	return `    <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
-      <dc:title>
+const (
+	dcMarker   = `xmlns:dc="` + dcNamespace + `"`
+	iptcMarker = `xmlns:Iptc4xmpCore="` + iptcNamespace + `"`
+)
+
+func renderLangAlt(element, value string) string {
+	return `
+      <` + element + `>
         <rdf:Alt>
-          <rdf:li xml:lang="x-default">` + xmlEscapeStr(title) + `</rdf:li>
+          <rdf:li xml:lang="x-default">` + xmlEscapeStr(value) + `</rdf:li>
         </rdf:Alt>
-      </dc:title>
-    </rdf:Description>`
+      </` + element + `>`
+}
+
+// renderCaptionBlocks produces the rdf:Description blocks for the fields of
+// c that are set, or "" when none is.
+func renderCaptionBlocks(c Captions) string {
+	var blocks []string
+	if c.Title != "" || c.Description != "" {
+		var b strings.Builder
+		b.WriteString(`    <rdf:Description rdf:about="" ` + dcMarker + `>`)
+		if c.Title != "" {
+			b.WriteString(renderLangAlt("dc:title", c.Title))
+		}
+		if c.Description != "" {
+			b.WriteString(renderLangAlt("dc:description", c.Description))
+		}
+		b.WriteString("\n    </rdf:Description>")
+		blocks = append(blocks, b.String())
+	}
+	if c.AltText != "" {
+		blocks = append(blocks, `    <rdf:Description rdf:about="" `+iptcMarker+`>`+
+			renderLangAlt("Iptc4xmpCore:AltTextAccessibility", c.AltText)+
+			"\n    </rdf:Description>")
+	}
+	return strings.Join(blocks, "\n")
 }
 
-func renderFreshXMPWithDC(title string) string {
+func renderFreshXMPWithBlocks(blocks string) string {
 	return `<?xml version="1.0" encoding="UTF-8"?>
 <x:xmpmeta xmlns:x="adobe:ns:meta/">
   <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
-` + renderDCBlock(title) + `
+` + blocks + `
   </rdf:RDF>
 </x:xmpmeta>`
 }
 
-func mergeDCBlock(existing []byte, title string) []byte {
-	s := string(existing)
-	newBlock := renderDCBlock(title)
-
-	const marker = `xmlns:dc="http://purl.org/dc/elements/1.1/"`
-	idx := strings.Index(s, marker)
-	if idx == -1 {
-		endTag := "</rdf:RDF>"
-		endIdx := strings.LastIndex(s, endTag)
-		if endIdx == -1 {
-			return []byte(renderFreshXMPWithDC(title))
+// setLangAlt sets element (e.g. "dc:title") in the XMP s to value, or removes
+// it when value is "". An existing element is replaced where it is; a new one
+// goes into the rdf:Description block declaring marker, or into a new block.
+func setLangAlt(s, element, marker, value string) string {
+	if start, end := findElement(s, element); start >= 0 {
+		if line := strings.LastIndex(s[:start], "\n"); line >= 0 && strings.TrimSpace(s[line:start]) == "" {
+			start = line
 		}
-		return []byte(s[:endIdx] + "  " + newBlock + "\n  " + s[endIdx:])
+		if value != "" {
+			return s[:start] + renderLangAlt(element, value) + s[end:]
+		}
+		return dropEmptyBlock(s[:start]+s[end:], start, marker)
 	}
-
-	descStart := strings.LastIndex(s[:idx], "<rdf:Description")
-	if descStart == -1 {
-		return []byte(renderFreshXMPWithDC(title))
+	if value == "" {
+		return s
 	}
 
-	closeTag := "</rdf:Description>"
-	descEnd := strings.Index(s[descStart:], closeTag)
-	if descEnd == -1 {
-		selfClose := strings.Index(s[descStart:], "/>")
-		if selfClose == -1 {
-			return []byte(renderFreshXMPWithDC(title))
+	if descStart, tagEnd := findDescription(s, marker); descStart >= 0 {
+		if strings.HasSuffix(s[:tagEnd], "/>") {
+			return s[:tagEnd-2] + ">" + renderLangAlt(element, value) + "\n    </rdf:Description>" + s[tagEnd:]
 		}
-		descEnd = descStart + selfClose + 2
-	} else {
-		descEnd = descStart + descEnd + len(closeTag)
+		if descEnd := descriptionClose(s, tagEnd); descEnd >= 0 {
+			return strings.TrimRight(s[:descEnd], " \t\n") + renderLangAlt(element, value) + "\n    " + s[descEnd:]
+		}
+	}
+	block := `    <rdf:Description rdf:about="" ` + marker + `>` + renderLangAlt(element, value) + "\n    </rdf:Description>"
+	endIdx := strings.LastIndex(s, "</rdf:RDF>")
+	if endIdx == -1 {
+		return renderFreshXMPWithBlocks(block)
 	}
+	return strings.TrimRight(s[:endIdx], " ") + block + "\n  " + s[endIdx:]
+}
 
-	return []byte(s[:descStart] + newBlock + s[descEnd:])
+// findElement returns the byte range of the first element called name, or
+// -1, -1.
+func findElement(s, name string) (int, int) {
+	open := "<" + name
+	for i := 0; ; {
+		j := strings.Index(s[i:], open)
+		if j == -1 {
+			return -1, -1
+		}
+		j += i
+		i = j + len(open)
+		if i < len(s) && strings.IndexByte("> \t\r\n/", s[i]) >= 0 {
+			tagEnd := strings.IndexByte(s[j:], '>')
+			if tagEnd == -1 {
+				return -1, -1
+			}
+			if s[j+tagEnd-1] == '/' {
+				return j, j + tagEnd + 1
+			}
+			closeTag := "</" + name + ">"
+			k := strings.Index(s[j:], closeTag)
+			if k == -1 {
+				return -1, -1
+			}
+			return j, j + k + len(closeTag)
+		}
+	}
 }
 
-func clearDCBlock(existing []byte) []byte {
-	s := string(existing)
+// findDescription returns the start of the rdf:Description whose start tag
+// declares marker and the end of that start tag, or -1, -1.
+func findDescription(s, marker string) (int, int) {
+	for i := 0; ; {
+		j := strings.Index(s[i:], "<rdf:Description")
+		if j == -1 {
+			return -1, -1
+		}
+		j += i
+		tagEnd := strings.IndexByte(s[j:], '>')
+		if tagEnd == -1 {
+			return -1, -1
+		}
+		tagEnd += j + 1
+		if strings.Contains(s[j:tagEnd], marker) {
+			return j, tagEnd
+		}
+		i = tagEnd
+	}
+}
 
-	const marker = `xmlns:dc="http://purl.org/dc/elements/1.1/"`
-	idx := strings.Index(s, marker)
-	if idx == -1 {
-		return existing
+// descriptionClose returns the index of the </rdf:Description> closing the
+// block whose start tag ends at from, skipping nested blocks (e.g. inside
+// crs:Look), or -1.
+func descriptionClose(s string, from int) int {
+	const closeTag = "</rdf:Description>"
+	depth := 0
+	for i := from; ; {
+		o := strings.Index(s[i:], "<rdf:Description")
+		c := strings.Index(s[i:], closeTag)
+		if c == -1 {
+			return -1
+		}
+		if o != -1 && o < c {
+			tagEnd := strings.IndexByte(s[i+o:], '>')
+			if tagEnd == -1 {
+				return -1
+			}
+			if s[i+o+tagEnd-1] != '/' {
+				depth++
+			}
+			i += o + tagEnd + 1
+			continue
+		}
+		if depth == 0 {
+			return i + c
+		}
+		depth--
+		i += c + len(closeTag)
 	}
+}
 
-	descStart := strings.LastIndex(s[:idx], "<rdf:Description")
+// dropEmptyBlock removes the block around pos if it is one unterlumen
+// rendered for marker's namespace and has nothing left in it.
+func dropEmptyBlock(s string, pos int, marker string) string {
+	descStart := strings.LastIndex(s[:pos], "<rdf:Description")
 	if descStart == -1 {
-		return existing
+		return s
 	}
-
-	closeTag := "</rdf:Description>"
-	descEnd := strings.Index(s[descStart:], closeTag)
-	if descEnd == -1 {
-		return existing
+	tag := `<rdf:Description rdf:about="" ` + marker + `>`
+	if !strings.HasPrefix(s[descStart:], tag) {
+		return s
 	}
-	descEnd = descStart + descEnd + len(closeTag)
-
-	return []byte(strings.TrimRight(s[:descStart], " \t") + s[descEnd:])
+	tagEnd := descStart + len(tag)
+	descEnd := descriptionClose(s, tagEnd)
+	if descEnd == -1 || strings.TrimSpace(s[tagEnd:descEnd]) != "" {
+		return s
+	}
+	before := strings.TrimRight(s[:descStart], " \t")
+	after := s[descEnd+len("</rdf:Description>"):]
+	if strings.HasSuffix(before, "\n") && strings.HasPrefix(after, "\n") {
+		after = after[1:]
+	}
+	return before + after
 }
 
 func xmlEscapeStr(s string) string {
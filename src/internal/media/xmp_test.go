@@ -1,8 +1,10 @@
 package media
 
 import (
+	"encoding/xml"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -145,3 +147,183 @@ func TestWriteTitle_ClearTitle(t *testing.T) {
 		t.Fatalf("expected empty title after clear, got %q", title)
 	}
 }
+
+func TestAppendPublication_RemoteURL(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "shot.jpg")
+	pub := Publication{
+		Channel:     "mastodon",
+		PostID:      "p1",
+		PublishedAt: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		RemoteID:    "1130",
+		URL:         "https://example.social/@me/1130?a=1&b=2",
+	}
+	if err := AppendPublication(photo, pub); err != nil {
+		t.Fatalf("AppendPublication: %v", err)
+	}
+	pubs, err := ReadSidecar(photo)
+	if err != nil {
+		t.Fatalf("ReadSidecar: %v", err)
+	}
+	if len(pubs) != 1 || pubs[0] != pub {
+		t.Fatalf("got %+v, want %+v", pubs, pub)
+	}
+}
+
+func TestCaptions_IndependentFields(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "shot.jpg")
+	if err := AppendPublication(photo, Publication{Channel: "site", PublishedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTitle(photo, "Harbour"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDescription(photo, "Boats at dawn & fog"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAltText(photo, "Three fishing boats moored in a foggy harbour"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTitle(photo, "Harbour at dawn"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := ReadCaptions(photo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != (Captions{"Harbour at dawn", "Boats at dawn & fog", "Three fishing boats moored in a foggy harbour"}) {
+		t.Fatalf("got %+v", c)
+	}
+	if pubs, _ := ReadSidecar(photo); len(pubs) != 1 {
+		t.Fatalf("ul block not preserved: %+v", pubs)
+	}
+
+	if err := WriteDescription(photo, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTitle(photo, ""); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(SidecarPath(photo))
+	if strings.Contains(string(data), "xmlns:dc=") {
+		t.Errorf("empty dc block kept:\n%s", data)
+	}
+	if alt, _ := ReadAltText(photo); alt == "" {
+		t.Error("alt text lost when clearing dc fields")
+	}
+}
+
+func TestReadCaptions_SharedDescription(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "shot.jpg")
+	xmp := `<?xml version="1.0" encoding="UTF-8"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+  <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+    <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:Iptc4xmpCore="http://iptc.org/std/Iptc4xmpCore/1.0/xmlns/">
+      <dc:description><rdf:Alt><rdf:li xml:lang="x-default">Caption</rdf:li></rdf:Alt></dc:description>
+      <Iptc4xmpCore:AltTextAccessibility><rdf:Alt><rdf:li xml:lang="x-default">Alt</rdf:li></rdf:Alt></Iptc4xmpCore:AltTextAccessibility>
+    </rdf:Description>
+  </rdf:RDF>
+</x:xmpmeta>`
+	os.WriteFile(SidecarPath(photo), []byte(xmp), 0o644)
+	if err := WriteTitle(photo, "Title"); err != nil {
+		t.Fatal(err)
+	}
+	title, _ := ReadTitle(photo)
+	desc, _ := ReadDescription(photo)
+	alt, _ := ReadAltText(photo)
+	if title != "Title" || desc != "Caption" || alt != "Alt" {
+		t.Fatalf("got title %q, description %q, alt %q", title, desc, alt)
+	}
+}
+
+func TestCaptions_PreserveThirdPartyFields(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "shot.jpg")
+	// Lightroom puts all namespaces on one block, with simple properties as
+	// attributes and a nested rdf:Description inside crs:Look.
+	xmp := `<?xml version="1.0" encoding="UTF-8"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/" x:xmptk="Adobe XMP Core 7.0-c000">
+  <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+    <rdf:Description rdf:about=""
+        xmlns:xmp="http://ns.adobe.com/xap/1.0/"
+        xmlns:dc="http://purl.org/dc/elements/1.1/"
+        xmlns:Iptc4xmpCore="http://iptc.org/std/Iptc4xmpCore/1.0/xmlns/"
+        xmlns:crs="http://ns.adobe.com/camera-raw-settings/1.0/"
+        xmp:Rating="4"
+        crs:Exposure2012="+0.35"
+        Iptc4xmpCore:Location="Harbour">
+      <crs:Look>
+        <rdf:Description crs:Name="Adobe Color" crs:Amount="1"/>
+      </crs:Look>
+      <dc:title>
+        <rdf:Alt>
+          <rdf:li xml:lang="x-default">Old title</rdf:li>
+          <rdf:li xml:lang="de">Alter Titel</rdf:li>
+        </rdf:Alt>
+      </dc:title>
+      <dc:subject>
+        <rdf:Bag>
+          <rdf:li>boats</rdf:li>
+        </rdf:Bag>
+      </dc:subject>
+      <dc:creator>
+        <rdf:Seq>
+          <rdf:li>Jane Doe</rdf:li>
+        </rdf:Seq>
+      </dc:creator>
+    </rdf:Description>
+  </rdf:RDF>
+</x:xmpmeta>`
+	os.WriteFile(SidecarPath(photo), []byte(xmp), 0o644)
+
+	if err := WriteTitle(photo, "Harbour at dawn"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDescription(photo, "Boats in the fog"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAltText(photo, "Three fishing boats"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendPublication(photo, Publication{Channel: "site", PublishedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(SidecarPath(photo))
+	out := string(data)
+	for _, want := range []string{
+		`xmp:Rating="4"`, `crs:Exposure2012="+0.35"`, `Iptc4xmpCore:Location="Harbour"`,
+		`<rdf:Description crs:Name="Adobe Color" crs:Amount="1"/>`,
+		`<rdf:li>boats</rdf:li>`, `<rdf:li>Jane Doe</rdf:li>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("lost %s:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "xmlns:dc=") != 1 || strings.Count(out, "xmlns:Iptc4xmpCore=") != 1 {
+		t.Errorf("caption fields not written into the existing block:\n%s", out)
+	}
+	title, _ := ReadTitle(photo)
+	desc, _ := ReadDescription(photo)
+	alt, _ := ReadAltText(photo)
+	if title != "Harbour at dawn" || desc != "Boats in the fog" || alt != "Three fishing boats" {
+		t.Fatalf("got title %q, description %q, alt %q", title, desc, alt)
+	}
+
+	if err := WriteTitle(photo, ""); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(SidecarPath(photo))
+	if !strings.Contains(string(data), `xmp:Rating="4"`) || !strings.Contains(string(data), "<dc:subject>") {
+		t.Errorf("clearing the title dropped other fields:\n%s", data)
+	}
+	if title, _ := ReadTitle(photo); title != "" {
+		t.Errorf("title after clear = %q", title)
+	}
+	var parsed struct{}
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Errorf("sidecar no longer well-formed: %v\n%s", err, data)
+	}
+}
@@ -0,0 +1,375 @@
+// Package objstore writes channel output to S3-compatible object storage
+// (AWS S3, MinIO, Cloudflare R2, Backblaze B2, …). Requests are signed with
+// AWS Signature Version 4; no SDK is needed.
+package objstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config keys, read from a channel's handler config.
+const (
+	ConfigStorage      = "storage"         // "s3" enables object storage
+	ConfigEndpoint     = "s3_endpoint"     // e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+	ConfigBucket       = "s3_bucket"       // bucket name
+	ConfigPrefix       = "s3_prefix"       // optional key prefix, e.g. "photos/"
+	ConfigRegion       = "s3_region"       // signing region; default us-east-1
+	ConfigAccessKey    = "s3_access_key"   // access key ID
+	ConfigSecretKey    = "s3_secret_key"   // secret access key
+	ConfigVirtualHost  = "s3_virtual_host" // "true" = bucket.endpoint addressing instead of endpoint/bucket
+	ConfigCacheControl = "s3_cache_control"
+)
+
+// ErrNotConfigured is returned by FromConfig when no storage is set.
+var ErrNotConfigured = errors.New("no object storage configured")
+
+// Bucket is an S3-compatible bucket.
+type Bucket struct {
+	Endpoint    *url.URL
+	Name        string
+	Prefix      string // prepended to every key; "" or ends in "/"
+	Region      string
+	AccessKey   string
+	SecretKey   string
+	VirtualHost bool
+
+	// CacheControl overrides the Cache-Control header of every object;
+	// "" = CacheControlFor.
+	CacheControl string
+
+	Client *http.Client // nil = client with a 5 minute timeout
+	now    func() time.Time
+}
+
+// Object is a listed object. Keys are relative to the bucket's prefix.
+type Object struct {
+	Key  string
+	ETag string // without quotes; the hex MD5 for single-part uploads
+	Size int64
+}
+
+// FromConfig returns the bucket configured in cfg.
+func FromConfig(cfg map[string]string) (*Bucket, error) {
+	switch cfg[ConfigStorage] {
+	case "":
+		return nil, ErrNotConfigured
+	case "s3":
+	default:
+		return nil, fmt.Errorf("unknown %s %q (want s3)", ConfigStorage, cfg[ConfigStorage])
+	}
+	u, err := url.Parse(cfg[ConfigEndpoint])
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+		return nil, fmt.Errorf("%s must be an http(s) URL without a path", ConfigEndpoint)
+	}
+	u.Path = ""
+	b := &Bucket{
+		Endpoint:     u,
+		Name:         cfg[ConfigBucket],
+		Prefix:       strings.Trim(cfg[ConfigPrefix], "/"),
+		Region:       cfg[ConfigRegion],
+		AccessKey:    cfg[ConfigAccessKey],
+		SecretKey:    cfg[ConfigSecretKey],
+		VirtualHost:  cfg[ConfigVirtualHost] == "true",
+		CacheControl: cfg[ConfigCacheControl],
+	}
+	if b.Name == "" || strings.ContainsAny(b.Name, "/ ") {
+		return nil, fmt.Errorf("%s is required", ConfigBucket)
+	}
+	if b.Prefix != "" {
+		b.Prefix += "/"
+	}
+	if b.Region == "" {
+		b.Region = "us-east-1"
+	}
+	if b.AccessKey == "" || b.SecretKey == "" {
+		return nil, fmt.Errorf("%s and %s are required", ConfigAccessKey, ConfigSecretKey)
+	}
+	return b, nil
+}
+
+func (b *Bucket) String() string {
+	return "s3://" + b.Name + "/" + b.Prefix
+}
+
+// URL returns the object URL of key, e.g. for links in responses.
+func (b *Bucket) URL(key string) string {
+	return b.objectURL(key).String()
+}
+
+// Put uploads the file at path as key with the given Content-Type. The
+// file's MD5 is sent as Content-MD5, so S3 rejects a body damaged in transit.
+func (b *Bucket) Put(ctx context.Context, key, path, contentType string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	cache := b.CacheControl
+	if cache == "" {
+		cache = CacheControlFor(key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.objectURL(key).String(), f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	if req.ContentLength == 0 {
+		// With a non-nil body, net/http would send an empty file chunked,
+		// which S3 rejects.
+		req.Body = http.NoBody
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", cache)
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(h.Sum(nil)))
+	_, err = b.do(req)
+	return err
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func (b *Bucket) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, b.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	_, err = b.do(req)
+	return err
+}
+
+// List returns the objects whose keys (relative to the bucket prefix) start
+// with prefix.
+func (b *Bucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	var out []Object
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {b.Prefix + prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u := b.bucketURL()
+		u.RawQuery = canonicalQuery(q) // sent exactly as signed
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		body, err := b.do(req)
+		if err != nil {
+			return nil, err
+		}
+		var res struct {
+			Contents []struct {
+				Key  string
+				ETag string
+				Size int64
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		if err := xml.Unmarshal(body, &res); err != nil {
+			return nil, fmt.Errorf("list %s: invalid response: %w", b, err)
+		}
+		for _, c := range res.Contents {
+			out = append(out, Object{
+				Key:  strings.TrimPrefix(c.Key, b.Prefix),
+				ETag: strings.Trim(c.ETag, `"`),
+				Size: c.Size,
+			})
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return out, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+func (b *Bucket) bucketURL() *url.URL {
+	u := *b.Endpoint
+	if b.VirtualHost {
+		u.Host = b.Name + "." + u.Host
+		u.Path = "/"
+	} else {
+		u.Path = "/" + b.Name + "/"
+	}
+	return &u
+}
+
+func (b *Bucket) objectURL(key string) *url.URL {
+	u := b.bucketURL()
+	u.Path += b.Prefix + key
+	// Send the path exactly as it is signed.
+	u.RawPath = uriEncode(u.Path, false)
+	return u
+}
+
+// do signs and sends req and returns the body of a 2xx response.
+func (b *Bucket) do(req *http.Request) ([]byte, error) {
+	b.sign(req)
+	hc := b.Client
+	if hc == nil {
+		hc = &http.Client{Timeout: 5 * time.Minute}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Code    string
+			Message string
+		}
+		if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+			return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+	return body, nil
+}
+
+// sign adds AWS Signature Version 4 headers. Bodies are not hashed
+// (UNSIGNED-PAYLOAD) so files can be streamed; uploads are integrity-checked
+// through the signed Content-MD5 header that Put sets instead.
+func (b *Bucket) sign(req *http.Request) {
+	now := time.Now
+	if b.now != nil {
+		now = b.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	day := t.Format("20060102")
+	payload := req.Header.Get("X-Amz-Content-Sha256")
+	if payload == "" {
+		payload = "UNSIGNED-PAYLOAD"
+		req.Header.Set("X-Amz-Content-Sha256", payload)
+	}
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	// Canonical headers: host plus every x-amz-*, content-type,
+	// content-md5, cache-control and range header, lower-cased and sorted.
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-") || lk == "content-type" || lk == "content-md5" || lk == "cache-control" || lk == "range" {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonHeaders.String(),
+		signed,
+		payload,
+	}, "\n")
+	scope := day + "/" + b.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+b.SecretKey), day)
+	key = hmacSHA256(key, b.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+b.AccessKey+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+sig)
+}
+
+// uriEncode percent-encodes everything but unreserved characters (and "/"
+// unless encodeSlash), as SigV4 expects.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), q[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// CacheControlFor returns the default Cache-Control header for a key. Pages,
+// feeds and stylesheets change on every rebuild and are revalidated soon;
+// photos and archives keep their content under a given name and are cached
+// for a day.
+func CacheControlFor(key string) string {
+	switch ext := strings.ToLower(key[strings.LastIndexByte(key, '.')+1:]); ext {
+	case "html", "json", "xml", "txt", "css", "js":
+		return "public, max-age=300"
+	default:
+		return "public, max-age=" + strconv.Itoa(24*60*60)
+	}
+}
@@ -0,0 +1,171 @@
+package objstore
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SyncOptions tune Sync.
+type SyncOptions struct {
+	// Mirror deletes objects below the key prefix that have no local file,
+	// e.g. photos of removed albums.
+	Mirror bool
+
+	// Exclude lists slash paths relative to the local directory that are
+	// neither uploaded nor, with Mirror, deleted.
+	Exclude []string
+
+	// Progress, if not nil, is called after each upload or delete.
+	Progress func(Progress)
+}
+
+// Progress reports one step of a sync.
+type Progress struct {
+	Step  string `json:"step"` // "upload" or "delete"
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+	File  string `json:"file"`
+}
+
+// SyncResult summarises a sync.
+type SyncResult struct {
+	Uploaded  int `json:"uploaded"`
+	Deleted   int `json:"deleted"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Sync uploads the files below dir to keys below prefix ("" or ending in
+// "/"), skipping files whose MD5 matches the object's ETag. Hidden files are
+// skipped.
+func Sync(ctx context.Context, b *Bucket, dir, prefix string, opts SyncOptions) (SyncResult, error) {
+	exclude := make(map[string]bool, len(opts.Exclude))
+	for _, e := range opts.Exclude {
+		exclude[e] = true
+	}
+	local := map[string]string{} // slash path → local file
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); !exclude[rel] {
+			local[rel] = p
+		}
+		return nil
+	})
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	remote, err := b.List(ctx, prefix)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	etags := make(map[string]string, len(remote))
+	for _, o := range remote {
+		etags[strings.TrimPrefix(o.Key, prefix)] = o.ETag
+	}
+
+	var res SyncResult
+	var upload, remove []string
+	for rel, p := range local {
+		if etag, ok := etags[rel]; ok {
+			sum, err := md5File(p)
+			if err != nil {
+				return res, err
+			}
+			if strings.EqualFold(sum, etag) {
+				res.Unchanged++
+				continue
+			}
+		}
+		upload = append(upload, rel)
+	}
+	if opts.Mirror {
+		for rel := range etags {
+			if _, ok := local[rel]; !ok && !exclude[rel] {
+				remove = append(remove, rel)
+			}
+		}
+	}
+	sort.Strings(upload)
+	sort.Strings(remove)
+
+	for i, rel := range upload {
+		if err := b.Put(ctx, prefix+rel, local[rel], ContentType(rel)); err != nil {
+			return res, err
+		}
+		res.Uploaded++
+		if opts.Progress != nil {
+			opts.Progress(Progress{Step: "upload", Done: i + 1, Total: len(upload), File: rel})
+		}
+	}
+	for i, rel := range remove {
+		if err := b.Delete(ctx, prefix+rel); err != nil {
+			return res, err
+		}
+		res.Deleted++
+		if opts.Progress != nil {
+			opts.Progress(Progress{Step: "delete", Done: i + 1, Total: len(remove), File: rel})
+		}
+	}
+	return res, nil
+}
+
+func md5File(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ContentType returns the Content-Type for a file name. Image formats newer
+// than the system MIME tables are covered explicitly.
+func ContentType(name string) string {
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".webp":
+		return "image/webp"
+	case ".avif":
+		return "image/avif"
+	case ".jxl":
+		return "image/jxl"
+	case ".html":
+		return "text/html; charset=utf-8"
+	case ".json":
+		return "application/json"
+	case ".zip":
+		return "application/zip"
+	default:
+		if t := mime.TypeByExtension(ext); t != "" {
+			return t
+		}
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,284 @@
+// Package pdf writes simple PDF documents: pages with JPEG images, lines and
+// single-line text in the standard Helvetica fonts. It has no dependencies
+// beyond the standard library and covers what contact sheets need, nothing
+// more.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"strings"
+	"time"
+)
+
+// Page sizes in points (1/72 inch), portrait.
+var (
+	A4     = Size{595.28, 841.89}
+	Letter = Size{612, 792}
+)
+
+// Size is a page size in points.
+type Size struct{ W, H float64 }
+
+// Landscape returns s with width and height swapped so that W >= H.
+func (s Size) Landscape() Size {
+	if s.W < s.H {
+		return Size{s.H, s.W}
+	}
+	return s
+}
+
+// Font is one of the standard PDF fonts, which viewers provide built in.
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+var fontNames = [...]string{Helvetica: "Helvetica", HelveticaBold: "Helvetica-Bold"}
+
+// Document is a PDF under construction. Pages are kept in memory until Write.
+type Document struct {
+	Title   string
+	Creator string
+
+	pages  []*Page
+	images []*Image
+}
+
+// New returns an empty document.
+func New() *Document {
+	return &Document{}
+}
+
+// Image is a JPEG added to a document; it can be placed on any number of pages.
+type Image struct {
+	id         int // index in Document.images
+	data       []byte
+	W, H       int // pixel dimensions
+	colorSpace string
+}
+
+// AddJPEG adds a baseline or progressive JPEG with one (grey) or three (RGB)
+// components. CMYK JPEGs are rejected; decode and re-encode them with
+// image/jpeg first, e.g. via NormalizeJPEG.
+func (d *Document) AddJPEG(data []byte) (*Image, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %w", err)
+	}
+	cs := "DeviceRGB"
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		cs = "DeviceGray"
+	case color.CMYKModel:
+		return nil, fmt.Errorf("pdf: CMYK JPEG not supported")
+	}
+	img := &Image{id: len(d.images), data: data, W: cfg.Width, H: cfg.Height, colorSpace: cs}
+	d.images = append(d.images, img)
+	return img, nil
+}
+
+// NormalizeJPEG decodes any image/* supported format and re-encodes it as an
+// RGB JPEG that AddJPEG accepts.
+func NormalizeJPEG(data []byte, quality int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			rgba.Set(x-b.Min.X, y-b.Min.Y, src.At(x, y))
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, rgba, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Page is a single page. Coordinates are in points from the bottom-left corner.
+type Page struct {
+	Size    Size
+	content bytes.Buffer
+	images  map[int]bool
+}
+
+// AddPage appends a page of the given size.
+func (d *Document) AddPage(size Size) *Page {
+	p := &Page{Size: size, images: make(map[int]bool)}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Image draws img scaled to w×h with its lower-left corner at x, y.
+func (p *Page) Image(img *Image, x, y, w, h float64) {
+	p.images[img.id] = true
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(w), num(h), num(x), num(y), img.id)
+}
+
+// Text draws s on one line starting at x with its baseline at y. gray is the
+// fill colour from 0 (black) to 1 (white). Characters outside Windows-1252 are
+// replaced with '?'.
+func (p *Page) Text(x, y, size float64, font Font, gray float64, s string) {
+	fmt.Fprintf(&p.content, "BT %s g /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		num(gray), font+1, num(size), num(x), num(y), escape(encodeWinAnsi(s)))
+}
+
+// Line draws a straight line of the given width and grey level.
+func (p *Page) Line(x1, y1, x2, y2, width, gray float64) {
+	fmt.Fprintf(&p.content, "%s G %s w %s %s m %s %s l S\n",
+		num(gray), num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Rect strokes a rectangle outline.
+func (p *Page) Rect(x, y, w, h, width, gray float64) {
+	fmt.Fprintf(&p.content, "%s G %s w %s %s %s %s re S\n",
+		num(gray), num(width), num(x), num(y), num(w), num(h))
+}
+
+// Write serialises the document.
+func (d *Document) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	pw := &writer{w: bw}
+
+	// Object numbers: 1 catalog, 2 page tree, 3 info, 4.. fonts, then images,
+	// then a page and its content stream per page.
+	const catalogID, pagesID, infoID, fontBase = 1, 2, 3, 4
+	imageBase := fontBase + len(fontNames)
+	pageBase := imageBase + len(d.images)
+
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	pw.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageBase+2*i)
+	}
+	pw.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	info := fmt.Sprintf("<< /Producer (%s) /CreationDate (D:%s)", escape(encodeWinAnsi(d.creator())), time.Now().UTC().Format("20060102150405Z"))
+	if d.Title != "" {
+		info += " /Title (" + escape(encodeWinAnsi(d.Title)) + ")"
+	}
+	pw.object(infoID, info+" >>")
+
+	for i, name := range fontNames {
+		pw.object(fontBase+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+
+	for _, img := range d.images {
+		pw.stream(imageBase+img.id, fmt.Sprintf(
+			"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /DCTDecode",
+			img.W, img.H, img.colorSpace), img.data)
+	}
+
+	var fonts strings.Builder
+	for i := range fontNames {
+		fmt.Fprintf(&fonts, "/F%d %d 0 R ", i+1, fontBase+i)
+	}
+	for i, p := range d.pages {
+		var xobj strings.Builder
+		for _, img := range d.images {
+			if p.images[img.id] {
+				fmt.Fprintf(&xobj, "/Im%d %d 0 R ", img.id, imageBase+img.id)
+			}
+		}
+		pageID := pageBase + 2*i
+		pw.object(pageID, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Contents %d 0 R /Resources << /Font << %s>> /XObject << %s>> >> >>",
+			pagesID, num(p.Size.W), num(p.Size.H), pageID+1, fonts.String(), xobj.String()))
+
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(p.content.Bytes()) //nolint:errcheck // bytes.Buffer cannot fail
+		zw.Close()
+		pw.stream(pageID+1, "/Filter /FlateDecode", z.Bytes())
+	}
+
+	total := pageBase + 2*len(d.pages)
+	xref := pw.n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", total)
+	for id := 1; id < total; id++ {
+		pw.printf("%010d 00000 n \n", pw.offsets[id])
+	}
+	pw.printf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", total, catalogID, infoID, xref)
+	if pw.err != nil {
+		return pw.err
+	}
+	return bw.Flush()
+}
+
+func (d *Document) creator() string {
+	if d.Creator != "" {
+		return d.Creator
+	}
+	return "unterlumen"
+}
+
+// writer tracks byte offsets of objects for the cross-reference table.
+type writer struct {
+	w       io.Writer
+	n       int64
+	offsets map[int]int64
+	err     error
+}
+
+func (pw *writer) write(b []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(b)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+func (pw *writer) printf(format string, args ...any) {
+	pw.write([]byte(fmt.Sprintf(format, args...)))
+}
+
+func (pw *writer) begin(id int) {
+	if pw.offsets == nil {
+		pw.offsets = make(map[int]int64)
+	}
+	pw.offsets[id] = pw.n
+	pw.printf("%d 0 obj\n", id)
+}
+
+func (pw *writer) object(id int, body string) {
+	pw.begin(id)
+	pw.printf("%s\nendobj\n", body)
+}
+
+func (pw *writer) stream(id int, dict string, data []byte) {
+	pw.begin(id)
+	pw.printf("<< %s /Length %d >>\nstream\n", dict, len(data))
+	pw.write(data)
+	pw.printf("\nendstream\nendobj\n")
+}
+
+// num formats a coordinate compactly with two decimals.
+func num(v float64) string {
+	s := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// escape escapes a string for a PDF literal string.
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", `\r`, "\n", `\n`)
+	return r.Replace(s)
+}
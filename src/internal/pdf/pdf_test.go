@@ -0,0 +1,93 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func testJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 3)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteProducesValidXref(t *testing.T) {
+	d := New()
+	d.Title = "Proofs (1)"
+	img, err := d.AddJPEG(testJPEG(t))
+	if err != nil {
+		t.Fatalf("AddJPEG: %v", err)
+	}
+	if img.W != 4 || img.H != 3 {
+		t.Errorf("image size = %dx%d", img.W, img.H)
+	}
+	for i := 0; i < 2; i++ {
+		p := d.AddPage(A4)
+		p.Image(img, 10, 10, 40, 30)
+		p.Text(10, 60, 8, Helvetica, 0, fmt.Sprintf("Page %d — Größe", i+1))
+		p.Line(0, 0, 100, 0, 0.5, 0.5)
+	}
+
+	var out bytes.Buffer
+	if err := d.Write(&out); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	pdf := out.Bytes()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	if !bytes.Contains(pdf, []byte("/Count 2")) || !bytes.Contains(pdf, []byte(`/Title (Proofs \(1\))`)) {
+		t.Error("page count or escaped title missing")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("startxref missing")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	var count int
+	fmt.Sscanf(lines[1], "0 %d", &count)
+	for id := 1; id < count; id++ {
+		off, _ := strconv.Atoi(lines[2+id][:10])
+		want := fmt.Sprintf("%d 0 obj", id)
+		if !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", id, pdf[off:off+12])
+		}
+	}
+}
+
+func TestAddJPEGRejectsNonJPEG(t *testing.T) {
+	if _, err := New().AddJPEG([]byte("not a jpeg")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestTextHelpers(t *testing.T) {
+	if got := encodeWinAnsi("f/2.8 · 1/500 – ä€😀"); got != "f/2.8 \xb7 1/500 \x96 \xe4\x80?" {
+		t.Errorf("encodeWinAnsi = %q", got)
+	}
+	if w := TextWidth("Hi", 10, Helvetica); w != 9.44 {
+		t.Errorf("TextWidth = %v, want 9.44", w)
+	}
+	long := "DSCF0001-a-very-long-file-name.JPG"
+	tr := Truncate(long, 8, 60, Helvetica)
+	if !strings.HasSuffix(tr, "…") || TextWidth(tr, 8, Helvetica) > 60 {
+		t.Errorf("Truncate = %q", tr)
+	}
+	if Truncate("short", 8, 60, Helvetica) != "short" {
+		t.Error("short text was truncated")
+	}
+}
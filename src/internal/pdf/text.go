@@ -0,0 +1,92 @@
+package pdf
+
+import "strings"
+
+// winAnsiExtra maps the Unicode characters that Windows-1252 places in
+// 0x80–0x9F. 0xA0–0xFF match Latin-1 and map one to one.
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// encodeWinAnsi converts s to Windows-1252 bytes, the encoding of the
+// standard fonts; other characters become '?'.
+func encodeWinAnsi(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		case winAnsiExtra[r] != 0:
+			b.WriteByte(winAnsiExtra[r])
+		case r == '\t':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// helveticaWidths are the Helvetica advance widths (1/1000 em) for ASCII
+// 0x20–0x7E, from the Adobe font metrics.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space … /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 … ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ … O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P … _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` … o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p … ~
+}
+
+// helveticaBoldWidths are the Helvetica-Bold widths for ASCII 0x20–0x7E.
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// specialWidths covers the non-ASCII characters used in captions; their
+// widths are the same in both fonts.
+var specialWidths = map[byte]int{0x85: 1000, 0x95: 350, 0x96: 556, 0x97: 1000, 0xB0: 400, 0xB7: 278}
+
+// TextWidth returns the width of s in points when set in font at size. Other
+// non-ASCII characters are measured as an average lowercase letter.
+func TextWidth(s string, size float64, font Font) float64 {
+	widths := &helveticaWidths
+	if font == HelveticaBold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, b := range []byte(encodeWinAnsi(s)) {
+		switch {
+		case b >= 0x20 && b < 0x7F:
+			total += widths[b-0x20]
+		case specialWidths[b] != 0:
+			total += specialWidths[b]
+		default:
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Truncate shortens s with a trailing "…" so that it fits maxWidth.
+func Truncate(s string, size, maxWidth float64, font Font) string {
+	if TextWidth(s, size, font) <= maxWidth {
+		return s
+	}
+	runes := []rune(s)
+	for n := len(runes) - 1; n > 0; n-- {
+		t := strings.TrimRight(string(runes[:n]), " ") + "…"
+		if TextWidth(t, size, font) <= maxWidth {
+			return t
+		}
+	}
+	return ""
+}
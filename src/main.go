@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"huepattl.de/unterlumen/internal/api"
+	apilibrary "huepattl.de/unterlumen/internal/api/library"
 	"huepattl.de/unterlumen/internal/channels"
 	"huepattl.de/unterlumen/internal/desktop"
 	"huepattl.de/unterlumen/internal/library"
@@ -60,8 +61,17 @@ func main() {
 	channelsDir := flag.String("channels-dir", channelsDirDefault, "Directory for channels.json; override to share channel config across installations (defaults to lib-dir; env: UNTERLUMEN_CHANNELS_DIR)")
 	desktopMode := flag.Bool("desktop", false, "Open in a Chrome app window (no URL bar); server shuts down when the window is closed")
 	desktopInstall := flag.Bool("desktop-install", false, "Install as a native app launcher (macOS .app, Linux .desktop, Windows Start Menu)")
+	dumpSiteTemplates := flag.String("dump-site-templates", "", "Write the built-in site and gallery templates to this directory and exit")
 	flag.Parse()
 
+	if *dumpSiteTemplates != "" {
+		if err := apilibrary.DumpSiteTemplates(*dumpSiteTemplates); err != nil {
+			log.Fatalf("Dump site templates: %v", err)
+		}
+		fmt.Printf("Site templates written to %s\n", *dumpSiteTemplates)
+		return
+	}
+
 	if *desktopInstall {
 		iconData, _ := webFS.ReadFile("web/logo.png")
 		execPath, _ := os.Executable()
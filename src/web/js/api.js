@@ -117,6 +117,19 @@ const API = {
         return resp.json();
     },
 
+    async geotagGPX(files, tracks, { offset = 0, maxGap = 300, timezone = '', apply = false } = {}) {
+        const form = new FormData();
+        for (const t of tracks) form.append('track', t);
+        form.append('files', JSON.stringify(files));
+        form.append('offset', String(offset));
+        form.append('maxGap', String(maxGap));
+        if (timezone) form.append('timezone', timezone);
+        form.append('apply', apply ? 'true' : 'false');
+        const resp = await fetch('/api/geotag-gpx', { method: 'POST', body: form });
+        if (!resp.ok) throw new Error(await resp.text());
+        return resp.json();
+    },
+
     async mkdir(path) {
         const resp = await fetch('/api/mkdir', {
             method: 'POST',
@@ -211,6 +224,34 @@ const API = {
         return resp.blob();
     },
 
+    async exportContactSheet(payload) {
+        const resp = await fetch('/api/export/contact-sheet', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(payload),
+        });
+        if (!resp.ok) throw new Error(await resp.text());
+        return resp.blob();
+    },
+
+    async submitSlideshowJob(payload) {
+        const resp = await fetch('/api/jobs/slideshow', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(payload),
+        });
+        if (!resp.ok) throw new Error(await resp.text());
+        return resp.json();
+    },
+
+    jobDownloadURL(id) {
+        return `/api/jobs/${encodeURIComponent(id)}/download`;
+    },
+
+    jobEventsURL(id) {
+        return `/api/jobs/${encodeURIComponent(id)}/events`;
+    },
+
     async exportZipDownload(token) {
         const resp = await fetch(`/api/export/zip-download?token=${encodeURIComponent(token)}`);
         if (!resp.ok) throw new Error(await resp.text());
@@ -227,6 +268,29 @@ const API = {
         return resp.json();
     },
 
+    async exportPresets() {
+        const resp = await fetch('/api/export/presets');
+        if (!resp.ok) throw new Error(await resp.text());
+        return resp.json();
+    },
+
+    // saveExportPreset creates the preset, or replaces it when replace is set.
+    async saveExportPreset(preset, replace = false) {
+        const url = replace ? `/api/export/presets/${encodeURIComponent(preset.name)}` : '/api/export/presets';
+        const resp = await fetch(url, {
+            method: replace ? 'PUT' : 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(preset),
+        });
+        if (!resp.ok) throw new Error(await resp.text());
+        return resp.json();
+    },
+
+    async deleteExportPreset(name) {
+        const resp = await fetch(`/api/export/presets/${encodeURIComponent(name)}`, { method: 'DELETE' });
+        if (!resp.ok) throw new Error(await resp.text());
+    },
+
     async crop(path, x, y, width, height) {
         const resp = await fetch('/api/crop', {
             method: 'POST',
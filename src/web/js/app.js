@@ -359,23 +359,24 @@ const App = {
         // Resolve each path to its correct image URL (LibraryPane overrides viewerImageURL
         // to use /api/library/{id}/photo/{photoID}; BrowsePane falls back to API.imageURL).
         const toURL = p => pane.viewerImageURL ? pane.viewerImageURL(p) : API.imageURL(p);
-        let images;
+        let paths;
         if (pane.selectedDirs?.size > 0) {
-            const allPaths = [];
+            paths = [];
             for (const dirPath of pane.selectedDirs) {
-                const paths = await pane.fetchRecursivePhotoPaths(dirPath);
-                allPaths.push(...paths);
+                paths.push(...await pane.fetchRecursivePhotoPaths(dirPath));
             }
-            images = allPaths.map(toURL);
         } else if (pane.selection.selected.size > 0) {
-            images = Array.from(pane.selection.selected).map(toURL);
+            paths = Array.from(pane.selection.selected);
         } else {
-            images = pane.getImageEntries().map(e => toURL(pane.fullPath(e.name)));
+            paths = pane.getImageEntries().map(e => pane.fullPath(e.name));
         }
+        const images = paths.map(toURL);
         if (images.length === 0) return;
         if (!this.slideshowModal) this.slideshowModal = new SlideshowModal();
         this.slideshowModal.onStart = (imgs, opts) => this.openSlideshow(imgs, opts);
-        this.slideshowModal.open(images);
+        // Paths (relative to the library source for library panes) let the
+        // modal render the same photos as a video on the server.
+        this.slideshowModal.open(images, { paths, sourcePath: pane._sourcePath || null });
     },
 
     openSlideshow(images, options) {
@@ -541,6 +542,8 @@ const App = {
                 serverRole: this.config?.serverRole ?? false,
                 exiftoolAvailable: this.toolsStatus?.exiftool ?? false,
                 webpSupport: this.toolsStatus?.webpAvailable ?? false,
+                avifSupport: this.toolsStatus?.avifAvailable ?? false,
+                jxlSupport: this.toolsStatus?.jxlAvailable ?? false,
                 sourcePath: sourcePath || null,
             });
         } else if (tool === 'clear-cache') {
@@ -18,6 +18,10 @@ const BATCH_RENAME_TOKENS = [
     { token: '{aperture}', label: 'Aperture (f-number)', example: 'f1.4', category: 'exposure' },
     { token: '{focal}', label: 'Focal length', example: '23mm', category: 'exposure' },
     { token: '{shutter}', label: 'Shutter speed', example: '1-125s', category: 'exposure' },
+    // Place tokens (offline reverse geocoding; needs a GeoNames dump in the lib dir)
+    { token: '{country}', label: 'Country (from GPS)', example: 'Germany', category: 'place' },
+    { token: '{region}', label: 'Region / state (from GPS)', example: 'Bavaria', category: 'place' },
+    { token: '{city}', label: 'City (from GPS)', example: 'Munich', category: 'place' },
     // File tokens
     { token: '{original}', label: 'Original filename (no extension)', example: 'DSCF1234', category: 'file' },
     { token: '{seq}', label: 'Auto-increment counter (3 digits, or {seq:N} for N digits)', example: '001', category: 'file' },
@@ -25,7 +29,7 @@ const BATCH_RENAME_TOKENS = [
 ];
 
 // Token pattern for matching in input text — matches {word} and {seq:N}
-const TOKEN_REGEX = /\{(?:YYYY|MM|DD|hh|mm|ss|make|model|lens|filmsim|iso|aperture|focal|shutter|original|title|seq(?::\d+)?)\}/g;
+const TOKEN_REGEX = /\{(?:YYYY|MM|DD|hh|mm|ss|make|model|lens|filmsim|iso|aperture|focal|shutter|country|region|city|original|title|seq(?::\d+)?)\}/g;
 
 class BatchRenameModal {
     constructor() {
@@ -35,6 +35,35 @@ const ChannelAPI = {
         if (!r.ok) throw new Error(await r.text());
         return r.json();
     },
+    // deploy streams deploy progress events to onProgress and resolves with the
+    // final event ({ target, uploaded, deleted, files }).
+    async deploy(slug, onProgress) {
+        const r = await fetch(`/api/channels/${encodeURIComponent(slug)}/deploy`, { method: 'POST' });
+        if (!r.ok) throw new Error(await r.text());
+        const reader = r.body.getReader();
+        const decoder = new TextDecoder();
+        let buffer = '';
+        let finalEvt = null;
+        while (true) {
+            const { done, value } = await reader.read();
+            if (done) break;
+            buffer += decoder.decode(value, { stream: true });
+            const blocks = buffer.split('\n\n');
+            buffer = blocks.pop() ?? '';
+            for (const block of blocks) {
+                const line = block.split('\n').find(l => l.startsWith('data: '));
+                if (!line) continue;
+                try {
+                    const evt = JSON.parse(line.slice(6));
+                    if (evt.complete) finalEvt = evt;
+                    else if (onProgress) onProgress(evt);
+                } catch { /* skip malformed */ }
+            }
+        }
+        if (!finalEvt) throw new Error('Deploy stream ended without completion event');
+        if (finalEvt.error) throw new Error(finalEvt.error);
+        return finalEvt;
+    },
     async path(slug) {
         const r = await fetch(`/api/channels/${encodeURIComponent(slug)}/path`);
         if (!r.ok) throw new Error(await r.text());
@@ -156,6 +185,7 @@ class ChannelSettingsModal {
                 </div>
                 <div class="channel-row-actions">
                     ${ch.siteExport ? '<button class="btn btn-sm ch-rebuild">Rebuild site</button>' : ''}
+                    ${ch.siteExport && ch.handlerConfig?.deploy ? '<button class="btn btn-sm ch-deploy">Deploy</button>' : ''}
                     <div class="ch-path-wrap">
                         <button class="btn btn-sm ch-path-toggle">Path ▾</button>
                         <div class="ch-path-menu" hidden>
@@ -190,6 +220,8 @@ class ChannelSettingsModal {
         if (ch.siteExport) {
             const rebuildBtn = row.querySelector('.ch-rebuild');
             rebuildBtn.addEventListener('click', () => this._rebuildSite(ch, rebuildBtn));
+            const deployBtn = row.querySelector('.ch-deploy');
+            if (deployBtn) deployBtn.addEventListener('click', () => this._deploySite(ch, deployBtn));
         }
         return row;
     }
@@ -219,6 +251,23 @@ class ChannelSettingsModal {
         }
     }
 
+    async _deploySite(ch, btn) {
+        const orig = btn.textContent;
+        btn.disabled = true;
+        btn.textContent = 'Deploying…';
+        try {
+            const res = await ChannelAPI.deploy(ch.slug, evt => {
+                btn.textContent = `${evt.step === 'delete' ? 'Deleting' : 'Uploading'} ${evt.done}/${evt.total}`;
+            });
+            btn.textContent = `Done (${res.uploaded}↑ ${res.deleted}✕)`;
+            setTimeout(() => { btn.textContent = orig; btn.disabled = false; }, 2500);
+        } catch (err) {
+            btn.textContent = 'Failed';
+            setTimeout(() => { btn.textContent = orig; btn.disabled = false; }, 2500);
+            alert('Deploy failed: ' + err.message);
+        }
+    }
+
     async _copyPath(ch, btn) {
         try {
             const path = await ChannelAPI.path(ch.slug);
@@ -308,9 +357,21 @@ class ChannelSettingsModal {
                             <option value="jpeg" ${ch.format==='jpeg'?'selected':''}>JPEG</option>
                             <option value="png"  ${ch.format==='png'?'selected':''}>PNG</option>
                             <option value="webp" ${ch.format==='webp'?'selected':''}>WebP</option>
+                            <option value="avif" ${ch.format==='avif'?'selected':''}>AVIF</option>
+                            <option value="jxl" ${ch.format==='jxl'?'selected':''}>JPEG XL</option>
                         </select>
                         <label class="form-label">Quality (1–100)</label>
                         <input class="form-input" id="chf-quality" type="number" min="1" max="100" value="${ch.quality}">
+                        <label class="form-label">Max file size <span class="form-hint">(MB; quality is lowered to fit, empty = no limit)</span></label>
+                        <div class="form-row">
+                            <input class="form-input" id="chf-max-mb" type="number" min="0" step="0.1" value="${ch.maxBytes ? +(ch.maxBytes / 1024 / 1024).toFixed(2) : ''}">
+                            <select class="form-select" id="chf-allow-downscale">
+                                <option value=""    ${!ch.allowDownscale?'selected':''}>Lower quality only</option>
+                                <option value="yes" ${ch.allowDownscale?'selected':''}>Also shrink if needed</option>
+                            </select>
+                        </div>
+                        <label class="form-label">File names <span class="form-hint">(batch-rename tokens; / creates subfolders)</span></label>
+                        <input class="form-input" id="chf-name-pattern" value="${escapeHtml(ch.namePattern || '')}" placeholder="default: ${escapeHtml(ch.slug || 'slug')}_<timestamp>_<name>, e.g. {YYYY}/{title}_{seq:3}">
                         <label class="form-label">Scale</label>
                         <div class="form-row">
                             <select class="form-select" id="chf-scale-mode">
@@ -320,19 +381,58 @@ class ChannelSettingsModal {
                             </select>
                             <div id="chf-scale-opts" class="form-scale-opts">${_scaleOptsHTML(ch.scale)}</div>
                         </div>
+                        <label class="form-label">Resampling &amp; sharpening</label>
+                        <div class="form-row">
+                            <select class="form-select" id="chf-filter">
+                                <option value=""           ${!ch.scale?.filter?'selected':''}>Default filter</option>
+                                <option value="lanczos3"   ${ch.scale?.filter==='lanczos3'?'selected':''}>Lanczos3</option>
+                                <option value="catmullrom" ${ch.scale?.filter==='catmullrom'?'selected':''}>Catmull-Rom</option>
+                                <option value="bilinear"   ${ch.scale?.filter==='bilinear'?'selected':''}>Bilinear</option>
+                            </select>
+                            <select class="form-select" id="chf-sharpen">
+                                <option value=""                ${!ch.sharpen?'selected':''}>No sharpening</option>
+                                <option value="screen_low"      ${ch.sharpen==='screen_low'?'selected':''}>Screen, low</option>
+                                <option value="screen_standard" ${ch.sharpen==='screen_standard'?'selected':''}>Screen, standard</option>
+                                <option value="screen_high"     ${ch.sharpen==='screen_high'?'selected':''}>Screen, high</option>
+                            </select>
+                        </div>
                         <label class="form-label">EXIF</label>
                         <select class="form-select" id="chf-exif">
                             <option value="strip"       ${ch.exifMode==='strip'?'selected':''}>Strip all</option>
                             <option value="keep_no_gps" ${ch.exifMode==='keep_no_gps'?'selected':''}>Keep (no GPS)</option>
+                            <option value="keep_outside_zones" ${ch.exifMode==='keep_outside_zones'?'selected':''}>Keep (protect privacy zones)</option>
                             <option value="keep"        ${ch.exifMode==='keep'?'selected':''}>Keep all</option>
                         </select>
 
+                        <label class="form-label">Color</label>
+                        <select class="form-select" id="chf-color-mode">
+                            <option value="srgb"     ${ch.colorMode!=='preserve'?'selected':''}>Convert to sRGB</option>
+                            <option value="preserve" ${ch.colorMode==='preserve'?'selected':''}>Preserve source profile</option>
+                        </select>
+
+                        <label class="form-label">Watermark</label>
+                        <input class="form-input" id="chf-wm-text" value="${escapeHtml(ch.watermark?.text || '')}" placeholder="Text, e.g. © Jane Doe">
+                        <input class="form-input" id="chf-wm-image" value="${escapeHtml(ch.watermark?.imagePath || '')}" placeholder="or absolute path to a PNG logo">
+                        <select class="form-select" id="chf-wm-position">
+                            ${['bottom-right', 'bottom-left', 'top-right', 'top-left', 'center'].map(p =>
+                                `<option value="${p}" ${(ch.watermark?.position || 'bottom-right') === p ? 'selected' : ''}>${p}</option>`).join('')}
+                        </select>
+
+                        <label class="form-label">Artist / Copyright / Usage terms</label>
+                        <input class="form-input" id="chf-artist" value="${escapeHtml(ch.artist || '')}" placeholder="Artist">
+                        <input class="form-input" id="chf-copyright" value="${escapeHtml(ch.copyright || '')}" placeholder="© 2026 Jane Doe">
+                        <input class="form-input" id="chf-usage-terms" value="${escapeHtml(ch.usageTerms || '')}" placeholder="e.g. CC BY-NC 4.0">
+
                         <label class="form-label">Export mode</label>
                         <select class="form-select" id="chf-export-mode">
                             <option value="standard" ${!ch.galleryExport && !ch.siteExport ? 'selected' : ''}>Standard — files only</option>
                             <option value="gallery"  ${ch.galleryExport && !ch.siteExport  ? 'selected' : ''}>Single gallery — index.html per publish</option>
                             <option value="site"     ${ch.siteExport                       ? 'selected' : ''}>Multi-album site — static website</option>
                         </select>
+                        <label class="form-label">Responsive sizes <span class="form-hint">(gallery and site; extra widths in px for srcset, also as WebP/AVIF when available)</span></label>
+                        <input class="form-input" id="chf-rendition-widths" value="${escapeHtml((ch.renditionWidths || []).join(', '))}" placeholder="e.g. 480, 960, 1600, 2560">
+                        <label class="form-label">Template folder <span class="form-hint">(gallery and site; absolute path with your own index.html, album.html, … and assets/ — start from <code>unterlumen -dump-site-templates &lt;dir&gt;</code>)</span></label>
+                        <input class="form-input" id="chf-site-template-dir" value="${escapeHtml(ch.siteTemplateDir || '')}" placeholder="empty = built-in templates">
                     </div>
 
                     <!-- Website tab -->
@@ -353,8 +453,18 @@ class ChannelSettingsModal {
                                 <option value="light" ${(ch.siteTheme || 'light') === 'light' ? 'selected' : ''}>Light</option>
                                 <option value="dark"  ${ch.siteTheme === 'dark'              ? 'selected' : ''}>Dark</option>
                             </select>
-                            <label class="form-label">Site URL <span class="form-hint">(optional — enables canonical links, OG tags, sitemap.xml)</span></label>
+                            <label class="form-label">Site URL <span class="form-hint">(optional — enables canonical links, OG tags, sitemap.xml and Atom/RSS/JSON feeds)</span></label>
                             <input class="form-input" id="chf-site-url" value="${escapeHtml(ch.siteURL || '')}" placeholder="https://example.com">
+                            <label class="form-label">Photo pages <span class="form-hint">(every photo gets its own page with title, date and prev/next links)</span></label>
+                            <select class="form-select" id="chf-site-photo-exif">
+                                <option value=""    ${!ch.sitePhotoExif?'selected':''}>Hide camera details</option>
+                                <option value="yes" ${ch.sitePhotoExif?'selected':''}>Show camera, lens, exposure and film simulation</option>
+                            </select>
+                            <label class="form-label">Map page <span class="form-hint">(photo locations rounded to about 1 km; photos in privacy zones are left out)</span></label>
+                            <select class="form-select" id="chf-site-map">
+                                <option value=""    ${!ch.siteMap?'selected':''}>No map</option>
+                                <option value="yes" ${ch.siteMap?'selected':''}>Add a map of photo locations</option>
+                            </select>
 
                             <label class="form-label">About page <span class="form-hint">(markdown — generates about.html)</span></label>
                             <textarea class="form-input" id="chf-site-about" rows="6" placeholder="Write a short introduction about yourself and your photography…" style="resize:vertical;font-family:inherit">${escapeHtml(ch.siteAbout || '')}</textarea>
@@ -400,10 +510,10 @@ class ChannelSettingsModal {
 
                     <!-- Advanced tab -->
                     <div class="ch-panel" data-tab="advanced">
-                        <label class="form-label">Handler <span class="form-hint">(optional, for future upload automation)</span></label>
-                        <input class="form-input" id="chf-handler" value="${escapeHtml(ch.handler || '')}" placeholder="e.g. mastodon, scp">
+                        <label class="form-label">Handler <span class="form-hint">(optional; uploads each publish, e.g. mastodon needs instance and token)</span></label>
+                        <input class="form-input" id="chf-handler" value="${escapeHtml(ch.handler || '')}" placeholder="e.g. mastodon">
 
-                        <label class="form-label">Handler config <span class="form-hint">(key → value)</span></label>
+                        <label class="form-label">Handler config <span class="form-hint">(key → value; site channels: deploy = dir or sftp; storage = s3 for any export channel; see docs)</span></label>
                         <div id="chf-hconfig" class="kv-editor">${_kvEditorHTML(ch.handlerConfig || {})}</div>
                         <button class="btn btn-sm" id="chf-hconfig-add" style="align-self:flex-start">+ Add config entry</button>
 
@@ -517,11 +627,24 @@ class ChannelSettingsModal {
                 quality:          parseInt(form.querySelector('#chf-quality').value, 10),
                 exifMode:         form.querySelector('#chf-exif').value,
                 scale:            _readScaleOpts(form),
+                colorMode:        form.querySelector('#chf-color-mode').value === 'preserve' ? 'preserve' : undefined,
+                sharpen:          form.querySelector('#chf-sharpen').value || undefined,
+                maxBytes:         _readMaxBytes(form),
+                allowDownscale:   form.querySelector('#chf-allow-downscale').value === 'yes' || undefined,
+                watermark:        _readWatermark(form, ch.watermark),
+                artist:           form.querySelector('#chf-artist').value.trim() || undefined,
+                copyright:        form.querySelector('#chf-copyright').value.trim() || undefined,
+                usageTerms:       form.querySelector('#chf-usage-terms').value.trim() || undefined,
+                namePattern:      form.querySelector('#chf-name-pattern').value.trim() || undefined,
                 galleryExport:    exportModeVal === 'gallery' ? true : undefined,
                 siteExport:       isSite ? true : undefined,
+                renditionWidths:  exportModeVal !== 'standard' ? _readRenditionWidths(form) : undefined,
+                siteTemplateDir:  exportModeVal !== 'standard' ? (form.querySelector('#chf-site-template-dir').value.trim() || undefined) : undefined,
                 siteTitle:        isSite ? (form.querySelector('#chf-site-title').value.trim() || undefined) : undefined,
                 siteTheme:        isSite ? (form.querySelector('#chf-site-theme').value || undefined) : undefined,
                 siteURL:          isSite ? (form.querySelector('#chf-site-url').value.trim() || undefined) : undefined,
+                sitePhotoExif:    isSite ? (form.querySelector('#chf-site-photo-exif').value === 'yes' || undefined) : undefined,
+                siteMap:          isSite ? (form.querySelector('#chf-site-map').value === 'yes' || undefined) : undefined,
                 siteAbout:        isSite ? (form.querySelector('#chf-site-about').value.trim() || undefined) : undefined,
                 siteImprint:      isSite ? (form.querySelector('#chf-site-imprint').value.trim() || undefined) : undefined,
                 siteContactEmail: isSite ? (form.querySelector('#chf-site-contact-email').value.trim() || undefined) : undefined,
@@ -765,16 +888,41 @@ function _scaleOptsHTML(scale) {
     return '';
 }
 
+// Keeps opacity/scale from the stored config; they are API-only settings.
+function _readWatermark(form, prev) {
+    const text = form.querySelector('#chf-wm-text').value.trim();
+    const imagePath = form.querySelector('#chf-wm-image').value.trim();
+    if (!text && !imagePath) return undefined;
+    return {
+        ...(prev || {}),
+        text: text || undefined,
+        imagePath: imagePath || undefined,
+        position: form.querySelector('#chf-wm-position').value,
+    };
+}
+
+function _readMaxBytes(form) {
+    const mb = parseFloat(form.querySelector('#chf-max-mb').value);
+    return mb > 0 ? Math.round(mb * 1024 * 1024) : undefined;
+}
+
+function _readRenditionWidths(form) {
+    const widths = form.querySelector('#chf-rendition-widths').value
+        .split(/[\s,]+/).map(v => parseInt(v, 10)).filter(w => w > 0);
+    return widths.length ? widths : undefined;
+}
+
 function _readScaleOpts(form) {
     const mode = form.querySelector('#chf-scale-mode').value;
+    const filter = form.querySelector('#chf-filter')?.value || undefined;
     if (mode === 'max_dim') {
         const dimEl = form.querySelector('#chf-max-dim');
         const valEl = form.querySelector('#chf-max-val');
-        return { mode, maxDimension: dimEl?.value || 'width', maxValue: parseInt(valEl?.value || '1920', 10) };
+        return { mode, maxDimension: dimEl?.value || 'width', maxValue: parseInt(valEl?.value || '1920', 10), filter };
     }
     if (mode === 'percent') {
         const pctEl = form.querySelector('#chf-percent');
-        return { mode, percent: parseFloat(pctEl?.value || '50') };
+        return { mode, percent: parseFloat(pctEl?.value || '50'), filter };
     }
     return { mode: 'none' };
 }
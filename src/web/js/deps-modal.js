@@ -55,6 +55,8 @@ class DepsModal {
         const sips = status && status.sips || {};
         const heifConvert = status && status.heifConvert || {};
         const webpAvailable = status && status.webpAvailable;
+        const avifAvailable = status && status.avifAvailable;
+        const jxlAvailable = status && status.jxlAvailable;
 
         const install = {
             ffmpeg: {
@@ -70,6 +72,16 @@ class DepsModal {
                 linux: 'sudo apt install webp   # Debian/Ubuntu\nsudo dnf install libwebp-tools   # Fedora/RHEL',
                 windows: 'Download from https://developers.google.com/speed/webp/download and add to PATH',
             },
+            avifenc: {
+                darwin: 'brew install libavif',
+                linux: 'sudo apt install libavif-bin   # Debian/Ubuntu\nsudo dnf install libavif-tools   # Fedora/RHEL',
+                windows: 'Download from https://github.com/AOMediaCodec/libavif/releases and add to PATH',
+            },
+            cjxl: {
+                darwin: 'brew install jpeg-xl',
+                linux: 'sudo apt install libjxl-tools   # Debian/Ubuntu\nsudo dnf install libjxl-utils   # Fedora/RHEL',
+                windows: 'Download from https://github.com/libjxl/libjxl/releases and add to PATH',
+            },
             exiftool: {
                 darwin: 'brew install exiftool',
                 linux: 'sudo apt install libimage-exiftool-perl   # Debian/Ubuntu\nsudo dnf install perl-Image-ExifTool   # Fedora/RHEL',
@@ -137,6 +149,22 @@ class DepsModal {
             });
         }
 
+        // avifenc / cjxl — optional encoders for AVIF and JPEG XL export
+        deps.push({
+            name: 'avifenc',
+            desc: 'AVIF encoder — optional, for AVIF export (ffmpeg with libaom/libsvtav1 also works)',
+            ok: avifAvailable,
+            note: avifAvailable ? null : 'Not installed — AVIF export is unavailable.',
+            install: avifAvailable ? null : get(install.avifenc),
+        });
+        deps.push({
+            name: 'cjxl',
+            desc: 'JPEG XL encoder — optional, for JPEG XL export (ffmpeg with libjxl also works)',
+            ok: jxlAvailable,
+            note: jxlAvailable ? null : 'Not installed — JPEG XL export is unavailable.',
+            install: jxlAvailable ? null : get(install.cjxl),
+        });
+
         // exiftool
         deps.push({
             name: 'exiftool',
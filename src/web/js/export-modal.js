@@ -1,4 +1,4 @@
-// ExportModal — convert and export selected images to JPEG, PNG, or WebP.
+// ExportModal — convert and export selected images to JPEG, PNG, WebP, AVIF, or JPEG XL.
 // Follows the same class pattern as LocationModal and BatchRenameModal.
 
 class ExportModal {
@@ -9,12 +9,14 @@ class ExportModal {
         this._estimateAbort = null; // AbortController for exact estimation
     }
 
-    open(files, { serverRole = false, exiftoolAvailable = false, webpSupport = true, sourcePath = null } = {}) {
+    open(files, { serverRole = false, exiftoolAvailable = false, webpSupport = true, avifSupport = false, jxlSupport = false, sourcePath = null } = {}) {
         if (this.overlay) this.close();
         this._files = files;
         this._serverRole = serverRole;
         this._exiftoolAvailable = exiftoolAvailable;
         this._webpSupport = webpSupport;
+        this._avifSupport = avifSupport;
+        this._jxlSupport = jxlSupport;
         this._sourcePath = sourcePath;
 
         this._buildDOM();
@@ -49,6 +51,8 @@ class ExportModal {
         const gpsNote = exiftoolAvailable ? '' : ' <span class="export-note">(requires exiftool)</span>';
         const gpsDisabled = exiftoolAvailable ? '' : ' disabled';
         const webpDisabled = webpSupport ? '' : ' disabled title="WebP encoder not available — install cwebp (brew install webp) or ffmpeg with libwebp"';
+        const avifDisabled = this._avifSupport ? '' : ' disabled title="AVIF encoder not available — install avifenc (brew install libavif) or ffmpeg with libaom/libsvtav1"';
+        const jxlDisabled = this._jxlSupport ? '' : ' disabled title="JPEG XL encoder not available — install cjxl (brew install jpeg-xl) or ffmpeg with libjxl"';
 
         const folderPlaceholder = serverRole ? 'relative path, e.g. exports/batch' : '/path/to/folder or relative/subfolder';
         const outputSection = `
@@ -64,6 +68,11 @@ class ExportModal {
                 <label class="export-radio-row">
                     <input type="radio" name="output-mode" value="zip"> Download as ZIP
                 </label>
+                <div class="export-row">
+                    <span class="export-label">File names</span>
+                    <input type="text" class="export-input export-name-pattern" style="flex:1" placeholder="original name, e.g. {YYYY}/{MM}/{model}_{seq:4}"
+                           title="Batch-rename tokens such as {YYYY} {MM} {DD} {model} {lens} {filmsim} {title} {original} {seq:4}; / creates subfolders">
+                </div>
             </div>`;
 
         this.overlay = document.createElement('div');
@@ -76,6 +85,17 @@ class ExportModal {
                 </div>
                 <div class="modal-body">
 
+                    <div class="export-section">
+                        <div class="export-row">
+                            <span class="export-label">Preset</span>
+                            <select class="export-input export-preset-select" style="flex:1">
+                                <option value="">— Custom —</option>
+                            </select>
+                            <button type="button" class="btn btn-sm export-preset-save" title="Save the current settings as a named preset">Save…</button>
+                            <button type="button" class="btn btn-sm export-preset-delete" title="Delete the selected preset" disabled>Delete</button>
+                        </div>
+                    </div>
+
                     <div class="export-section">
                         <div class="export-row">
                             <span class="export-label">Format</span>
@@ -83,6 +103,8 @@ class ExportModal {
                                 <button class="btn btn-sm active" data-format="jpeg">JPEG</button>
                                 <button class="btn btn-sm" data-format="png">PNG</button>
                                 <button class="btn btn-sm" data-format="webp"${webpDisabled}>WebP</button>
+                                <button class="btn btn-sm" data-format="avif"${avifDisabled}>AVIF</button>
+                                <button class="btn btn-sm" data-format="jxl"${jxlDisabled}>JPEG XL</button>
                             </div>
                         </div>
                         <div class="export-row export-quality-row">
@@ -90,6 +112,14 @@ class ExportModal {
                             <input type="range" class="export-quality-slider" min="1" max="100" value="85">
                             <span class="export-quality-value">85</span>
                         </div>
+                        <div class="export-row">
+                            <span class="export-label">Max size</span>
+                            <input type="number" class="export-input export-max-mb" min="0" step="0.1" placeholder="no limit" style="width:80px"
+                                   title="Searches the highest quality (up to the setting above) that keeps each file under this size"> MB
+                            <label class="export-ar-label" title="If the lowest quality is still too large, reduce the dimensions as well">
+                                <input type="checkbox" class="export-allow-downscale"> shrink if needed
+                            </label>
+                        </div>
                     </div>
 
                     <div class="export-section">
@@ -111,6 +141,22 @@ class ExportModal {
                                 <input type="number" class="export-input export-maxdim-val" placeholder="px" min="1" style="width:70px">
                             </span>
                         </label>
+                        <div class="export-row">
+                            <span class="export-label">Filter</span>
+                            <select class="export-input export-filter">
+                                <option value="">Default</option>
+                                <option value="lanczos3">Lanczos3</option>
+                                <option value="catmullrom">Catmull-Rom</option>
+                                <option value="bilinear">Bilinear</option>
+                            </select>
+                            <span class="export-label">Sharpen</span>
+                            <select class="export-input export-sharpen" title="Unsharp mask applied after scaling, for on-screen viewing">
+                                <option value="">None</option>
+                                <option value="screen_low">Screen, low</option>
+                                <option value="screen_standard">Screen, standard</option>
+                                <option value="screen_high">Screen, high</option>
+                            </select>
+                        </div>
                     </div>
 
                     <div class="export-section">
@@ -124,6 +170,44 @@ class ExportModal {
                         <label class="export-radio-row">
                             <input type="radio" name="exif-mode" value="keep_no_gps"${gpsDisabled}> Keep EXIF, remove GPS${gpsNote}
                         </label>
+                        <label class="export-radio-row" title="GPS is removed or coarsened only for photos taken inside a privacy zone (library settings)">
+                            <input type="radio" name="exif-mode" value="keep_outside_zones"${gpsDisabled}> Keep EXIF, protect privacy zones${gpsNote}
+                        </label>
+                    </div>
+
+                    <div class="export-section">
+                        <div class="export-section-title">Color</div>
+                        <label class="export-radio-row" title="Wide-gamut sources (Display P3, Adobe RGB) are converted so they look right everywhere">
+                            <input type="radio" name="color-mode" value="srgb" checked> Convert to sRGB
+                        </label>
+                        <label class="export-radio-row" title="Keeps the original pixel values and embeds the source ICC profile">
+                            <input type="radio" name="color-mode" value="preserve"> Preserve source profile
+                        </label>
+                    </div>
+
+                    <div class="export-section">
+                        <div class="export-section-title">Watermark &amp; rights</div>
+                        <label class="export-radio-row">
+                            Watermark text
+                            <input type="text" class="export-input export-wm-text" placeholder="e.g. © Jane Doe" style="width:160px">
+                        </label>
+                        <label class="export-radio-row">
+                            Position
+                            <select class="export-input export-wm-position">
+                                <option value="bottom-right">Bottom right</option>
+                                <option value="bottom-left">Bottom left</option>
+                                <option value="top-right">Top right</option>
+                                <option value="top-left">Top left</option>
+                                <option value="center">Center</option>
+                            </select>
+                            Opacity <input type="number" class="export-input export-wm-opacity" min="5" max="100" value="60" style="width:55px">%
+                        </label>
+                        <label class="export-radio-row">
+                            Artist <input type="text" class="export-input export-artist" style="width:140px">
+                        </label>
+                        <label class="export-radio-row">
+                            Copyright <input type="text" class="export-input export-copyright" style="width:140px">${gpsNote}
+                        </label>
                     </div>
 
                     <div class="export-section">
@@ -156,6 +240,7 @@ class ExportModal {
                 </div>
                 <div class="modal-footer">
                     <div class="export-status"></div>
+                    <button class="btn" id="export-sheet-btn" title="Download a printable PDF contact sheet of the selected photos">Contact sheet…</button>
                     <button class="btn" id="export-cancel-btn">Cancel</button>
                     <button class="btn btn-accent" id="export-confirm-btn">Export</button>
                 </div>
@@ -201,6 +286,7 @@ class ExportModal {
         // Scale value inputs
         this.overlay.querySelector('.export-percent-val').addEventListener('input', () => this._scheduleEstimate());
         this.overlay.querySelector('.export-maxdim-val').addEventListener('input', () => this._scheduleEstimate());
+        this.overlay.querySelector('.export-max-mb').addEventListener('input', () => this._scheduleEstimate());
         this.overlay.querySelectorAll('[name="max-dim-axis"]').forEach(r => r.addEventListener('change', () => this._scheduleEstimate()));
 
         // Estimate method toggle
@@ -233,13 +319,129 @@ class ExportModal {
             });
         }
 
+        // Presets
+        const presetSelect = this.overlay.querySelector('.export-preset-select');
+        const presetDelete = this.overlay.querySelector('.export-preset-delete');
+        presetSelect.addEventListener('change', () => {
+            presetDelete.disabled = !presetSelect.value;
+            const preset = (this._presets || []).find(p => p.name === presetSelect.value);
+            if (preset) this._applyPreset(preset);
+        });
+        this.overlay.querySelector('.export-preset-save').addEventListener('click', () => this._savePreset());
+        presetDelete.addEventListener('click', () => this._deletePreset());
+        this._loadPresets();
+
         // Export button
         this.overlay.querySelector('#export-confirm-btn').addEventListener('click', () => this._doExport());
+        this.overlay.querySelector('#export-sheet-btn').addEventListener('click', () => this._doContactSheet());
 
         // Build initial file list
         this._buildFileList();
     }
 
+    async _loadPresets(selected = '') {
+        try {
+            this._presets = await API.exportPresets();
+        } catch {
+            this._presets = [];
+        }
+        if (!this.overlay) return;
+        const select = this.overlay.querySelector('.export-preset-select');
+        select.innerHTML = '<option value="">— Custom —</option>' + this._presets.map(p =>
+            `<option value="${escapeHtml(p.name)}">${escapeHtml(p.name)}</option>`).join('');
+        select.value = selected;
+        this.overlay.querySelector('.export-preset-delete').disabled = !select.value;
+    }
+
+    // _applyPreset sets the dialog controls from a stored preset.
+    _applyPreset(preset) {
+        const o = preset.options || {};
+        const q = sel => this.overlay.querySelector(sel);
+        const check = (name, value) => {
+            const radio = q(`[name="${name}"][value="${value}"]`);
+            if (radio && !radio.disabled) radio.checked = true;
+        };
+
+        const tab = q(`[data-format="${o.format || 'jpeg'}"]`);
+        if (tab && !tab.disabled) tab.click();
+        if (o.quality) {
+            q('.export-quality-slider').value = o.quality;
+            q('.export-quality-value').textContent = o.quality;
+        }
+
+        const scale = o.scale || {};
+        check('scale-mode', scale.mode || 'none');
+        if (scale.percent) q('.export-percent-val').value = scale.percent;
+        if (scale.maxDimension) check('max-dim-axis', scale.maxDimension);
+        if (scale.maxValue) q('.export-maxdim-val').value = scale.maxValue;
+        q('.export-filter').value = scale.filter || '';
+        q('.export-max-mb').value = o.maxBytes ? +(o.maxBytes / 1024 / 1024).toFixed(2) : '';
+        q('.export-allow-downscale').checked = !!o.allowDownscale;
+        q('.export-sharpen').value = o.sharpen || '';
+        this._updateScaleSubInputs();
+
+        check('exif-mode', o.exifMode || 'strip');
+        check('color-mode', o.colorMode || 'srgb');
+
+        const wm = o.watermark || {};
+        q('.export-wm-text').value = wm.text || '';
+        if (wm.position) q('.export-wm-position').value = wm.position;
+        q('.export-wm-opacity').value = Math.round((wm.opacity || 0.6) * 100);
+        q('.export-artist').value = o.artist || '';
+        q('.export-copyright').value = o.copyright || '';
+
+        q('.export-name-pattern').value = preset.namePattern || '';
+        if (preset.destination) {
+            check('output-mode', 'folder');
+            q('.export-destination-input').value = preset.destination;
+        }
+        this._scheduleEstimate();
+    }
+
+    async _savePreset() {
+        const statusEl = this.overlay.querySelector('.export-status');
+        const current = this.overlay.querySelector('.export-preset-select').value;
+        const name = prompt('Preset name', current)?.trim();
+        if (!name) return;
+        const exists = (this._presets || []).some(p => p.name === name);
+        if (exists && name !== current && !confirm(`Replace the preset "${name}"?`)) return;
+
+        const scale = this._getScaleOptions();
+        const preset = {
+            name,
+            options: {
+                format: this._getFormat(),
+                quality: this._getQuality(),
+                scale,
+                exifMode: this._getExifMode(),
+                colorMode: this._getColorMode(),
+                sharpen: this._getSharpen(),
+                ...this._getSizeLimit(),
+                ...this._getRightsOptions(),
+            },
+            namePattern: this._getNamePattern(),
+            destination: this._getOutputMode() === 'folder' ? this._getDestination() : '',
+        };
+        try {
+            await API.saveExportPreset(preset, exists);
+            statusEl.textContent = `Preset "${name}" saved.`;
+            await this._loadPresets(name);
+        } catch (err) {
+            statusEl.textContent = 'Saving preset failed: ' + err.message;
+        }
+    }
+
+    async _deletePreset() {
+        const name = this.overlay.querySelector('.export-preset-select').value;
+        if (!name || !confirm(`Delete the preset "${name}"?`)) return;
+        try {
+            await API.deleteExportPreset(name);
+            await this._loadPresets();
+        } catch (err) {
+            this.overlay.querySelector('.export-status').textContent = 'Deleting preset failed: ' + err.message;
+        }
+    }
+
     _buildFileList() {
         const list = this.overlay.querySelector('.export-file-list');
         list.innerHTML = this._files.map(f => {
@@ -279,6 +481,8 @@ class ExportModal {
     _getScaleOptions() {
         const mode = this._getScaleMode();
         const opts = { mode };
+        const filter = this.overlay.querySelector('.export-filter')?.value;
+        if (filter && mode !== 'none') opts.filter = filter;
         if (mode === 'percent') {
             opts.percent = parseFloat(this.overlay.querySelector('.export-percent-val').value) || 50;
         } else if (mode === 'max_dim') {
@@ -294,6 +498,43 @@ class ExportModal {
         return checked ? checked.value : 'strip';
     }
 
+    _getColorMode() {
+        const checked = this.overlay.querySelector('[name="color-mode"]:checked');
+        return checked ? checked.value : 'srgb';
+    }
+
+    _getSizeLimit() {
+        const mb = parseFloat(this.overlay.querySelector('.export-max-mb')?.value);
+        if (!(mb > 0)) return {};
+        const opts = { maxBytes: Math.round(mb * 1024 * 1024) };
+        if (this.overlay.querySelector('.export-allow-downscale')?.checked) opts.allowDownscale = true;
+        return opts;
+    }
+
+    _getSharpen() {
+        return this.overlay.querySelector('.export-sharpen')?.value || '';
+    }
+
+    _getNamePattern() {
+        return this.overlay.querySelector('.export-name-pattern')?.value.trim() || '';
+    }
+
+    _getRightsOptions() {
+        const val = sel => this.overlay.querySelector(sel)?.value.trim() || '';
+        const opts = {};
+        const text = val('.export-wm-text');
+        if (text) {
+            opts.watermark = {
+                text,
+                position: val('.export-wm-position'),
+                opacity: (parseInt(val('.export-wm-opacity')) || 60) / 100,
+            };
+        }
+        if (val('.export-artist')) opts.artist = val('.export-artist');
+        if (val('.export-copyright')) opts.copyright = val('.export-copyright');
+        return opts;
+    }
+
     _getEstimateMethod() {
         const active = this.overlay.querySelector('[data-estimate].active');
         return active ? active.dataset.estimate : 'heuristic';
@@ -327,6 +568,7 @@ class ExportModal {
             format: this._getFormat(),
             quality: this._getQuality(),
             scale: this._getScaleOptions(),
+            ...this._getSizeLimit(),
             ...(this._sourcePath ? { sourcePath: this._sourcePath } : {}),
         };
         const method = this._getEstimateMethod();
@@ -415,6 +657,7 @@ class ExportModal {
             } else {
                 row.querySelector('.export-file-orig').textContent = est.inputBytes ? _fmtBytes(est.inputBytes) : '—';
                 row.querySelector('.export-file-out').textContent = est.outputBytes ? '~' + _fmtBytes(est.outputBytes) : '—';
+                row.querySelector('.export-file-out').title = est.quality ? `quality ${est.quality}` : '';
                 _applyDims(row, est);
                 if (est.inputBytes) totalIn += est.inputBytes;
                 if (est.outputBytes) totalOut += est.outputBytes;
@@ -448,6 +691,93 @@ class ExportModal {
         }
     }
 
+    // POST payload to an export SSE endpoint, updating progress and marking failed
+    // rows as files finish. Resolves with the final (complete) event.
+    async _streamExport(url, payload) {
+        const resp = await fetch(url, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(payload),
+        });
+        if (!resp.ok) throw new Error(await resp.text());
+
+        const reader = resp.body.getReader();
+        const decoder = new TextDecoder();
+        let buffer = '';
+        let final = null;
+
+        while (true) {
+            const { done, value } = await reader.read();
+            if (done) break;
+            buffer += decoder.decode(value, { stream: true });
+
+            // Parse SSE blocks (separated by blank lines)
+            const blocks = buffer.split('\n\n');
+            buffer = blocks.pop() ?? '';
+
+            for (const block of blocks) {
+                const dataLine = block.split('\n').find(l => l.startsWith('data: '));
+                if (!dataLine) continue;
+                try {
+                    const evt = JSON.parse(dataLine.slice(6));
+                    if (evt.complete) {
+                        final = evt;
+                    } else if (this.overlay) {
+                        this._setProgress(true, evt.done, evt.total, evt.file || 'Exporting…', false);
+                        const row = evt.path && this.overlay.querySelector(`[data-file="${CSS.escape(evt.path)}"]`);
+                        if (row && evt.error) {
+                            _applyRowError(row, { error: evt.error });
+                        } else if (row && evt.quality && payload.maxBytes) {
+                            // Size-limited export: show the quality the server settled on.
+                            row.querySelector('.export-file-out').title = `quality ${evt.quality}` +
+                                (evt.scale ? `, scaled to ${Math.round(evt.scale * 100)}%` : '');
+                        }
+                    }
+                } catch { /* malformed event, skip */ }
+            }
+        }
+        return final;
+    }
+
+    // Builds a PDF contact sheet of the selected files. The grid is asked for
+    // as "columns x rows"; page size and captions use the server defaults.
+    async _doContactSheet() {
+        const grid = prompt('Contact sheet grid (columns x rows):', '4x5');
+        if (grid === null) return;
+        const m = grid.trim().match(/^(\d+)\s*[x×]\s*(\d+)$/i);
+        const statusEl = this.overlay.querySelector('.export-status');
+        if (!m) {
+            statusEl.textContent = 'Enter the grid as columns x rows, e.g. 4x5.';
+            return;
+        }
+
+        const btn = this.overlay.querySelector('#export-sheet-btn');
+        btn.disabled = true;
+        statusEl.textContent = 'Building contact sheet…';
+        try {
+            const blob = await API.exportContactSheet({
+                files: this._files,
+                columns: parseInt(m[1], 10),
+                rows: parseInt(m[2], 10),
+                ...(this._sourcePath ? { sourcePath: this._sourcePath } : {}),
+            });
+            if (!this.overlay) return;
+            const url = URL.createObjectURL(blob);
+            const a = document.createElement('a');
+            a.href = url;
+            a.download = 'contact-sheet.pdf';
+            document.body.appendChild(a);
+            a.click();
+            document.body.removeChild(a);
+            URL.revokeObjectURL(url);
+            statusEl.textContent = '';
+        } catch (err) {
+            if (this.overlay) statusEl.textContent = 'Contact sheet failed: ' + err.message;
+        } finally {
+            btn.disabled = false;
+        }
+    }
+
     async _doExport() {
         const confirmBtn = this.overlay.querySelector('#export-confirm-btn');
         const cancelBtn = this.overlay.querySelector('#export-cancel-btn');
@@ -462,6 +792,11 @@ class ExportModal {
             quality: this._getQuality(),
             scale: this._getScaleOptions(),
             exifMode: this._getExifMode(),
+            colorMode: this._getColorMode(),
+            ...(this._getSharpen() ? { sharpen: this._getSharpen() } : {}),
+            ...this._getSizeLimit(),
+            ...this._getRightsOptions(),
+            ...(this._getNamePattern() ? { namePattern: this._getNamePattern() } : {}),
             ...(this._sourcePath ? { sourcePath: this._sourcePath } : {}),
         };
 
@@ -471,41 +806,8 @@ class ExportModal {
             if (outputMode === 'zip') {
                 // Stream SSE progress while the server builds the ZIP, then download.
                 this._setProgress(true, 0, this._files.length, 'Exporting…', false);
-
-                const resp = await fetch('/api/export/zip-stream', {
-                    method: 'POST',
-                    headers: { 'Content-Type': 'application/json' },
-                    body: JSON.stringify({ ...basePayload, files: this._files }),
-                });
-                if (!resp.ok) throw new Error(await resp.text());
-
-                const reader = resp.body.getReader();
-                const decoder = new TextDecoder();
-                let buffer = '';
-                let token = null;
-
-                while (true) {
-                    const { done, value } = await reader.read();
-                    if (done) break;
-                    buffer += decoder.decode(value, { stream: true });
-
-                    // Parse SSE blocks (separated by blank lines)
-                    const blocks = buffer.split('\n\n');
-                    buffer = blocks.pop() ?? '';
-
-                    for (const block of blocks) {
-                        const dataLine = block.split('\n').find(l => l.startsWith('data: '));
-                        if (!dataLine) continue;
-                        try {
-                            const evt = JSON.parse(dataLine.slice(6));
-                            if (evt.complete) {
-                                token = evt.token;
-                            } else if (this.overlay) {
-                                this._setProgress(true, evt.done, evt.total, evt.file || 'Exporting…', false);
-                            }
-                        } catch { /* malformed event, skip */ }
-                    }
-                }
+                const final = await this._streamExport('/api/export/zip-stream', { ...basePayload, files: this._files });
+                const token = final?.token;
 
                 if (!token) throw new Error('Export stream ended without a download token');
 
@@ -530,28 +832,11 @@ class ExportModal {
                     return;
                 }
 
-                // Per-file loop so we can show progress
-                let done = 0, failed = 0;
+                // Files are exported in parallel on the server; progress arrives per file.
                 this._setProgress(true, 0, this._files.length, 'Exporting…', false);
-
-                for (const file of this._files) {
-                    if (!this.overlay) break;
-                    const filename = file.split('/').pop();
-                    this._setProgress(true, done, this._files.length, filename, false);
-                    const row = this.overlay.querySelector(`[data-file="${CSS.escape(file)}"]`);
-                    try {
-                        const result = await API.exportSave({ ...basePayload, files: [file], destination });
-                        const r = result.results?.[0];
-                        if (!r?.success) {
-                            failed++;
-                            if (row && r?.error) _applyRowError(row, { error: r.error });
-                        }
-                    } catch (err) {
-                        failed++;
-                        if (row) _applyRowError(row, { error: err.message });
-                    }
-                    done++;
-                }
+                const final = await this._streamExport('/api/export/save-stream', { ...basePayload, files: this._files, destination });
+                if (!final) throw new Error('Export stream ended before completion');
+                const failed = final.summary?.failed ?? 0;
 
                 if (!this.overlay) return;
                 this._setProgress(false);
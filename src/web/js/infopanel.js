@@ -1,5 +1,13 @@
 // Info side panel — displays file metadata and EXIF data
 
+// Meta keys edited as dedicated fields at the top of the panel; each is
+// mirrored to the photo's XMP sidecar by the server.
+const CAPTION_FIELDS = [
+    { key: 'title', label: 'Title', placeholder: 'Add title...' },
+    { key: 'description', label: 'Caption', placeholder: 'Add caption...' },
+    { key: 'alt', label: 'Alt text', placeholder: 'Describe the image...' },
+];
+
 class InfoPanel {
     constructor(container) {
         this.container = container;
@@ -259,8 +267,10 @@ class InfoPanel {
         const sections = [];
 
         if (this._metaContext) {
-            const titleEntry = (this._metaContext.entries || []).find(e => e.key === 'title');
-            sections.push(this._renderTitleField(titleEntry));
+            const entries = this._metaContext.entries || [];
+            for (const f of CAPTION_FIELDS) {
+                sections.push(this._renderCaptionField(f, entries.find(e => e.key === f.key)));
+            }
         }
 
         // File section
@@ -401,15 +411,16 @@ class InfoPanel {
         return this.section('Publications', cards);
     }
 
-    _renderTitleField(entry) {
+    _renderCaptionField(field, entry) {
         const val = entry ? escapeHtml(entry.value) : '';
+        const valCls = field.key === 'title' ? ' info-title-val' : ' info-caption-val';
         return `<div class="info-meta-row info-title-row">` +
-            `<span class="info-label">Title</span>` +
-            `<span class="info-meta-val info-value info-title-val" contenteditable="true" ` +
-                `data-key="title"${!val ? ` data-placeholder="Add title..."` : ''}>` +
+            `<span class="info-label">${field.label}</span>` +
+            `<span class="info-meta-val info-value${valCls}" contenteditable="true" ` +
+                `data-key="${field.key}"${!val ? ` data-placeholder="${field.placeholder}"` : ''}>` +
                 val +
             `</span>` +
-            (entry ? `<button class="info-meta-del" title="Remove" data-key="title">\u00d7</button>` : '') +
+            (entry ? `<button class="info-meta-del" title="Remove" data-key="${field.key}">\u00d7</button>` : '') +
         `</div>`;
     }
 
@@ -418,7 +429,7 @@ class InfoPanel {
         const rows = [];
 
         const genericEntries = (ctx.entries || []).filter(e =>
-            e.key !== 'title' && !e.key.startsWith('published:')
+            !CAPTION_FIELDS.some(f => f.key === e.key) && !e.key.startsWith('published:')
         );
 
         for (const e of genericEntries) {
@@ -132,6 +132,14 @@ const LibraryAPI = {
         if (!r.ok) throw new Error(await r.text());
         return r.json();
     },
+    async geoClusters({ ids, zoom, ...rest } = {}) {
+        const params = new URLSearchParams({ zoom });
+        if (ids) params.set('ids', ids);
+        for (const [k, v] of Object.entries(rest)) params.set(k, v);
+        const r = await fetch(`/api/library/geo-clusters?${params}`);
+        if (!r.ok) throw new Error(await r.text());
+        return r.json();
+    },
     async statistics(ids) {
         const params = ids?.length ? `?ids=${ids.join(',')}` : '';
         const r = await fetch(`/api/library/statistics${params}`);
@@ -160,13 +168,19 @@ const LibraryAPI = {
         if (!r.ok) throw new Error(await r.text());
         return r.json();
     },
-    async publish(libID, { photoIDs, channel, account, publishedAt, recordXMP, outputPath }) {
+    async publish(libID, { photoIDs, channel, account, publishedAt, recordXMP, outputPath, caption }) {
         const r = await fetch(`/api/library/${libID}/publish`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ photoIDs, channel, account, publishedAt, recordXMP, outputPath }),
+            body: JSON.stringify({ photoIDs, channel, account, publishedAt, recordXMP, outputPath, caption }),
         });
-        if (!r.ok) throw new Error(await r.text());
+        if (!r.ok) {
+            // Handler failures (502) carry the export results and the upload error as JSON.
+            const text = await r.text();
+            let msg = text;
+            try { msg = JSON.parse(text).error || text; } catch { /* plain text */ }
+            throw new Error(msg);
+        }
         return r.json();
     },
     async publishStream(libID, { photoIDs, channel, account, publishedAt, galleryTitle, targetPostID, recordXMP, outputPath }, onProgress) {
@@ -1234,6 +1248,10 @@ class LibraryTab {
                             <input class="form-input" id="pub-gallery-title" placeholder="e.g. Summer 2026" autocomplete="off">
                         </div>
                     </div>
+                    <div id="pub-caption-wrap" style="display:none">
+                        <label class="form-label">Post text</label>
+                        <textarea class="form-input" id="pub-caption" rows="3" placeholder="Caption for the post; photo titles become alt text"></textarea>
+                    </div>
                     <div class="pub-output-section">
                         <label class="form-label">Output</label>
                         <select class="form-select" id="pub-output-mode">
@@ -1381,6 +1399,9 @@ class LibraryTab {
                 galleryWrap.style.display = 'none';
             }
 
+            // Post text for channels that upload through a handler
+            dlg.querySelector('#pub-caption-wrap').style.display = ch.handler ? '' : 'none';
+
             // Export summary
             const scaleDesc = _scaleDesc(ch.scale);
             const handlerNote = ch.handler ? ` · handler: ${ch.handler}` : '';
@@ -1534,6 +1555,8 @@ class LibraryTab {
                                     progressEl.textContent = `${prefix}Exporting photo ${evt.done} of ${evt.total}…`;
                                 else if (evt.step === 'zip' || evt.step === 'html' || evt.step === 'site')
                                     progressEl.textContent = prefix + evt.file;
+                                else if (evt.step === 'storage')
+                                    progressEl.textContent = `${prefix}Uploading ${evt.done} of ${evt.total}: ${evt.file}`;
                             }
                         );
                         if (gi === 0 && !targetPostID) currentTargetPostID = resp.postID;
@@ -1543,16 +1566,23 @@ class LibraryTab {
                     dlg.remove();
                     if (allErrors.length > 0) {
                         alert(`Published with ${allErrors.length} error(s):\n${allErrors.map(e => e.error).join('\n')}`);
+                    } else if (lastResp.storageError) {
+                        alert(`Published locally, but the upload to object storage failed:\n${lastResp.storageError}`);
+                    } else if (lastResp.storageURL) {
+                        App.showToast(`Published to ${lastResp.storageURL}`);
                     } else if (lastResp.sitePath) {
                         App.showToast(targetPostID ? `Photos added to album · site at ${lastResp.sitePath}` : `Album added · site at ${lastResp.sitePath}`);
                     } else {
                         App.showToast(targetPostID ? `Photos added to gallery: ${lastResp.galleryPath}` : `Gallery ready: ${lastResp.galleryPath}`);
                     }
                 } else {
+                    const caption = ch?.handler ? (dlg.querySelector('#pub-caption').value.trim() || undefined) : undefined;
                     let totalPublished = 0;
                     let allErrors = [];
+                    let postURL;
                     for (const g of validGroups) {
-                        const resp = await LibraryAPI.publish(g.libID, { photoIDs: g.photoIDs, channel, account, publishedAt, recordXMP, outputPath });
+                        const resp = await LibraryAPI.publish(g.libID, { photoIDs: g.photoIDs, channel, account, publishedAt, recordXMP, outputPath, caption });
+                        postURL = postURL || (resp.results || []).find(r => r.url)?.url;
                         const errors = (resp.results || []).filter(r => r.error);
                         allErrors = [...allErrors, ...errors];
                         totalPublished += g.photoIDs.length - errors.length;
@@ -1561,7 +1591,7 @@ class LibraryTab {
                     if (allErrors.length > 0) {
                         alert(`Published with ${allErrors.length} error(s):\n${allErrors.map(e => e.error).join('\n')}`);
                     } else {
-                        App.showToast(`Published ${totalPublished} photo${totalPublished !== 1 ? 's' : ''} to ${channel}.`);
+                        App.showToast(`Published ${totalPublished} photo${totalPublished !== 1 ? 's' : ''} to ${channel}${postURL ? ': ' + postURL : '.'}`);
                     }
                 }
             } catch (err) {
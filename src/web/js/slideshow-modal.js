@@ -6,20 +6,30 @@ class SlideshowModal {
         this.overlay = null;
         this.onStart = null;
         this._images = [];
+        this._paths = [];
+        this._sourcePath = null;
+        this._events = null;
         this._audioFiles = [];  // persists within session — not reset on re-open
         this._audioMode = 'none';
         this._onKeyDown = this._onKeyDown.bind(this);
     }
 
-    open(images) {
+    open(images, { paths = [], sourcePath = null } = {}) {
         if (this.overlay) this.close();
         this._images = images;
+        this._paths = paths;
+        this._sourcePath = sourcePath;
         this._buildDOM();
         document.body.appendChild(this.overlay);
         document.addEventListener('keydown', this._onKeyDown);
     }
 
     close() {
+        // A running video job continues on the server; only stop watching it.
+        if (this._events) {
+            this._events.close();
+            this._events = null;
+        }
         if (this.overlay) {
             this.overlay.remove();
             this.overlay = null;
@@ -111,6 +121,13 @@ class SlideshowModal {
                     <input type="file" class="ss-folder-input" accept="audio/*" style="display:none" webkitdirectory>
                 </div>
                 <div class="modal-footer">
+                    <div class="export-status ss-video-status"></div>
+                    <select class="ss-video-resolution" title="Video resolution"${this._paths.length ? '' : ' disabled'}>
+                        <option value="1280x720">720p</option>
+                        <option value="1920x1080" selected>1080p</option>
+                        <option value="3840x2160">4K</option>
+                    </select>
+                    <button class="btn ss-video-btn" title="Render an MP4 video on the server"${this._paths.length ? '' : ' disabled'}>Render video</button>
                     <button class="btn ss-cancel-btn">Cancel</button>
                     <button class="btn btn-accent ss-start-btn">Start</button>
                 </div>
@@ -240,6 +257,7 @@ class SlideshowModal {
 
         // Start button
         this.overlay.querySelector('.ss-start-btn').addEventListener('click', () => this._onStart());
+        this.overlay.querySelector('.ss-video-btn').addEventListener('click', () => this._onRenderVideo());
     }
 
     _getOptions() {
@@ -278,4 +296,71 @@ class SlideshowModal {
         this.close();
         if (this.onStart) this.onStart(this._images, options);
     }
+
+    // Maps the player options onto a server-side video job. Slide and zoom
+    // transitions become crossfades; 2-up and 4-up layouts are not rendered.
+    // Only built-in music can be used: picked files live in the browser.
+    _videoPayload(options) {
+        const [width, height] = this.overlay.querySelector('.ss-video-resolution').value.split('x').map(Number);
+        let transition = options.transition === 'instant' ? 'cut' : 'crossfade';
+        if (options.display === 'kenburns') transition = 'kenburns';
+        return {
+            files: this._paths,
+            ...(this._sourcePath ? { sourcePath: this._sourcePath } : {}),
+            width,
+            height,
+            slideDuration: options.delay,
+            transition,
+            ...(options.builtinTracks.length ? { music: options.builtinTracks[0] } : {}),
+        };
+    }
+
+    async _onRenderVideo() {
+        const options = this._getOptions();
+        const statusEl = this.overlay.querySelector('.ss-video-status');
+        const btn = this.overlay.querySelector('.ss-video-btn');
+        btn.disabled = true;
+        statusEl.textContent = 'Starting…';
+        let job;
+        try {
+            job = await API.submitSlideshowJob(this._videoPayload(options));
+        } catch (err) {
+            statusEl.textContent = 'Video failed: ' + err.message;
+            btn.disabled = false;
+            return;
+        }
+
+        this._events = new EventSource(API.jobEventsURL(job.id));
+        this._events.onmessage = (e) => {
+            const j = JSON.parse(e.data);
+            if (j.status === 'done') {
+                this._events.close();
+                this._events = null;
+                statusEl.textContent = 'Video ready.';
+                btn.disabled = false;
+                const a = document.createElement('a');
+                a.href = API.jobDownloadURL(j.id);
+                a.download = 'slideshow.mp4';
+                document.body.appendChild(a);
+                a.click();
+                document.body.removeChild(a);
+            } else if (j.status === 'failed' || j.status === 'cancelled') {
+                this._events.close();
+                this._events = null;
+                statusEl.textContent = `Video ${j.status}${j.error ? ': ' + j.error : ''}`;
+                btn.disabled = false;
+            } else {
+                const pct = j.total ? Math.round(100 * j.done / j.total) : 0;
+                const phase = j.current === 'encoding' ? 'Encoding' : 'Preparing';
+                statusEl.textContent = j.status === 'queued' ? 'Queued…' : `${phase}… ${pct}%`;
+            }
+        };
+        this._events.onerror = () => {
+            if (this._events?.readyState === EventSource.CLOSED) {
+                this._events = null;
+                statusEl.textContent = 'Lost connection; the video keeps rendering in the background.';
+                btn.disabled = false;
+            }
+        };
+    }
 }